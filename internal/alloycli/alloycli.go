@@ -24,7 +24,11 @@ func Command() *cobra.Command {
 	cmd.AddCommand(
 		convertCommand(),
 		fmtCommand(),
+		lspCommand(),
+		planCommand(),
 		RunCommand(),
+		supportBundleCommand(),
+		testCommand(),
 		toolsCommand(),
 		validateCommand(),
 	)
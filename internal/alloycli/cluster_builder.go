@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net"
 	"os"
+	"runtime"
 	"strconv"
 	"time"
 
@@ -39,6 +40,21 @@ type ClusterOptions struct {
 	TLSCertPath            string
 	TLSKeyPath             string
 	TLSServerName          string
+	// NodeWeight is the weight of the node in the cluster. Zero means a
+	// weight of 1.
+	NodeWeight        float64
+	NodeZone          string
+	ReplicationFactor int
+}
+
+// nodeWeight returns the weight of the node for the value of the
+// --cluster.node-weight flag. Zero opts in to weighting the node by the number
+// of CPUs available to the process.
+func nodeWeight(flagValue float64) float64 {
+	if flagValue == 0 {
+		return min(float64(runtime.GOMAXPROCS(0)), cluster.MaxNodeWeight)
+	}
+	return flagValue
 }
 
 func buildClusterService(opts ClusterOptions) (*cluster.Service, error) {
@@ -70,6 +86,9 @@ func NewClusterService(
 		TLSCertPath:            opts.TLSCertPath,
 		TLSKeyPath:             opts.TLSKeyPath,
 		TLSServerName:          opts.TLSServerName,
+		NodeWeight:             opts.NodeWeight,
+		NodeZone:               opts.NodeZone,
+		ReplicationFactor:      opts.ReplicationFactor,
 	}
 
 	if config.NodeName == "" {
@@ -2,23 +2,38 @@ package alloycli
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"reflect"
+	"sort"
 
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 
+	"github.com/grafana/alloy/syntax/ast"
 	"github.com/grafana/alloy/syntax/diag"
+	"github.com/grafana/alloy/syntax/encoding/alloyjson"
 	"github.com/grafana/alloy/syntax/parser"
 	"github.com/grafana/alloy/syntax/printer"
+	"github.com/grafana/alloy/syntax/token"
+)
+
+// Formats supported by fmt.
+const (
+	fmtFormatAlloy = "alloy"
+	fmtFormatJSON  = "json"
+	fmtFormatYAML  = "yaml"
 )
 
 func fmtCommand() *cobra.Command {
 	f := &alloyFmt{
-		write: false,
-		test:  false,
+		write:  false,
+		test:   false,
+		input:  fmtFormatAlloy,
+		output: fmtFormatAlloy,
 	}
 
 	cmd := &cobra.Command{
@@ -29,7 +44,9 @@ specified configuration file.
 
 If the file argument is not supplied or if the file argument is "-", then fmt will read from stdin.
 
-The -w flag can be used to write the formatted file back to disk. -w can not be provided when fmt is reading from stdin. When -w is not provided, fmt will write the result to stdout.`,
+The -w flag can be used to write the formatted file back to disk. -w can not be provided when fmt is reading from stdin. When -w is not provided, fmt will write the result to stdout.
+
+The --output flag converts the file to JSON or YAML instead of formatting it as Alloy syntax. The --input flag reads the file from its JSON or YAML representation, so that it can be converted back to Alloy syntax.`,
 		Args:         cobra.RangeArgs(0, 1),
 		SilenceUsage: true,
 		Aliases:      []string{"format"},
@@ -58,25 +75,39 @@ The -w flag can be used to write the formatted file back to disk. -w can not be
 
 	cmd.Flags().BoolVarP(&f.write, "write", "w", f.write, "write result to (source) file instead of stdout")
 	cmd.Flags().BoolVarP(&f.test, "test", "t", f.test, "exit with non-zero when changes would be made. Cannot be used with -w/--write")
+	cmd.Flags().StringVar(&f.input, "input", f.input, "format to read the file in. Supported formats: alloy, json, yaml")
+	cmd.Flags().StringVar(&f.output, "output", f.output, "format to write the result in. Supported formats: alloy, json, yaml")
 	return cmd
 }
 
 type alloyFmt struct {
-	write bool
-	test  bool
+	write  bool
+	test   bool
+	input  string
+	output string
 }
 
 func (ff *alloyFmt) Run(configFile string) error {
 	if ff.write && ff.test {
 		return fmt.Errorf("cannot use -w/--write and -t/--test at the same time")
 	}
+	for _, format := range []string{ff.input, ff.output} {
+		switch format {
+		case fmtFormatAlloy, fmtFormatJSON, fmtFormatYAML:
+		default:
+			return fmt.Errorf("unsupported format %q, supported formats: alloy, json, yaml", format)
+		}
+	}
+	if ff.write && ff.output != ff.input {
+		return fmt.Errorf("cannot use -w/--write when converting to a different format")
+	}
 
 	switch configFile {
 	case "-":
 		if ff.write {
 			return fmt.Errorf("cannot use -w with standard input")
 		}
-		return format("<stdin>", nil, os.Stdin, false, ff.test)
+		return format("<stdin>", nil, os.Stdin, ff.input, ff.output, false, ff.test)
 
 	default:
 		fi, err := os.Stat(configFile)
@@ -92,29 +123,26 @@ func (ff *alloyFmt) Run(configFile string) error {
 			return err
 		}
 		defer f.Close()
-		return format(configFile, fi, f, ff.write, ff.test)
+		return format(configFile, fi, f, ff.input, ff.output, ff.write, ff.test)
 	}
 }
 
-func format(filename string, fi os.FileInfo, r io.Reader, write bool, test bool) error {
+func format(filename string, fi os.FileInfo, r io.Reader, input, output string, write bool, test bool) error {
 	bb, err := io.ReadAll(r)
 	if err != nil {
 		return err
 	}
 
-	f, err := parser.ParseFile(filename, bb)
+	f, err := decodeFile(filename, bb, input)
 	if err != nil {
 		return err
 	}
 
 	var buf bytes.Buffer
-	if err := printer.Fprint(&buf, f); err != nil {
+	if err := encodeFile(&buf, f, output); err != nil {
 		return err
 	}
 
-	// Add a newline at the end of the file.
-	_, _ = buf.Write([]byte{'\n'})
-
 	// If -t/--test flag is check, only check if file is formatted correctly
 	if test {
 		if !reflect.DeepEqual(bb, buf.Bytes()) {
@@ -137,3 +165,180 @@ func format(filename string, fi os.FileInfo, r io.Reader, write bool, test bool)
 	_, err = io.Copy(wf, &buf)
 	return err
 }
+
+// encodeFile writes f to w in the given output format, followed by a
+// newline.
+func encodeFile(w io.Writer, f *ast.File, output string) error {
+	switch output {
+	case fmtFormatJSON:
+		bb, err := alloyjson.MarshalFile(f)
+		if err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, bb, "", "  "); err != nil {
+			return err
+		}
+		_ = buf.WriteByte('\n')
+		_, err = w.Write(buf.Bytes())
+		return err
+
+	case fmtFormatYAML:
+		bb, err := alloyjson.MarshalFile(f)
+		if err != nil {
+			return err
+		}
+
+		// JSON is a subset of YAML. Decoding it as a YAML node keeps the order
+		// of keys, and resetting the style of nodes writes them in block style.
+		var node yaml.Node
+		if err := yaml.Unmarshal(bb, &node); err != nil {
+			return err
+		}
+		resetStyle(&node)
+
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(&node); err != nil {
+			return err
+		}
+		return enc.Close()
+
+	default:
+		if err := printer.Fprint(w, f); err != nil {
+			return err
+		}
+		// Add a newline at the end of the file.
+		_, err := w.Write([]byte{'\n'})
+		return err
+	}
+}
+
+func resetStyle(n *yaml.Node) {
+	n.Style = 0
+	for _, child := range n.Content {
+		resetStyle(child)
+	}
+}
+
+// decodeFile decodes the file bb in the given input format.
+func decodeFile(filename string, bb []byte, input string) (*ast.File, error) {
+	switch input {
+	case fmtFormatJSON:
+		return alloyjson.UnmarshalFile(filename, bb)
+
+	case fmtFormatYAML:
+		jsonBytes, positions, err := yamlToJSON(bb)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+		f, err := alloyjson.UnmarshalFile(filename, jsonBytes)
+		var diags diag.Diagnostics
+		if errors.As(err, &diags) {
+			// Diagnostics point to the converted JSON, so move them to the YAML
+			// value they were reported for.
+			for i := range diags {
+				diags[i].StartPos = positions.find(filename, diags[i].StartPos.Offset)
+				diags[i].EndPos = token.Position{}
+			}
+			return nil, diags
+		}
+		return f, err
+
+	default:
+		return parser.ParseFile(filename, bb)
+	}
+}
+
+// yamlPosition is the position in a YAML document of the value encoded at an
+// offset of the converted JSON document.
+type yamlPosition struct {
+	offset       int
+	line, column int
+}
+
+type yamlPositions []yamlPosition
+
+// find returns the position in the YAML document of the value holding the
+// offset of the JSON document.
+func (pp yamlPositions) find(filename string, offset int) token.Position {
+	i := sort.Search(len(pp), func(i int) bool { return pp[i].offset > offset })
+	if i == 0 {
+		return token.Position{Filename: filename}
+	}
+	return token.Position{Filename: filename, Line: pp[i-1].line, Column: pp[i-1].column}
+}
+
+// yamlToJSON converts a YAML document to JSON, and returns the position in the
+// YAML document of each value of the JSON document, sorted by offset.
+func yamlToJSON(bb []byte) ([]byte, yamlPositions, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(bb, &doc); err != nil {
+		return nil, nil, err
+	}
+	if len(doc.Content) == 0 {
+		// An empty document is an empty file.
+		return []byte("[]"), nil, nil
+	}
+
+	var (
+		buf       bytes.Buffer
+		positions yamlPositions
+		encode    func(n *yaml.Node) error
+	)
+	encode = func(n *yaml.Node) error {
+		positions = append(positions, yamlPosition{offset: buf.Len(), line: n.Line, column: n.Column})
+
+		switch n.Kind {
+		case yaml.AliasNode:
+			return encode(n.Alias)
+
+		case yaml.SequenceNode:
+			buf.WriteByte('[')
+			for i, child := range n.Content {
+				if i > 0 {
+					buf.WriteByte(',')
+				}
+				if err := encode(child); err != nil {
+					return err
+				}
+			}
+			buf.WriteByte(']')
+
+		case yaml.MappingNode:
+			buf.WriteByte('{')
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				if i > 0 {
+					buf.WriteByte(',')
+				}
+				key, err := json.Marshal(n.Content[i].Value)
+				if err != nil {
+					return err
+				}
+				buf.Write(key)
+				buf.WriteByte(':')
+				if err := encode(n.Content[i+1]); err != nil {
+					return err
+				}
+			}
+			buf.WriteByte('}')
+
+		default:
+			var v any
+			if err := n.Decode(&v); err != nil {
+				return err
+			}
+			value, err := json.Marshal(v)
+			if err != nil {
+				return fmt.Errorf("line %d: %w", n.Line, err)
+			}
+			buf.Write(value)
+		}
+		return nil
+	}
+
+	if err := encode(doc.Content[0]); err != nil {
+		return nil, nil, err
+	}
+	return buf.Bytes(), positions, nil
+}
@@ -0,0 +1,72 @@
+package alloycli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/lsp"
+	"github.com/grafana/alloy/internal/service/cluster"
+	"github.com/grafana/alloy/internal/service/http"
+	"github.com/grafana/alloy/internal/service/labelstore"
+	"github.com/grafana/alloy/internal/service/livedebugging"
+	"github.com/grafana/alloy/internal/service/otel"
+	"github.com/grafana/alloy/internal/service/remotecfg"
+	"github.com/grafana/alloy/internal/service/ui"
+	"github.com/spf13/cobra"
+)
+
+func lspCommand() *cobra.Command {
+	l := &alloyLSP{
+		minStability: featuregate.StabilityGenerallyAvailable,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "lsp [flags]",
+		Short: "Run a language server for configuration files",
+		Long: `The lsp subcommand runs a Language Server Protocol server for Alloy
+configuration files, communicating with the editor over stdin and stdout.
+
+The server reports syntax and validation errors, completes component names,
+arguments and references to exports, shows documentation on hover, navigates
+to definitions and formats documents.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, cancel := interruptContext(cmd.Context())
+			defer cancel()
+			return l.Run(ctx)
+		},
+	}
+
+	cmd.Flags().Var(&l.minStability, "stability.level", fmt.Sprintf("Minimum stability level of features to enable. Supported values: %s", strings.Join(featuregate.AllowedValues(), ", ")))
+	cmd.Flags().BoolVar(&l.enableCommunityComps, "feature.community-components.enabled", l.enableCommunityComps, "Enable community components.")
+
+	return cmd
+}
+
+type alloyLSP struct {
+	minStability         featuregate.Stability
+	enableCommunityComps bool
+}
+
+func (l *alloyLSP) Run(ctx context.Context) error {
+	server := lsp.NewServer(lsp.Options{
+		ComponentRegistry: component.NewDefaultRegistry(l.minStability, l.enableCommunityComps),
+		ComponentNames:    component.AllNames(),
+		ServiceDefinitions: getServiceDefinitions(
+			&cluster.Service{},
+			&http.Service{},
+			&labelstore.Service{},
+			&livedebugging.Service{},
+			&otel.Service{},
+			&remotecfg.Service{},
+			&ui.Service{},
+		),
+		MinStability: l.minStability,
+	})
+	return server.Serve(ctx, os.Stdin, os.Stdout)
+}
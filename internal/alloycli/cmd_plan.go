@@ -0,0 +1,185 @@
+package alloycli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/grafana/alloy/internal/featuregate"
+	alloy_runtime "github.com/grafana/alloy/internal/runtime"
+	"github.com/grafana/alloy/internal/runtime/logging"
+)
+
+func planCommand() *cobra.Command {
+	p := &alloyPlan{
+		configFormat: "alloy",
+		output:       "text",
+		minStability: featuregate.StabilityGenerallyAvailable,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "plan [flags] old-path new-path",
+		Short: "Show the changes between two configurations",
+		Long: `The plan subcommand compares two configuration files or directories and
+reports which components would be created, deleted, updated, or re-evaluated
+when reloading from the old configuration to the new one.
+
+Blocks are compared without being evaluated, so any change to the contents
+of a block is reported as an update. The new configuration is also loaded in
+a dry run, which builds and evaluates its graph without running any
+component, and the problems found are reported. To evaluate the new
+configuration against the state of a running instance, send a GET or POST
+request to the /-/plan endpoint of that instance instead.
+
+The command exits with a non-zero exit code when the dry run of the new
+configuration reports errors. The --test flag can be used to also exit with
+a non-zero exit code when the configurations differ.`,
+		Args:         cobra.ExactArgs(2),
+		SilenceUsage: true,
+
+		RunE: func(_ *cobra.Command, args []string) error {
+			return p.Run(os.Stdout, args[0], args[1])
+		},
+	}
+
+	cmd.Flags().StringVar(&p.configFormat, "config.format", p.configFormat, fmt.Sprintf("The format of the source files. Supported formats: %s.", supportedFormatsList()))
+	cmd.Flags().BoolVar(&p.configBypassConversionErrors, "config.bypass-conversion-errors", p.configBypassConversionErrors, "Enable bypassing errors when converting")
+	cmd.Flags().StringVar(&p.configExtraArgs, "config.extra-args", p.configExtraArgs, "Extra arguments from the original format used by the converter. Multiple arguments can be passed by separating them with a space.")
+	cmd.Flags().StringVarP(&p.output, "output", "o", p.output, "Output format of the plan. Supported formats: text, json.")
+	cmd.Flags().BoolVarP(&p.test, "test", "t", p.test, "exit with non-zero when the configurations differ")
+	cmd.Flags().Var(&p.minStability, "stability.level", fmt.Sprintf("Minimum stability level of features to enable. Supported values: %s", strings.Join(featuregate.AllowedValues(), ", ")))
+	cmd.Flags().BoolVar(&p.enableCommunityComps, "feature.community-components.enabled", p.enableCommunityComps, "Enable community components.")
+	return cmd
+}
+
+type alloyPlan struct {
+	configFormat                 string
+	configBypassConversionErrors bool
+	configExtraArgs              string
+	output                       string
+	test                         bool
+
+	minStability         featuregate.Stability
+	enableCommunityComps bool
+}
+
+var (
+	// errPlanNotEmpty is returned when --test is set and the plan has changes.
+	errPlanNotEmpty = errors.New("configurations differ")
+	// errPlanHasErrors is returned when the dry run of the new configuration
+	// reports errors.
+	errPlanHasErrors = errors.New("new configuration has errors")
+)
+
+func (p *alloyPlan) Run(w io.Writer, oldPath, newPath string) error {
+	if p.output != "text" && p.output != "json" {
+		return fmt.Errorf("unsupported output format %q", p.output)
+	}
+
+	oldSource, err := p.loadSource(oldPath)
+	if err != nil {
+		return err
+	}
+	newSource, err := p.loadSource(newPath)
+	if err != nil {
+		return err
+	}
+
+	plan := alloy_runtime.DiffSources(oldSource, newSource)
+	plan.Diagnostics, err = p.dryRun(newSource, newPath)
+	if err != nil {
+		return err
+	}
+
+	switch p.output {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(plan); err != nil {
+			return err
+		}
+	default:
+		printPlan(w, plan)
+	}
+
+	if plan.HasErrors() {
+		return errPlanHasErrors
+	}
+	if p.test && !plan.Empty() {
+		return errPlanNotEmpty
+	}
+	return nil
+}
+
+// dryRun loads source with the same loader as the run command, without
+// building or running any component, and returns the problems found.
+func (p *alloyPlan) dryRun(source *alloy_runtime.Source, path string) ([]alloy_runtime.PlanDiagnostic, error) {
+	f, err := alloy_runtime.New(alloy_runtime.Options{
+		Logger:               logging.NewNop(),
+		MinStability:         p.minStability,
+		EnableCommunityComps: p.enableCommunityComps,
+		Services:             unconfiguredServices(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("creating the dry run controller: %w", err)
+	}
+	return f.DryRun(source, path), nil
+}
+
+func (p *alloyPlan) loadSource(path string) (*alloy_runtime.Source, error) {
+	sources, err := loadSourceFiles(path, p.configFormat, p.configBypassConversionErrors, p.configExtraArgs)
+	if err != nil {
+		return nil, fmt.Errorf("reading config path %q: %w", path, err)
+	}
+	source, err := alloy_runtime.ParseSourcesFormat(sources, sourceFormat(p.configFormat))
+	if err != nil {
+		return nil, fmt.Errorf("reading config path %q: %w", path, err)
+	}
+	return source, nil
+}
+
+var planActionSymbols = map[alloy_runtime.PlanAction]string{
+	alloy_runtime.PlanActionCreate:     "+",
+	alloy_runtime.PlanActionDelete:     "-",
+	alloy_runtime.PlanActionUpdate:     "~",
+	alloy_runtime.PlanActionReevaluate: "*",
+}
+
+func printPlan(w io.Writer, plan alloy_runtime.Plan) {
+	if plan.Empty() {
+		fmt.Fprintln(w, "No changes.")
+	}
+
+	printChanges := func(title string, changes []alloy_runtime.PlanChange) {
+		if len(changes) == 0 {
+			return
+		}
+		fmt.Fprintf(w, "%s:\n", title)
+		for _, c := range changes {
+			fmt.Fprintf(w, "  %s %s (%s)", planActionSymbols[c.Action], c.ID, c.Action)
+			if c.Message != "" {
+				fmt.Fprintf(w, ": %s", c.Message)
+			}
+			fmt.Fprintln(w)
+		}
+	}
+
+	printChanges("Components", plan.Components)
+	printChanges("Exports", plan.Exports)
+
+	if len(plan.Diagnostics) > 0 {
+		fmt.Fprintln(w, "Diagnostics:")
+		for _, d := range plan.Diagnostics {
+			fmt.Fprintf(w, "  %s: ", d.Severity)
+			if d.Position != "" {
+				fmt.Fprintf(w, "%s: ", d.Position)
+			}
+			fmt.Fprintln(w, d.Message)
+		}
+	}
+}
@@ -58,20 +58,22 @@ import (
 
 func RunCommand() *cobra.Command {
 	r := &alloyRun{
-		inMemoryAddr:          "alloy.internal:12345",
-		httpListenAddr:        "127.0.0.1:12345",
-		storagePath:           "data-alloy/",
-		minStability:          featuregate.StabilityGenerallyAvailable,
-		uiPrefix:              "/",
-		disableReporting:      false,
-		enablePprof:           true,
-		configFormat:          "alloy",
-		clusterAdvInterfaces:  advertise.DefaultInterfaces,
-		clusterMaxJoinPeers:   5,
-		clusterRejoinInterval: 60 * time.Second,
-		disableSupportBundle:  false,
-		windowsPriority:       windowspriority.PriorityNormal,
-		taskShutdownDeadline:  10 * time.Minute,
+		inMemoryAddr:             "alloy.internal:12345",
+		httpListenAddr:           "127.0.0.1:12345",
+		storagePath:              "data-alloy/",
+		minStability:             featuregate.StabilityGenerallyAvailable,
+		uiPrefix:                 "/",
+		disableReporting:         false,
+		enablePprof:              true,
+		configFormat:             "alloy",
+		clusterAdvInterfaces:     advertise.DefaultInterfaces,
+		clusterMaxJoinPeers:      5,
+		clusterRejoinInterval:    60 * time.Second,
+		clusterNodeWeight:        1,
+		clusterReplicationFactor: 1,
+		disableSupportBundle:     false,
+		windowsPriority:          windowspriority.PriorityNormal,
+		taskShutdownDeadline:     10 * time.Minute,
 	}
 
 	cmd := &cobra.Command{
@@ -90,7 +92,9 @@ into a single unit. Subdirectories are not recursively searched for further merg
 
 run starts an HTTP server which can be used to debug Grafana Alloy or
 force it to reload (by sending a GET or POST request to /-/reload). The listen
-address can be changed through the --server.http.listen-addr flag.
+address can be changed through the --server.http.listen-addr flag. Sending a
+GET or POST request to /-/plan reports the changes a reload would make
+without applying them.
 
 By default, the HTTP server exposes a debugging UI at /. The path of the
 debugging UI can be changed by providing a different value to
@@ -155,6 +159,12 @@ depending on the nature of the reload error.
 		IntVar(&r.clusterWaitForSize, "cluster.wait-for-size", r.clusterWaitForSize, "Wait for the cluster to reach the specified number of instances before allowing components that use clustering to begin processing. Zero means disabled")
 	cmd.Flags().
 		DurationVar(&r.clusterWaitTimeout, "cluster.wait-timeout", 0, "Maximum duration to wait for minimum cluster size before proceeding with available nodes. Zero means wait forever, no timeout")
+	cmd.Flags().
+		Float64Var(&r.clusterNodeWeight, "cluster.node-weight", r.clusterNodeWeight, "Share of the work assigned to this node relative to other nodes. Set to 0 to use the number of CPUs available to the process")
+	cmd.Flags().
+		StringVar(&r.clusterNodeZone, "cluster.node-zone", r.clusterNodeZone, "Zone of this node, such as its availability zone. Owners of a target are picked from distinct zones when possible")
+	cmd.Flags().
+		IntVar(&r.clusterReplicationFactor, "cluster.replication-factor", r.clusterReplicationFactor, "Number of nodes owning each target distributed across the cluster")
 
 	// Config flags
 	cmd.Flags().StringVar(&r.configFormat, "config.format", r.configFormat, fmt.Sprintf("The format of the source file. Supported formats: %s.", supportedFormatsList()))
@@ -172,6 +182,8 @@ depending on the nature of the reload error.
 	}
 	cmd.Flags().DurationVar(&r.taskShutdownDeadline, "feature.component-shutdown-deadline", r.taskShutdownDeadline, "Maximum duration to wait for a component to shut down before giving up and logging an error")
 	cmd.Flags().BoolVar(&r.enableDirectFanout, "feature.prometheus.direct-fanout.enabled", r.enableDirectFanout, "Enable experimental direct fanout for metric forwarding without a global label store")
+	cmd.Flags().DurationVar(&r.configRollbackGracePeriod, "feature.config-rollback.grace-period", r.configRollbackGracePeriod, "Duration to watch component health after a reload before reverting to the last-known-good config if health degraded. Zero means disabled")
+	cmd.Flags().DurationVar(&r.resourceUsageInterval, "feature.component-resource-accounting.interval", r.resourceUsageInterval, "How often to estimate the CPU time, heap allocations, and goroutines of each component. Zero means disabled")
 
 	addDeprecatedFlags(cmd)
 	return cmd
@@ -202,6 +214,9 @@ type alloyRun struct {
 	clusterTLSServerName         string
 	clusterWaitForSize           int
 	clusterWaitTimeout           time.Duration
+	clusterNodeWeight            float64
+	clusterNodeZone              string
+	clusterReplicationFactor     int
 	configFormat                 string
 	configBypassConversionErrors bool
 	configExtraArgs              string
@@ -209,6 +224,8 @@ type alloyRun struct {
 	disableSupportBundle         bool
 	windowsPriority              string
 	taskShutdownDeadline         time.Duration
+	configRollbackGracePeriod    time.Duration
+	resourceUsageInterval        time.Duration
 }
 
 func (fr *alloyRun) checkExperimentalFlags() error {
@@ -220,6 +237,14 @@ func (fr *alloyRun) checkExperimentalFlags() error {
 		return fmt.Errorf("the '--feature.prometheus.direct-fanout.enabled' can be used only at experimental stability level")
 	}
 
+	if fr.configRollbackGracePeriod != 0 {
+		return fmt.Errorf("the '--feature.config-rollback.grace-period' can be used only at experimental stability level")
+	}
+
+	if fr.resourceUsageInterval != 0 {
+		return fmt.Errorf("the '--feature.component-resource-accounting.interval' can be used only at experimental stability level")
+	}
+
 	return nil
 }
 
@@ -308,6 +333,7 @@ func (fr *alloyRun) Run(cmd *cobra.Command, configPath string) error {
 	// service needs and set them after the Alloy controller exists.
 	var (
 		reload func() (map[string][]byte, error)
+		plan   func() (alloy_runtime.Plan, error)
 		ready  func() bool
 	)
 
@@ -333,6 +359,9 @@ func (fr *alloyRun) Run(cmd *cobra.Command, configPath string) error {
 		TLSServerName:          fr.clusterTLSServerName,
 		MinimumClusterSize:     fr.clusterWaitForSize,
 		MinimumSizeWaitTimeout: fr.clusterWaitTimeout,
+		NodeWeight:             nodeWeight(fr.clusterNodeWeight),
+		NodeZone:               fr.clusterNodeZone,
+		ReplicationFactor:      fr.clusterReplicationFactor,
 	})
 	if err != nil {
 		return err
@@ -355,6 +384,7 @@ func (fr *alloyRun) Run(cmd *cobra.Command, configPath string) error {
 			_, err := reload()
 			return err
 		},
+		PlanFunc: func() (alloy_runtime.Plan, error) { return plan() },
 
 		HTTPListenAddr:   fr.httpListenAddr,
 		MemoryListenAddr: fr.inMemoryAddr,
@@ -376,11 +406,15 @@ func (fr *alloyRun) Run(cmd *cobra.Command, configPath string) error {
 		return fmt.Errorf("failed to create the remotecfg service: %w", err)
 	}
 
-	liveDebuggingService := livedebugging.New()
+	liveDebuggingService := livedebugging.New(livedebugging.Options{
+		Logger:      log.With(l, "service", "livedebugging"),
+		StoragePath: fr.storagePath,
+	})
 
 	uiService := uiservice.New(uiservice.Options{
 		UIPrefix:        fr.uiPrefix,
 		CallbackManager: liveDebuggingService.Data().(livedebugging.CallbackManager),
+		CaptureReader:   liveDebuggingService.Data().(livedebugging.CaptureReader),
 		Logger:          log.With(l, "service", "ui"),
 	})
 
@@ -412,7 +446,9 @@ func (fr *alloyRun) Run(cmd *cobra.Command, configPath string) error {
 			remoteCfgService,
 			uiService,
 		},
-		TaskShutdownDeadline: fr.taskShutdownDeadline,
+		TaskShutdownDeadline:  fr.taskShutdownDeadline,
+		RollbackGracePeriod:   fr.configRollbackGracePeriod,
+		ResourceUsageInterval: fr.resourceUsageInterval,
 	})
 	if err != nil {
 		return err
@@ -426,7 +462,7 @@ func (fr *alloyRun) Run(cmd *cobra.Command, configPath string) error {
 			return nil, fmt.Errorf("reading config path %q: %w", configPath, err)
 		}
 
-		alloySource, err := alloy_runtime.ParseSources(sources)
+		alloySource, err := alloy_runtime.ParseSourcesFormat(sources, sourceFormat(fr.configFormat))
 		defer instrumentation.InstrumentConfig(err == nil, hashSourceFiles(sources), fr.clusterName)
 		if err != nil {
 			return sources, fmt.Errorf("reading config path %q: %w", configPath, err)
@@ -439,6 +475,18 @@ func (fr *alloyRun) Run(cmd *cobra.Command, configPath string) error {
 
 		return sources, nil
 	}
+	plan = func() (alloy_runtime.Plan, error) {
+		sources, err := loadSourceFiles(configPath, fr.configFormat, fr.configBypassConversionErrors, fr.configExtraArgs)
+		if err != nil {
+			return alloy_runtime.Plan{}, fmt.Errorf("reading config path %q: %w", configPath, err)
+		}
+
+		alloySource, err := alloy_runtime.ParseSourcesFormat(sources, sourceFormat(fr.configFormat))
+		if err != nil {
+			return alloy_runtime.Plan{}, fmt.Errorf("reading config path %q: %w", configPath, err)
+		}
+		return f.Plan(alloySource, configPath), nil
+	}
 
 	// Alloy controller
 	{
@@ -543,6 +591,13 @@ func loadSourceFiles(path string, converterSourceFormat string, converterBypassE
 	}
 
 	if fi.IsDir() {
+		// Directories hold .alloy files, or .json files with the JSON
+		// representation of Alloy files when using the json format.
+		ext := ".alloy"
+		if sourceFormat(converterSourceFormat) == alloy_runtime.SourceFormatJSON {
+			ext = ".json"
+		}
+
 		sources := map[string][]byte{}
 		err := filepath.WalkDir(path, func(curPath string, d fs.DirEntry, err error) error {
 			if err != nil {
@@ -555,8 +610,8 @@ func loadSourceFiles(path string, converterSourceFormat string, converterBypassE
 				}
 				return nil
 			}
-			// Ignore files not ending in .alloy extension
-			if !strings.HasSuffix(curPath, ".alloy") {
+			// Ignore files not ending in the extension of the format
+			if !strings.HasSuffix(curPath, ext) {
 				return nil
 			}
 
@@ -575,7 +630,8 @@ func loadSourceFiles(path string, converterSourceFormat string, converterBypassE
 	if err != nil {
 		return nil, err
 	}
-	if converterSourceFormat != "alloy" {
+	// JSON sources are parsed by the runtime.
+	if converterSourceFormat != "alloy" && sourceFormat(converterSourceFormat) != alloy_runtime.SourceFormatJSON {
 		var diags convert_diag.Diagnostics
 		ea, err := parseExtraArgs(configExtraArgs)
 		if err != nil {
@@ -593,6 +649,17 @@ func loadSourceFiles(path string, converterSourceFormat string, converterBypassE
 	return map[string][]byte{path: bb}, nil
 }
 
+// sourceFormat returns the format of the sources loaded by loadSourceFiles
+// for the given --config.format. JSON sources are parsed by the runtime so
+// that diagnostics point to the JSON document, and other formats are
+// converted to the Alloy syntax.
+func sourceFormat(configFormat string) alloy_runtime.SourceFormat {
+	if configFormat == string(converter.InputJSON) {
+		return alloy_runtime.SourceFormatJSON
+	}
+	return alloy_runtime.SourceFormatAlloy
+}
+
 func hashSourceFiles(sources map[string][]byte) [sha256.Size]byte {
 	// Combined hash of all the sources.
 	hash := sha256.New()
@@ -0,0 +1,144 @@
+package alloycli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	httpservice "github.com/grafana/alloy/internal/service/http"
+)
+
+func supportBundleCommand() *cobra.Command {
+	s := &alloySupportBundle{
+		output:  "alloy-support-bundle.zip",
+		timeout: 30 * time.Second,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "support-bundle [flags] URL",
+		Short: "Download a support bundle from a running instance",
+		Long: `The support-bundle subcommand downloads a support bundle from the /-/support
+endpoint of the Alloy instance listening at URL, checks that the bundle is
+valid, and writes it to the output file.
+
+The --duration flag sets how many seconds the instance collects profiles,
+logs, and live debugging data for. When --duration isn't set, the instance
+uses its HTTP server write timeout.
+
+The --redact flag makes the instance replace the values of the secrets of
+its components with "(secret)" in the bundle. The --redact-pattern flag can
+be passed multiple times to also replace text matching regular expressions
+with "(redacted)".`,
+		Args:         cobra.ExactArgs(1),
+		SilenceUsage: true,
+
+		RunE: func(_ *cobra.Command, args []string) error {
+			return s.Run(os.Stdout, args[0])
+		},
+	}
+
+	cmd.Flags().StringVarP(&s.output, "output", "o", s.output, "Path of the file to write the support bundle to.")
+	cmd.Flags().IntVar(&s.duration, "duration", s.duration, "Duration, in seconds, of the collection of the support bundle data.")
+	cmd.Flags().DurationVar(&s.timeout, "timeout", s.timeout, "Time to wait for the instance to generate the bundle, on top of --duration.")
+	cmd.Flags().BoolVar(&s.redact, "redact", s.redact, "Redact the secrets of components from the support bundle.")
+	cmd.Flags().StringArrayVar(&s.redactPatterns, "redact-pattern", s.redactPatterns, "Regular expression of text to redact from the support bundle. Can be passed multiple times.")
+	cmd.Flags().StringVar(&s.basicAuthUsername, "basic-auth.username", s.basicAuthUsername, "Username for basic authentication to the instance.")
+	cmd.Flags().StringVar(&s.basicAuthPasswordFile, "basic-auth.password-file", s.basicAuthPasswordFile, "Path of a file holding the password for basic authentication to the instance.")
+	return cmd
+}
+
+type alloySupportBundle struct {
+	output                string
+	duration              int
+	timeout               time.Duration
+	redact                bool
+	redactPatterns        []string
+	basicAuthUsername     string
+	basicAuthPasswordFile string
+}
+
+func (s *alloySupportBundle) Run(w io.Writer, rawURL string) error {
+	req, err := s.newRequest(rawURL)
+	if err != nil {
+		return err
+	}
+
+	client := http.Client{Timeout: time.Duration(s.duration)*time.Second + s.timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to request the support bundle: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read the support bundle: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to generate the support bundle: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	meta, err := httpservice.ValidateSupportBundle(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return fmt.Errorf("invalid support bundle: %w", err)
+	}
+
+	if err := os.WriteFile(s.output, body, 0o600); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Wrote support bundle to %s (%d bytes)\n", s.output, len(body))
+	fmt.Fprintf(w, "Alloy version: %s\n", meta.BuildVersion)
+	fmt.Fprintf(w, "Platform: %s/%s\n", meta.OS, meta.Architecture)
+	fmt.Fprintf(w, "Uptime: %s\n", time.Duration(meta.Uptime*float64(time.Second)).Round(time.Second))
+	return nil
+}
+
+func (s *alloySupportBundle) newRequest(rawURL string) (*http.Request, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL %q: %w", rawURL, err)
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid URL %q: the URL must have a scheme and a host", rawURL)
+	}
+	u = u.JoinPath("/-/support")
+
+	query := u.Query()
+	if s.duration > 0 {
+		query.Set("duration", strconv.Itoa(s.duration))
+	}
+	if s.redact {
+		query.Set("redact", "true")
+	}
+	for _, p := range s.redactPatterns {
+		query.Add("redact_pattern", p)
+	}
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.basicAuthUsername != "" {
+		var password string
+		if s.basicAuthPasswordFile != "" {
+			bb, err := os.ReadFile(s.basicAuthPasswordFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read the basic authentication password: %w", err)
+			}
+			password = strings.TrimSpace(string(bb))
+		}
+		req.SetBasicAuth(s.basicAuthUsername, password)
+	}
+	return req, nil
+}
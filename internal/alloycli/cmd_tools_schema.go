@@ -0,0 +1,98 @@
+package alloycli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/component/schema"
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/syntax/encoding/jsonschema"
+	"github.com/spf13/cobra"
+)
+
+func schemaCommand() *cobra.Command {
+	s := &alloySchema{
+		minStability: featuregate.StabilityGenerallyAvailable,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "schema [flags] [component ...]",
+		Short: "Generate JSON Schema documents for components",
+		Long: `The schema subcommand generates a JSON Schema document describing the
+arguments and exports of each component.
+
+If no components are given, documents are generated for every component
+allowed by the --stability.level and --feature.community-components.enabled
+flags.
+
+By default, a single JSON object mapping component names to their schema is
+written to stdout. If --output.dir is set, a <component>.json file is written
+for each component in that directory instead.`,
+		SilenceUsage: true,
+		RunE: func(_ *cobra.Command, args []string) error {
+			return s.Run(args)
+		},
+	}
+
+	cmd.Flags().StringVar(&s.outputDir, "output.dir", s.outputDir, "Directory to write one schema file per component to.")
+	cmd.Flags().Var(&s.minStability, "stability.level", fmt.Sprintf("Minimum stability level of components to include. Supported values: %s", strings.Join(featuregate.AllowedValues(), ", ")))
+	cmd.Flags().BoolVar(&s.enableCommunityComps, "feature.community-components.enabled", s.enableCommunityComps, "Include community components.")
+
+	return cmd
+}
+
+type alloySchema struct {
+	outputDir            string
+	minStability         featuregate.Stability
+	enableCommunityComps bool
+}
+
+func (s *alloySchema) Run(names []string) error {
+	registry := component.NewDefaultRegistry(s.minStability, s.enableCommunityComps)
+
+	explicit := len(names) > 0
+	if !explicit {
+		names = component.AllNames()
+	}
+
+	schemas := make(map[string]*jsonschema.Schema, len(names))
+	for _, name := range names {
+		reg, err := registry.Get(name)
+		if err != nil {
+			if explicit {
+				return err
+			}
+			// Skip components not allowed by the flags.
+			continue
+		}
+		schemas[name] = schema.Component(reg)
+	}
+
+	if s.outputDir == "" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(schemas)
+	}
+
+	if err := os.MkdirAll(s.outputDir, 0o755); err != nil {
+		return err
+	}
+	var errs []error
+	for name, sch := range schemas {
+		bb, err := json.MarshalIndent(sch, "", "  ")
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		bb = append(bb, '\n')
+		if err := os.WriteFile(filepath.Join(s.outputDir, name+".json"), bb, 0o644); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
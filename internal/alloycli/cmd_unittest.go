@@ -0,0 +1,166 @@
+package alloycli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/grafana/alloy/internal/alloytest"
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/runtime/logging"
+)
+
+// testFileExt is the extension of test files searched for in directories.
+const testFileExt = ".alloytest"
+
+func testCommand() *cobra.Command {
+	t := &alloyTest{
+		minStability: featuregate.StabilityGenerallyAvailable,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "test [flags] path ...",
+		Short: "Run unit tests for configurations",
+		Long: `The test subcommand runs unit tests for configurations.
+
+Each path is either a test file or a directory, which is searched recursively
+for files with the .alloytest extension. Test files hold test blocks which
+load a configuration, the data sent to one of its components and the data
+it's expected to output.
+
+The command exits with a non-zero exit code if any test fails.`,
+		Args:         cobra.MinimumNArgs(1),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := interruptContext(cmd.Context())
+			defer cancel()
+			return t.Run(ctx, os.Stdout, args)
+		},
+	}
+
+	cmd.Flags().StringVar(&t.run, "run", t.run, "Only run the tests whose name matches this regular expression.")
+	cmd.Flags().BoolVarP(&t.verbose, "verbose", "v", t.verbose, "Write the logs of configurations under test to stderr.")
+	cmd.Flags().Var(&t.minStability, "stability.level", fmt.Sprintf("Minimum stability level of features to enable. Supported values: %s", strings.Join(featuregate.AllowedValues(), ", ")))
+	cmd.Flags().BoolVar(&t.enableCommunityComps, "feature.community-components.enabled", t.enableCommunityComps, "Enable community components.")
+
+	return cmd
+}
+
+type alloyTest struct {
+	run                  string
+	verbose              bool
+	minStability         featuregate.Stability
+	enableCommunityComps bool
+}
+
+func (t *alloyTest) Run(ctx context.Context, w io.Writer, paths []string) error {
+	opts := alloytest.Options{
+		MinStability:         t.minStability,
+		EnableCommunityComps: t.enableCommunityComps,
+	}
+	if t.run != "" {
+		re, err := regexp.Compile(t.run)
+		if err != nil {
+			return fmt.Errorf("invalid --run expression: %w", err)
+		}
+		opts.Run = re
+	}
+	if t.verbose {
+		l, err := logging.New(os.Stderr, logging.DefaultOptions)
+		if err != nil {
+			return err
+		}
+		opts.Logger = l
+	}
+
+	files, err := testFiles(paths)
+	if err != nil {
+		return err
+	}
+
+	var failed bool
+	for _, file := range files {
+		bb, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+
+		results, err := alloytest.Run(ctx, opts, file, bb)
+		for _, res := range results {
+			if res.Err == nil {
+				fmt.Fprintf(w, "--- PASS: %s: %s (%.2fs)\n", file, res.Name, res.Duration.Seconds())
+				continue
+			}
+			failed = true
+			fmt.Fprintf(w, "--- FAIL: %s: %s (%.2fs)\n", file, res.Name, res.Duration.Seconds())
+			fmt.Fprintln(w, indent(res.Err.Error(), "    "))
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			failed = true
+			fmt.Fprintf(w, "--- FAIL: %s\n", file)
+			fmt.Fprintln(w, indent(err.Error(), "    "))
+		}
+	}
+
+	if failed {
+		fmt.Fprintln(w, "FAIL")
+		return errors.New("tests failed")
+	}
+	fmt.Fprintln(w, "PASS")
+	return nil
+}
+
+// testFiles returns the test files of paths, searching directories for files
+// with the test file extension.
+func testFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		fi, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !fi.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		err = filepath.WalkDir(path, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && filepath.Ext(path) == testFileExt {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no test files found in %s", strings.Join(paths, ", "))
+	}
+	return files, nil
+}
+
+func indent(s, prefix string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -66,18 +66,11 @@ func (v *alloyValidate) Run(configFile string) error {
 
 	if err := validator.Validate(
 		validator.Options{
-			Sources: sources,
-			ServiceDefinitions: getServiceDefinitions(
-				&cluster.Service{},
-				&http.Service{},
-				&labelstore.Service{},
-				&livedebugging.Service{},
-				&otel.Service{},
-				&remotecfg.Service{},
-				&ui.Service{},
-			),
-			ComponentRegistry: component.NewDefaultRegistry(v.minStability, v.enableCommunityComps),
-			MinStability:      v.minStability,
+			Sources:            sources,
+			Format:             sourceFormat(v.configFormat),
+			ServiceDefinitions: getServiceDefinitions(unconfiguredServices()...),
+			ComponentRegistry:  component.NewDefaultRegistry(v.minStability, v.enableCommunityComps),
+			MinStability:       v.minStability,
 		},
 	); err != nil {
 		validator.Report(os.Stderr, err, sources)
@@ -87,6 +80,20 @@ func (v *alloyValidate) Run(configFile string) error {
 	return nil
 }
 
+// unconfiguredServices returns zero values of the services of the run
+// command, which can only be used for their definitions.
+func unconfiguredServices() []service.Service {
+	return []service.Service{
+		&cluster.Service{},
+		&http.Service{},
+		&labelstore.Service{},
+		&livedebugging.Service{},
+		&otel.Service{},
+		&remotecfg.Service{},
+		&ui.Service{},
+	}
+}
+
 func getServiceDefinitions(services ...service.Service) []service.Definition {
 	def := make([]service.Definition, 0, len(services))
 	for _, s := range services {
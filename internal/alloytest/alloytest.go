@@ -0,0 +1,595 @@
+// Package alloytest runs unit tests for Alloy configurations.
+//
+// Test files use the Alloy syntax. Each top-level test block loads a
+// configuration, sends data to one of its components, and compares the data
+// output by the configuration with the expected data:
+//
+//	test "drops_debug_lines" {
+//		config = "config.alloy"
+//
+//		loki.process "default" {
+//			forward_to = [test.loki]
+//		}
+//
+//		input {
+//			loki {
+//				labels = { job = "app" }
+//				line   = "level=debug msg=hello"
+//			}
+//		}
+//
+//		expect {}
+//	}
+//
+// The configuration is loaded and run by an Alloy runtime, so components
+// declared with declare blocks and imported modules can be tested like
+// builtin components. Blocks written in the test block are added to the
+// configuration, or override the arguments of the block of the configuration
+// with the same ID. Data output by the configuration is captured by sinks
+// which are referenced with test.loki, test.prometheus and test.otelcol.
+package alloytest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/featuregate"
+	alloy_runtime "github.com/grafana/alloy/internal/runtime"
+	"github.com/grafana/alloy/internal/runtime/logging"
+	"github.com/grafana/alloy/internal/service"
+	"github.com/grafana/alloy/internal/service/labelstore"
+	"github.com/grafana/alloy/internal/service/livedebugging"
+	"github.com/grafana/alloy/internal/service/otel"
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/diag"
+	"github.com/grafana/alloy/syntax/parser"
+	"github.com/grafana/alloy/syntax/vm"
+)
+
+const (
+	// defaultTimeout is how long to wait for a component to output the
+	// expected data.
+	defaultTimeout = time.Second
+
+	// pollInterval is how often outputs are compared while waiting.
+	pollInterval = 10 * time.Millisecond
+
+	// settleTime is how long outputs must keep matching the expected data
+	// before a test passes, so that data output late isn't missed.
+	settleTime = 100 * time.Millisecond
+)
+
+// Options configures running tests.
+type Options struct {
+	// MinStability is the minimum stability level of the components which
+	// can be used by configurations under test. Only generally available
+	// components can be used if MinStability is undefined.
+	MinStability featuregate.Stability
+
+	// EnableCommunityComps enables the use of community components.
+	EnableCommunityComps bool
+
+	// Logger receives the logs of configurations under test. Logs are
+	// discarded if Logger is nil.
+	Logger *logging.Logger
+
+	// Run, if set, only runs the tests whose name matches it.
+	Run *regexp.Regexp
+}
+
+// Result is the result of a single test.
+type Result struct {
+	Name     string
+	Duration time.Duration
+
+	// Err is nil if the test passed. Err is a *Failure if the component ran
+	// but didn't output the expected data.
+	Err error
+}
+
+// Failure is the error of tests where the outputs of the component don't
+// match the expected outputs.
+type Failure struct {
+	// Diffs holds the unified diffs between the expected and actual outputs.
+	Diffs []string
+}
+
+// Error implements error.
+func (f *Failure) Error() string {
+	return "unexpected outputs:\n\n" + strings.Join(f.Diffs, "\n")
+}
+
+// Run runs the tests in the test file filename holding src. An error is
+// returned if the file isn't a valid test file, in which case no tests are
+// run.
+func Run(ctx context.Context, opts Options, filename string, src []byte) ([]Result, error) {
+	f, err := parser.ParseFile(filename, src)
+	if err != nil {
+		return nil, err
+	}
+
+	tests, err := parseTests(f)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	for _, tc := range tests {
+		if opts.Run != nil && !opts.Run.MatchString(tc.name) {
+			continue
+		}
+		tc.dir = filepath.Dir(filename)
+		start := time.Now()
+		err := tc.run(ctx, opts, filename, src)
+		results = append(results, Result{Name: tc.name, Duration: time.Since(start), Err: err})
+
+		if ctx.Err() != nil {
+			return results, ctx.Err()
+		}
+	}
+	return results, nil
+}
+
+// testCase is a test block of a test file.
+type testCase struct {
+	name      string
+	block     *ast.BlockStmt
+	config    string           // Path of the configuration under test, relative to dir.
+	component string           // ID of the component under test.
+	blocks    []*ast.BlockStmt // Blocks added to the configuration under test.
+	timeout   time.Duration
+	dir       string // Directory of the test file, to resolve relative paths.
+
+	input  input
+	expect expectation
+}
+
+func parseTests(f *ast.File) ([]*testCase, error) {
+	var (
+		diags diag.Diagnostics
+		tests []*testCase
+		names = map[string]bool{}
+	)
+
+	for _, stmt := range f.Body {
+		block, ok := stmt.(*ast.BlockStmt)
+		if !ok || strings.Join(block.Name, ".") != "test" {
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				StartPos: ast.StartPos(stmt).Position(),
+				EndPos:   ast.EndPos(stmt).Position(),
+				Message:  "test files may only contain test blocks",
+			})
+			continue
+		}
+
+		switch {
+		case block.Label == "":
+			diags.Add(blockError(block, "test blocks must have a label naming the test"))
+			continue
+		case names[block.Label]:
+			diags.Add(blockError(block, fmt.Sprintf("test %q is defined multiple times", block.Label)))
+			continue
+		}
+		names[block.Label] = true
+
+		tc, err := parseTest(block)
+		if err != nil {
+			diags.Merge(err)
+			continue
+		}
+		tests = append(tests, tc)
+	}
+
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return tests, nil
+}
+
+func parseTest(block *ast.BlockStmt) (*testCase, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	tc := &testCase{name: block.Label, block: block, timeout: defaultTimeout}
+	for _, stmt := range block.Body {
+		switch stmt := stmt.(type) {
+		case *ast.AttributeStmt:
+			var target any
+			switch stmt.Name.Name {
+			case "timeout":
+				target = &tc.timeout
+			case "config":
+				target = &tc.config
+			case "component":
+				target = &tc.component
+			default:
+				diags.Add(stmtError(stmt, fmt.Sprintf("unrecognized attribute name %q", stmt.Name.Name)))
+				continue
+			}
+			if err := vm.New(stmt.Value).Evaluate(nil, target); err != nil {
+				diags.Merge(toDiagnostics(err, stmt))
+			}
+
+		case *ast.BlockStmt:
+			switch strings.Join(stmt.Name, ".") {
+			case "input":
+				if err := vm.New(stmt).Evaluate(nil, &tc.input); err != nil {
+					diags.Merge(toDiagnostics(err, stmt))
+				}
+			case "expect":
+				if err := tc.expect.parse(stmt); err != nil {
+					diags.Merge(toDiagnostics(err, stmt))
+				}
+			default:
+				tc.blocks = append(tc.blocks, stmt)
+			}
+		}
+	}
+	if diags.HasErrors() {
+		return tc, diags
+	}
+
+	switch {
+	case tc.config == "" && len(tc.blocks) == 0:
+		diags.Add(blockError(block, fmt.Sprintf("test %q has no configuration: set the config attribute or add blocks to the test", tc.name)))
+	case tc.component == "" && len(tc.blocks) == 1:
+		tc.component = blockID(tc.blocks[0])
+	case tc.component == "":
+		diags.Add(blockError(block, fmt.Sprintf("test %q must set the component attribute to the ID of the component under test", tc.name)))
+	}
+	return tc, diags
+}
+
+// run runs the test, returning a non-nil error if it fails. filename and src
+// are the name and contents of the test file.
+func (tc *testCase) run(ctx context.Context, opts Options, filename string, src []byte) error {
+	in, err := tc.input.load(tc.dir)
+	if err != nil {
+		return err
+	}
+	source, configPath, err := tc.source(filename, src)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	sinks := newSinks(ctx)
+	defer func() {
+		cancel()
+		sinks.stop()
+	}()
+
+	dataPath, err := os.MkdirTemp("", "alloytest-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dataPath)
+
+	logger := opts.Logger
+	if logger == nil {
+		logger = logging.NewNop()
+	}
+	minStability := opts.MinStability
+	if minStability == featuregate.StabilityUndefined {
+		minStability = featuregate.StabilityGenerallyAvailable
+	}
+	f, err := alloy_runtime.New(alloy_runtime.Options{
+		Logger:               logger,
+		DataPath:             dataPath,
+		MinStability:         minStability,
+		EnableCommunityComps: opts.EnableCommunityComps,
+		Services: []service.Service{
+			labelstore.New(logger, prometheus.NewRegistry()),
+			livedebugging.New(livedebugging.Options{}),
+			otel.New(logger),
+		},
+		Variables: sinks.variables(),
+	})
+	if err != nil {
+		return err
+	}
+	if err := f.LoadSource(source, nil, configPath); err != nil {
+		return err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		f.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	id := component.ID{LocalID: tc.component}
+	if err := waitHealthy(ctx, f, id, tc.timeout); err != nil {
+		return err
+	}
+	exports := func() component.Exports {
+		info, err := f.GetComponent(id, component.InfoOptions{GetExports: true})
+		if err != nil {
+			return nil
+		}
+		return info.Exports
+	}
+
+	if err := in.send(ctx, exports()); err != nil {
+		return err
+	}
+
+	// Wait for the outputs to match the expected outputs, and keep matching
+	// them for settleTime.
+	var (
+		deadline = time.Now().Add(tc.timeout)
+		diffs    []string
+	)
+	for {
+		diffs, err = tc.expect.compare(sinks, exports())
+		if err != nil {
+			return err
+		}
+		if len(diffs) == 0 || time.Now().After(deadline) {
+			break
+		}
+		if err := sleep(ctx, pollInterval); err != nil {
+			return err
+		}
+	}
+	if len(diffs) == 0 {
+		if err := sleep(ctx, settleTime); err != nil {
+			return err
+		}
+		if diffs, err = tc.expect.compare(sinks, exports()); err != nil {
+			return err
+		}
+	}
+
+	if len(diffs) > 0 {
+		return &Failure{Diffs: diffs}
+	}
+	return nil
+}
+
+// source returns the configuration under test, and the path it's loaded
+// from. Blocks of the test with the same ID as a block of the configuration
+// override the block of the configuration, and the other blocks of the test
+// are added to the configuration.
+func (tc *testCase) source(filename string, src []byte) (*alloy_runtime.Source, string, error) {
+	files := map[string][]byte{}
+	configPath := filename
+	if tc.config != "" {
+		configPath = tc.config
+		if !filepath.IsAbs(configPath) {
+			configPath = filepath.Join(tc.dir, configPath)
+		}
+		var err error
+		if files, err = readConfig(configPath); err != nil {
+			return nil, "", err
+		}
+		// A test file in the directory of the configuration isn't part of it.
+		delete(files, filename)
+	}
+
+	// Find the blocks of the configuration overridden by the test.
+	ids := map[string]bool{}
+	for name, bb := range files {
+		f, err := parser.ParseFile(name, bb)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, stmt := range f.Body {
+			if block, ok := stmt.(*ast.BlockStmt); ok {
+				ids[blockID(block)] = true
+			}
+		}
+	}
+	var added []*ast.BlockStmt
+	overrides := map[string]*ast.BlockStmt{}
+	for _, block := range tc.blocks {
+		if ids[blockID(block)] {
+			overrides[blockID(block)] = block
+		} else {
+			added = append(added, block)
+		}
+	}
+	if len(added) > 0 {
+		files[filename] = keepBlocks(src, added)
+	}
+
+	source, err := alloy_runtime.ParseSources(files)
+	if err != nil {
+		return nil, "", err
+	}
+	for _, blocks := range [][]*ast.BlockStmt{source.Components(), source.Configs(), source.Declares()} {
+		for _, block := range blocks {
+			if with, ok := overrides[blockID(block)]; ok {
+				override(block, with)
+			}
+		}
+	}
+	return source, configPath, nil
+}
+
+// readConfig reads the configuration at path, which is either a file or a
+// directory holding .alloy files.
+func readConfig(path string) (map[string][]byte, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		bb, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return map[string][]byte{path: bb}, nil
+	}
+
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	files := map[string][]byte{}
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".alloy" {
+			continue
+		}
+		name := filepath.Join(path, e.Name())
+		bb, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		files[name] = bb
+	}
+	return files, nil
+}
+
+// keepBlocks returns src with everything but blocks replaced with spaces.
+// Newlines are kept so that the positions of diagnostics don't change.
+func keepBlocks(src []byte, blocks []*ast.BlockStmt) []byte {
+	out := make([]byte, len(src))
+	for i, c := range src {
+		if c == '\n' {
+			out[i] = c
+		} else {
+			out[i] = ' '
+		}
+	}
+	for _, block := range blocks {
+		start, end := ast.StartPos(block).Position().Offset, ast.EndPos(block).Position().Offset+1
+		copy(out[start:end], src[start:end])
+	}
+	return out
+}
+
+// override overrides the arguments of block with the arguments of with.
+// Attributes of with replace the attributes of block with the same name, and
+// blocks of with replace all the blocks of block with the same name.
+func override(block, with *ast.BlockStmt) {
+	var (
+		attrs  = map[string]bool{}
+		blocks = map[string][]ast.Stmt{}
+		body   ast.Body
+	)
+	for _, stmt := range with.Body {
+		switch stmt := stmt.(type) {
+		case *ast.AttributeStmt:
+			attrs[stmt.Name.Name] = true
+			body = append(body, stmt)
+		case *ast.BlockStmt:
+			name := strings.Join(stmt.Name, ".")
+			blocks[name] = append(blocks[name], stmt)
+		}
+	}
+
+	replaced := map[string]bool{}
+	for _, stmt := range block.Body {
+		switch stmt := stmt.(type) {
+		case *ast.AttributeStmt:
+			if attrs[stmt.Name.Name] {
+				continue
+			}
+		case *ast.BlockStmt:
+			name := strings.Join(stmt.Name, ".")
+			if _, ok := blocks[name]; ok {
+				// Keep the overridden blocks where the first block with the
+				// same name was, as the order of blocks may matter.
+				if !replaced[name] {
+					body = append(body, blocks[name]...)
+					replaced[name] = true
+				}
+				continue
+			}
+		}
+		body = append(body, stmt)
+	}
+	for _, stmt := range with.Body {
+		stmt, ok := stmt.(*ast.BlockStmt)
+		if !ok || replaced[strings.Join(stmt.Name, ".")] {
+			continue
+		}
+		name := strings.Join(stmt.Name, ".")
+		body = append(body, blocks[name]...)
+		replaced[name] = true
+	}
+	block.Body = body
+}
+
+// waitHealthy waits for the component id to be healthy.
+func waitHealthy(ctx context.Context, f *alloy_runtime.Runtime, id component.ID, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		info, err := f.GetComponent(id, component.InfoOptions{GetHealth: true})
+		if errors.Is(err, component.ErrComponentNotFound) {
+			return fmt.Errorf("the configuration doesn't have a component %q", id.LocalID)
+		} else if err != nil {
+			return err
+		}
+		if info.Health.Health == component.HealthTypeHealthy {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("component %q isn't healthy: %s", id.LocalID, info.Health.Message)
+		}
+		if err := sleep(ctx, pollInterval); err != nil {
+			return err
+		}
+	}
+}
+
+func blockID(block *ast.BlockStmt) string {
+	id := strings.Join(block.Name, ".")
+	if block.Label != "" {
+		id += "." + block.Label
+	}
+	return id
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
+
+func blockError(block *ast.BlockStmt, msg string) diag.Diagnostic {
+	return diag.Diagnostic{
+		Severity: diag.SeverityLevelError,
+		StartPos: ast.StartPos(block).Position(),
+		EndPos:   block.LCurlyPos.Position(),
+		Message:  msg,
+	}
+}
+
+func stmtError(stmt ast.Stmt, msg string) diag.Diagnostic {
+	return diag.Diagnostic{
+		Severity: diag.SeverityLevelError,
+		StartPos: ast.StartPos(stmt).Position(),
+		EndPos:   ast.EndPos(stmt).Position(),
+		Message:  msg,
+	}
+}
+
+// toDiagnostics converts err into diagnostics, using the position of stmt
+// for errors which aren't diagnostics already.
+func toDiagnostics(err error, stmt ast.Stmt) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if errors.As(err, &diags) {
+		return diags
+	}
+	return diag.Diagnostics{stmtError(stmt, err.Error())}
+}
@@ -0,0 +1,483 @@
+package alloytest_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/alloytest"
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/syntax/diag"
+
+	_ "github.com/grafana/alloy/internal/component/discovery/relabel"
+	_ "github.com/grafana/alloy/internal/component/loki/process"
+	_ "github.com/grafana/alloy/internal/component/loki/write"
+	_ "github.com/grafana/alloy/internal/component/otelcol/processor/attributes"
+	_ "github.com/grafana/alloy/internal/component/prometheus/relabel"
+)
+
+func runTests(t *testing.T, src string) []alloytest.Result {
+	t.Helper()
+
+	return runTestFile(t, "test.alloy", src)
+}
+
+func runTestFile(t *testing.T, filename, src string) []alloytest.Result {
+	t.Helper()
+
+	results, err := alloytest.Run(t.Context(), alloytest.Options{
+		MinStability:         featuregate.StabilityExperimental,
+		EnableCommunityComps: true,
+	}, filename, []byte(src))
+	require.NoError(t, err)
+	return results
+}
+
+func TestRun_Loki(t *testing.T) {
+	results := runTests(t, `
+		test "drops_debug_lines" {
+			loki.process "default" {
+				forward_to = [test.loki]
+
+				stage.drop {
+					expression = ".*level=debug.*"
+				}
+				stage.static_labels {
+					values = { env = "prod" }
+				}
+			}
+
+			input {
+				loki {
+					labels = { job = "app" }
+					line   = "level=debug msg=hello"
+				}
+				loki {
+					labels    = { job = "app" }
+					line      = "level=info msg=hello"
+					timestamp = "2024-01-01T00:00:00Z"
+				}
+			}
+
+			expect {
+				loki {
+					labels    = { job = "app", env = "prod" }
+					line      = "level=info msg=hello"
+					timestamp = "2024-01-01T00:00:00Z"
+				}
+			}
+		}
+
+		test "wrong_line" {
+			timeout = "200ms"
+
+			loki.process "default" {
+				forward_to = [test.loki]
+			}
+
+			input {
+				loki {
+					labels = { job = "app" }
+					line   = "level=info msg=hello"
+				}
+			}
+
+			expect {
+				loki {
+					labels = { job = "app" }
+					line   = "level=info msg=bye"
+				}
+			}
+		}
+	`)
+
+	require.Len(t, results, 2)
+	require.Equal(t, "drops_debug_lines", results[0].Name)
+	require.NoError(t, results[0].Err)
+
+	require.Equal(t, "wrong_line", results[1].Name)
+	var failure *alloytest.Failure
+	require.ErrorAs(t, results[1].Err, &failure)
+	require.Equal(t, []string{`--- expected log entries
++++ actual log entries
+@@ -1 +1 @@
+-{job="app"} "level=info msg=bye"
++{job="app"} "level=info msg=hello"
+`}, failure.Diffs)
+}
+
+func TestRun_Prometheus(t *testing.T) {
+	results := runTests(t, `
+		test "relabels_samples" {
+			prometheus.relabel "default" {
+				forward_to = [test.prometheus]
+
+				rule {
+					action        = "replace"
+					source_labels = ["instance"]
+					target_label  = "host"
+				}
+				rule {
+					action = "labeldrop"
+					regex  = "instance"
+				}
+			}
+
+			input {
+				sample {
+					name   = "up"
+					labels = { instance = "localhost:9090" }
+					value  = 1
+				}
+			}
+
+			expect {
+				sample {
+					name   = "up"
+					labels = { host = "localhost:9090" }
+					value  = 1
+				}
+			}
+		}
+	`)
+
+	require.Len(t, results, 1)
+	require.NoError(t, results[0].Err)
+}
+
+func TestRun_Discovery(t *testing.T) {
+	results := runTests(t, `
+		test "keeps_matching_targets" {
+			discovery.relabel "default" {
+				targets = [
+					{ __address__ = "a:80", app = "web" },
+					{ __address__ = "b:80", app = "db" },
+				]
+
+				rule {
+					action        = "keep"
+					source_labels = ["app"]
+					regex         = "web"
+				}
+			}
+
+			expect {
+				exports = {
+					output = [{ __address__ = "a:80", app = "web" }],
+				}
+			}
+		}
+	`)
+
+	require.Len(t, results, 1)
+	require.NoError(t, results[0].Err)
+}
+
+func TestRun_OTLP(t *testing.T) {
+	results := runTests(t, `
+		test "inserts_attributes" {
+			otelcol.processor.attributes "default" {
+				action {
+					key    = "env"
+					value  = "prod"
+					action = "insert"
+				}
+
+				output {
+					traces = [test.otelcol]
+				}
+			}
+
+			input {
+				otlp {
+					traces = "{\"resourceSpans\":[{\"scopeSpans\":[{\"spans\":[{\"name\":\"op\"}]}]}]}"
+				}
+			}
+
+			expect {
+				otlp {
+					traces = "{\"resourceSpans\":[{\"scopeSpans\":[{\"spans\":[{\"name\":\"op\",\"attributes\":[{\"key\":\"env\",\"value\":{\"stringValue\":\"prod\"}}]}]}]}]}"
+				}
+			}
+		}
+	`)
+
+	require.Len(t, results, 1)
+	require.NoError(t, results[0].Err)
+}
+
+func TestRun_Capture(t *testing.T) {
+	dir := t.TempDir()
+	capture := `{"timestamp":"2024-01-01T00:00:00Z","component_id":"loki.source.file.default","type":"loki_log","count":1,"data":"a","replay":{"loki":{"labels":{"job":"app"},"line":"level=info msg=a","timestamp":"2024-01-01T00:00:00Z"}}}
+{"timestamp":"2024-01-01T00:00:01Z","component_id":"loki.source.file.other","type":"loki_log","count":1,"data":"b","replay":{"loki":{"labels":{"job":"other"},"line":"level=info msg=b","timestamp":"2024-01-01T00:00:01Z"}}}
+{"timestamp":"2024-01-01T00:00:02Z","component_id":"loki.source.file.default","type":"loki_log","count":1,"data":"not replayable"}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "capture.ndjson"), []byte(capture), 0o644))
+
+	results, err := alloytest.Run(t.Context(), alloytest.Options{}, filepath.Join(dir, "test.alloytest"), []byte(`
+		test "replays_capture" {
+			loki.process "default" {
+				forward_to = [test.loki]
+
+				stage.static_labels {
+					values = { env = "prod" }
+				}
+			}
+
+			input {
+				capture {
+					path   = "capture.ndjson"
+					filter = "{component_id=\"loki.source.file.default\"}"
+				}
+			}
+
+			expect {
+				loki {
+					labels    = { job = "app", env = "prod" }
+					line      = "level=info msg=a"
+					timestamp = "2024-01-01T00:00:00Z"
+				}
+			}
+		}
+
+		test "missing_capture" {
+			loki.process "default" {
+				forward_to = [test.loki]
+			}
+
+			input {
+				capture {
+					path = "missing.ndjson"
+				}
+			}
+		}
+	`))
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	require.NoError(t, results[0].Err)
+	require.ErrorContains(t, results[1].Err, "reading capture")
+}
+
+func TestRun_Filter(t *testing.T) {
+	results, err := alloytest.Run(context.Background(), alloytest.Options{
+		Run: regexp.MustCompile("^b"),
+	}, "test.alloy", []byte(`
+		test "a" {
+			loki.process "default" {
+				forward_to = []
+			}
+		}
+		test "b" {
+			loki.process "default" {
+				forward_to = []
+			}
+		}
+	`))
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.Equal(t, "b", results[0].Name)
+	require.NoError(t, results[0].Err)
+}
+
+func TestRun_Errors(t *testing.T) {
+	tt := []struct {
+		name   string
+		input  string
+		expect string
+	}{
+		{
+			name:   "not a test block",
+			input:  `logging {}`,
+			expect: `test.alloy:1:1: test files may only contain test blocks`,
+		},
+		{
+			name:   "missing label",
+			input:  `test { }`,
+			expect: `test.alloy:1:1: test blocks must have a label naming the test`,
+		},
+		{
+			name:   "duplicate test",
+			input:  "test \"a\" {\n loki.process \"default\" {}\n}\ntest \"a\" {\n loki.process \"default\" {}\n}",
+			expect: `test.alloy:4:1: test "a" is defined multiple times`,
+		},
+		{
+			name:   "missing configuration",
+			input:  `test "a" { }`,
+			expect: `test.alloy:1:1: test "a" has no configuration: set the config attribute or add blocks to the test`,
+		},
+		{
+			name:   "ambiguous component",
+			input:  "test \"a\" {\n loki.process \"a\" {}\n loki.process \"b\" {}\n}",
+			expect: `test.alloy:1:1: test "a" must set the component attribute to the ID of the component under test`,
+		},
+		{
+			name:   "invalid input",
+			input:  "test \"a\" {\n loki.process \"default\" {}\n input {\n  loki { labels = {} }\n }\n}",
+			expect: `test.alloy:3:2: missing required attribute "line"`,
+		},
+		{
+			name:   "invalid OTLP",
+			input:  "test \"a\" {\n loki.process \"default\" {}\n input {\n  otlp { traces = \"[\" }\n }\n}",
+			expect: `test.alloy:3:2: invalid OTLP traces`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := alloytest.Run(t.Context(), alloytest.Options{}, "test.alloy", []byte(tc.input))
+
+			var diags diag.Diagnostics
+			require.ErrorAs(t, err, &diags)
+			require.Contains(t, diags[0].Error(), tc.expect)
+		})
+	}
+}
+
+func TestRun_Config(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "config.alloy"), []byte(`
+		loki.process "default" {
+			forward_to = [loki.write.default.receiver]
+
+			stage.static_labels {
+				values = { env = "prod" }
+			}
+			stage.drop {
+				expression = ".*level=debug.*"
+			}
+		}
+
+		loki.write "default" {
+			endpoint {
+				url = "http://localhost:3100/loki/api/v1/push"
+			}
+		}
+	`), 0o644))
+
+	results := runTestFile(t, filepath.Join(dir, "config.alloytest"), `
+		test "overrides_output" {
+			config    = "config.alloy"
+			component = "loki.process.default"
+
+			loki.process "default" {
+				forward_to = [test.loki]
+			}
+
+			input {
+				loki {
+					labels = { job = "app" }
+					line   = "level=debug msg=hello"
+				}
+				loki {
+					labels = { job = "app" }
+					line   = "level=info msg=hello"
+				}
+			}
+
+			expect {
+				loki {
+					labels = { job = "app", env = "prod" }
+					line   = "level=info msg=hello"
+				}
+			}
+		}
+
+		test "overrides_stages" {
+			config = "."
+
+			loki.process "default" {
+				forward_to = [test.loki]
+
+				stage.drop {
+					expression = ".*level=info.*"
+				}
+			}
+
+			input {
+				loki {
+					labels = { job = "app" }
+					line   = "level=info msg=hello"
+				}
+				loki {
+					labels = { job = "app" }
+					line   = "level=warn msg=hello"
+				}
+			}
+
+			expect {
+				loki {
+					labels = { job = "app", env = "prod" }
+					line   = "level=warn msg=hello"
+				}
+			}
+		}
+
+		test "unknown_component" {
+			config    = "config.alloy"
+			component = "loki.process.missing"
+		}
+	`)
+
+	require.Len(t, results, 3)
+	require.NoError(t, results[0].Err)
+	require.NoError(t, results[1].Err)
+	require.ErrorContains(t, results[2].Err, `the configuration doesn't have a component "loki.process.missing"`)
+}
+
+func TestRun_Declare(t *testing.T) {
+	results := runTests(t, `
+		test "custom_component" {
+			component = "add_env.default"
+
+			declare "add_env" {
+				argument "env" {}
+				argument "forward_to" {}
+
+				loki.process "labels" {
+					forward_to = argument.forward_to.value
+
+					stage.static_labels {
+						values = { env = argument.env.value }
+					}
+				}
+
+				export "receiver" {
+					value = loki.process.labels.receiver
+				}
+				export "env" {
+					value = argument.env.value
+				}
+			}
+
+			add_env "default" {
+				env        = "prod"
+				forward_to = [test.loki]
+			}
+
+			input {
+				loki {
+					labels = { job = "app" }
+					line   = "hello"
+				}
+			}
+
+			expect {
+				loki {
+					labels = { job = "app", env = "prod" }
+					line   = "hello"
+				}
+
+				exports = {
+					env = "prod",
+				}
+			}
+		}
+	`)
+
+	require.Len(t, results, 1)
+	require.NoError(t, results[0].Err)
+}
@@ -0,0 +1,92 @@
+package alloytest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/grafana/alloy/internal/service/livedebugging"
+)
+
+// input is the data sent to a component.
+type input struct {
+	Loki     []lokiEntry `alloy:"loki,block,optional"`
+	Samples  []sample    `alloy:"sample,block,optional"`
+	OTLP     []otlpData  `alloy:"otlp,block,optional"`
+	Captures []capture   `alloy:"capture,block,optional"`
+}
+
+// capture replays live debugging data captured to disk, as downloaded from
+// the API of the UI.
+type capture struct {
+	Path   string `alloy:"path,attr"`
+	Filter string `alloy:"filter,attr,optional"`
+}
+
+// Validate implements syntax.Validator.
+func (c *capture) Validate() error {
+	_, err := livedebugging.ParseMatchers(c.Filter)
+	return err
+}
+
+// load returns the data of the captures. Relative paths are resolved from
+// dir. Records which can't be replayed are ignored.
+func (in *input) load(dir string) (data, error) {
+	res := data{
+		Loki:    append([]lokiEntry(nil), in.Loki...),
+		Samples: append([]sample(nil), in.Samples...),
+		OTLP:    append([]otlpData(nil), in.OTLP...),
+	}
+
+	for _, c := range in.Captures {
+		path := c.Path
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		// The filter was checked in Validate.
+		matchers, _ := livedebugging.ParseMatchers(c.Filter)
+
+		f, err := os.Open(path)
+		if err != nil {
+			return res, fmt.Errorf("reading capture: %w", err)
+		}
+		err = livedebugging.ReadRecords(f, livedebugging.Filter{Matchers: matchers}, func(r livedebugging.Record) error {
+			return res.addReplay(r.Replay)
+		})
+		f.Close()
+		if err != nil {
+			return res, fmt.Errorf("reading capture %s: %w", c.Path, err)
+		}
+	}
+	return res, nil
+}
+
+func (d *data) addReplay(r *livedebugging.Replay) error {
+	switch {
+	case r == nil:
+	case r.Loki != nil:
+		d.Loki = append(d.Loki, lokiEntry{
+			Labels:             r.Loki.Labels,
+			Line:               r.Loki.Line,
+			Timestamp:          r.Loki.Timestamp,
+			StructuredMetadata: r.Loki.StructuredMetadata,
+		})
+	case r.Sample != nil:
+		d.Samples = append(d.Samples, sample{
+			Labels:    r.Sample.Labels,
+			Value:     r.Sample.Value,
+			Timestamp: r.Sample.Timestamp,
+		})
+	case r.OTLP != nil:
+		o := otlpData{
+			Traces:  string(r.OTLP.Traces),
+			Metrics: string(r.OTLP.Metrics),
+			Logs:    string(r.OTLP.Logs),
+		}
+		if err := o.Validate(); err != nil {
+			return err
+		}
+		d.OTLP = append(d.OTLP, o)
+	}
+	return nil
+}
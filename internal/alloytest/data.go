@@ -0,0 +1,439 @@
+package alloytest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/loki/pkg/push"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/storage"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/component/common/loki"
+	"github.com/grafana/alloy/internal/component/otelcol"
+	"github.com/grafana/alloy/internal/runtime/equality"
+	"github.com/grafana/alloy/internal/util/testappender"
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/token/builder"
+	"github.com/grafana/alloy/syntax/vm"
+)
+
+// data is the data sent to a component, or expected to be output by it.
+type data struct {
+	Loki    []lokiEntry `alloy:"loki,block,optional"`
+	Samples []sample    `alloy:"sample,block,optional"`
+	OTLP    []otlpData  `alloy:"otlp,block,optional"`
+}
+
+// lokiEntry is a Loki log entry.
+type lokiEntry struct {
+	Labels             map[string]string `alloy:"labels,attr,optional"`
+	Line               string            `alloy:"line,attr"`
+	Timestamp          time.Time         `alloy:"timestamp,attr,optional"`
+	StructuredMetadata map[string]string `alloy:"structured_metadata,attr,optional"`
+}
+
+func (e lokiEntry) entry(now time.Time) loki.Entry {
+	ts := e.Timestamp
+	if ts.IsZero() {
+		ts = now
+	}
+
+	lset := make(model.LabelSet, len(e.Labels))
+	for k, v := range e.Labels {
+		lset[model.LabelName(k)] = model.LabelValue(v)
+	}
+	var metadata push.LabelsAdapter
+	for _, k := range slices.Sorted(maps.Keys(e.StructuredMetadata)) {
+		metadata = append(metadata, push.LabelAdapter{Name: k, Value: e.StructuredMetadata[k]})
+	}
+
+	return loki.NewEntry(lset, push.Entry{Timestamp: ts, Line: e.Line, StructuredMetadata: metadata})
+}
+
+// sample is a Prometheus sample.
+type sample struct {
+	Name      string            `alloy:"name,attr,optional"`
+	Labels    map[string]string `alloy:"labels,attr,optional"`
+	Value     float64           `alloy:"value,attr"`
+	Timestamp time.Time         `alloy:"timestamp,attr,optional"`
+}
+
+func (s sample) labels() labels.Labels {
+	m := make(map[string]string, len(s.Labels)+1)
+	for k, v := range s.Labels {
+		m[k] = v
+	}
+	if s.Name != "" {
+		m[model.MetricNameLabel] = s.Name
+	}
+	return labels.FromMap(m)
+}
+
+// otlpData holds OTLP data encoded as JSON.
+type otlpData struct {
+	Traces  string `alloy:"traces,attr,optional"`
+	Metrics string `alloy:"metrics,attr,optional"`
+	Logs    string `alloy:"logs,attr,optional"`
+}
+
+// Validate implements syntax.Validator.
+func (o *otlpData) Validate() error {
+	_, _, _, err := o.decode()
+	return err
+}
+
+func (o *otlpData) decode() (ptrace.Traces, pmetric.Metrics, plog.Logs, error) {
+	var (
+		traces  = ptrace.NewTraces()
+		metrics = pmetric.NewMetrics()
+		logs    = plog.NewLogs()
+		err     error
+	)
+	if o.Traces != "" {
+		if traces, err = (&ptrace.JSONUnmarshaler{}).UnmarshalTraces([]byte(o.Traces)); err != nil {
+			return traces, metrics, logs, fmt.Errorf("invalid OTLP traces: %w", err)
+		}
+	}
+	if o.Metrics != "" {
+		if metrics, err = (&pmetric.JSONUnmarshaler{}).UnmarshalMetrics([]byte(o.Metrics)); err != nil {
+			return traces, metrics, logs, fmt.Errorf("invalid OTLP metrics: %w", err)
+		}
+	}
+	if o.Logs != "" {
+		if logs, err = (&plog.JSONUnmarshaler{}).UnmarshalLogs([]byte(o.Logs)); err != nil {
+			return traces, metrics, logs, fmt.Errorf("invalid OTLP logs: %w", err)
+		}
+	}
+	return traces, metrics, logs, nil
+}
+
+// otlp returns the OTLP data of all otlp blocks, merged by signal.
+func (d *data) otlp() (ptrace.Traces, pmetric.Metrics, plog.Logs) {
+	traces, metrics, logs := ptrace.NewTraces(), pmetric.NewMetrics(), plog.NewLogs()
+	for _, o := range d.OTLP {
+		// Blocks were validated when decoded.
+		t, m, l, _ := o.decode()
+		t.ResourceSpans().MoveAndAppendTo(traces.ResourceSpans())
+		m.ResourceMetrics().MoveAndAppendTo(metrics.ResourceMetrics())
+		l.ResourceLogs().MoveAndAppendTo(logs.ResourceLogs())
+	}
+	return traces, metrics, logs
+}
+
+// send sends the data to the component with the given exports. The data is
+// sent to the first export of the matching type.
+func (d *data) send(ctx context.Context, exports component.Exports) error {
+	now := time.Now()
+
+	if len(d.Loki) > 0 {
+		var receiver loki.LogsReceiver
+		if !findExport(exports, &receiver) {
+			return fmt.Errorf("the component doesn't export a receiver for log entries")
+		}
+		for _, e := range d.Loki {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case receiver.Chan() <- e.entry(now):
+			}
+		}
+	}
+
+	if len(d.Samples) > 0 {
+		var appendable storage.Appendable
+		if !findExport(exports, &appendable) {
+			return fmt.Errorf("the component doesn't export a receiver for samples")
+		}
+		app := appendable.Appender(ctx)
+		for _, s := range d.Samples {
+			ts := s.Timestamp
+			if ts.IsZero() {
+				ts = now
+			}
+			if _, err := app.Append(0, s.labels(), ts.UnixMilli(), s.Value); err != nil {
+				_ = app.Rollback()
+				return fmt.Errorf("appending sample: %w", err)
+			}
+		}
+		if err := app.Commit(); err != nil {
+			return fmt.Errorf("committing samples: %w", err)
+		}
+	}
+
+	if len(d.OTLP) > 0 {
+		var consumer otelcol.Consumer
+		if !findExport(exports, &consumer) {
+			return fmt.Errorf("the component doesn't export an input for OTLP data")
+		}
+		traces, metrics, logs := d.otlp()
+		if traces.ResourceSpans().Len() > 0 {
+			if err := consumer.ConsumeTraces(ctx, traces); err != nil {
+				return fmt.Errorf("sending traces: %w", err)
+			}
+		}
+		if metrics.ResourceMetrics().Len() > 0 {
+			if err := consumer.ConsumeMetrics(ctx, metrics); err != nil {
+				return fmt.Errorf("sending metrics: %w", err)
+			}
+		}
+		if logs.ResourceLogs().Len() > 0 {
+			if err := consumer.ConsumeLogs(ctx, logs); err != nil {
+				return fmt.Errorf("sending logs: %w", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// findExport sets target to the first field of exports which can be assigned
+// to it, reporting whether one was found. The exports of custom components
+// are maps, whose values are searched in the order of their keys.
+func findExport(exports component.Exports, target any) bool {
+	dst := reflect.ValueOf(target).Elem()
+	if m, ok := exports.(map[string]any); ok {
+		for _, k := range slices.Sorted(maps.Keys(m)) {
+			v := reflect.ValueOf(m[k])
+			if v.IsValid() && v.Type().AssignableTo(dst.Type()) {
+				dst.Set(v)
+				return true
+			}
+		}
+		return false
+	}
+
+	rv := reflect.ValueOf(exports)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return false
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return false
+	}
+
+	for i := 0; i < rv.NumField(); i++ {
+		field := rv.Field(i)
+		if !rv.Type().Field(i).IsExported() || !field.Type().AssignableTo(dst.Type()) {
+			continue
+		}
+		if field.Kind() == reflect.Interface && field.IsNil() {
+			continue
+		}
+		dst.Set(field)
+		return true
+	}
+	return false
+}
+
+// expectation is the expected outputs of a component.
+type expectation struct {
+	data
+	exports []*ast.ObjectField
+}
+
+// parse parses an expect block. The exports attribute is kept as an
+// expression, as it's decoded into the types of the exports of the component.
+func (e *expectation) parse(block *ast.BlockStmt) error {
+	var rest ast.Body
+	for _, stmt := range block.Body {
+		attr, ok := stmt.(*ast.AttributeStmt)
+		if !ok || attr.Name.Name != "exports" {
+			rest = append(rest, stmt)
+			continue
+		}
+		obj, ok := attr.Value.(*ast.ObjectExpr)
+		if !ok {
+			return stmtError(attr, "exports must be an object literal")
+		}
+		e.exports = append(e.exports, obj.Fields...)
+	}
+	return vm.New(&ast.BlockStmt{Name: block.Name, NamePos: block.NamePos, Body: rest, LCurlyPos: block.LCurlyPos, RCurlyPos: block.RCurlyPos}).Evaluate(nil, &e.data)
+}
+
+// compare compares the expected outputs to the outputs captured by sinks and
+// the current exports of the component, returning the diffs of outputs which
+// don't match.
+func (e *expectation) compare(s *sinks, exports component.Exports) ([]string, error) {
+	var diffs []string
+	add := func(what string, expect, actual []string) {
+		if slices.Equal(expect, actual) {
+			return
+		}
+		diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        expect,
+			B:        actual,
+			FromFile: "expected " + what,
+			ToFile:   "actual " + what,
+			Context:  3,
+		})
+		diffs = append(diffs, diff)
+	}
+
+	expectEntries, actualEntries := e.lokiLines(s.lokiEntries())
+	add("log entries", expectEntries, actualEntries)
+
+	expectSamples, actualSamples := e.sampleLines(s.prometheus.app.CollectedSamples())
+	add("samples", expectSamples, actualSamples)
+
+	expectTraces, expectMetrics, expectLogs := e.otlp()
+	actualTraces, actualMetrics, actualLogs := s.otelcol.received()
+	add("traces", otlpLines(expectTraces.ResourceSpans().Len(), (&ptrace.JSONMarshaler{}).MarshalTraces, expectTraces),
+		otlpLines(actualTraces.ResourceSpans().Len(), (&ptrace.JSONMarshaler{}).MarshalTraces, actualTraces))
+	add("metrics", otlpLines(expectMetrics.ResourceMetrics().Len(), (&pmetric.JSONMarshaler{}).MarshalMetrics, expectMetrics),
+		otlpLines(actualMetrics.ResourceMetrics().Len(), (&pmetric.JSONMarshaler{}).MarshalMetrics, actualMetrics))
+	add("logs", otlpLines(expectLogs.ResourceLogs().Len(), (&plog.JSONMarshaler{}).MarshalLogs, expectLogs),
+		otlpLines(actualLogs.ResourceLogs().Len(), (&plog.JSONMarshaler{}).MarshalLogs, actualLogs))
+
+	for _, field := range e.exports {
+		expect, actual, err := exportValues(exports, field)
+		if err != nil {
+			return nil, err
+		}
+		if !equality.DeepEqual(expect, actual) {
+			add(fmt.Sprintf("export %q", field.Name.Name), valueLines(expect), valueLines(actual))
+		}
+	}
+
+	return diffs, nil
+}
+
+// lokiLines formats the expected and actual log entries. Timestamps and
+// structured metadata are only compared for entries which expect them.
+func (e *expectation) lokiLines(actual []loki.Entry) (expectLines, actualLines []string) {
+	for i, entry := range actual {
+		withTimestamp, withMetadata := true, true
+		if i < len(e.Loki) {
+			withTimestamp = !e.Loki[i].Timestamp.IsZero()
+			withMetadata = e.Loki[i].StructuredMetadata != nil
+		}
+		actualLines = append(actualLines, formatEntry(entry, withTimestamp, withMetadata))
+	}
+	for _, expect := range e.Loki {
+		entry := expect.entry(expect.Timestamp)
+		expectLines = append(expectLines, formatEntry(entry, !expect.Timestamp.IsZero(), expect.StructuredMetadata != nil))
+	}
+	return expectLines, actualLines
+}
+
+func formatEntry(entry loki.Entry, withTimestamp, withMetadata bool) string {
+	var sb strings.Builder
+	sb.WriteString(entry.Labels.String())
+	if withTimestamp {
+		sb.WriteString(" " + entry.Timestamp.UTC().Format(time.RFC3339Nano))
+	}
+	sb.WriteString(" " + strconv.Quote(entry.Line))
+	if withMetadata {
+		metadata := make(model.LabelSet, len(entry.StructuredMetadata))
+		for _, l := range entry.StructuredMetadata {
+			metadata[model.LabelName(l.Name)] = model.LabelValue(l.Value)
+		}
+		sb.WriteString(" " + metadata.String())
+	}
+	return sb.String() + "\n"
+}
+
+// sampleLines formats the expected and actual samples, sorted by series.
+// Timestamps are only compared for series which expect them.
+func (e *expectation) sampleLines(actual map[string]*testappender.MetricSample) (expectLines, actualLines []string) {
+	withTimestamp := map[string]bool{}
+	for _, s := range e.Samples {
+		lbls := s.labels()
+		withTimestamp[lbls.String()] = !s.Timestamp.IsZero()
+		expectLines = append(expectLines, formatSample(lbls, s.Value, s.Timestamp.UnixMilli(), !s.Timestamp.IsZero()))
+	}
+	for _, s := range actual {
+		ts, ok := withTimestamp[s.Labels.String()]
+		actualLines = append(actualLines, formatSample(s.Labels, s.Value, s.Timestamp, ts || !ok))
+	}
+	slices.Sort(expectLines)
+	slices.Sort(actualLines)
+	return expectLines, actualLines
+}
+
+func formatSample(lbls labels.Labels, value float64, ts int64, withTimestamp bool) string {
+	line := lbls.String() + " " + strconv.FormatFloat(value, 'g', -1, 64)
+	if withTimestamp {
+		line += " @ " + time.UnixMilli(ts).UTC().Format(time.RFC3339Nano)
+	}
+	return line + "\n"
+}
+
+// otlpLines formats OTLP data as indented JSON. Data without resources is
+// formatted as no lines.
+func otlpLines[T any](resources int, marshal func(T) ([]byte, error), v T) []string {
+	if resources == 0 {
+		return nil
+	}
+	bb, err := marshal(v)
+	if err != nil {
+		return []string{fmt.Sprintf("error encoding data: %s\n", err)}
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, bb, "", "  "); err != nil {
+		return difflib.SplitLines(string(bb))
+	}
+	return difflib.SplitLines(indented.String())
+}
+
+// exportValues returns the expected and actual values of the export named by
+// field.
+func exportValues(exports component.Exports, field *ast.ObjectField) (expect, actual any, err error) {
+	rv := reflect.ValueOf(exports)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		rv = rv.Elem()
+	}
+
+	name := field.Name.Name
+	if m, ok := exports.(map[string]any); ok {
+		if actual, ok := m[name]; ok {
+			// Decode the expected value into the type of the actual value, so
+			// that values such as numbers are compared with the same type.
+			expect := reflect.New(reflect.TypeFor[any]())
+			if actual != nil {
+				expect = reflect.New(reflect.TypeOf(actual))
+			}
+			if err := vm.New(field.Value).Evaluate(nil, expect.Interface()); err != nil {
+				return nil, nil, err
+			}
+			return expect.Elem().Interface(), actual, nil
+		}
+	}
+	if rv.Kind() == reflect.Struct {
+		for i := 0; i < rv.NumField(); i++ {
+			tag, _, _ := strings.Cut(rv.Type().Field(i).Tag.Get("alloy"), ",")
+			if tag != name {
+				continue
+			}
+
+			actual := rv.Field(i)
+			expect := reflect.New(actual.Type())
+			if err := vm.New(field.Value).Evaluate(nil, expect.Interface()); err != nil {
+				return nil, nil, err
+			}
+			return expect.Elem().Interface(), actual.Interface(), nil
+		}
+	}
+	return nil, nil, fmt.Errorf("%s: the component doesn't have an export named %q", field.Name.NamePos.Position(), name)
+}
+
+func valueLines(v any) []string {
+	expr := builder.NewExpr()
+	expr.SetValue(v)
+	return difflib.SplitLines(string(expr.Bytes()) + "\n")
+}
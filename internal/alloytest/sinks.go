@@ -0,0 +1,147 @@
+package alloytest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/prometheus/prometheus/storage"
+	otelconsumer "go.opentelemetry.io/collector/consumer"
+	"go.opentelemetry.io/collector/pdata/plog"
+	"go.opentelemetry.io/collector/pdata/pmetric"
+	"go.opentelemetry.io/collector/pdata/ptrace"
+
+	"github.com/grafana/alloy/internal/component/common/loki"
+	"github.com/grafana/alloy/internal/component/otelcol"
+	"github.com/grafana/alloy/internal/util/testappender"
+)
+
+// sinks capture the data output by the component under test.
+type sinks struct {
+	loki       loki.LogsReceiver
+	prometheus *appendable
+	otelcol    *consumer
+
+	mut     sync.Mutex
+	entries []loki.Entry
+
+	wg sync.WaitGroup
+}
+
+func newSinks(ctx context.Context) *sinks {
+	s := &sinks{
+		loki:       loki.NewLogsReceiver(loki.WithComponentID("test")),
+		prometheus: &appendable{app: testappender.NewCollectingAppender()},
+		otelcol: &consumer{
+			traces:  ptrace.NewTraces(),
+			metrics: pmetric.NewMetrics(),
+			logs:    plog.NewLogs(),
+		},
+	}
+
+	s.wg.Go(func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case entry := <-s.loki.Chan():
+				s.mut.Lock()
+				s.entries = append(s.entries, entry)
+				s.mut.Unlock()
+			}
+		}
+	})
+	return s
+}
+
+// variables returns the variables exposing the sinks to the configuration
+// under test.
+func (s *sinks) variables() map[string]any {
+	return map[string]any{
+		"test": map[string]any{
+			"loki":       s.loki,
+			"prometheus": storage.Appendable(s.prometheus),
+			"otelcol":    otelcol.Consumer(s.otelcol),
+		},
+	}
+}
+
+// lokiEntries returns the log entries received so far.
+func (s *sinks) lokiEntries() []loki.Entry {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return append([]loki.Entry(nil), s.entries...)
+}
+
+// stop waits for the sinks to stop once the context passed to newSinks is
+// canceled.
+func (s *sinks) stop() { s.wg.Wait() }
+
+// appendable is a storage.Appendable collecting the samples appended to it.
+type appendable struct {
+	app testappender.CollectingAppender
+}
+
+var _ storage.Appendable = (*appendable)(nil)
+
+// Appender implements storage.Appendable.
+func (a *appendable) Appender(context.Context) storage.Appender { return a.app }
+
+// consumer is an otelcol.Consumer collecting the data passed to it.
+type consumer struct {
+	mut     sync.Mutex
+	traces  ptrace.Traces
+	metrics pmetric.Metrics
+	logs    plog.Logs
+}
+
+var _ otelcol.Consumer = (*consumer)(nil)
+
+// Capabilities implements otelcol.Consumer.
+func (c *consumer) Capabilities() otelconsumer.Capabilities {
+	return otelconsumer.Capabilities{MutatesData: false}
+}
+
+// ConsumeTraces implements otelcol.Consumer.
+func (c *consumer) ConsumeTraces(_ context.Context, td ptrace.Traces) error {
+	cp := ptrace.NewTraces()
+	td.CopyTo(cp)
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	cp.ResourceSpans().MoveAndAppendTo(c.traces.ResourceSpans())
+	return nil
+}
+
+// ConsumeMetrics implements otelcol.Consumer.
+func (c *consumer) ConsumeMetrics(_ context.Context, md pmetric.Metrics) error {
+	cp := pmetric.NewMetrics()
+	md.CopyTo(cp)
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	cp.ResourceMetrics().MoveAndAppendTo(c.metrics.ResourceMetrics())
+	return nil
+}
+
+// ConsumeLogs implements otelcol.Consumer.
+func (c *consumer) ConsumeLogs(_ context.Context, ld plog.Logs) error {
+	cp := plog.NewLogs()
+	ld.CopyTo(cp)
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	cp.ResourceLogs().MoveAndAppendTo(c.logs.ResourceLogs())
+	return nil
+}
+
+// received returns copies of the data received so far.
+func (c *consumer) received() (ptrace.Traces, pmetric.Metrics, plog.Logs) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	traces, metrics, logs := ptrace.NewTraces(), pmetric.NewMetrics(), plog.NewLogs()
+	c.traces.CopyTo(traces)
+	c.metrics.CopyTo(metrics)
+	c.logs.CopyTo(logs)
+	return traces, metrics, logs
+}
@@ -40,8 +40,10 @@ import (
 	_ "github.com/grafana/alloy/internal/component/local/file_match"                         // Import local.file_match
 	_ "github.com/grafana/alloy/internal/component/loki/echo"                                // Import loki.echo
 	_ "github.com/grafana/alloy/internal/component/loki/enrich"                              // Import loki.enrich
+	_ "github.com/grafana/alloy/internal/component/loki/patterns"                            // Import loki.patterns
 	_ "github.com/grafana/alloy/internal/component/loki/process"                             // Import loki.process
 	_ "github.com/grafana/alloy/internal/component/loki/relabel"                             // Import loki.relabel
+	_ "github.com/grafana/alloy/internal/component/loki/route"                               // Import loki.route
 	_ "github.com/grafana/alloy/internal/component/loki/rules/kubernetes"                    // Import loki.rules.kubernetes
 	_ "github.com/grafana/alloy/internal/component/loki/secretfilter"                        // Import loki.secretfilter
 	_ "github.com/grafana/alloy/internal/component/loki/source/api"                          // Import loki.source.api
@@ -64,6 +64,10 @@ type InfoOptions struct {
 	GetArguments bool // When true, sets the Arguments field of returned components.
 	GetExports   bool // When true, sets the Exports field of returned components.
 	GetDebugInfo bool // When true, sets the DebugInfo field of returned components.
+
+	// When true, sets the ResourceUsage field of returned components if
+	// resource accounting is enabled.
+	GetResourceUsage bool
 }
 
 // String returns the "<ModuleID>/<LocalID>" string representation of the id.
@@ -130,12 +134,22 @@ type Info struct {
 	Exports              Exports   // Current exports value of the component.
 	DebugInfo            any       // Current debug info of the component.
 	LiveDebuggingEnabled bool
+
+	// ResourceUsage is the estimated resource usage of the component. It is
+	// nil if resource accounting is disabled or the component isn't running.
+	ResourceUsage *ResourceUsage
 }
 
 // MarshalJSON returns a JSON representation of cd. The format of the
 // representation is not stable and is subject to change.
 func (info *Info) MarshalJSON() ([]byte, error) {
 	type (
+		componentResourceUsageJSON struct {
+			Goroutines     int     `json:"goroutines"`
+			CPUSeconds     float64 `json:"cpuSeconds"`
+			AllocatedBytes float64 `json:"allocatedBytes"`
+		}
+
 		componentHealthJSON struct {
 			State       string    `json:"state"`
 			Message     string    `json:"message"`
@@ -143,21 +157,22 @@ func (info *Info) MarshalJSON() ([]byte, error) {
 		}
 
 		componentDetailJSON struct {
-			Name                 string               `json:"name"`
-			Type                 string               `json:"type,omitempty"`
-			LocalID              string               `json:"localID"`
-			ModuleID             string               `json:"moduleID"`
-			Label                string               `json:"label,omitempty"`
-			References           []string             `json:"referencesTo"`
-			ReferencedBy         []string             `json:"referencedBy"`
-			DataFlowEdgesTo      []string             `json:"dataFlowEdgesTo"`
-			Health               *componentHealthJSON `json:"health"`
-			Original             string               `json:"original"`
-			Arguments            json.RawMessage      `json:"arguments,omitempty"`
-			Exports              json.RawMessage      `json:"exports,omitempty"`
-			DebugInfo            json.RawMessage      `json:"debugInfo,omitempty"`
-			CreatedModuleIDs     []string             `json:"createdModuleIDs,omitempty"`
-			LiveDebuggingEnabled bool                 `json:"liveDebuggingEnabled"`
+			Name                 string                      `json:"name"`
+			Type                 string                      `json:"type,omitempty"`
+			LocalID              string                      `json:"localID"`
+			ModuleID             string                      `json:"moduleID"`
+			Label                string                      `json:"label,omitempty"`
+			References           []string                    `json:"referencesTo"`
+			ReferencedBy         []string                    `json:"referencedBy"`
+			DataFlowEdgesTo      []string                    `json:"dataFlowEdgesTo"`
+			Health               *componentHealthJSON        `json:"health"`
+			Original             string                      `json:"original"`
+			Arguments            json.RawMessage             `json:"arguments,omitempty"`
+			Exports              json.RawMessage             `json:"exports,omitempty"`
+			DebugInfo            json.RawMessage             `json:"debugInfo,omitempty"`
+			CreatedModuleIDs     []string                    `json:"createdModuleIDs,omitempty"`
+			LiveDebuggingEnabled bool                        `json:"liveDebuggingEnabled"`
+			ResourceUsage        *componentResourceUsageJSON `json:"resourceUsage,omitempty"`
 		}
 	)
 
@@ -167,6 +182,7 @@ func (info *Info) MarshalJSON() ([]byte, error) {
 		dataFlowEdgesTo = info.DataFlowEdgesTo
 
 		arguments, exports, debugInfo json.RawMessage
+		resourceUsage                 *componentResourceUsageJSON
 		err                           error
 	)
 
@@ -192,6 +208,13 @@ func (info *Info) MarshalJSON() ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
+	if info.ResourceUsage != nil {
+		resourceUsage = &componentResourceUsageJSON{
+			Goroutines:     info.ResourceUsage.Goroutines,
+			CPUSeconds:     info.ResourceUsage.CPUSeconds,
+			AllocatedBytes: info.ResourceUsage.AllocatedBytes,
+		}
+	}
 
 	return json.Marshal(&componentDetailJSON{
 		Name:            info.ComponentName,
@@ -212,6 +235,7 @@ func (info *Info) MarshalJSON() ([]byte, error) {
 		DebugInfo:            debugInfo,
 		CreatedModuleIDs:     info.ModuleIDs,
 		LiveDebuggingEnabled: info.LiveDebuggingEnabled,
+		ResourceUsage:        resourceUsage,
 	})
 }
 
@@ -0,0 +1,26 @@
+package component
+
+// ProfileLabel is the pprof label set on the goroutines of a running
+// component. The value of the label is the global ID of the component, and is
+// inherited by the goroutines the component starts.
+//
+// The label is set on the goroutine which runs the component, so CPU and
+// goroutine profiles can be filtered by component. Heap profiles don't
+// support labels.
+const ProfileLabel = "component_id"
+
+// ResourceUsage is an estimate of the resources used by a running component,
+// computed from profiles filtered by [ProfileLabel].
+type ResourceUsage struct {
+	// Goroutines is the current number of goroutines started by the
+	// component.
+	Goroutines int
+
+	// CPUSeconds is the estimated CPU time used by the component since
+	// resource accounting started, in seconds.
+	CPUSeconds float64
+
+	// AllocatedBytes is the estimated number of heap bytes allocated by the
+	// component since resource accounting started.
+	AllocatedBytes float64
+}
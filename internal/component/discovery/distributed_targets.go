@@ -1,6 +1,8 @@
 package discovery
 
 import (
+	"slices"
+
 	"github.com/grafana/ckit/peer"
 	"github.com/grafana/ckit/shard"
 
@@ -30,18 +32,22 @@ func NewDistributedTargetsWithCustomLabels(clusteringEnabled bool, cluster clust
 		cluster = disabledCluster{}
 	}
 
+	// Targets can't be owned by more nodes than can own them.
+	replicationFactor := min(cluster.ReplicationFactor(), participants(cluster.Peers()))
+	replicationFactor = max(replicationFactor, 1)
+
 	var localCap int
 	if !cluster.Ready() {
 		localCap = 0 // cluster not ready - won't take any traffic locally
 	} else if peerCount := len(cluster.Peers()); peerCount != 0 {
-		localCap = (len(allTargets) + 1) / peerCount // if we have peers - calculate expected capacity
+		localCap = (len(allTargets)*replicationFactor + 1) / peerCount // if we have peers - calculate expected capacity
 	} else {
 		localCap = len(allTargets) // cluster ready but no peers? fall back to all traffic locally
 	}
 
 	localTargets := make([]Target, 0, localCap)
 	localTargetKeys := make([]shard.Key, 0, localCap)
-	remoteTargetKeys := make(map[shard.Key]struct{}, len(allTargets)-localCap)
+	remoteTargetKeys := make(map[shard.Key]struct{}, max(len(allTargets)-localCap, 0))
 
 	// Need to handle duplicate entries.
 	unique := make(map[shard.Key]struct{})
@@ -61,10 +67,11 @@ func NewDistributedTargetsWithCustomLabels(clusteringEnabled bool, cluster clust
 		unique[targetKey] = struct{}{}
 
 		// Determine if target belongs locally. Make sure it doesn't if cluster not ready.
+		// With replication, the target belongs to all of its owners.
 		belongsToLocal := false
 		if cluster.Ready() {
-			peers, err := cluster.Lookup(targetKey, 1, shard.OpReadWrite)
-			belongsToLocal = err != nil || len(peers) == 0 || peers[0].Self
+			peers, err := cluster.Lookup(targetKey, replicationFactor, shard.OpReadWrite)
+			belongsToLocal = err != nil || len(peers) == 0 || slices.ContainsFunc(peers[:min(len(peers), replicationFactor)], isSelf)
 		}
 
 		if belongsToLocal {
@@ -109,6 +116,19 @@ func (dt *DistributedTargets) MovedToRemoteInstance(prev *DistributedTargets) []
 	return movedAwayTargets
 }
 
+func isSelf(p peer.Peer) bool { return p.Self }
+
+// participants returns the number of peers which can own targets.
+func participants(peers []peer.Peer) int {
+	var n int
+	for _, p := range peers {
+		if p.State == peer.StateParticipant {
+			n++
+		}
+	}
+	return n
+}
+
 func keyFor(tgt Target) shard.Key {
 	return shard.Key(tgt.NonMetaLabelsHash())
 }
@@ -132,3 +152,12 @@ func (l disabledCluster) Peers() []peer.Peer {
 func (l disabledCluster) Ready() bool {
 	return true
 }
+
+func (l disabledCluster) ReplicationFactor() int {
+	return 1
+}
+
+// KV returns nil, as disabledCluster is only used to distribute targets.
+func (l disabledCluster) KV() cluster.KV {
+	return nil
+}
@@ -95,6 +95,38 @@ var localTargetsTestCases = []struct {
 			target1, target2,
 		},
 	},
+	{
+		name: "targets replicated to local node are seen as local",
+		cluster: &fakeCluster{
+			peers:             allTestPeers,
+			replicationFactor: 2,
+			lookupMap: map[shard.Key][]peer.Peer{
+				keyFor(target1): {peer2, peer1Self},
+				keyFor(target2): {peer2, peer3},
+				keyFor(target3): {peer1Self, peer3},
+			},
+		},
+		allTargets: allTestTargets,
+		expectedLocalTargets: []Target{
+			target1, target3,
+		},
+	},
+	{
+		name: "replication factor is capped to the number of participants",
+		cluster: &fakeCluster{
+			peers:             allTestPeers,
+			replicationFactor: 5,
+			lookupMap: map[shard.Key][]peer.Peer{
+				keyFor(target1): {peer2, peer3, peer1Self},
+				keyFor(target2): {peer2, peer3},
+				keyFor(target3): {peer3, peer2},
+			},
+		},
+		allTargets: allTestTargets,
+		expectedLocalTargets: []Target{
+			target1,
+		},
+	},
 	{
 		name: "lookup errors fall back to local target assignment",
 		cluster: &fakeCluster{
@@ -300,14 +332,18 @@ func testDistTargets(lookupMap map[shard.Key][]peer.Peer) *DistributedTargets {
 }
 
 type fakeCluster struct {
-	lookupMap map[shard.Key][]peer.Peer
-	peers     []peer.Peer
+	lookupMap         map[shard.Key][]peer.Peer
+	peers             []peer.Peer
+	replicationFactor int
 }
 
-func (f *fakeCluster) Lookup(key shard.Key, _ int, _ shard.Op) ([]peer.Peer, error) {
+func (f *fakeCluster) Lookup(key shard.Key, numOwners int, _ shard.Op) ([]peer.Peer, error) {
 	if key == magicErrorKey {
 		return nil, fmt.Errorf("test error for magic error key")
 	}
+	if numOwners > len(f.peers) {
+		return nil, fmt.Errorf("not enough peers")
+	}
 	return f.lookupMap[key], nil
 }
 
@@ -318,3 +354,11 @@ func (f *fakeCluster) Peers() []peer.Peer {
 func (f *fakeCluster) Ready() bool {
 	return true
 }
+
+func (f *fakeCluster) ReplicationFactor() int {
+	return max(f.replicationFactor, 1)
+}
+
+func (f *fakeCluster) KV() cluster.KV {
+	return nil
+}
@@ -102,6 +102,14 @@ func (c *Component) Update(args component.Arguments) error {
 			livedebugging.Target,
 			1,
 			func() string { return fmt.Sprintf("%s => %s", t, relabelled) },
+			livedebugging.WithAttributes(t.AsMap),
+			livedebugging.WithPayload(func() any {
+				diff := livedebugging.LabelsDiff{Before: t.AsMap(), Dropped: !keep}
+				if keep {
+					diff.After = relabelled.AsMap()
+				}
+				return diff
+			}),
 		))
 	}
 
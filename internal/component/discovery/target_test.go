@@ -16,6 +16,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/alloy/internal/runtime/equality"
+	"github.com/grafana/alloy/internal/service/cluster"
 	"github.com/grafana/alloy/syntax"
 	"github.com/grafana/alloy/syntax/parser"
 	"github.com/grafana/alloy/syntax/token/builder"
@@ -1119,6 +1120,14 @@ func (f *randomCluster) Ready() bool {
 	return true
 }
 
+func (f *randomCluster) ReplicationFactor() int {
+	return 1
+}
+
+func (f *randomCluster) KV() cluster.KV {
+	return nil
+}
+
 func mapToLabelSet(m map[string]string) model.LabelSet {
 	r := make(model.LabelSet, len(m))
 	for k, v := range m {
@@ -0,0 +1,225 @@
+package patterns
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/hashicorp/golang-lru/v2/simplelru"
+)
+
+// paramString replaces the variable tokens of a pattern. It matches the
+// placeholder used by the Loki pattern parser, so templates can be used in
+// LogQL queries.
+const paramString = "<_>"
+
+// drainConfig configures the Drain clustering algorithm.
+type drainConfig struct {
+	depth               int     // Depth of the prefix tree, including the root, the length layer and the leaves.
+	maxChildren         int     // Maximum number of children of a tree node.
+	maxClusters         int     // Maximum number of clusters. Least recently used clusters are evicted.
+	similarityThreshold float64 // Minimum similarity of a line with a cluster for the line to join it.
+}
+
+// cluster is a group of log lines sharing a template.
+type cluster struct {
+	id     int
+	tokens []string
+}
+
+// template returns the template of the cluster, where variable tokens are
+// replaced with paramString.
+func (c *cluster) template() string {
+	return strings.Join(c.tokens, " ")
+}
+
+type drainNode struct {
+	children   map[string]*drainNode
+	clusterIDs []int
+}
+
+func newDrainNode() *drainNode {
+	return &drainNode{children: make(map[string]*drainNode)}
+}
+
+// drain implements the Drain log clustering algorithm, as described in
+// "Drain: An Online Log Parsing Approach with Fixed Depth Tree" by He et al.
+//
+// Lines are split into tokens on whitespace. The first layer of the prefix
+// tree groups lines by number of tokens, and the following layers by their
+// first tokens. The line joins the most similar cluster of the leaf it
+// reaches if the similarity is above the threshold, or creates a new cluster
+// otherwise.
+//
+// drain isn't safe for concurrent use.
+type drain struct {
+	cfg      drainConfig
+	root     *drainNode
+	clusters *simplelru.LRU[int, *cluster]
+	nextID   int
+}
+
+// newDrain creates a new drain. onEvict is called when a cluster is evicted
+// to stay below the maximum number of clusters.
+func newDrain(cfg drainConfig, onEvict func(*cluster)) *drain {
+	clusters, _ := simplelru.NewLRU(cfg.maxClusters, func(_ int, c *cluster) {
+		if onEvict != nil {
+			onEvict(c)
+		}
+	})
+	return &drain{
+		cfg:      cfg,
+		root:     newDrainNode(),
+		clusters: clusters,
+	}
+}
+
+// train adds a line to its cluster, creating the cluster if needed. It
+// returns the cluster and whether it was created.
+func (d *drain) train(line string) (*cluster, bool) {
+	tokens := strings.Fields(line)
+
+	match := d.treeSearch(tokens)
+	if match == nil {
+		d.nextID++
+		match = &cluster{id: d.nextID, tokens: tokens}
+		d.clusters.Add(match.id, match)
+		d.addToPrefixTree(match)
+		return match, true
+	}
+
+	// Tokens which differ from the template become parameters.
+	for i, tok := range tokens {
+		if match.tokens[i] != tok {
+			match.tokens[i] = paramString
+		}
+	}
+	// Mark the cluster as recently used.
+	d.clusters.Get(match.id)
+	return match, false
+}
+
+// remove removes the cluster with the given ID, calling the eviction
+// callback of the drain.
+func (d *drain) remove(id int) {
+	d.clusters.Remove(id)
+}
+
+// clusterList returns all clusters, from least to most recently used.
+func (d *drain) clusterList() []*cluster {
+	return d.clusters.Values()
+}
+
+// treeSearch returns the cluster the tokens belong to, or nil if there is no
+// cluster similar enough.
+func (d *drain) treeSearch(tokens []string) *cluster {
+	node, ok := d.root.children[strconv.Itoa(len(tokens))]
+	if !ok {
+		return nil
+	}
+
+	for i := 0; i < d.tokenLayers() && i < len(tokens); i++ {
+		tok := tokens[i]
+		next, ok := node.children[tok]
+		if !ok {
+			next, ok = node.children[paramString]
+		}
+		if !ok {
+			return nil
+		}
+		node = next
+	}
+
+	var (
+		best          *cluster
+		bestSim       = -1.0
+		bestParamsCnt = -1
+	)
+	for _, id := range node.clusterIDs {
+		// Peek doesn't update the recency of clusters which don't match.
+		c, ok := d.clusters.Peek(id)
+		if !ok {
+			continue
+		}
+		sim, params := similarity(c.tokens, tokens)
+		if sim > bestSim || (sim == bestSim && params > bestParamsCnt) {
+			best, bestSim, bestParamsCnt = c, sim, params
+		}
+	}
+	if bestSim < d.cfg.similarityThreshold {
+		return nil
+	}
+	return best
+}
+
+// tokenLayers returns the number of layers of the prefix tree which match
+// tokens, which excludes the root, the length layer and the leaves.
+func (d *drain) tokenLayers() int {
+	return d.cfg.depth - 3
+}
+
+func (d *drain) addToPrefixTree(c *cluster) {
+	lengthKey := strconv.Itoa(len(c.tokens))
+	node, ok := d.root.children[lengthKey]
+	if !ok {
+		node = newDrainNode()
+		d.root.children[lengthKey] = node
+	}
+
+	for i := 0; i < d.tokenLayers() && i < len(c.tokens); i++ {
+		tok := c.tokens[i]
+
+		if next, ok := node.children[tok]; ok {
+			node = next
+			continue
+		}
+
+		// Tokens with digits are likely variable, and would make the tree grow
+		// without bounds.
+		if hasDigit(tok) {
+			tok = paramString
+		} else if len(node.children)+1 >= d.cfg.maxChildren {
+			// Keep room for the parameter child.
+			tok = paramString
+		}
+
+		next, ok := node.children[tok]
+		if !ok {
+			next = newDrainNode()
+			node.children[tok] = next
+		}
+		node = next
+	}
+
+	// Drop the IDs of evicted clusters while adding the new one.
+	ids := node.clusterIDs[:0]
+	for _, id := range node.clusterIDs {
+		if d.clusters.Contains(id) {
+			ids = append(ids, id)
+		}
+	}
+	node.clusterIDs = append(ids, c.id)
+}
+
+// similarity returns the ratio of tokens of a line equal to the tokens of a
+// template, and the number of parameters of the template.
+func similarity(template, tokens []string) (float64, int) {
+	if len(tokens) == 0 {
+		return 1, 0
+	}
+
+	var equal, params int
+	for i, tok := range template {
+		switch {
+		case tok == paramString:
+			params++
+		case tok == tokens[i]:
+			equal++
+		}
+	}
+	return float64(equal) / float64(len(tokens)), params
+}
+
+func hasDigit(s string) bool {
+	return strings.IndexFunc(s, unicode.IsDigit) >= 0
+}
@@ -0,0 +1,83 @@
+package patterns
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testDrainConfig() drainConfig {
+	return drainConfig{
+		depth:               4,
+		maxChildren:         15,
+		maxClusters:         300,
+		similarityThreshold: 0.3,
+	}
+}
+
+func TestDrain(t *testing.T) {
+	d := newDrain(testDrainConfig(), nil)
+
+	lines := []string{
+		"user alice logged in from 10.0.0.1",
+		"user bob logged in from 10.0.0.2",
+		"connection to db-1 closed after 30s",
+		"user carol logged in from 10.0.0.3",
+		"connection to db-2 closed after 12s",
+		"starting server",
+	}
+	ids := make([]int, len(lines))
+	for i, line := range lines {
+		c, _ := d.train(line)
+		ids[i] = c.id
+	}
+
+	require.Equal(t, ids[0], ids[1])
+	require.Equal(t, ids[0], ids[3])
+	require.Equal(t, ids[2], ids[4])
+	require.NotEqual(t, ids[0], ids[2])
+	require.NotEqual(t, ids[0], ids[5])
+
+	var templates []string
+	for _, c := range d.clusterList() {
+		templates = append(templates, c.template())
+	}
+	require.ElementsMatch(t, []string{
+		"user <_> logged in from <_>",
+		"connection to <_> closed after <_>",
+		"starting server",
+	}, templates)
+}
+
+func TestDrainCreated(t *testing.T) {
+	d := newDrain(testDrainConfig(), nil)
+
+	_, created := d.train("request served in 10ms")
+	require.True(t, created)
+	_, created = d.train("request served in 12ms")
+	require.False(t, created)
+	_, created = d.train("request served")
+	require.True(t, created, "lines with a different number of tokens have different patterns")
+}
+
+func TestDrainEviction(t *testing.T) {
+	cfg := testDrainConfig()
+	cfg.maxClusters = 2
+
+	var evicted []string
+	d := newDrain(cfg, func(c *cluster) {
+		evicted = append(evicted, c.template())
+	})
+
+	d.train("first line")
+	d.train("second pattern here")
+	d.train("first line")
+	d.train("third pattern with more tokens")
+
+	require.Equal(t, []string{"second pattern here"}, evicted)
+	require.Len(t, d.clusterList(), 2)
+
+	// The evicted pattern is detected again.
+	_, created := d.train("second pattern here")
+	require.True(t, created)
+}
@@ -0,0 +1,58 @@
+package patterns
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/alloy/internal/util"
+)
+
+type metrics struct {
+	entriesTotal     *prometheus.CounterVec
+	patterns         prometheus.Gauge
+	streams          prometheus.Gauge
+	newPatterns      prometheus.Counter
+	evictedPatterns  prometheus.Counter
+	entriesProcessed prometheus.Counter
+}
+
+// newMetrics creates a new set of metrics. If reg is non-nil, the metrics
+// will also be registered.
+func newMetrics(reg prometheus.Registerer) *metrics {
+	var m metrics
+
+	m.entriesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "loki_patterns_pattern_entries_total",
+		Help: "Total number of log entries matching each pattern.",
+	}, []string{"pattern_id"})
+	m.patterns = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "loki_patterns_patterns",
+		Help: "Number of patterns currently tracked.",
+	})
+	m.streams = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "loki_patterns_streams",
+		Help: "Number of streams currently tracked.",
+	})
+	m.newPatterns = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "loki_patterns_new_patterns_total",
+		Help: "Total number of new patterns detected.",
+	})
+	m.evictedPatterns = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "loki_patterns_evicted_patterns_total",
+		Help: "Total number of patterns evicted to stay below the maximum number of patterns or streams.",
+	})
+	m.entriesProcessed = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "loki_patterns_entries_processed_total",
+		Help: "Total number of log entries processed.",
+	})
+
+	if reg != nil {
+		m.entriesTotal = util.MustRegisterOrGet(reg, m.entriesTotal).(*prometheus.CounterVec)
+		m.patterns = util.MustRegisterOrGet(reg, m.patterns).(prometheus.Gauge)
+		m.streams = util.MustRegisterOrGet(reg, m.streams).(prometheus.Gauge)
+		m.newPatterns = util.MustRegisterOrGet(reg, m.newPatterns).(prometheus.Counter)
+		m.evictedPatterns = util.MustRegisterOrGet(reg, m.evictedPatterns).(prometheus.Counter)
+		m.entriesProcessed = util.MustRegisterOrGet(reg, m.entriesProcessed).(prometheus.Counter)
+	}
+
+	return &m
+}
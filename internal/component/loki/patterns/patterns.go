@@ -0,0 +1,412 @@
+package patterns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/grafana/loki/pkg/push"
+	"github.com/hashicorp/golang-lru/v2/simplelru"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/component/common/loki"
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/service/livedebugging"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:      "loki.patterns",
+		Stability: featuregate.StabilityExperimental,
+		Args:      Arguments{},
+		Exports:   Exports{},
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// Arguments holds values which are used to configure the loki.patterns
+// component.
+type Arguments struct {
+	ForwardTo []loki.LogsReceiver `alloy:"forward_to,attr"`
+
+	// Labels identifying the stream of an entry. All labels are used if empty.
+	StreamLabels []string `alloy:"stream_labels,attr,optional"`
+
+	SimilarityThreshold float64 `alloy:"similarity_threshold,attr,optional"`
+	TreeDepth           int     `alloy:"tree_depth,attr,optional"`
+	MaxChildren         int     `alloy:"max_children,attr,optional"`
+	MaxPatterns         int     `alloy:"max_patterns_per_stream,attr,optional"`
+	MaxStreams          int     `alloy:"max_streams,attr,optional"`
+
+	// Maximum number of patterns across all streams, which bounds the number
+	// of series of the per-pattern metrics.
+	MaxTotalPatterns int `alloy:"max_patterns,attr,optional"`
+
+	// Structured metadata keys to store the pattern ID and template of an
+	// entry in. Empty keys disable the structured metadata.
+	PatternIDKey string `alloy:"pattern_id_key,attr,optional"`
+	PatternKey   string `alloy:"pattern_key,attr,optional"`
+}
+
+// DefaultArguments provides the default arguments for the loki.patterns
+// component.
+var DefaultArguments = Arguments{
+	SimilarityThreshold: 0.3,
+	TreeDepth:           4,
+	MaxChildren:         15,
+	MaxPatterns:         50,
+	MaxStreams:          100,
+	MaxTotalPatterns:    1000,
+	PatternIDKey:        "pattern_id",
+	PatternKey:          "pattern",
+}
+
+// SetToDefault implements syntax.Defaulter.
+func (a *Arguments) SetToDefault() {
+	*a = DefaultArguments
+}
+
+// Validate implements syntax.Validator.
+func (a *Arguments) Validate() error {
+	var errs []error
+	if a.SimilarityThreshold <= 0 || a.SimilarityThreshold > 1 {
+		errs = append(errs, fmt.Errorf("similarity_threshold must be greater than 0 and at most 1, got %v", a.SimilarityThreshold))
+	}
+	if a.TreeDepth < 3 {
+		errs = append(errs, fmt.Errorf("tree_depth must be at least 3, got %d", a.TreeDepth))
+	}
+	if a.MaxChildren < 2 {
+		errs = append(errs, fmt.Errorf("max_children must be at least 2, got %d", a.MaxChildren))
+	}
+	if a.MaxPatterns < 1 {
+		errs = append(errs, fmt.Errorf("max_patterns_per_stream must be at least 1, got %d", a.MaxPatterns))
+	}
+	if a.MaxStreams < 1 {
+		errs = append(errs, fmt.Errorf("max_streams must be at least 1, got %d", a.MaxStreams))
+	}
+	if a.MaxTotalPatterns < 1 {
+		errs = append(errs, fmt.Errorf("max_patterns must be at least 1, got %d", a.MaxTotalPatterns))
+	}
+	if a.PatternIDKey != "" && a.PatternIDKey == a.PatternKey {
+		errs = append(errs, fmt.Errorf("pattern_id_key and pattern_key must be different, got %q", a.PatternKey))
+	}
+	return errors.Join(errs...)
+}
+
+func (a *Arguments) drainConfig() drainConfig {
+	return drainConfig{
+		depth:               a.TreeDepth,
+		maxChildren:         a.MaxChildren,
+		maxClusters:         a.MaxPatterns,
+		similarityThreshold: a.SimilarityThreshold,
+	}
+}
+
+// Exports holds values which are exported by the loki.patterns component.
+type Exports struct {
+	Receiver loki.LogsReceiver `alloy:"receiver,attr"`
+}
+
+// Component implements the loki.patterns component.
+type Component struct {
+	opts               component.Options
+	metrics            *metrics
+	receiver           loki.LogsReceiver
+	fanout             *loki.Fanout
+	debugDataPublisher livedebugging.DebugDataPublisher
+
+	mut         sync.Mutex
+	args        Arguments
+	streams     *simplelru.LRU[model.Fingerprint, *stream]
+	numPatterns int // Number of patterns across all streams.
+}
+
+// stream holds the patterns of the entries of a stream.
+type stream struct {
+	labels   model.LabelSet
+	drain    *drain
+	patterns map[int]*pattern // Keyed by cluster ID.
+}
+
+type pattern struct {
+	id        string
+	template  string
+	count     uint64
+	firstSeen time.Time
+	lastSeen  time.Time
+}
+
+var (
+	_ component.Component      = (*Component)(nil)
+	_ component.DebugComponent = (*Component)(nil)
+	_ component.LiveDebugging  = (*Component)(nil)
+)
+
+// New creates a new loki.patterns component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	debugDataPublisher, err := o.GetServiceData(livedebugging.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Component{
+		opts:               o,
+		metrics:            newMetrics(o.Registerer),
+		receiver:           loki.NewLogsReceiver(loki.WithComponentID(o.ID)),
+		fanout:             loki.NewFanout(args.ForwardTo),
+		debugDataPublisher: debugDataPublisher.(livedebugging.DebugDataPublisher),
+	}
+	o.OnStateChange(Exports{Receiver: c.receiver})
+
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	componentID := livedebugging.ComponentID(c.opts.ID)
+	loki.ConsumeAndProcess(ctx, c.receiver, c.fanout, func(entry loki.Entry) (loki.Entry, bool) {
+		processed, p := c.process(entry)
+
+		c.debugDataPublisher.PublishIfActive(livedebugging.NewData(
+			componentID,
+			livedebugging.LokiLog,
+			1,
+			func() string {
+				return fmt.Sprintf("entry: %s, labels: %s => pattern %s: %s", entry.Line, entry.Labels.String(), p.id, p.template)
+			},
+			livedebugging.WithAttributes(func() map[string]string { return livedebugging.LokiLabels(entry.Labels) }),
+			livedebugging.WithReplay(func() *livedebugging.Replay { return livedebugging.NewLokiReplay(entry.Labels, entry.Entry) }),
+		))
+		return processed, true
+	})
+	return nil
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	// Patterns are detected again from scratch when the settings of the
+	// clustering change.
+	if c.streams == nil || clusteringChanged(c.args, newArgs) {
+		c.reset(newArgs.MaxStreams)
+	}
+	c.args = newArgs
+	c.fanout.UpdateChildren(newArgs.ForwardTo)
+	return nil
+}
+
+func clusteringChanged(prev, next Arguments) bool {
+	return prev.drainConfig() != next.drainConfig() ||
+		prev.MaxStreams != next.MaxStreams ||
+		prev.MaxTotalPatterns != next.MaxTotalPatterns ||
+		!slices.Equal(prev.StreamLabels, next.StreamLabels)
+}
+
+// reset drops all streams and their patterns. reset must be called with mut
+// held.
+func (c *Component) reset(maxStreams int) {
+	if c.streams != nil {
+		for _, s := range c.streams.Values() {
+			c.deleteStream(s)
+		}
+	}
+	c.streams, _ = simplelru.NewLRU(maxStreams, func(_ model.Fingerprint, s *stream) {
+		c.deleteStream(s)
+		c.metrics.evictedPatterns.Add(float64(len(s.patterns)))
+	})
+	c.metrics.streams.Set(0)
+}
+
+func (c *Component) deleteStream(s *stream) {
+	for _, p := range s.patterns {
+		c.deletePatternMetrics(p)
+	}
+}
+
+func (c *Component) deletePatternMetrics(p *pattern) {
+	c.metrics.entriesTotal.DeleteLabelValues(p.id)
+	c.metrics.patterns.Dec()
+	c.numPatterns--
+}
+
+// evictOldestPattern evicts the least recently seen pattern across all
+// streams, other than keep. evictOldestPattern must be called with mut held.
+func (c *Component) evictOldestPattern(keep *pattern) {
+	var (
+		oldest       *pattern
+		oldestStream *stream
+		oldestID     int
+	)
+	for _, s := range c.streams.Values() {
+		for id, p := range s.patterns {
+			if p != keep && (oldest == nil || p.lastSeen.Before(oldest.lastSeen)) {
+				oldest, oldestStream, oldestID = p, s, id
+			}
+		}
+	}
+	if oldest != nil {
+		// The eviction callback of the drain deletes the pattern.
+		oldestStream.drain.remove(oldestID)
+	}
+}
+
+// process assigns the entry to a pattern of its stream, and returns the entry
+// with the pattern stored in its structured metadata.
+func (c *Component) process(e loki.Entry) (loki.Entry, pattern) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	c.metrics.entriesProcessed.Inc()
+
+	s := c.getStream(e.Labels)
+	cl, created := s.drain.train(e.Line)
+
+	now := time.Now()
+	p := s.patterns[cl.id]
+	if created {
+		p = &pattern{id: patternID(s.labels, cl), firstSeen: now, lastSeen: now}
+		s.patterns[cl.id] = p
+		c.numPatterns++
+		c.metrics.newPatterns.Inc()
+		c.metrics.patterns.Inc()
+		level.Debug(c.opts.Logger).Log("msg", "new pattern detected", "pattern_id", p.id, "pattern", cl.template(), "stream", s.labels.String())
+
+		if c.numPatterns > c.args.MaxTotalPatterns {
+			c.evictOldestPattern(p)
+		}
+	}
+	p.template = cl.template()
+	p.count++
+	p.lastSeen = now
+	c.metrics.entriesTotal.WithLabelValues(p.id).Inc()
+
+	e.StructuredMetadata = setStructuredMetadata(e.StructuredMetadata, c.args.PatternIDKey, p.id)
+	e.StructuredMetadata = setStructuredMetadata(e.StructuredMetadata, c.args.PatternKey, p.template)
+	return e, *p
+}
+
+// getStream returns the stream of an entry with the given labels, creating it
+// if needed. getStream must be called with mut held.
+func (c *Component) getStream(lbls model.LabelSet) *stream {
+	if len(c.args.StreamLabels) > 0 {
+		streamLabels := make(model.LabelSet, len(c.args.StreamLabels))
+		for _, name := range c.args.StreamLabels {
+			if v, ok := lbls[model.LabelName(name)]; ok {
+				streamLabels[model.LabelName(name)] = v
+			}
+		}
+		lbls = streamLabels
+	}
+
+	fp := lbls.Fingerprint()
+	if s, ok := c.streams.Get(fp); ok {
+		return s
+	}
+
+	s := &stream{
+		labels:   lbls,
+		patterns: make(map[int]*pattern),
+	}
+	s.drain = newDrain(c.args.drainConfig(), func(cl *cluster) {
+		if p, ok := s.patterns[cl.id]; ok {
+			delete(s.patterns, cl.id)
+			c.deletePatternMetrics(p)
+			c.metrics.evictedPatterns.Inc()
+		}
+	})
+	c.streams.Add(fp, s)
+	c.metrics.streams.Set(float64(c.streams.Len()))
+	return s
+}
+
+// patternID returns the ID of a new pattern, derived from its stream and the
+// first line of the pattern.
+func patternID(streamLabels model.LabelSet, cl *cluster) string {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(streamLabels.String()))
+	_, _ = h.Write([]byte{0xff})
+	_, _ = h.Write([]byte(cl.template()))
+	return strconv.FormatUint(h.Sum64(), 16)
+}
+
+// setStructuredMetadata returns a copy of md with the key set to value. md is
+// returned unchanged if key is empty.
+func setStructuredMetadata(md push.LabelsAdapter, key, value string) push.LabelsAdapter {
+	if key == "" {
+		return md
+	}
+	res := make(push.LabelsAdapter, 0, len(md)+1)
+	for _, l := range md {
+		if l.Name != key {
+			res = append(res, l)
+		}
+	}
+	return append(res, push.LabelAdapter{Name: key, Value: value})
+}
+
+// maxDebugInfoPatterns is the maximum number of patterns in the debug info.
+const maxDebugInfoPatterns = 100
+
+type debugInfo struct {
+	Patterns []patternDebugInfo `alloy:"pattern,block,optional"`
+}
+
+type patternDebugInfo struct {
+	ID        string `alloy:"id,attr"`
+	Pattern   string `alloy:"pattern,attr"`
+	Stream    string `alloy:"stream,attr"`
+	Count     uint64 `alloy:"count,attr"`
+	FirstSeen string `alloy:"first_seen,attr"`
+	LastSeen  string `alloy:"last_seen,attr"`
+}
+
+// DebugInfo implements component.DebugComponent. It returns the patterns with
+// the most entries.
+func (c *Component) DebugInfo() any {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	var res debugInfo
+	for _, s := range c.streams.Values() {
+		for _, p := range s.patterns {
+			res.Patterns = append(res.Patterns, patternDebugInfo{
+				ID:        p.id,
+				Pattern:   p.template,
+				Stream:    s.labels.String(),
+				Count:     p.count,
+				FirstSeen: p.firstSeen.Format(time.RFC3339),
+				LastSeen:  p.lastSeen.Format(time.RFC3339),
+			})
+		}
+	}
+	sort.Slice(res.Patterns, func(i, j int) bool {
+		if res.Patterns[i].Count != res.Patterns[j].Count {
+			return res.Patterns[i].Count > res.Patterns[j].Count
+		}
+		return res.Patterns[i].ID < res.Patterns[j].ID
+	})
+	if len(res.Patterns) > maxDebugInfoPatterns {
+		res.Patterns = res.Patterns[:maxDebugInfoPatterns]
+	}
+	return res
+}
+
+// LiveDebugging implements component.LiveDebugging.
+func (c *Component) LiveDebugging() {}
@@ -0,0 +1,191 @@
+package patterns
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/loki/pkg/push"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/component/common/loki"
+	"github.com/grafana/alloy/internal/service/livedebugging"
+	"github.com/grafana/alloy/internal/util"
+	"github.com/grafana/alloy/syntax"
+)
+
+func TestArguments(t *testing.T) {
+	var args Arguments
+	require.NoError(t, syntax.Unmarshal([]byte(`
+		forward_to    = []
+		stream_labels = ["job"]
+	`), &args))
+	require.Equal(t, 0.3, args.SimilarityThreshold)
+	require.Equal(t, "pattern_id", args.PatternIDKey)
+
+	err := syntax.Unmarshal([]byte(`
+		forward_to           = []
+		similarity_threshold = 1.5
+		tree_depth           = 2
+	`), &args)
+	require.ErrorContains(t, err, "similarity_threshold must be greater than 0 and at most 1")
+	require.ErrorContains(t, err, "tree_depth must be at least 3")
+}
+
+func TestComponent(t *testing.T) {
+	collector := loki.NewCollectingHandler()
+	defer collector.Stop()
+
+	reg := prometheus.NewRegistry()
+	opts := component.Options{
+		Logger:         util.TestAlloyLogger(t),
+		Registerer:     reg,
+		OnStateChange:  func(e component.Exports) {},
+		GetServiceData: getServiceData,
+	}
+	args := DefaultArguments
+	args.ForwardTo = []loki.LogsReceiver{collector.Receiver()}
+	args.StreamLabels = []string{"job"}
+
+	c, err := New(opts, args)
+	require.NoError(t, err)
+	go c.Run(t.Context())
+
+	lines := []string{
+		"user alice logged in",
+		"user bob logged in",
+		"disk usage at 91%",
+	}
+	for i, line := range lines {
+		c.receiver.Chan() <- loki.Entry{
+			Labels: model.LabelSet{"job": "app", "instance": model.LabelValue(fmt.Sprint(i))},
+			Entry: push.Entry{
+				Timestamp:          time.Now(),
+				Line:               line,
+				StructuredMetadata: push.LabelsAdapter{{Name: "trace_id", Value: "abc"}},
+			},
+		}
+	}
+
+	var received []loki.Entry
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		received = collector.Received()
+		require.Len(c, received, len(lines))
+	}, 5*time.Second, 10*time.Millisecond)
+
+	metadata := func(e loki.Entry, name string) string {
+		for _, l := range e.StructuredMetadata {
+			if l.Name == name {
+				return l.Value
+			}
+		}
+		return ""
+	}
+	for _, e := range received {
+		require.Equal(t, "abc", metadata(e, "trace_id"))
+	}
+	require.Equal(t, "user alice logged in", metadata(received[0], "pattern"))
+	require.Equal(t, "user <_> logged in", metadata(received[1], "pattern"))
+	require.Equal(t, "disk usage at 91%", metadata(received[2], "pattern"))
+	require.Equal(t, metadata(received[0], "pattern_id"), metadata(received[1], "pattern_id"))
+	require.NotEqual(t, metadata(received[0], "pattern_id"), metadata(received[2], "pattern_id"))
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP loki_patterns_new_patterns_total Total number of new patterns detected.
+		# TYPE loki_patterns_new_patterns_total counter
+		loki_patterns_new_patterns_total 2
+		# HELP loki_patterns_streams Number of streams currently tracked.
+		# TYPE loki_patterns_streams gauge
+		loki_patterns_streams 1
+	`), "loki_patterns_new_patterns_total", "loki_patterns_streams"))
+
+	info := c.DebugInfo().(debugInfo)
+	require.Len(t, info.Patterns, 2)
+	require.Equal(t, "user <_> logged in", info.Patterns[0].Pattern)
+	require.Equal(t, uint64(2), info.Patterns[0].Count)
+	require.Equal(t, `{job="app"}`, info.Patterns[0].Stream)
+}
+
+func TestComponentMaxStreams(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	opts := component.Options{
+		Logger:         util.TestAlloyLogger(t),
+		Registerer:     reg,
+		OnStateChange:  func(e component.Exports) {},
+		GetServiceData: getServiceData,
+	}
+	args := DefaultArguments
+	args.MaxStreams = 1
+	args.PatternKey = ""
+
+	c, err := New(opts, args)
+	require.NoError(t, err)
+
+	e, _ := c.process(loki.Entry{Labels: model.LabelSet{"job": "a"}, Entry: push.Entry{Line: "first"}})
+	require.Len(t, e.StructuredMetadata, 1)
+	require.Equal(t, "pattern_id", e.StructuredMetadata[0].Name)
+
+	c.process(loki.Entry{Labels: model.LabelSet{"job": "b"}, Entry: push.Entry{Line: "second"}})
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP loki_patterns_evicted_patterns_total Total number of patterns evicted to stay below the maximum number of patterns or streams.
+		# TYPE loki_patterns_evicted_patterns_total counter
+		loki_patterns_evicted_patterns_total 1
+		# HELP loki_patterns_patterns Number of patterns currently tracked.
+		# TYPE loki_patterns_patterns gauge
+		loki_patterns_patterns 1
+	`), "loki_patterns_evicted_patterns_total", "loki_patterns_patterns"))
+}
+
+func TestComponentMaxTotalPatterns(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	opts := component.Options{
+		Logger:         util.TestAlloyLogger(t),
+		Registerer:     reg,
+		OnStateChange:  func(e component.Exports) {},
+		GetServiceData: getServiceData,
+	}
+	args := DefaultArguments
+	args.MaxTotalPatterns = 2
+
+	c, err := New(opts, args)
+	require.NoError(t, err)
+
+	first, _ := c.process(loki.Entry{Labels: model.LabelSet{"job": "a"}, Entry: push.Entry{Line: "first"}})
+	c.process(loki.Entry{Labels: model.LabelSet{"job": "b"}, Entry: push.Entry{Line: "second"}})
+	c.process(loki.Entry{Labels: model.LabelSet{"job": "c"}, Entry: push.Entry{Line: "third"}})
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP loki_patterns_evicted_patterns_total Total number of patterns evicted to stay below the maximum number of patterns or streams.
+		# TYPE loki_patterns_evicted_patterns_total counter
+		loki_patterns_evicted_patterns_total 1
+		# HELP loki_patterns_patterns Number of patterns currently tracked.
+		# TYPE loki_patterns_patterns gauge
+		loki_patterns_patterns 2
+	`), "loki_patterns_evicted_patterns_total", "loki_patterns_patterns"))
+
+	// The least recently seen pattern is evicted with its series, even though
+	// its stream is still tracked.
+	count, err := testutil.GatherAndCount(reg, "loki_patterns_pattern_entries_total")
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+	require.Equal(t, 3, c.streams.Len())
+	for _, s := range c.DebugInfo().(debugInfo).Patterns {
+		require.NotEqual(t, first.StructuredMetadata[0].Value, s.ID)
+	}
+}
+
+func getServiceData(name string) (any, error) {
+	switch name {
+	case livedebugging.ServiceName:
+		return livedebugging.NewLiveDebugging(), nil
+	default:
+		return nil, fmt.Errorf("service not found %s", name)
+	}
+}
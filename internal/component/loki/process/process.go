@@ -123,6 +123,7 @@ func (c *Component) Run(ctx context.Context) error {
 						string(structured_metadata),
 					)
 				},
+				livedebugging.WithAttributes(func() map[string]string { return livedebugging.LokiLabels(e.Labels) }),
 			))
 
 			return e, true
@@ -183,6 +184,8 @@ func (c *Component) handleIn(ctx context.Context) {
 					}
 					return fmt.Sprintf("[IN]: timestamp: %s, entry: %s, labels: %s, structured_metadata: %s", entry.Timestamp.Format(time.RFC3339Nano), entry.Line, entry.Labels.String(), string(structured_metadata))
 				},
+				livedebugging.WithAttributes(func() map[string]string { return livedebugging.LokiLabels(entry.Labels) }),
+				livedebugging.WithReplay(func() *livedebugging.Replay { return livedebugging.NewLokiReplay(entry.Labels, entry.Entry) }),
 			))
 
 			select {
@@ -0,0 +1,194 @@
+package stages
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-kit/log"
+
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+)
+
+// Config Errors
+var (
+	ErrEmptyCSVStageConfig = errors.New("empty csv stage configuration")
+	ErrEmptyCSVStageSource = errors.New("empty source")
+	ErrCSVColumnsRequired  = errors.New("csv columns are required")
+	ErrCSVDuplicateColumn  = errors.New("csv columns must be unique")
+	ErrCSVUnknownColumn    = errors.New("csv expression refers to an unknown column")
+	ErrCSVInvalidDelimiter = errors.New("csv delimiter must be a single character other than a quote, carriage return or newline")
+	ErrMalformedCSV        = errors.New("malformed csv")
+)
+
+// CSVConfig represents a CSV Stage configuration
+type CSVConfig struct {
+	Columns          []string          `alloy:"columns,attr"`
+	Expressions      map[string]string `alloy:"expressions,attr,optional"`
+	Source           *string           `alloy:"source,attr,optional"`
+	Delimiter        string            `alloy:"delimiter,attr,optional"`
+	LazyQuotes       bool              `alloy:"lazy_quotes,attr,optional"`
+	TrimLeadingSpace bool              `alloy:"trim_leading_space,attr,optional"`
+	DropMalformed    bool              `alloy:"drop_malformed,attr,optional"`
+}
+
+// DefaultCSVConfig applies the default values on
+var DefaultCSVConfig = CSVConfig{
+	Delimiter: ",",
+}
+
+// SetToDefault implements syntax.Defaulter.
+func (c *CSVConfig) SetToDefault() {
+	*c = DefaultCSVConfig
+}
+
+// validateCSVConfig validates a csv config and returns a mapping of the names
+// in the extracted map to the index of the column to extract.
+func validateCSVConfig(c *CSVConfig) (map[string]int, rune, error) {
+	if c == nil {
+		return nil, 0, ErrEmptyCSVStageConfig
+	}
+
+	if len(c.Columns) == 0 {
+		return nil, 0, ErrCSVColumnsRequired
+	}
+
+	if c.Source != nil && *c.Source == "" {
+		return nil, 0, ErrEmptyCSVStageSource
+	}
+
+	delimiter, size := utf8.DecodeRuneInString(c.Delimiter)
+	if size == 0 || size != len(c.Delimiter) || delimiter == '"' || delimiter == '\r' || delimiter == '\n' || delimiter == utf8.RuneError {
+		return nil, 0, ErrCSVInvalidDelimiter
+	}
+
+	columns := make(map[string]int, len(c.Columns))
+	for i, col := range c.Columns {
+		if _, ok := columns[col]; ok {
+			return nil, 0, fmt.Errorf("%w: %q", ErrCSVDuplicateColumn, col)
+		}
+		columns[col] = i
+	}
+
+	// Extract all the columns if there are no expressions.
+	if len(c.Expressions) == 0 {
+		return columns, delimiter, nil
+	}
+
+	indexes := make(map[string]int, len(c.Expressions))
+	for n, col := range c.Expressions {
+		// If there is no column, use the name as the column.
+		if col == "" {
+			col = n
+		}
+		i, ok := columns[col]
+		if !ok {
+			return nil, 0, fmt.Errorf("%w: %q", ErrCSVUnknownColumn, col)
+		}
+		indexes[n] = i
+	}
+	return indexes, delimiter, nil
+}
+
+// csvStage sets extracted data using the columns of a CSV record
+type csvStage struct {
+	cfg       *CSVConfig
+	indexes   map[string]int
+	delimiter rune
+	logger    log.Logger
+}
+
+// newCSVStage creates a new csv pipeline stage from a config.
+func newCSVStage(logger log.Logger, cfg CSVConfig) (Stage, error) {
+	indexes, delimiter, err := validateCSVConfig(&cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &csvStage{
+		cfg:       &cfg,
+		indexes:   indexes,
+		delimiter: delimiter,
+		logger:    log.With(logger, "component", "stage", "type", "csv"),
+	}, nil
+}
+
+func (c *csvStage) Run(in chan Entry) chan Entry {
+	out := make(chan Entry)
+	go func() {
+		defer close(out)
+		for e := range in {
+			err := c.processEntry(e.Extracted, &e.Line)
+			if err != nil && c.cfg.DropMalformed {
+				continue
+			}
+			out <- e
+		}
+	}()
+	return out
+}
+
+func (c *csvStage) processEntry(extracted map[string]any, entry *string) error {
+	// If a source key is provided, the csv stage should process it
+	// from the extracted map, otherwise should fall back to the entry
+	input := entry
+
+	if c.cfg.Source != nil {
+		if _, ok := extracted[*c.cfg.Source]; !ok {
+			if Debug {
+				level.Debug(c.logger).Log("msg", "source does not exist in the set of extracted values", "source", *c.cfg.Source)
+			}
+			return nil
+		}
+
+		value, err := getString(extracted[*c.cfg.Source])
+		if err != nil {
+			if Debug {
+				level.Debug(c.logger).Log("msg", "failed to convert source value to string", "source", *c.cfg.Source, "err", err, "type", reflect.TypeOf(extracted[*c.cfg.Source]))
+			}
+			return nil
+		}
+
+		input = &value
+	}
+
+	if input == nil {
+		if Debug {
+			level.Debug(c.logger).Log("msg", "cannot parse a nil entry")
+		}
+		return nil
+	}
+
+	r := csv.NewReader(strings.NewReader(*input))
+	r.Comma = c.delimiter
+	r.LazyQuotes = c.cfg.LazyQuotes
+	r.TrimLeadingSpace = c.cfg.TrimLeadingSpace
+	// Records with missing or additional columns are allowed.
+	r.FieldsPerRecord = -1
+
+	// Only the first record is parsed, a log line is a single record.
+	record, err := r.Read()
+	if err != nil {
+		if Debug {
+			level.Debug(c.logger).Log("msg", "failed to parse log line", "err", err)
+		}
+		return ErrMalformedCSV
+	}
+
+	for n, i := range c.indexes {
+		if i < len(record) {
+			extracted[n] = record[i]
+		}
+	}
+	if Debug {
+		level.Debug(c.logger).Log("msg", "extracted data debug in csv stage", "extracted_data", fmt.Sprintf("%v", extracted))
+	}
+	return nil
+}
+
+// Cleanup implements Stage.
+func (*csvStage) Cleanup() {
+	// no-op
+}
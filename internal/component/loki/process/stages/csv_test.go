@@ -0,0 +1,156 @@
+package stages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/util"
+)
+
+var testCSVAlloyAllColumns = `
+stage.csv {
+		columns = ["ip", "method", "path", "status", "user_agent"]
+}`
+
+var testCSVAlloyWithExpressionsAndDelimiter = `
+stage.csv {
+		columns            = ["ip", "method", "path", "status", "user_agent"]
+		expressions        = { "client" = "ip", "status" = "" }
+		delimiter          = ";"
+		trim_leading_space = true
+}`
+
+var testCSVAlloyWithSource = `
+stage.json {
+		expressions = { "fields" = "" }
+}
+stage.csv {
+		columns = ["a", "b"]
+		source  = "fields"
+}`
+
+func TestCSV(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		config          string
+		entry           string
+		expectedExtract map[string]any
+	}{
+		"extract all columns with quoted fields": {
+			testCSVAlloyAllColumns,
+			`10.0.0.1,GET,/index.html,200,"Mozilla/5.0 (X11, Linux) ""quoted"""`,
+			map[string]any{
+				"ip":         "10.0.0.1",
+				"method":     "GET",
+				"path":       "/index.html",
+				"status":     "200",
+				"user_agent": `Mozilla/5.0 (X11, Linux) "quoted"`,
+			},
+		},
+		"missing columns are skipped": {
+			testCSVAlloyAllColumns,
+			`10.0.0.1,GET`,
+			map[string]any{
+				"ip":     "10.0.0.1",
+				"method": "GET",
+			},
+		},
+		"extract expressions with custom delimiter": {
+			testCSVAlloyWithExpressionsAndDelimiter,
+			`10.0.0.1; POST; /api; 500; curl`,
+			map[string]any{
+				"client": "10.0.0.1",
+				"status": "500",
+			},
+		},
+		"extract from source": {
+			testCSVAlloyWithSource,
+			`{"fields": "x,y"}`,
+			map[string]any{
+				"fields": "x,y",
+				"a":      "x",
+				"b":      "y",
+			},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			pl, err := NewPipeline(log.NewNopLogger(), loadConfig(testData.config), prometheus.DefaultRegisterer, featuregate.StabilityGenerallyAvailable)
+			require.NoError(t, err)
+			out := processEntries(pl, newEntry(nil, nil, testData.entry, time.Now()))[0]
+			assert.Equal(t, testData.expectedExtract, out.Extracted)
+		})
+	}
+}
+
+func TestCSVConfigValidation(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		config CSVConfig
+		err    error
+	}{
+		"no columns": {
+			CSVConfig{Delimiter: ","},
+			ErrCSVColumnsRequired,
+		},
+		"duplicate columns": {
+			CSVConfig{Columns: []string{"a", "a"}, Delimiter: ","},
+			ErrCSVDuplicateColumn,
+		},
+		"unknown column": {
+			CSVConfig{Columns: []string{"a"}, Expressions: map[string]string{"b": ""}, Delimiter: ","},
+			ErrCSVUnknownColumn,
+		},
+		"multi character delimiter": {
+			CSVConfig{Columns: []string{"a"}, Delimiter: ";;"},
+			ErrCSVInvalidDelimiter,
+		},
+		"quote delimiter": {
+			CSVConfig{Columns: []string{"a"}, Delimiter: `"`},
+			ErrCSVInvalidDelimiter,
+		},
+		"valid": {
+			CSVConfig{Columns: []string{"a", "b"}, Expressions: map[string]string{"c": "a"}, Delimiter: "\t"},
+			nil,
+		},
+	}
+	for tName, tt := range tests {
+		t.Run(tName, func(t *testing.T) {
+			_, _, err := validateCSVConfig(&tt.config)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestCSVMalformed(t *testing.T) {
+	t.Parallel()
+	logger := util.TestAlloyLogger(t)
+
+	for _, lazyQuotes := range []bool{false, true} {
+		s, err := newCSVStage(logger, CSVConfig{Columns: []string{"a", "b"}, Delimiter: ",", LazyQuotes: lazyQuotes, DropMalformed: true})
+		require.NoError(t, err)
+
+		out := processEntries(s, newEntry(nil, nil, `a "b",c`, time.Now()))
+		if lazyQuotes {
+			require.Len(t, out, 1)
+			assert.Equal(t, map[string]any{"a": `a "b"`, "b": "c"}, out[0].Extracted)
+		} else {
+			assert.Empty(t, out)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package stages
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-kit/log"
+
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+)
+
+// Config Errors
+var (
+	ErrEmptyKVStageConfig = errors.New("empty kv stage configuration")
+	ErrEmptyKVStageSource = errors.New("empty source")
+	ErrKVEmptyDelimiter   = errors.New("kv pair_delimiter and value_delimiter must not be empty")
+	ErrKVSameDelimiters   = errors.New("kv pair_delimiter and value_delimiter must be different")
+	ErrKVInvalidQuoteChar = errors.New("kv quote_chars must only contain ASCII characters")
+	ErrMalformedKV        = errors.New("malformed key-value pairs")
+)
+
+// KVConfig represents a key-value Stage configuration
+type KVConfig struct {
+	Expressions    map[string]string `alloy:"expressions,attr,optional"`
+	Source         *string           `alloy:"source,attr,optional"`
+	PairDelimiter  string            `alloy:"pair_delimiter,attr,optional"`
+	ValueDelimiter string            `alloy:"value_delimiter,attr,optional"`
+	QuoteChars     string            `alloy:"quote_chars,attr,optional"`
+	DropMalformed  bool              `alloy:"drop_malformed,attr,optional"`
+}
+
+// DefaultKVConfig applies the default values on
+var DefaultKVConfig = KVConfig{
+	PairDelimiter:  " ",
+	ValueDelimiter: "=",
+	QuoteChars:     `"`,
+}
+
+// SetToDefault implements syntax.Defaulter.
+func (c *KVConfig) SetToDefault() {
+	*c = DefaultKVConfig
+}
+
+// validateKVConfig validates a kv config and returns an inverse mapping of
+// the configured expressions, keyed by the key of the pairs.
+func validateKVConfig(c *KVConfig) (map[string][]string, error) {
+	if c == nil {
+		return nil, ErrEmptyKVStageConfig
+	}
+
+	if c.Source != nil && *c.Source == "" {
+		return nil, ErrEmptyKVStageSource
+	}
+
+	if c.PairDelimiter == "" || c.ValueDelimiter == "" {
+		return nil, ErrKVEmptyDelimiter
+	}
+	if c.PairDelimiter == c.ValueDelimiter {
+		return nil, ErrKVSameDelimiters
+	}
+	for _, r := range c.QuoteChars {
+		if r >= utf8.RuneSelf {
+			return nil, ErrKVInvalidQuoteChar
+		}
+	}
+
+	// A key can be extracted under several names.
+	inverseMapping := make(map[string][]string, len(c.Expressions))
+	for n, key := range c.Expressions {
+		// If there is no key, use the name as the key.
+		if key == "" {
+			key = n
+		}
+		inverseMapping[key] = append(inverseMapping[key], n)
+	}
+	return inverseMapping, nil
+}
+
+// kvStage sets extracted data using key-value pairs
+type kvStage struct {
+	cfg            *KVConfig
+	inverseMapping map[string][]string
+	logger         log.Logger
+}
+
+// newKVStage creates a new kv pipeline stage from a config.
+func newKVStage(logger log.Logger, cfg KVConfig) (Stage, error) {
+	inverseMapping, err := validateKVConfig(&cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &kvStage{
+		cfg:            &cfg,
+		inverseMapping: inverseMapping,
+		logger:         log.With(logger, "component", "stage", "type", "kv"),
+	}, nil
+}
+
+func (k *kvStage) Run(in chan Entry) chan Entry {
+	out := make(chan Entry)
+	go func() {
+		defer close(out)
+		for e := range in {
+			err := k.processEntry(e.Extracted, &e.Line)
+			if err != nil && k.cfg.DropMalformed {
+				continue
+			}
+			out <- e
+		}
+	}()
+	return out
+}
+
+func (k *kvStage) processEntry(extracted map[string]any, entry *string) error {
+	// If a source key is provided, the kv stage should process it
+	// from the extracted map, otherwise should fall back to the entry
+	input := entry
+
+	if k.cfg.Source != nil {
+		if _, ok := extracted[*k.cfg.Source]; !ok {
+			if Debug {
+				level.Debug(k.logger).Log("msg", "source does not exist in the set of extracted values", "source", *k.cfg.Source)
+			}
+			return nil
+		}
+
+		value, err := getString(extracted[*k.cfg.Source])
+		if err != nil {
+			if Debug {
+				level.Debug(k.logger).Log("msg", "failed to convert source value to string", "source", *k.cfg.Source, "err", err, "type", reflect.TypeOf(extracted[*k.cfg.Source]))
+			}
+			return nil
+		}
+
+		input = &value
+	}
+
+	if input == nil {
+		if Debug {
+			level.Debug(k.logger).Log("msg", "cannot parse a nil entry")
+		}
+		return nil
+	}
+
+	pairs, err := parseKV(*input, k.cfg.PairDelimiter, k.cfg.ValueDelimiter, k.cfg.QuoteChars)
+	if err != nil {
+		if Debug {
+			level.Debug(k.logger).Log("msg", "failed to parse log line", "err", err)
+		}
+		return ErrMalformedKV
+	}
+
+	for _, p := range pairs {
+		// Extract all the pairs if there are no expressions.
+		if len(k.inverseMapping) == 0 {
+			extracted[p.key] = p.value
+			continue
+		}
+		for _, n := range k.inverseMapping[p.key] {
+			extracted[n] = p.value
+		}
+	}
+	if Debug {
+		level.Debug(k.logger).Log("msg", "extracted data debug in kv stage", "extracted_data", fmt.Sprintf("%v", extracted))
+	}
+	return nil
+}
+
+type kvPair struct {
+	key, value string
+}
+
+// parseKV splits s into key-value pairs. Keys and unquoted values are trimmed
+// of surrounding whitespace. Values starting with one of quoteChars end at the
+// same unescaped quote character, and can contain the delimiters. Pairs
+// without valueDelimiter are skipped.
+func parseKV(s, pairDelimiter, valueDelimiter, quoteChars string) ([]kvPair, error) {
+	var pairs []kvPair
+	for len(s) > 0 {
+		// The key ends at the first value delimiter, unless the pair ends first.
+		keyEnd := strings.Index(s, valueDelimiter)
+		pairEnd := strings.Index(s, pairDelimiter)
+		if keyEnd < 0 || (pairEnd >= 0 && pairEnd < keyEnd) {
+			if pairEnd < 0 {
+				break
+			}
+			s = s[pairEnd+len(pairDelimiter):]
+			continue
+		}
+
+		key := strings.TrimSpace(s[:keyEnd])
+		s = s[keyEnd+len(valueDelimiter):]
+		if strings.TrimSpace(pairDelimiter) != "" {
+			// Whitespace isn't a delimiter, so the value can be preceded by some.
+			s = strings.TrimLeft(s, " \t")
+		}
+
+		var value string
+		if len(s) > 0 && strings.IndexByte(quoteChars, s[0]) >= 0 {
+			var (
+				n   int
+				err error
+			)
+			value, n, err = unquoteKVValue(s)
+			if err != nil {
+				return nil, fmt.Errorf("value of key %q: %w", key, err)
+			}
+			s = s[n:]
+			// Skip anything between the closing quote and the next pair.
+			if i := strings.Index(s, pairDelimiter); i >= 0 {
+				s = s[i+len(pairDelimiter):]
+			} else {
+				s = ""
+			}
+		} else if i := strings.Index(s, pairDelimiter); i >= 0 {
+			value, s = strings.TrimSpace(s[:i]), s[i+len(pairDelimiter):]
+		} else {
+			value, s = strings.TrimSpace(s), ""
+		}
+
+		if key != "" {
+			pairs = append(pairs, kvPair{key: key, value: value})
+		}
+	}
+	return pairs, nil
+}
+
+// unquoteKVValue returns the value quoted at the start of s, and the length
+// of the quoted value in s. A backslash escapes the next character.
+func unquoteKVValue(s string) (string, int, error) {
+	quote := s[0]
+	var sb strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 < len(s) {
+				i++
+				sb.WriteByte(s[i])
+			}
+		case quote:
+			return sb.String(), i + 1, nil
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return "", 0, errors.New("unterminated quoted value")
+}
+
+// Cleanup implements Stage.
+func (*kvStage) Cleanup() {
+	// no-op
+}
@@ -0,0 +1,133 @@
+package stages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/util"
+)
+
+var testKVAlloyDefaults = `
+stage.kv {}`
+
+var testKVAlloyFirewall = `
+stage.kv {
+		expressions     = { "src" = "", "action" = "act", "dst" = "" }
+		pair_delimiter  = ";"
+		value_delimiter = ":"
+		quote_chars     = "'\""
+}`
+
+var testKVAlloyWithSource = `
+stage.json {
+		expressions = { "attrs" = "" }
+}
+stage.kv {
+		expressions = { "user" = "" }
+		source      = "attrs"
+}`
+
+func TestKV(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		config          string
+		entry           string
+		expectedExtract map[string]any
+	}{
+		"extract all pairs with defaults": {
+			testKVAlloyDefaults,
+			`level=info msg="request served" duration=12ms flag empty= path=/a=b`,
+			map[string]any{
+				"level":    "info",
+				"msg":      "request served",
+				"duration": "12ms",
+				"empty":    "",
+				"path":     "/a=b",
+			},
+		},
+		"extract expressions with custom delimiters and quotes": {
+			testKVAlloyFirewall,
+			`src: 10.0.0.1; dst:10.0.0.2; act:'deny; reset'; rule:"block \"all\""`,
+			map[string]any{
+				"src":    "10.0.0.1",
+				"dst":    "10.0.0.2",
+				"action": "deny; reset",
+			},
+		},
+		"extract from source": {
+			testKVAlloyWithSource,
+			`{"attrs": "user=alice role=admin"}`,
+			map[string]any{
+				"attrs": "user=alice role=admin",
+				"user":  "alice",
+			},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			pl, err := NewPipeline(log.NewNopLogger(), loadConfig(testData.config), prometheus.DefaultRegisterer, featuregate.StabilityGenerallyAvailable)
+			require.NoError(t, err)
+			out := processEntries(pl, newEntry(nil, nil, testData.entry, time.Now()))[0]
+			assert.Equal(t, testData.expectedExtract, out.Extracted)
+		})
+	}
+}
+
+func TestKVConfigValidation(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		config KVConfig
+		err    error
+	}{
+		"empty delimiter": {
+			KVConfig{PairDelimiter: "", ValueDelimiter: "="},
+			ErrKVEmptyDelimiter,
+		},
+		"same delimiters": {
+			KVConfig{PairDelimiter: "=", ValueDelimiter: "="},
+			ErrKVSameDelimiters,
+		},
+		"non ASCII quote": {
+			KVConfig{PairDelimiter: " ", ValueDelimiter: "=", QuoteChars: "«"},
+			ErrKVInvalidQuoteChar,
+		},
+		"valid": {
+			DefaultKVConfig,
+			nil,
+		},
+	}
+	for tName, tt := range tests {
+		t.Run(tName, func(t *testing.T) {
+			_, err := validateKVConfig(&tt.config)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestKVMalformed(t *testing.T) {
+	t.Parallel()
+	logger := util.TestAlloyLogger(t)
+
+	cfg := DefaultKVConfig
+	cfg.DropMalformed = true
+	s, err := newKVStage(logger, cfg)
+	require.NoError(t, err)
+
+	out := processEntries(s, newEntry(nil, nil, `a=b msg="unterminated`, time.Now()))
+	assert.Empty(t, out)
+}
@@ -17,12 +17,14 @@ import (
 // exactly one is set.
 type StageConfig struct {
 	CRIConfig                    *CRIConfig                    `alloy:"cri,block,optional"`
+	CSVConfig                    *CSVConfig                    `alloy:"csv,block,optional"`
 	DecolorizeConfig             *DecolorizeConfig             `alloy:"decolorize,block,optional"`
 	DockerConfig                 *DockerConfig                 `alloy:"docker,block,optional"`
 	DropConfig                   *DropConfig                   `alloy:"drop,block,optional"`
 	EventLogMessageConfig        *EventLogMessageConfig        `alloy:"eventlogmessage,block,optional"`
 	GeoIPConfig                  *GeoIPConfig                  `alloy:"geoip,block,optional"`
 	JSONConfig                   *JSONConfig                   `alloy:"json,block,optional"`
+	KVConfig                     *KVConfig                     `alloy:"kv,block,optional"`
 	LabelAllowConfig             *LabelAllowConfig             `alloy:"label_keep,block,optional"`
 	LabelDropConfig              *LabelDropConfig              `alloy:"label_drop,block,optional"`
 	LabelsConfig                 *LabelsConfig                 `alloy:"labels,block,optional"`
@@ -37,6 +39,7 @@ type StageConfig struct {
 	PatternConfig                *PatternConfig                `alloy:"pattern,block,optional"`
 	RegexConfig                  *RegexConfig                  `alloy:"regex,block,optional"`
 	ReplaceConfig                *ReplaceConfig                `alloy:"replace,block,optional"`
+	ScriptConfig                 *ScriptConfig                 `alloy:"script,block,optional"`
 	StaticLabelsConfig           *StaticLabelsConfig           `alloy:"static_labels,block,optional"`
 	StructuredMetadata           *StructuredMetadataConfig     `alloy:"structured_metadata,block,optional"`
 	StructuredMetadataDropConfig *StructuredMetadataDropConfig `alloy:"structured_metadata_drop,block,optional"`
@@ -45,7 +48,9 @@ type StageConfig struct {
 	TenantConfig                 *TenantConfig                 `alloy:"tenant,block,optional"`
 	TruncateConfig               *TruncateConfig               `alloy:"truncate,block,optional"`
 	TimestampConfig              *TimestampConfig              `alloy:"timestamp,block,optional"`
+	UnpackConfig                 *UnpackConfig                 `alloy:"unpack,block,optional"`
 	WindowsEventConfig           *WindowsEventConfig           `alloy:"windowsevent,block,optional"`
+	XMLConfig                    *XMLConfig                    `alloy:"xml,block,optional"`
 }
 
 // Pipeline pass down a log entry to each stage for mutation and/or label extraction.
@@ -0,0 +1,324 @@
+package stages
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/ast"
+	"github.com/expr-lang/expr/vm"
+	"github.com/go-kit/log"
+	"github.com/grafana/loki/pkg/push"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/util"
+)
+
+// Configuration errors.
+var (
+	ErrScriptStageEmptySource = errors.New("script stage requires a source")
+	ErrScriptStageCompile     = errors.New("script stage failed to compile source")
+)
+
+var errScriptTimeout = errors.New("script evaluation timed out")
+
+// Limits of the resources a script can use.
+const (
+	// scriptMaxNodes is the maximum number of nodes of a compiled script.
+	scriptMaxNodes = 10_000
+	// scriptMemoryBudget is the maximum number of elements of the collections
+	// a script can allocate during a single evaluation.
+	scriptMemoryBudget = 1_000_000
+)
+
+// scriptCheckTimeout is the name of the function the iterations of a script
+// call to stop the evaluation once the timeout is reached.
+const scriptCheckTimeout = "__check_timeout"
+
+// scriptTimeoutPatcher makes every iteration of a script check whether the
+// evaluation timed out. Iterations are the only way for a script to run for
+// long, so a script which times out stops at its next iteration.
+type scriptTimeoutPatcher struct{}
+
+// Visit implements ast.Visitor.
+func (scriptTimeoutPatcher) Visit(node *ast.Node) {
+	predicate, ok := (*node).(*ast.PredicateNode)
+	if !ok {
+		return
+	}
+	predicate.Node = &ast.SequenceNode{Nodes: []ast.Node{
+		&ast.CallNode{Callee: &ast.IdentifierNode{Value: scriptCheckTimeout}},
+		predicate.Node,
+	}}
+}
+
+// ScriptConfig contains the configuration for a scriptStage.
+type ScriptConfig struct {
+	Source      string        `alloy:"source,attr"`
+	Timeout     time.Duration `alloy:"timeout,attr,optional"`
+	DropOnError bool          `alloy:"drop_on_error,attr,optional"`
+	DropReason  string        `alloy:"drop_counter_reason,attr,optional"`
+}
+
+// DefaultScriptConfig applies the default values on
+var DefaultScriptConfig = ScriptConfig{
+	Timeout:    100 * time.Millisecond,
+	DropReason: "script_stage",
+}
+
+// SetToDefault implements syntax.Defaulter.
+func (args *ScriptConfig) SetToDefault() {
+	*args = DefaultScriptConfig
+}
+
+// Validate implements syntax.Validator.
+func (args *ScriptConfig) Validate() error {
+	if args.Timeout < 0 {
+		return fmt.Errorf("timeout must not be negative")
+	}
+	return nil
+}
+
+// scriptState holds the changes a script makes to an entry. Changes are only
+// applied once the script completes, so a script which times out doesn't
+// modify the entry.
+type scriptState struct {
+	line               *string
+	timestamp          *time.Time
+	labels             map[string]*string // nil values delete the label.
+	structuredMetadata map[string]*string // nil values delete the structured metadata.
+	extracted          map[string]any
+	drop               bool
+}
+
+// newScriptEnv returns the environment a script is evaluated in. The
+// functions of the environment record the changes to the entry in state, and
+// the evaluation stops with errScriptTimeout once ctx is done.
+func newScriptEnv(ctx context.Context, e Entry, state *scriptState) map[string]any {
+	labels := make(map[string]string, len(e.Labels))
+	for k, v := range e.Labels {
+		labels[string(k)] = string(v)
+	}
+	structuredMetadata := make(map[string]string, len(e.StructuredMetadata))
+	for _, l := range e.StructuredMetadata {
+		structuredMetadata[l.Name] = l.Value
+	}
+
+	return map[string]any{
+		"line":                e.Line,
+		"timestamp":           e.Timestamp,
+		"labels":              labels,
+		"structured_metadata": structuredMetadata,
+		"extracted":           maps.Clone(e.Extracted),
+
+		"set_line": func(line string) bool {
+			state.line = &line
+			return true
+		},
+		"set_timestamp": func(t time.Time) bool {
+			state.timestamp = &t
+			return true
+		},
+		"set_label": func(name, value string) (bool, error) {
+			if !model.LabelName(name).IsValid() {
+				return false, fmt.Errorf("invalid label name %q", name)
+			}
+			if !model.LabelValue(value).IsValid() {
+				return false, fmt.Errorf("invalid value for label %q", name)
+			}
+			state.labels[name] = &value
+			return true, nil
+		},
+		"delete_label": func(name string) bool {
+			state.labels[name] = nil
+			return true
+		},
+		"set_structured_metadata": func(name, value string) (bool, error) {
+			if !model.LabelName(name).IsValid() {
+				return false, fmt.Errorf("invalid structured metadata name %q", name)
+			}
+			state.structuredMetadata[name] = &value
+			return true, nil
+		},
+		"delete_structured_metadata": func(name string) bool {
+			state.structuredMetadata[name] = nil
+			return true
+		},
+		"set_extracted": func(name string, value any) bool {
+			state.extracted[name] = value
+			return true
+		},
+		"drop": func() bool {
+			state.drop = true
+			return true
+		},
+
+		scriptCheckTimeout: func() (bool, error) {
+			if ctx.Err() != nil {
+				return false, errScriptTimeout
+			}
+			return true, nil
+		},
+	}
+}
+
+// scriptStage evaluates an expression on each entry.
+type scriptStage struct {
+	logger       log.Logger
+	cfg          ScriptConfig
+	program      *vm.Program
+	dropCount    *prometheus.CounterVec
+	scriptErrors *prometheus.CounterVec
+}
+
+// newScriptStage creates a new scriptStage from config. The source is
+// compiled and type checked, so invalid scripts are rejected when the
+// pipeline is created.
+func newScriptStage(logger log.Logger, config ScriptConfig, registerer prometheus.Registerer, minStability featuregate.Stability) (Stage, error) {
+	if err := featuregate.CheckAllowed(featuregate.StabilityExperimental, minStability, "stage.script"); err != nil {
+		return nil, err
+	}
+	if config.Source == "" {
+		return nil, ErrScriptStageEmptySource
+	}
+
+	program, err := expr.Compile(config.Source,
+		expr.Env(newScriptEnv(context.Background(), Entry{}, nil)),
+		expr.MaxNodes(scriptMaxNodes),
+		expr.Patch(scriptTimeoutPatcher{}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrScriptStageCompile, err)
+	}
+
+	if config.DropReason == "" {
+		config.DropReason = DefaultScriptConfig.DropReason
+	}
+
+	return &scriptStage{
+		logger:       log.With(logger, "component", "stage", "type", "script"),
+		cfg:          config,
+		program:      program,
+		dropCount:    getDropCountMetric(registerer),
+		scriptErrors: getScriptErrorsMetric(registerer),
+	}, nil
+}
+
+func getScriptErrorsMetric(registerer prometheus.Registerer) *prometheus.CounterVec {
+	scriptErrors := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "loki_process_script_errors_total",
+		Help: "A count of all log entries for which a script stage failed, partitioned by reason",
+	}, []string{"reason"})
+	return util.MustRegisterOrGet(registerer, scriptErrors).(*prometheus.CounterVec)
+}
+
+// Run implements Stage.
+func (s *scriptStage) Run(in chan Entry) chan Entry {
+	return RunWithSkipOrSendMany(in, func(e Entry) ([]Entry, bool) {
+		state, err := s.eval(e)
+		if err != nil {
+			reason := "error"
+			if errors.Is(err, errScriptTimeout) {
+				reason = "timeout"
+			}
+			s.scriptErrors.WithLabelValues(reason).Inc()
+			level.Debug(s.logger).Log("msg", "failed to evaluate script", "err", err)
+
+			if s.cfg.DropOnError {
+				s.dropCount.WithLabelValues(s.cfg.DropReason).Inc()
+				return nil, true
+			}
+			return []Entry{e}, false
+		}
+
+		if state.drop {
+			s.dropCount.WithLabelValues(s.cfg.DropReason).Inc()
+			return nil, true
+		}
+		return []Entry{applyScriptState(e, state)}, false
+	})
+}
+
+// Cleanup implements Stage.
+func (*scriptStage) Cleanup() {
+	// no-op
+}
+
+// eval evaluates the script on an entry. The evaluation stops with
+// errScriptTimeout if it takes longer than the timeout.
+func (s *scriptStage) eval(e Entry) (*scriptState, error) {
+	state := &scriptState{
+		labels:             make(map[string]*string),
+		structuredMetadata: make(map[string]*string),
+		extracted:          make(map[string]any),
+	}
+
+	ctx := context.Background()
+	if s.cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.cfg.Timeout)
+		defer cancel()
+	}
+
+	machine := vm.VM{MemoryBudget: scriptMemoryBudget}
+	_, err := machine.Run(s.program, newScriptEnv(ctx, e, state))
+	if err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// applyScriptState returns the entry with the changes made by a script.
+func applyScriptState(e Entry, state *scriptState) Entry {
+	if state.line != nil {
+		e.Line = *state.line
+	}
+	if state.timestamp != nil {
+		e.Timestamp = *state.timestamp
+	}
+
+	if len(state.labels) > 0 {
+		lbls := e.Labels.Clone()
+		for name, value := range state.labels {
+			if value == nil {
+				delete(lbls, model.LabelName(name))
+			} else {
+				lbls[model.LabelName(name)] = model.LabelValue(*value)
+			}
+		}
+		e.Labels = lbls
+	}
+
+	if len(state.structuredMetadata) > 0 {
+		md := make(push.LabelsAdapter, 0, len(e.StructuredMetadata)+len(state.structuredMetadata))
+		for _, l := range e.StructuredMetadata {
+			if _, changed := state.structuredMetadata[l.Name]; !changed {
+				md = append(md, l)
+			}
+		}
+		for _, name := range slices.Sorted(maps.Keys(state.structuredMetadata)) {
+			if value := state.structuredMetadata[name]; value != nil {
+				md = append(md, push.LabelAdapter{Name: name, Value: *value})
+			}
+		}
+		e.StructuredMetadata = md
+	}
+
+	if len(state.extracted) > 0 {
+		extracted := maps.Clone(e.Extracted)
+		if extracted == nil {
+			extracted = make(map[string]any, len(state.extracted))
+		}
+		maps.Copy(extracted, state.extracted)
+		e.Extracted = extracted
+	}
+
+	return e
+}
@@ -0,0 +1,167 @@
+package stages
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/loki/pkg/push"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/goleak"
+
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/util"
+)
+
+func newTestScriptStage(t *testing.T, cfg ScriptConfig, reg prometheus.Registerer) Stage {
+	t.Helper()
+	s, err := newScriptStage(util.TestAlloyLogger(t), cfg, reg, featuregate.StabilityExperimental)
+	require.NoError(t, err)
+	return s
+}
+
+func TestScriptStage(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := map[string]struct {
+		source  string
+		want    Entry
+		dropped bool
+	}{
+		"unchanged": {
+			source: `line != ""`,
+			want: func() Entry {
+				e := newEntry(map[string]any{"level": "warn"}, model.LabelSet{"app": "api"}, "hello world", ts)
+				e.StructuredMetadata = push.LabelsAdapter{{Name: "trace_id", Value: "abc"}}
+				return e
+			}(),
+		},
+		"set line and labels": {
+			source: `
+				set_line(upper(line));
+				set_label("level", extracted.level);
+				delete_label("app")
+			`,
+			want: func() Entry {
+				e := newEntry(map[string]any{"level": "warn"}, model.LabelSet{"level": "warn"}, "HELLO WORLD", ts)
+				e.StructuredMetadata = push.LabelsAdapter{{Name: "trace_id", Value: "abc"}}
+				return e
+			}(),
+		},
+		"set structured metadata, extracted and timestamp": {
+			source: `
+				set_structured_metadata("app", labels.app);
+				delete_structured_metadata("trace_id");
+				set_extracted("words", len(split(line, " ")));
+				set_timestamp(timestamp + duration("1h"))
+			`,
+			want: func() Entry {
+				e := newEntry(map[string]any{"level": "warn", "words": 2}, model.LabelSet{"app": "api"}, "hello world", ts.Add(time.Hour))
+				e.StructuredMetadata = push.LabelsAdapter{{Name: "app", Value: "api"}}
+				return e
+			}(),
+		},
+		"conditional drop": {
+			source:  `extracted.level == "warn" && structured_metadata.trace_id == "abc" ? drop() : false`,
+			dropped: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			reg := prometheus.NewRegistry()
+			s := newTestScriptStage(t, ScriptConfig{Source: tt.source, Timeout: time.Second}, reg)
+
+			in := newEntry(map[string]any{"level": "warn"}, model.LabelSet{"app": "api"}, "hello world", ts)
+			in.StructuredMetadata = push.LabelsAdapter{{Name: "trace_id", Value: "abc"}}
+
+			out := processEntries(s, in)
+			if tt.dropped {
+				require.Empty(t, out)
+				require.Equal(t, 1.0, testutil.ToFloat64(getDropCountMetric(reg).WithLabelValues("script_stage")))
+				return
+			}
+			require.Len(t, out, 1)
+			require.Equal(t, tt.want, out[0])
+		})
+	}
+}
+
+func TestScriptStageErrors(t *testing.T) {
+	ts := time.Now()
+
+	t.Run("runtime error forwards entry", func(t *testing.T) {
+		reg := prometheus.NewRegistry()
+		s := newTestScriptStage(t, ScriptConfig{Source: `set_label("", line)`}, reg)
+
+		in := newEntry(nil, model.LabelSet{"app": "api"}, "hello", ts)
+		out := processEntries(s, in)
+		require.Equal(t, []Entry{in}, out)
+		require.Equal(t, 1.0, testutil.ToFloat64(getScriptErrorsMetric(reg).WithLabelValues("error")))
+	})
+
+	t.Run("timeout drops entry", func(t *testing.T) {
+		defer goleak.VerifyNone(t, goleak.IgnoreCurrent())
+
+		reg := prometheus.NewRegistry()
+		s := newTestScriptStage(t, ScriptConfig{
+			// Iterating over the characters of the line doesn't allocate
+			// from the memory budget, so only the timeout stops the script.
+			Source:      `all(split(line, ""), {all(split(line, ""), {all(split(line, ""), {# != "x"})})})`,
+			Timeout:     50 * time.Millisecond,
+			DropOnError: true,
+			DropReason:  "slow_script",
+		}, reg)
+
+		start := time.Now()
+		out := processEntries(s, newEntry(nil, nil, strings.Repeat("a", 10_000), ts))
+		require.Less(t, time.Since(start), 5*time.Second)
+		require.Empty(t, out)
+		require.Equal(t, 1.0, testutil.ToFloat64(getScriptErrorsMetric(reg).WithLabelValues("timeout")))
+		require.Equal(t, 1.0, testutil.ToFloat64(getDropCountMetric(reg).WithLabelValues("slow_script")))
+	})
+
+	t.Run("memory budget", func(t *testing.T) {
+		reg := prometheus.NewRegistry()
+		s := newTestScriptStage(t, ScriptConfig{
+			Source:  `set_line(string(len(filter(1..10000000, # % 7 == 0))))`,
+			Timeout: 0,
+		}, reg)
+
+		in := newEntry(nil, nil, "hello", ts)
+		out := processEntries(s, in)
+		require.Equal(t, []Entry{in}, out)
+		require.Equal(t, 1.0, testutil.ToFloat64(getScriptErrorsMetric(reg).WithLabelValues("error")))
+	})
+}
+
+func TestScriptStageConfig(t *testing.T) {
+	logger := util.TestAlloyLogger(t)
+	reg := prometheus.NewRegistry()
+
+	_, err := newScriptStage(logger, ScriptConfig{}, reg, featuregate.StabilityExperimental)
+	require.ErrorIs(t, err, ErrScriptStageEmptySource)
+
+	_, err = newScriptStage(logger, ScriptConfig{Source: `set_line(1)`}, reg, featuregate.StabilityExperimental)
+	require.ErrorIs(t, err, ErrScriptStageCompile)
+
+	_, err = newScriptStage(logger, ScriptConfig{Source: `unknown_variable`}, reg, featuregate.StabilityExperimental)
+	require.ErrorIs(t, err, ErrScriptStageCompile)
+
+	_, err = newScriptStage(logger, ScriptConfig{Source: strings.Repeat("1 + ", scriptMaxNodes) + "1"}, reg, featuregate.StabilityExperimental)
+	require.ErrorIs(t, err, ErrScriptStageCompile)
+
+	_, err = newScriptStage(logger, ScriptConfig{Source: `true`}, reg, featuregate.StabilityGenerallyAvailable)
+	require.ErrorContains(t, err, "stage.script")
+
+	pl, err := NewPipeline(logger, loadConfig(`
+		stage.script {
+			source = "set_line(lower(line))"
+		}
+	`), reg, featuregate.StabilityExperimental)
+	require.NoError(t, err)
+	require.Len(t, pl.stages, 1)
+}
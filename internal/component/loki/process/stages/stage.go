@@ -68,6 +68,21 @@ func New(logger log.Logger, cfg StageConfig, registerer prometheus.Registerer, m
 		if err != nil {
 			return nil, err
 		}
+	case cfg.XMLConfig != nil:
+		s, err = newXMLStage(logger, *cfg.XMLConfig)
+		if err != nil {
+			return nil, err
+		}
+	case cfg.CSVConfig != nil:
+		s, err = newCSVStage(logger, *cfg.CSVConfig)
+		if err != nil {
+			return nil, err
+		}
+	case cfg.KVConfig != nil:
+		s, err = newKVStage(logger, *cfg.KVConfig)
+		if err != nil {
+			return nil, err
+		}
 	case cfg.LogfmtConfig != nil:
 		s, err = newLogfmtStage(logger, *cfg.LogfmtConfig)
 		if err != nil {
@@ -150,6 +165,8 @@ func New(logger log.Logger, cfg StageConfig, registerer prometheus.Registerer, m
 		}
 	case cfg.PackConfig != nil:
 		s = newPackStage(logger, *cfg.PackConfig, registerer)
+	case cfg.UnpackConfig != nil:
+		s = newUnpackStage(logger, *cfg.UnpackConfig)
 	case cfg.LabelAllowConfig != nil:
 		s, err = newLabelAllowStage(*cfg.LabelAllowConfig)
 		if err != nil {
@@ -186,6 +203,11 @@ func New(logger log.Logger, cfg StageConfig, registerer prometheus.Registerer, m
 		if err != nil {
 			return nil, err
 		}
+	case cfg.ScriptConfig != nil:
+		s, err = newScriptStage(logger, *cfg.ScriptConfig, registerer, minStability)
+		if err != nil {
+			return nil, err
+		}
 	case cfg.TruncateConfig != nil:
 		s, err = newTruncateStage(logger, *cfg.TruncateConfig, registerer)
 		if err != nil {
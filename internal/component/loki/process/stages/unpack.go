@@ -0,0 +1,113 @@
+package stages
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/loki/pkg/push"
+	json "github.com/json-iterator/go"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+)
+
+// UnpackConfig contains the configuration for an unpackStage
+type UnpackConfig struct {
+	StructuredMetadata []string `alloy:"structured_metadata,attr,optional"`
+}
+
+// newUnpackStage creates an unpackStage from config
+func newUnpackStage(logger log.Logger, config UnpackConfig) Stage {
+	structuredMetadata := make(map[string]struct{}, len(config.StructuredMetadata))
+	for _, k := range config.StructuredMetadata {
+		structuredMetadata[k] = struct{}{}
+	}
+	return &unpackStage{
+		logger:             log.With(logger, "component", "stage", "type", "unpack"),
+		structuredMetadata: structuredMetadata,
+	}
+}
+
+// unpackStage restores the labels and the log line of entries packed by a
+// packStage.
+type unpackStage struct {
+	logger             log.Logger
+	structuredMetadata map[string]struct{}
+}
+
+func (m *unpackStage) Run(in chan Entry) chan Entry {
+	return RunWith(in, m.unpack)
+}
+
+func (m *unpackStage) unpack(e Entry) Entry {
+	// Skip the parsing of lines which can't be packed objects.
+	if !strings.HasPrefix(strings.TrimSpace(e.Line), "{") {
+		return e
+	}
+
+	w, ok := parsePacked(e.Line)
+	if !ok {
+		if Debug {
+			level.Debug(m.logger).Log("msg", "line is not a packed object, unpacking will be skipped")
+		}
+		return e
+	}
+
+	// Sort the keys so that structured metadata is added in a stable order.
+	for _, k := range slices.Sorted(maps.Keys(w.Labels)) {
+		v := w.Labels[k]
+		e.Extracted[k] = v
+
+		if _, ok := m.structuredMetadata[k]; ok {
+			e.StructuredMetadata = append(e.StructuredMetadata, push.LabelAdapter{Name: k, Value: v})
+			continue
+		}
+
+		name := model.LabelName(k)
+		value := model.LabelValue(v)
+		if !name.IsValid() || !value.IsValid() || v == "" {
+			level.Debug(m.logger).Log("msg", "invalid label parsed from packed object, it will not be restored", "label", k, "value", v)
+			continue
+		}
+		if e.Labels == nil {
+			e.Labels = model.LabelSet{}
+		}
+		e.Labels[name] = value
+	}
+
+	e.Line = w.Entry
+	return e
+}
+
+// parsePacked parses a line created by a packStage. Lines without an _entry
+// key or with values which aren't strings aren't packed objects.
+func parsePacked(line string) (Packed, bool) {
+	var m map[string]any
+	if err := json.UnmarshalFromString(line, &m); err != nil {
+		return Packed{}, false
+	}
+	if _, ok := m[packedEntryKey].(string); !ok {
+		return Packed{}, false
+	}
+
+	w := Packed{Labels: make(map[string]string, len(m)-1)}
+	for k, v := range m {
+		s, ok := v.(string)
+		if !ok {
+			return Packed{}, false
+		}
+		if k == packedEntryKey {
+			w.Entry = s
+		} else {
+			w.Labels[k] = s
+		}
+	}
+	return w, true
+}
+
+// Cleanup implements Stage.
+func (*unpackStage) Cleanup() {
+	// no-op
+}
@@ -0,0 +1,186 @@
+package stages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/grafana/loki/pkg/push"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/component/common/loki"
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/util"
+)
+
+var testUnpackAlloy = `
+stage.pack {
+		labels           = ["pod", "container", "trace_id"]
+		ingest_timestamp = false
+}
+stage.unpack {
+		structured_metadata = ["trace_id"]
+}`
+
+// TestUnpackPipeline verifies that unpacking a packed line restores the
+// original line and labels.
+func TestUnpackPipeline(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	logger := util.TestAlloyLogger(t)
+	pl, err := NewPipeline(logger, loadConfig(testUnpackAlloy), registry, featuregate.StabilityGenerallyAvailable)
+	require.NoError(t, err)
+
+	lbls := model.LabelSet{
+		"pod":       "foo-xsfs3",
+		"container": "foo",
+		"trace_id":  "abc",
+		"namespace": "dev",
+	}
+	testTime := time.Now()
+
+	out := processEntries(pl, newEntry(nil, lbls, testMatchLogLineApp1, testTime))[0]
+
+	assert.Equal(t, testMatchLogLineApp1, out.Line)
+	assert.Equal(t, model.LabelSet{
+		"pod":       "foo-xsfs3",
+		"container": "foo",
+		"namespace": "dev",
+	}, out.Labels)
+	assert.Equal(t, push.LabelsAdapter{{Name: "trace_id", Value: "abc"}}, out.StructuredMetadata)
+	assert.Equal(t, testTime, out.Timestamp)
+}
+
+func TestUnpackStage(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        UnpackConfig
+		inputLine     string
+		expectedEntry Entry
+	}{
+		{
+			name:      "unpack labels",
+			inputLine: `{"bar":"baz","foo":"bar","_entry":"test line 1"}`,
+			expectedEntry: Entry{
+				Extracted: map[string]any{
+					"bar": "baz",
+					"foo": "bar",
+				},
+				Entry: loki.Entry{
+					Labels: model.LabelSet{
+						"app": "test",
+						"bar": "baz",
+						"foo": "bar",
+					},
+					Entry: push.Entry{
+						Line: "test line 1",
+					},
+				},
+			},
+		},
+		{
+			name: "unpack structured metadata",
+			config: UnpackConfig{
+				StructuredMetadata: []string{"foo", "bar"},
+			},
+			inputLine: `{"foo":"bar","bar":"baz","_entry":"{\"nested\":\"json\"}"}`,
+			expectedEntry: Entry{
+				Extracted: map[string]any{
+					"bar": "baz",
+					"foo": "bar",
+				},
+				Entry: loki.Entry{
+					Labels: model.LabelSet{
+						"app": "test",
+					},
+					Entry: push.Entry{
+						Line: `{"nested":"json"}`,
+						StructuredMetadata: push.LabelsAdapter{
+							{Name: "bar", Value: "baz"},
+							{Name: "foo", Value: "bar"},
+						},
+					},
+				},
+			},
+		},
+		{
+			name:      "empty values aren't restored as labels",
+			inputLine: `{"foo":"","_entry":"test line 1"}`,
+			expectedEntry: Entry{
+				Extracted: map[string]any{
+					"foo": "",
+				},
+				Entry: loki.Entry{
+					Labels: model.LabelSet{
+						"app": "test",
+					},
+					Entry: push.Entry{
+						Line: "test line 1",
+					},
+				},
+			},
+		},
+		{
+			name:      "not json",
+			inputLine: "test line 1",
+			expectedEntry: Entry{
+				Extracted: map[string]any{},
+				Entry: loki.Entry{
+					Labels: model.LabelSet{
+						"app": "test",
+					},
+					Entry: push.Entry{
+						Line: "test line 1",
+					},
+				},
+			},
+		},
+		{
+			name:      "no entry key",
+			inputLine: `{"foo":"bar"}`,
+			expectedEntry: Entry{
+				Extracted: map[string]any{},
+				Entry: loki.Entry{
+					Labels: model.LabelSet{
+						"app": "test",
+					},
+					Entry: push.Entry{
+						Line: `{"foo":"bar"}`,
+					},
+				},
+			},
+		},
+		{
+			name:      "values which aren't strings",
+			inputLine: `{"foo":1,"_entry":"test line 1"}`,
+			expectedEntry: Entry{
+				Extracted: map[string]any{},
+				Entry: loki.Entry{
+					Labels: model.LabelSet{
+						"app": "test",
+					},
+					Entry: push.Entry{
+						Line: `{"foo":1,"_entry":"test line 1"}`,
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := util.TestAlloyLogger(t)
+			m := newUnpackStage(logger, tt.config)
+
+			out := processEntries(m, Entry{
+				Extracted: map[string]any{},
+				Entry: loki.Entry{
+					Labels: model.LabelSet{"app": "test"},
+					Entry:  push.Entry{Line: tt.inputLine},
+				},
+			})[0]
+
+			assert.Equal(t, tt.expectedEntry, out)
+		})
+	}
+}
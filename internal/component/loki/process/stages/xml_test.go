@@ -0,0 +1,144 @@
+package stages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/util"
+)
+
+var testXMLLogLine = `<Event xmlns='http://schemas.microsoft.com/win/2004/08/events/event'>
+	<System>
+		<Provider Name='Microsoft-Windows-Security-Auditing'/>
+		<EventID>4624</EventID>
+		<Level>0</Level>
+	</System>
+	<EventData>
+		<Data Name='TargetUserName'>alice</Data>
+		<Data Name='LogonType'>3</Data>
+	</EventData>
+</Event>`
+
+var testXMLAlloySingleStageWithoutSource = `
+stage.xml {
+		expressions = {
+			event_id  = "/Event/System/EventID",
+			provider  = "//Provider/@Name",
+			user      = "//Data[@Name='TargetUserName']",
+			data      = "count(//Data)",
+			is_logon  = "//EventID = 4624",
+			unknown   = "//Unknown",
+		}
+}`
+
+var testXMLAlloyMultiStageWithSource = `
+stage.json {
+		expressions = { "event" = "" }
+}
+stage.xml {
+		expressions = { "logon_type" = "//ev:Data[@Name='LogonType']" }
+		namespaces  = { "ev" = "http://schemas.microsoft.com/win/2004/08/events/event" }
+		source      = "event"
+}`
+
+func TestXML(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		config          string
+		entry           string
+		expectedExtract map[string]any
+	}{
+		"successfully run a pipeline with 1 xml stage without source": {
+			testXMLAlloySingleStageWithoutSource,
+			testXMLLogLine,
+			map[string]any{
+				"event_id": "4624",
+				"provider": "Microsoft-Windows-Security-Auditing",
+				"user":     "alice",
+				"data":     float64(2),
+				"is_logon": true,
+			},
+		},
+		"successfully run a pipeline with xml stage with source and namespaces": {
+			testXMLAlloyMultiStageWithSource,
+			`{"event": "<Event xmlns='http://schemas.microsoft.com/win/2004/08/events/event'><EventData><Data Name='LogonType'>3</Data></EventData></Event>"}`,
+			map[string]any{
+				"event":      "<Event xmlns='http://schemas.microsoft.com/win/2004/08/events/event'><EventData><Data Name='LogonType'>3</Data></EventData></Event>",
+				"logon_type": "3",
+			},
+		},
+	}
+
+	for testName, testData := range tests {
+		t.Run(testName, func(t *testing.T) {
+			t.Parallel()
+
+			pl, err := NewPipeline(log.NewNopLogger(), loadConfig(testData.config), prometheus.DefaultRegisterer, featuregate.StabilityGenerallyAvailable)
+			require.NoError(t, err)
+			out := processEntries(pl, newEntry(nil, nil, testData.entry, time.Now()))[0]
+			assert.Equal(t, testData.expectedExtract, out.Extracted)
+		})
+	}
+}
+
+func TestXMLConfigValidation(t *testing.T) {
+	t.Parallel()
+
+	emptySource := ""
+	tests := map[string]struct {
+		config XMLConfig
+		err    string
+	}{
+		"no expressions": {
+			XMLConfig{},
+			ErrExpressionsRequired,
+		},
+		"empty source": {
+			XMLConfig{Expressions: map[string]string{"a": "//a"}, Source: &emptySource},
+			ErrEmptyXMLStageSource.Error(),
+		},
+		"invalid expression": {
+			XMLConfig{Expressions: map[string]string{"a": "//a["}},
+			ErrCouldNotCompileXPath.Error(),
+		},
+		"valid with name as expression": {
+			XMLConfig{Expressions: map[string]string{"a": ""}},
+			"",
+		},
+	}
+	for tName, tt := range tests {
+		t.Run(tName, func(t *testing.T) {
+			_, err := validateXMLConfig(&tt.config)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestXMLMalformed(t *testing.T) {
+	t.Parallel()
+	logger := util.TestAlloyLogger(t)
+
+	for _, drop := range []bool{false, true} {
+		s, err := newXMLStage(logger, XMLConfig{Expressions: map[string]string{"a": "//a"}, DropMalformed: drop})
+		require.NoError(t, err)
+
+		out := processEntries(s, newEntry(nil, nil, "<a>unterminated", time.Now()))
+		if drop {
+			assert.Empty(t, out)
+		} else {
+			assert.Len(t, out, 1)
+			assert.Empty(t, out[0].Extracted)
+		}
+	}
+}
@@ -138,6 +138,8 @@ func (c *Component) Run(ctx context.Context) error {
 				}
 				return fmt.Sprintf("entry: %s, labels: %s => %s", entry.Line, entry.Labels.String(), relabeled.Labels.String())
 			},
+			livedebugging.WithAttributes(func() map[string]string { return livedebugging.LokiLabels(entry.Labels) }),
+			livedebugging.WithReplay(func() *livedebugging.Replay { return livedebugging.NewLokiReplay(entry.Labels, entry.Entry) }),
 		))
 
 		if !ok {
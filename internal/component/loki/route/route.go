@@ -0,0 +1,274 @@
+package route
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/component/common/loki"
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/loki/logql"
+	"github.com/grafana/alloy/internal/service/livedebugging"
+	"github.com/grafana/alloy/internal/util"
+)
+
+func init() {
+	component.Register(component.Registration{
+		Name:      "loki.route",
+		Stability: featuregate.StabilityExperimental,
+		Args:      Arguments{},
+		Exports:   Exports{},
+		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
+			return New(opts, args.(Arguments))
+		},
+	})
+}
+
+// defaultRouteName is the name of the default route in metrics.
+const defaultRouteName = "default"
+
+// Arguments holds values which are used to configure the loki.route
+// component.
+type Arguments struct {
+	Routes           []RouteConfig       `alloy:"route,block,optional"`
+	DefaultForwardTo []loki.LogsReceiver `alloy:"default_forward_to,attr,optional"`
+	MatchOnce        bool                `alloy:"match_once,attr,optional"`
+}
+
+// RouteConfig configures a route of the loki.route component.
+type RouteConfig struct {
+	Name      string              `alloy:",label"`
+	Selector  string              `alloy:"selector,attr"`
+	ForwardTo []loki.LogsReceiver `alloy:"forward_to,attr"`
+}
+
+// DefaultArguments provides the default arguments for the loki.route
+// component.
+var DefaultArguments = Arguments{
+	MatchOnce: true,
+}
+
+// SetToDefault implements syntax.Defaulter.
+func (a *Arguments) SetToDefault() {
+	*a = DefaultArguments
+}
+
+// Validate implements syntax.Validator.
+func (a *Arguments) Validate() error {
+	var errs []error
+	names := make(map[string]struct{}, len(a.Routes))
+	for _, r := range a.Routes {
+		if r.Name == defaultRouteName {
+			errs = append(errs, fmt.Errorf("route name %q is reserved for the default route", defaultRouteName))
+		}
+		if _, ok := names[r.Name]; ok {
+			errs = append(errs, fmt.Errorf("route %q is defined more than once", r.Name))
+		}
+		names[r.Name] = struct{}{}
+
+		if _, err := logql.ParseExpr(r.Selector); err != nil {
+			errs = append(errs, fmt.Errorf("invalid selector for route %q: %w", r.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Exports holds values which are exported by the loki.route component.
+type Exports struct {
+	Receiver loki.LogsReceiver `alloy:"receiver,attr"`
+}
+
+// route is a compiled RouteConfig.
+type route struct {
+	name     string
+	matchers []*labels.Matcher
+	filter   logql.Filter
+	fanout   *loki.Fanout
+}
+
+func newRoute(cfg RouteConfig) (*route, error) {
+	expr, err := logql.ParseExpr(cfg.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector for route %q: %w", cfg.Name, err)
+	}
+	filter, err := expr.Filter()
+	if err != nil {
+		return nil, fmt.Errorf("invalid line filter for route %q: %w", cfg.Name, err)
+	}
+	return &route{
+		name:     cfg.Name,
+		matchers: expr.Matchers(),
+		filter:   filter,
+		fanout:   loki.NewFanout(cfg.ForwardTo),
+	}, nil
+}
+
+func (r *route) matches(e loki.Entry) bool {
+	for _, m := range r.matchers {
+		if !m.Matches(string(e.Labels[model.LabelName(m.Name)])) {
+			return false
+		}
+	}
+	return r.filter == nil || r.filter([]byte(e.Line))
+}
+
+// Component implements the loki.route component.
+type Component struct {
+	opts               component.Options
+	receiver           loki.LogsReceiver
+	debugDataPublisher livedebugging.DebugDataPublisher
+
+	routedEntries   *prometheus.CounterVec
+	unroutedEntries prometheus.Counter
+
+	// mut is held while sending entries, so that entries aren't sent to
+	// receivers removed by an update.
+	mut           sync.RWMutex
+	matchOnce     bool
+	routes        []*route
+	hasDefault    bool
+	defaultFanout *loki.Fanout
+}
+
+var (
+	_ component.Component     = (*Component)(nil)
+	_ component.LiveDebugging = (*Component)(nil)
+)
+
+// New creates a new loki.route component.
+func New(o component.Options, args Arguments) (*Component, error) {
+	debugDataPublisher, err := o.GetServiceData(livedebugging.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+
+	routedEntries := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "loki_route_routed_entries_total",
+		Help: "Total number of log entries sent to each route.",
+	}, []string{"route"})
+	unroutedEntries := prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "loki_route_unrouted_entries_total",
+		Help: "Total number of log entries dropped because they didn't match any route and there is no default route.",
+	})
+
+	c := &Component{
+		opts:               o,
+		receiver:           loki.NewLogsReceiver(loki.WithComponentID(o.ID)),
+		debugDataPublisher: debugDataPublisher.(livedebugging.DebugDataPublisher),
+		routedEntries:      util.MustRegisterOrGet(o.Registerer, routedEntries).(*prometheus.CounterVec),
+		unroutedEntries:    util.MustRegisterOrGet(o.Registerer, unroutedEntries).(prometheus.Counter),
+		defaultFanout:      loki.NewFanout(nil),
+	}
+	o.OnStateChange(Exports{Receiver: c.receiver})
+
+	if err := c.Update(args); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Run implements component.Component.
+func (c *Component) Run(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case entry := <-c.receiver.Chan():
+			// NOTE: the only error we can get is context.Canceled.
+			if err := c.route(ctx, entry); err != nil {
+				return nil
+			}
+		}
+	}
+}
+
+// route sends an entry to the routes it matches, or to the default route if
+// it doesn't match any route.
+func (c *Component) route(ctx context.Context, entry loki.Entry) error {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+
+	var matched []string
+	for _, r := range c.routes {
+		if !r.matches(entry) {
+			continue
+		}
+		matched = append(matched, r.name)
+		c.routedEntries.WithLabelValues(r.name).Inc()
+		if err := r.fanout.Send(ctx, entry); err != nil {
+			return err
+		}
+		if c.matchOnce {
+			break
+		}
+	}
+
+	if len(matched) == 0 {
+		if !c.hasDefault {
+			c.unroutedEntries.Inc()
+		} else {
+			matched = append(matched, defaultRouteName)
+			c.routedEntries.WithLabelValues(defaultRouteName).Inc()
+			if err := c.defaultFanout.Send(ctx, entry); err != nil {
+				return err
+			}
+		}
+	}
+
+	c.debugDataPublisher.PublishIfActive(livedebugging.NewData(
+		livedebugging.ComponentID(c.opts.ID),
+		livedebugging.LokiLog,
+		1,
+		func() string {
+			routes := "none"
+			if len(matched) > 0 {
+				routes = strings.Join(matched, ", ")
+			}
+			return fmt.Sprintf("entry: %s, labels: %s => routes: %s", entry.Line, entry.Labels.String(), routes)
+		},
+		livedebugging.WithAttributes(func() map[string]string { return livedebugging.LokiLabels(entry.Labels) }),
+		livedebugging.WithReplay(func() *livedebugging.Replay { return livedebugging.NewLokiReplay(entry.Labels, entry.Entry) }),
+	))
+	return nil
+}
+
+// Update implements component.Component.
+func (c *Component) Update(args component.Arguments) error {
+	newArgs := args.(Arguments)
+
+	routes := make([]*route, 0, len(newArgs.Routes))
+	for _, cfg := range newArgs.Routes {
+		r, err := newRoute(cfg)
+		if err != nil {
+			return err
+		}
+		routes = append(routes, r)
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	// Delete the series of removed routes.
+	for _, r := range c.routes {
+		if !slices.ContainsFunc(routes, func(nr *route) bool { return nr.name == r.name }) {
+			c.routedEntries.DeleteLabelValues(r.name)
+		}
+	}
+
+	c.matchOnce = newArgs.MatchOnce
+	c.routes = routes
+	c.hasDefault = len(newArgs.DefaultForwardTo) > 0
+	c.defaultFanout.UpdateChildren(newArgs.DefaultForwardTo)
+	return nil
+}
+
+// LiveDebugging implements component.LiveDebugging.
+func (c *Component) LiveDebugging() {}
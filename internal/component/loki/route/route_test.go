@@ -0,0 +1,210 @@
+package route
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/grafana/loki/pkg/push"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/component/common/loki"
+	"github.com/grafana/alloy/internal/service/livedebugging"
+	"github.com/grafana/alloy/internal/util"
+	"github.com/grafana/alloy/syntax"
+)
+
+func TestArguments(t *testing.T) {
+	var args Arguments
+	require.NoError(t, syntax.Unmarshal([]byte(`
+		route "errors" {
+			selector   = "{app=\"api\"} |= \"error\""
+			forward_to = []
+		}
+		default_forward_to = []
+	`), &args))
+	require.True(t, args.MatchOnce)
+	require.Len(t, args.Routes, 1)
+	require.Equal(t, "errors", args.Routes[0].Name)
+
+	err := syntax.Unmarshal([]byte(`
+		route "default" {
+			selector   = "{app=\"api\"}"
+			forward_to = []
+		}
+		route "a" {
+			selector   = "{app=\"api\"}"
+			forward_to = []
+		}
+		route "a" {
+			selector   = "app=api"
+			forward_to = []
+		}
+	`), &args)
+	require.ErrorContains(t, err, `route name "default" is reserved for the default route`)
+	require.ErrorContains(t, err, `route "a" is defined more than once`)
+	require.ErrorContains(t, err, `invalid selector for route "a"`)
+}
+
+func TestComponent(t *testing.T) {
+	tests := []struct {
+		name      string
+		matchOnce bool
+		noDefault bool
+		line      string
+		labels    model.LabelSet
+		want      []string // Names of the routes receiving the entry.
+	}{
+		{
+			name:      "first matching route",
+			matchOnce: true,
+			line:      "error: connection refused",
+			labels:    model.LabelSet{"app": "api"},
+			want:      []string{"errors"},
+		},
+		{
+			name:   "all matching routes",
+			line:   "error: connection refused",
+			labels: model.LabelSet{"app": "api"},
+			want:   []string{"errors", "api"},
+		},
+		{
+			name:      "line filter",
+			matchOnce: true,
+			line:      "request served",
+			labels:    model.LabelSet{"app": "api"},
+			want:      []string{"api"},
+		},
+		{
+			name:      "default route",
+			matchOnce: true,
+			line:      "request served",
+			labels:    model.LabelSet{"app": "web"},
+			want:      []string{"default"},
+		},
+		{
+			name:      "no route",
+			matchOnce: true,
+			noDefault: true,
+			line:      "request served",
+			labels:    model.LabelSet{"app": "web"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collectors := map[string]*loki.CollectingHandler{}
+			for _, name := range []string{"errors", "api", "default"} {
+				collectors[name] = loki.NewCollectingHandler()
+				defer collectors[name].Stop()
+			}
+
+			reg := prometheus.NewRegistry()
+			opts := component.Options{
+				Logger:         util.TestAlloyLogger(t),
+				Registerer:     reg,
+				OnStateChange:  func(e component.Exports) {},
+				GetServiceData: getServiceData,
+			}
+			args := Arguments{
+				MatchOnce: tt.matchOnce,
+				Routes: []RouteConfig{
+					{
+						Name:      "errors",
+						Selector:  `{app=~".+"} |~ "(?i)error"`,
+						ForwardTo: []loki.LogsReceiver{collectors["errors"].Receiver()},
+					},
+					{
+						Name:      "api",
+						Selector:  `{app="api"}`,
+						ForwardTo: []loki.LogsReceiver{collectors["api"].Receiver()},
+					},
+				},
+			}
+			if !tt.noDefault {
+				args.DefaultForwardTo = []loki.LogsReceiver{collectors["default"].Receiver()}
+			}
+
+			c, err := New(opts, args)
+			require.NoError(t, err)
+			go c.Run(t.Context())
+
+			c.receiver.Chan() <- loki.Entry{
+				Labels: tt.labels,
+				Entry:  push.Entry{Timestamp: time.Now(), Line: tt.line},
+			}
+
+			require.EventuallyWithT(t, func(c *assert.CollectT) {
+				for name, collector := range collectors {
+					want := 0
+					for _, n := range tt.want {
+						if n == name {
+							want = 1
+						}
+					}
+					require.Len(c, collector.Received(), want, name)
+				}
+			}, 5*time.Second, 10*time.Millisecond)
+
+			if tt.noDefault {
+				require.Eventually(t, func() bool {
+					return testutil.ToFloat64(c.unroutedEntries) == 1
+				}, 5*time.Second, 10*time.Millisecond)
+			}
+		})
+	}
+}
+
+func TestComponentUpdate(t *testing.T) {
+	collector := loki.NewCollectingHandler()
+	defer collector.Stop()
+
+	reg := prometheus.NewRegistry()
+	opts := component.Options{
+		Logger:         util.TestAlloyLogger(t),
+		Registerer:     reg,
+		OnStateChange:  func(e component.Exports) {},
+		GetServiceData: getServiceData,
+	}
+	args := Arguments{
+		Routes: []RouteConfig{{
+			Name:      "old",
+			Selector:  `{app="api"}`,
+			ForwardTo: []loki.LogsReceiver{collector.Receiver()},
+		}},
+	}
+
+	c, err := New(opts, args)
+	require.NoError(t, err)
+	go c.Run(t.Context())
+
+	entry := loki.Entry{Labels: model.LabelSet{"app": "api"}, Entry: push.Entry{Line: "hello"}}
+	c.receiver.Chan() <- entry
+	require.Eventually(t, func() bool { return len(collector.Received()) == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	args.Routes[0].Name = "new"
+	require.NoError(t, c.Update(args))
+	c.receiver.Chan() <- entry
+	require.Eventually(t, func() bool { return len(collector.Received()) == 2 }, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, testutil.GatherAndCompare(reg, strings.NewReader(`
+		# HELP loki_route_routed_entries_total Total number of log entries sent to each route.
+		# TYPE loki_route_routed_entries_total counter
+		loki_route_routed_entries_total{route="new"} 1
+	`), "loki_route_routed_entries_total"))
+}
+
+func getServiceData(name string) (any, error) {
+	switch name {
+	case livedebugging.ServiceName:
+		return livedebugging.NewLiveDebugging(), nil
+	default:
+		return nil, fmt.Errorf("service not found %s", name)
+	}
+}
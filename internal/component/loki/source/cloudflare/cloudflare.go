@@ -15,6 +15,7 @@ import (
 	"github.com/grafana/alloy/internal/component/loki/source/internal/positions"
 	"github.com/grafana/alloy/internal/featuregate"
 	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/service/cluster"
 	"github.com/grafana/alloy/syntax/alloytypes"
 )
 
@@ -41,6 +42,8 @@ type Arguments struct {
 	FieldsType       FieldsType          `alloy:"fields_type,attr,optional"`
 	AdditionalFields []string            `alloy:"additional_fields,attr,optional"`
 	ForwardTo        []loki.LogsReceiver `alloy:"forward_to,attr"`
+
+	Clustering cluster.SingletonBlock `alloy:"clustering,block,optional"`
 }
 
 func (c Arguments) tailerConfig() *tailerConfig {
@@ -87,13 +90,24 @@ type Component struct {
 	handler loki.LogsReceiver
 	metrics *metrics
 
-	// mut is used to protect access to tailer.
+	// mut is used to protect access to args and tailer.
 	mut    sync.RWMutex
-	tailer *tailer
+	args   Arguments
+	tailer *tailer // nil if another node of the cluster pulls logs.
 
 	fanout *loki.Fanout
+
+	// singleton elects the node pulling logs when clustering is enabled.
+	singleton *cluster.Singleton
 }
 
+var (
+	_ component.Component       = (*Component)(nil)
+	_ component.DebugComponent  = (*Component)(nil)
+	_ component.HealthComponent = (*Component)(nil)
+	_ cluster.Component         = (*Component)(nil)
+)
+
 // New creates a new loki.source.cloudflare component.
 func New(o component.Options, args Arguments) (*Component, error) {
 	err := os.MkdirAll(o.DataPath, 0750)
@@ -110,6 +124,11 @@ func New(o component.Options, args Arguments) (*Component, error) {
 		return nil, err
 	}
 
+	data, err := o.GetServiceData(cluster.ServiceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get information about cluster service: %w", err)
+	}
+
 	c := &Component{
 		opts:    o,
 		metrics: newMetrics(o.Registerer),
@@ -117,6 +136,12 @@ func New(o component.Options, args Arguments) (*Component, error) {
 		fanout:  loki.NewFanout(args.ForwardTo),
 		posFile: positionsFile,
 	}
+	c.singleton = cluster.NewSingleton(data.(cluster.Cluster), cluster.SingletonOptions{
+		Logger:     o.Logger,
+		Registerer: o.Registerer,
+		ID:         o.ID,
+		OnChange:   c.onLeadershipChange,
+	})
 
 	// Call to Update() to start readers and set receivers once at the start.
 	if err := c.Update(args); err != nil {
@@ -136,10 +161,22 @@ func (c *Component) Run(ctx context.Context) error {
 		loki.Drain(c.handler, c.fanout, loki.DefaultDrainTimeout, func() {
 			c.mut.Lock()
 			defer c.mut.Unlock()
-			c.tailer.stop()
+			if c.tailer != nil {
+				c.tailer.stop()
+			}
 		})
 	}()
 
+	// Stop the election before the tailer, so that no tailer is started after
+	// the component stopped.
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		c.singleton.Run(ctx)
+	}()
+
 	loki.Consume(ctx, c.handler, c.fanout)
 	return nil
 }
@@ -153,25 +190,56 @@ func (c *Component) Update(args component.Arguments) error {
 
 	c.fanout.UpdateChildren(newArgs.ForwardTo)
 
+	c.args = newArgs
+	c.singleton.SetEnabled(newArgs.Clustering.Mode == cluster.ModeSingleton)
+	return c.restartTailer()
+}
+
+// restartTailer stops the running tailer, and starts a new one if the local
+// node should pull logs. restartTailer must only be called when c.mut is
+// held.
+func (c *Component) restartTailer() error {
 	if c.tailer != nil {
 		c.tailer.stop()
+		c.tailer = nil
+	}
+	if !c.singleton.Leading() {
+		return nil
 	}
 
-	t, err := newTailer(c.metrics, c.opts.Logger, c.handler, c.posFile, newArgs.tailerConfig())
+	t, err := newTailer(c.metrics, c.opts.Logger, c.handler, c.posFile, c.args.tailerConfig())
 	if err != nil {
 		level.Error(c.opts.Logger).Log("msg", "failed to create cloudflare target with provided config", "err", err)
 		return err
 	}
 	c.tailer = t
-
 	return nil
 }
 
+func (c *Component) onLeadershipChange(_ bool) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	_ = c.restartTailer()
+}
+
+// NotifyClusterChange implements cluster.Component.
+func (c *Component) NotifyClusterChange() {
+	c.singleton.NotifyClusterChange()
+}
+
+// CurrentHealth implements component.HealthComponent.
+func (c *Component) CurrentHealth() component.Health {
+	return c.singleton.CurrentHealth()
+}
+
 // DebugInfo returns information about the status of targets.
 func (c *Component) DebugInfo() any {
 	c.mut.RLock()
 	defer c.mut.RUnlock()
 
+	if c.tailer == nil {
+		return targetDebugInfo{}
+	}
 	return targetDebugInfo{
 		Ready:   c.tailer.ready(),
 		Details: c.tailer.details(),
@@ -21,6 +21,7 @@ import (
 	"github.com/grafana/alloy/internal/component/loki/source"
 	"github.com/grafana/alloy/internal/component/loki/source/internal/positions"
 	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/service/cluster"
 )
 
 // Generous timeout period for configuring informers
@@ -49,6 +50,8 @@ type Arguments struct {
 
 	// Client settings to connect to Kubernetes.
 	Client kubernetes.ClientArguments `alloy:"client,block,optional"`
+
+	Clustering cluster.SingletonBlock `alloy:"clustering,block,optional"`
 }
 
 // DefaultArguments holds default settings for loki.source.kubernetes_events.
@@ -90,11 +93,16 @@ type Component struct {
 	scheduler  *source.Scheduler[string]
 
 	fanout *loki.Fanout
+
+	// singleton elects the node watching events when clustering is enabled.
+	singleton *cluster.Singleton
 }
 
 var (
-	_ component.Component      = (*Component)(nil)
-	_ component.DebugComponent = (*Component)(nil)
+	_ component.Component       = (*Component)(nil)
+	_ component.DebugComponent  = (*Component)(nil)
+	_ component.HealthComponent = (*Component)(nil)
+	_ cluster.Component         = (*Component)(nil)
 )
 
 // New creates a new loki.source.kubernetes_events component.
@@ -111,6 +119,11 @@ func New(o component.Options, args Arguments) (*Component, error) {
 		return nil, err
 	}
 
+	data, err := o.GetServiceData(cluster.ServiceName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get information about cluster service: %w", err)
+	}
+
 	c := &Component{
 		log:       o.Logger,
 		opts:      o,
@@ -119,6 +132,12 @@ func New(o component.Options, args Arguments) (*Component, error) {
 		scheduler: source.NewScheduler[string](),
 		fanout:    loki.NewFanout(args.ForwardTo),
 	}
+	c.singleton = cluster.NewSingleton(data.(cluster.Cluster), cluster.SingletonOptions{
+		Logger:     o.Logger,
+		Registerer: o.Registerer,
+		ID:         o.ID,
+		OnChange:   c.onLeadershipChange,
+	})
 	if err := c.Update(args); err != nil {
 		return nil, err
 	}
@@ -136,6 +155,16 @@ func (c *Component) Run(ctx context.Context) error {
 		})
 	}()
 
+	// Stop the election before the sources, so that no source is started
+	// after the scheduler stopped.
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		c.singleton.Run(ctx)
+	}()
+
 	loki.Consume(ctx, c.handler, c.fanout)
 	return nil
 }
@@ -163,27 +192,57 @@ func (c *Component) Update(args component.Arguments) error {
 		c.scheduler.Reset()
 	}
 
+	c.restConfig = restConfig
+	c.args = newArgs
+	c.singleton.SetEnabled(newArgs.Clustering.Mode == cluster.ModeSingleton)
+	c.reconcile()
+	return nil
+}
+
+// reconcile starts and stops event controllers to watch the namespaces of
+// c.args. No namespace is watched if another node of the cluster watches
+// events. reconcile must only be called when c.mut is held.
+func (c *Component) reconcile() {
+	namespaces := getNamespaces(c.args)
+	if !c.singleton.Leading() {
+		namespaces = func(func(string) bool) {}
+	}
+
+	args := c.args
 	source.Reconcile(
 		c.opts.Logger,
 		c.scheduler,
-		getNamespaces(newArgs),
+		namespaces,
 		func(namespace string) string { return namespace },
 		func(_ string, namespace string) (source.Source[string], error) {
 			return newEventController(eventControllerOptions{
 				Log:          c.log,
-				Config:       restConfig,
+				Config:       c.restConfig,
 				Namespace:    namespace,
-				JobName:      newArgs.JobName,
+				JobName:      args.JobName,
 				InstanceName: c.opts.ID,
 				Receiver:     c.handler,
 				Positions:    c.positions,
-				LogFormat:    newArgs.LogFormat,
+				LogFormat:    args.LogFormat,
 			}), nil
 		},
 	)
+}
 
-	c.args = newArgs
-	return nil
+func (c *Component) onLeadershipChange(_ bool) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.reconcile()
+}
+
+// NotifyClusterChange implements cluster.Component.
+func (c *Component) NotifyClusterChange() {
+	c.singleton.NotifyClusterChange()
+}
+
+// CurrentHealth implements component.HealthComponent.
+func (c *Component) CurrentHealth() component.Health {
+	return c.singleton.CurrentHealth()
 }
 
 // getNamespaces returns a iterator of namespaces to watch from the arguments. If the
@@ -15,6 +15,8 @@ import (
 	"github.com/grafana/alloy/internal/component/common/loki/wal"
 	"github.com/grafana/alloy/internal/featuregate"
 	"github.com/grafana/alloy/internal/loki/util"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+	alloyutil "github.com/grafana/alloy/internal/util"
 	"github.com/prometheus/common/model"
 )
 
@@ -75,7 +77,8 @@ type Exports struct {
 }
 
 var (
-	_ component.Component = (*Component)(nil)
+	_ component.Component      = (*Component)(nil)
+	_ component.DebugComponent = (*Component)(nil)
 )
 
 // Component implements the loki.write component.
@@ -207,6 +210,27 @@ func (c *Component) Update(args component.Arguments) error {
 	return nil
 }
 
+// DebugInfo implements [component.DebugComponent].
+func (c *Component) DebugInfo() any {
+	type Info struct {
+		WALEnabled bool  `alloy:"wal_enabled,attr"`
+		WALSize    int64 `alloy:"wal_size_bytes,attr,optional"`
+	}
+
+	c.mut.RLock()
+	info := Info{WALEnabled: c.args.WAL.Enabled}
+	c.mut.RUnlock()
+
+	if info.WALEnabled {
+		size, err := alloyutil.DirSize(filepath.Join(c.opts.DataPath, "wal"))
+		if err != nil {
+			level.Debug(c.opts.Logger).Log("msg", "failed to compute the size of the WAL", "err", err)
+		}
+		info.WALSize = size
+	}
+	return info
+}
+
 func newEntryHandler(handler loki.EntryHandler, externalLabels model.LabelSet) loki.EntryHandler {
 	return loki.NewEntryMutatorHandler(handler, func(e loki.Entry) loki.Entry {
 		if len(externalLabels) == 0 {
@@ -120,6 +120,14 @@ func (f fakeCluster) Ready() bool {
 	return true
 }
 
+func (f fakeCluster) ReplicationFactor() int {
+	return 1
+}
+
+func (f fakeCluster) KV() cluster.KV {
+	return nil
+}
+
 type fakeLeadership struct {
 	leader    bool
 	changed   bool
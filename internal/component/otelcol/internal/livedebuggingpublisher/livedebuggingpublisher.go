@@ -22,6 +22,13 @@ func PublishLogsIfActive(debugDataPublisher livedebugging.DebugDataPublisher, co
 			return string(data)
 		},
 		livedebugging.WithTargetComponentIDs(extractIds(nextLogs)),
+		livedebugging.WithReplay(func() *livedebugging.Replay {
+			data, err := (&plog.JSONMarshaler{}).MarshalLogs(ld)
+			if err != nil {
+				return nil
+			}
+			return &livedebugging.Replay{OTLP: &livedebugging.ReplayOTLP{Logs: data}}
+		}),
 	))
 }
 
@@ -38,6 +45,13 @@ func PublishTracesIfActive(debugDataPublisher livedebugging.DebugDataPublisher,
 			return string(data)
 		},
 		livedebugging.WithTargetComponentIDs(extractIds(nextTraces)),
+		livedebugging.WithReplay(func() *livedebugging.Replay {
+			data, err := (&ptrace.JSONMarshaler{}).MarshalTraces(td)
+			if err != nil {
+				return nil
+			}
+			return &livedebugging.Replay{OTLP: &livedebugging.ReplayOTLP{Traces: data}}
+		}),
 	))
 }
 
@@ -54,6 +68,13 @@ func PublishMetricsIfActive(debugDataPublisher livedebugging.DebugDataPublisher,
 			return string(data)
 		},
 		livedebugging.WithTargetComponentIDs(extractIds(nextMetrics)),
+		livedebugging.WithReplay(func() *livedebugging.Replay {
+			data, err := (&pmetric.JSONMarshaler{}).MarshalMetrics(md)
+			if err != nil {
+				return nil
+			}
+			return &livedebugging.Replay{OTLP: &livedebugging.ReplayOTLP{Metrics: data}}
+		}),
 	))
 }
 
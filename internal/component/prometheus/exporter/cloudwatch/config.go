@@ -10,7 +10,9 @@ import (
 	yaceConf "github.com/prometheus-community/yet-another-cloudwatch-exporter/pkg/config"
 	yaceModel "github.com/prometheus-community/yet-another-cloudwatch-exporter/pkg/model"
 
+	"github.com/grafana/alloy/internal/component/prometheus/exporter"
 	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/service/cluster"
 	"github.com/grafana/alloy/internal/static/integrations/cloudwatch_exporter"
 	"github.com/grafana/alloy/syntax"
 )
@@ -44,6 +46,15 @@ type Arguments struct {
 
 	// UseAWSSDKVersion2 is deprecated and has no effect.
 	UseAWSSDKVersion2 bool `alloy:"aws_sdk_version_v2,attr,optional"`
+
+	Clustering cluster.SingletonBlock `alloy:"clustering,block,optional"`
+}
+
+var _ exporter.SingletonArguments = Arguments{}
+
+// SingletonEnabled implements exporter.SingletonArguments.
+func (a Arguments) SingletonEnabled() bool {
+	return a.Clustering.Mode == cluster.ModeSingleton
 }
 
 // DecoupledScrapeConfig is the configuration for decoupled scraping feature.
@@ -13,6 +13,7 @@ import (
 	"github.com/grafana/alloy/internal/component"
 	"github.com/grafana/alloy/internal/component/discovery"
 	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/service/cluster"
 	http_service "github.com/grafana/alloy/internal/service/http"
 	"github.com/grafana/alloy/internal/static/integrations"
 )
@@ -25,6 +26,15 @@ type Exports struct {
 	Targets []discovery.Target `alloy:"targets,attr"`
 }
 
+// SingletonArguments is implemented by the arguments of exporters which can
+// run on a single node of the cluster, such as exporters querying a shared
+// API.
+type SingletonArguments interface {
+	// SingletonEnabled reports whether the exporter should only run on a
+	// single node of the cluster.
+	SingletonEnabled() bool
+}
+
 type Component struct {
 	opts component.Options
 
@@ -38,6 +48,11 @@ type Component struct {
 
 	exporter       integrations.Integration
 	metricsHandler http.Handler
+	targets        []discovery.Target
+
+	// singleton elects the node running the exporter, if the exporter
+	// supports it. Other nodes don't run the exporter and export no targets.
+	singleton *cluster.Singleton
 }
 
 // New creates a new exporter component.
@@ -53,6 +68,16 @@ func NewWithTargetBuilder(creator Creator, name string, targetBuilderFunc func(d
 
 // Run implements component.Component.
 func (c *Component) Run(ctx context.Context) error {
+	if c.singleton != nil {
+		var wg sync.WaitGroup
+		defer wg.Wait()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.singleton.Run(ctx)
+		}()
+	}
+
 	var cancel context.CancelFunc
 	for {
 		select {
@@ -69,6 +94,11 @@ func (c *Component) Run(ctx context.Context) error {
 			newCtx, cancelFunc := context.WithCancel(ctx)
 			cancel = cancelFunc
 
+			// Only the elected node runs singleton exporters.
+			if !c.leading() {
+				continue
+			}
+
 			// finally create and run new exporter
 			c.mut.Lock()
 			exporter := c.exporter
@@ -98,22 +128,51 @@ func (c *Component) Update(args component.Arguments) error {
 	tb.Set("instance", instanceKey)
 	c.baseTarget = tb.Target()
 
-	var targets []discovery.Target
 	if c.targetBuilderFunc == nil {
-		targets = []discovery.Target{c.baseTarget}
+		c.targets = []discovery.Target{c.baseTarget}
 	} else {
-		targets = c.targetBuilderFunc(c.baseTarget, args)
+		c.targets = c.targetBuilderFunc(c.baseTarget, args)
+	}
+
+	if c.singleton != nil {
+		c.singleton.SetEnabled(args.(SingletonArguments).SingletonEnabled())
 	}
+	c.exportTargets()
+	c.mut.Unlock()
+	c.triggerReload()
+	return err
+}
 
+// exportTargets exports the targets of the exporter, or no targets if another
+// node runs the exporter. exportTargets must only be called when c.mut is
+// held.
+func (c *Component) exportTargets() {
+	targets := c.targets
+	if !c.leading() {
+		targets = []discovery.Target{}
+	}
 	c.opts.OnStateChange(Exports{
 		Targets: targets,
 	})
-	c.mut.Unlock()
+}
+
+func (c *Component) triggerReload() {
 	select {
 	case c.reload <- struct{}{}:
 	default:
 	}
-	return err
+}
+
+// leading reports whether the exporter should run on the local node.
+func (c *Component) leading() bool {
+	return c.singleton == nil || c.singleton.Leading()
+}
+
+func (c *Component) onLeadershipChange(_ bool) {
+	c.mut.Lock()
+	c.exportTargets()
+	c.mut.Unlock()
+	c.triggerReload()
 }
 
 // Handler serves metrics endpoint from the integration implementation.
@@ -138,6 +197,19 @@ func newExporter(creator Creator, name string, targetBuilderFunc func(discovery.
 		}
 		httpData := data.(http_service.Data)
 
+		if _, ok := args.(SingletonArguments); ok {
+			data, err := opts.GetServiceData(cluster.ServiceName)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get information about cluster service: %w", err)
+			}
+			c.singleton = cluster.NewSingleton(data.(cluster.Cluster), cluster.SingletonOptions{
+				Logger:     opts.Logger,
+				Registerer: opts.Registerer,
+				ID:         opts.ID,
+				OnChange:   c.onLeadershipChange,
+			})
+		}
+
 		componentName := opts.ID[:strings.LastIndex(opts.ID, ".")]
 		if opts.ID == "prometheus.exporter.unix" {
 			componentName = opts.ID
@@ -156,10 +228,34 @@ func newExporter(creator Creator, name string, targetBuilderFunc func(discovery.
 			return nil, err
 		}
 
+		if c.singleton != nil {
+			return &singletonComponent{Component: c}, nil
+		}
 		return c, nil
 	}
 }
 
+// singletonComponent is an exporter component which can run on a single node
+// of the cluster.
+type singletonComponent struct {
+	*Component
+}
+
+var (
+	_ component.HealthComponent = (*singletonComponent)(nil)
+	_ cluster.Component         = (*singletonComponent)(nil)
+)
+
+// CurrentHealth implements component.HealthComponent.
+func (c *singletonComponent) CurrentHealth() component.Health {
+	return c.singleton.CurrentHealth()
+}
+
+// NotifyClusterChange implements cluster.Component.
+func (c *singletonComponent) NotifyClusterChange() {
+	c.singleton.NotifyClusterChange()
+}
+
 // get the http handler once and save it, so we don't create extra garbage
 func (c *Component) getHttpHandler(integration integrations.Integration) http.Handler {
 	h, err := integration.MetricsHandler()
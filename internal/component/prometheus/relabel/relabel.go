@@ -292,6 +292,7 @@ func (c *Component) relabel(val float64, lbls labels.Labels) labels.Labels {
 		func() string {
 			return fmt.Sprintf("%s => %s", lbls.String(), relabelled.String())
 		},
+		livedebugging.WithAttributes(lbls.Map),
 	))
 
 	return relabelled
@@ -77,6 +77,8 @@ func NewInterceptor(componentID string, exited *atomic.Bool, debugDataPublisher
 				func() string {
 					return fmt.Sprintf("sample: ts=%d, labels=%s, value=%f", t, l, v)
 				},
+				livedebugging.WithAttributes(l.Map),
+				livedebugging.WithReplay(func() *livedebugging.Replay { return livedebugging.NewSampleReplay(l, t, v) }),
 			))
 			return finalRef, err
 		}),
@@ -26,6 +26,7 @@ import (
 	"github.com/grafana/alloy/internal/service/livedebugging"
 	"github.com/grafana/alloy/internal/static/metrics/wal"
 	"github.com/grafana/alloy/internal/useragent"
+	"github.com/grafana/alloy/internal/util"
 )
 
 // Options.
@@ -138,6 +139,7 @@ func startTime() (int64, error) { return 0, nil }
 
 var _ component.Component = (*Component)(nil)
 var _ component.LiveDebugging = (*Component)(nil)
+var _ component.DebugComponent = (*Component)(nil)
 
 // Run implements Component.
 func (c *Component) Run(ctx context.Context) error {
@@ -249,6 +251,19 @@ func (c *Component) Update(newConfig component.Arguments) error {
 
 func (c *Component) LiveDebugging() {}
 
+// DebugInfo implements [component.DebugComponent].
+func (c *Component) DebugInfo() any {
+	type Info struct {
+		WALSize int64 `alloy:"wal_size_bytes,attr"`
+	}
+
+	size, err := util.DirSize(wal.SubDirectory(c.opts.DataPath))
+	if err != nil {
+		level.Debug(c.log).Log("msg", "failed to compute the size of the WAL", "err", err)
+	}
+	return Info{WALSize: size}
+}
+
 func validateStabilityLevelForRemoteWritev2(o component.Options, args Arguments) error {
 	for _, endpoint := range args.Endpoints {
 		if endpoint.ProtobufMessage == PrometheusProtobufMessageV2 && !o.MinStability.Permits(featuregate.StabilityExperimental) {
@@ -26,6 +26,8 @@ func NewInterceptor(componentID livedebugging.ComponentID, debugDataPublisher li
 				func() string {
 					return fmt.Sprintf("sample: ts=%d, labels=%s, value=%f", t, l, v)
 				},
+				livedebugging.WithAttributes(l.Map),
+				livedebugging.WithReplay(func() *livedebugging.Replay { return livedebugging.NewSampleReplay(l, t, v) }),
 			))
 			return newRef, nextErr
 		}),
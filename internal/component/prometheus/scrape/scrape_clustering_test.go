@@ -383,3 +383,11 @@ func (f *fakeCluster) Peers() []peer.Peer {
 func (f *fakeCluster) Ready() bool {
 	return true
 }
+
+func (f *fakeCluster) ReplicationFactor() int {
+	return 1
+}
+
+func (f *fakeCluster) KV() cluster.KV {
+	return nil
+}
@@ -13,6 +13,7 @@ import (
 
 	"github.com/grafana/alloy/internal/component"
 	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/util"
 )
 
 func init() {
@@ -44,6 +45,8 @@ func NewComponent(opts component.Options, args Arguments) (*Queue, error) {
 	return s, nil
 }
 
+var _ component.DebugComponent = (*Queue)(nil)
+
 // Queue is a queue based WAL used to send data to a remote_write endpoint. Queue supports replaying
 // and TTLs.
 type Queue struct {
@@ -132,6 +135,30 @@ func (s *Queue) Update(args component.Arguments) error {
 	return nil
 }
 
+// DebugInfo implements [component.DebugComponent].
+func (s *Queue) DebugInfo() any {
+	type endpointInfo struct {
+		Name      string `alloy:",label"`
+		QueueSize int64  `alloy:"queue_size_bytes,attr"`
+	}
+	type Info struct {
+		Endpoints []endpointInfo `alloy:"endpoint,block,optional"`
+	}
+
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+
+	var info Info
+	for _, ep := range s.args.Endpoints {
+		size, err := util.DirSize(filepath.Join(s.opts.DataPath, ep.Name, "wal"))
+		if err != nil {
+			level.Debug(s.log).Log("msg", "failed to compute the size of the queue", "endpoint", ep.Name, "err", err)
+		}
+		info.Endpoints = append(info.Endpoints, endpointInfo{Name: ep.Name, QueueSize: size})
+	}
+	return info
+}
+
 func (s *Queue) createEndpoints() error {
 	for _, ep := range s.args.Endpoints {
 		nativeCfg := ep.ToNativeType()
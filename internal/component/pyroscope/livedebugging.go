@@ -0,0 +1,103 @@
+package pyroscope
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/google/pprof/profile"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/alloy/internal/service/livedebugging"
+)
+
+// maxTopFrames is the number of functions in the summary of a profile.
+const maxTopFrames = 10
+
+// NewProfileData returns the live debugging data of a profile. The profile is
+// only parsed when the data is consumed.
+func NewProfileData(componentID string, lbls labels.Labels, rawProfile []byte) livedebugging.Data {
+	summary := sync.OnceValue(func() livedebugging.ProfileSummary {
+		return SummarizeProfile(lbls, rawProfile)
+	})
+	return livedebugging.NewData(
+		livedebugging.ComponentID(componentID),
+		livedebugging.Profile,
+		1,
+		func() string { return formatProfileSummary(summary()) },
+		livedebugging.WithAttributes(lbls.Map),
+		livedebugging.WithPayload(func() any { return summary() }),
+	)
+}
+
+// SummarizeProfile returns the summary of a profile. Only pprof profiles,
+// compressed or not, are parsed: the summary of profiles in other formats
+// only has their labels and size.
+func SummarizeProfile(lbls labels.Labels, rawProfile []byte) livedebugging.ProfileSummary {
+	summary := livedebugging.ProfileSummary{
+		Labels: lbls.Map(),
+		Size:   len(rawProfile),
+	}
+
+	p, err := profile.ParseData(rawProfile)
+	if err != nil || len(p.SampleType) == 0 {
+		return summary
+	}
+
+	for _, st := range p.SampleType {
+		summary.SampleTypes = append(summary.SampleTypes, st.Type+":"+st.Unit)
+	}
+	summary.Samples = len(p.Sample)
+
+	// Like pprof, use the last sample type unless the profile has a default.
+	index := len(p.SampleType) - 1
+	for i, st := range p.SampleType {
+		if st.Type == p.DefaultSampleType {
+			index = i
+		}
+	}
+
+	self := make(map[string]int64)
+	for _, s := range p.Sample {
+		if len(s.Location) == 0 || index >= len(s.Value) {
+			continue
+		}
+		self[leafFunction(s.Location[0])] += s.Value[index]
+	}
+	for fn, v := range self {
+		summary.TopFrames = append(summary.TopFrames, livedebugging.ProfileFrame{Function: fn, Self: v})
+	}
+	slices.SortFunc(summary.TopFrames, func(a, b livedebugging.ProfileFrame) int {
+		if c := cmp.Compare(b.Self, a.Self); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Function, b.Function)
+	})
+	if len(summary.TopFrames) > maxTopFrames {
+		summary.TopFrames = summary.TopFrames[:maxTopFrames]
+	}
+	return summary
+}
+
+// leafFunction returns the name of the innermost function of a location, or
+// its address when the profile isn't symbolized.
+func leafFunction(loc *profile.Location) string {
+	if len(loc.Line) > 0 && loc.Line[0].Function != nil {
+		return loc.Line[0].Function.Name
+	}
+	return fmt.Sprintf("0x%x", loc.Address)
+}
+
+func formatProfileSummary(s livedebugging.ProfileSummary) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s size=%d", labels.FromMap(s.Labels), s.Size)
+	if len(s.SampleTypes) > 0 {
+		fmt.Fprintf(&sb, " sample_types=%s samples=%d", strings.Join(s.SampleTypes, ","), s.Samples)
+	}
+	for _, f := range s.TopFrames {
+		fmt.Fprintf(&sb, "\n  %s %d", f.Function, f.Self)
+	}
+	return sb.String()
+}
@@ -0,0 +1,80 @@
+package pyroscope
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/google/pprof/profile"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/service/livedebugging"
+)
+
+func testProfile(t *testing.T, selfByFunction map[string]int64) []byte {
+	t.Helper()
+
+	p := &profile.Profile{
+		SampleType: []*profile.ValueType{
+			{Type: "samples", Unit: "count"},
+			{Type: "cpu", Unit: "nanoseconds"},
+		},
+		PeriodType: &profile.ValueType{Type: "cpu", Unit: "nanoseconds"},
+		Period:     10_000_000,
+	}
+	id := uint64(1)
+	for name, self := range selfByFunction {
+		fn := &profile.Function{ID: id, Name: name}
+		loc := &profile.Location{ID: id, Line: []profile.Line{{Function: fn}}}
+		p.Function = append(p.Function, fn)
+		p.Location = append(p.Location, loc)
+		p.Sample = append(p.Sample, &profile.Sample{Location: []*profile.Location{loc}, Value: []int64{1, self}})
+		id++
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, p.Write(&buf))
+	return buf.Bytes()
+}
+
+func TestSummarizeProfile(t *testing.T) {
+	lbls := labels.FromStrings("service_name", "app")
+
+	self := map[string]int64{"main.work": 300, "main.idle": 100}
+	for i := range 12 {
+		self[fmt.Sprintf("main.f%02d", i)] = int64(i + 1)
+	}
+	raw := testProfile(t, self)
+
+	summary := SummarizeProfile(lbls, raw)
+	require.Equal(t, map[string]string{"service_name": "app"}, summary.Labels)
+	require.Equal(t, []string{"samples:count", "cpu:nanoseconds"}, summary.SampleTypes)
+	require.Equal(t, len(raw), summary.Size)
+	require.Equal(t, 14, summary.Samples)
+	require.Len(t, summary.TopFrames, maxTopFrames)
+	require.Equal(t, livedebugging.ProfileFrame{Function: "main.work", Self: 300}, summary.TopFrames[0])
+	require.Equal(t, livedebugging.ProfileFrame{Function: "main.idle", Self: 100}, summary.TopFrames[1])
+	require.Equal(t, livedebugging.ProfileFrame{Function: "main.f11", Self: 12}, summary.TopFrames[2])
+
+	// Profiles which aren't pprof only have their labels and size.
+	summary = SummarizeProfile(lbls, []byte("main;work 300"))
+	require.Equal(t, livedebugging.ProfileSummary{Labels: map[string]string{"service_name": "app"}, Size: 13}, summary)
+}
+
+func TestNewProfileData(t *testing.T) {
+	lbls := labels.FromStrings("service_name", "app")
+	raw := testProfile(t, map[string]int64{"main.work": 300})
+
+	data := NewProfileData("pyroscope.write.default", lbls, raw)
+	require.Equal(t, livedebugging.Profile, data.Type)
+	require.Equal(t, map[string]string{"service_name": "app"}, data.AttributesFunc())
+	require.Equal(t, fmt.Sprintf("{service_name=\"app\"} size=%d sample_types=samples:count,cpu:nanoseconds samples=1\n  main.work 300", len(raw)), data.DataFunc())
+	require.JSONEq(t, fmt.Sprintf(`{
+		"labels": {"service_name": "app"},
+		"sample_types": ["samples:count", "cpu:nanoseconds"],
+		"size": %d,
+		"samples": 1,
+		"top_frames": [{"function": "main.work", "self": 300}]
+	}`, len(raw)), string(data.Payload()))
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/grafana/alloy/internal/component/pyroscope/write"
 	"github.com/grafana/alloy/internal/featuregate"
 	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/service/livedebugging"
 	"github.com/grafana/alloy/internal/util"
 	"github.com/grafana/pyroscope/api/gen/proto/go/debuginfo/v1alpha1/debuginfov1alpha1connect"
 	pushv1 "github.com/grafana/pyroscope/api/gen/proto/go/push/v1"
@@ -37,8 +38,7 @@ func init() {
 		Stability: featuregate.StabilityGenerallyAvailable,
 		Args:      Arguments{},
 		Build: func(opts component.Options, args component.Arguments) (component.Component, error) {
-			tracer := opts.Tracer.Tracer("pyroscope.receive_http")
-			return New(opts.Logger, tracer, opts.Registerer, args.(Arguments))
+			return New(opts, args.(Arguments))
 		},
 	})
 }
@@ -64,17 +64,31 @@ type Component struct {
 	mut                sync.Mutex
 	logger             log.Logger
 	tracer             trace.Tracer
+	componentID        string
+	debugDataPublisher livedebugging.DebugDataPublisher
 }
 
-func New(logger log.Logger, tracer trace.Tracer, reg prometheus.Registerer, args Arguments) (*Component, error) {
+var (
+	_ component.Component     = (*Component)(nil)
+	_ component.LiveDebugging = (*Component)(nil)
+)
+
+func New(opts component.Options, args Arguments) (*Component, error) {
+	debugDataPublisher, err := opts.GetServiceData(livedebugging.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+
 	uncheckedCollector := util.NewUncheckedCollector(nil)
-	reg.MustRegister(uncheckedCollector)
+	opts.Registerer.MustRegister(uncheckedCollector)
 
 	c := &Component{
-		logger:             logger,
-		tracer:             tracer,
+		logger:             opts.Logger,
+		tracer:             opts.Tracer.Tracer("pyroscope.receive_http"),
 		uncheckedCollector: uncheckedCollector,
 		appendables:        args.ForwardTo,
+		componentID:        opts.ID,
+		debugDataPublisher: debugDataPublisher.(livedebugging.DebugDataPublisher),
 	}
 
 	if err := c.Update(args); err != nil {
@@ -180,6 +194,15 @@ func (c *Component) Push(ctx context.Context, req *connect.Request[pushv1.PushRe
 	defer sp.End()
 	l := pyroutil.TraceLog(c.logger, sp)
 
+	for _, series := range req.Msg.Series {
+		var lb = labels.NewBuilder(labels.EmptyLabels())
+		setLabelBuilderFromAPI(lb, series.Labels)
+		lbls := ensureServiceName(lb.Labels())
+		for _, sample := range series.Samples {
+			c.debugDataPublisher.PublishIfActive(pyroscope.NewProfileData(c.componentID, lbls, sample.RawProfile))
+		}
+	}
+
 	var wg sync.WaitGroup
 	var errs error
 	var errorMut sync.Mutex
@@ -267,6 +290,8 @@ func (c *Component) handleIngest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	c.debugDataPublisher.PublishIfActive(pyroscope.NewProfileData(c.componentID, lbls, buf.Bytes()))
+
 	var wg sync.WaitGroup
 	var errs error
 	var errorMut sync.Mutex
@@ -305,6 +330,8 @@ func (c *Component) handleIngest(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+func (c *Component) LiveDebugging() {}
+
 func (c *Component) shutdownServer() {
 	if c.server != nil {
 		c.server.StopAndShutdown()
@@ -21,9 +21,12 @@ import (
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/alloy/internal/component"
 	fnet "github.com/grafana/alloy/internal/component/common/net"
 	"github.com/grafana/alloy/internal/component/pyroscope"
 	"github.com/grafana/alloy/internal/component/pyroscope/write/debuginfo"
+	"github.com/grafana/alloy/internal/service/livedebugging"
+	"github.com/grafana/alloy/internal/service/livedebugging/livedebuggingtest"
 	"github.com/grafana/alloy/internal/util"
 	pushv1 "github.com/grafana/pyroscope/api/gen/proto/go/push/v1"
 	"github.com/grafana/pyroscope/api/gen/proto/go/push/v1/pushv1connect"
@@ -413,6 +416,52 @@ func verifyForwardedProfiles(
 	}
 }
 
+func testOptions(t *testing.T) component.Options {
+	return component.Options{
+		ID:         "pyroscope.receive_http.test",
+		Logger:     util.TestAlloyLogger(t),
+		Tracer:     noop.NewTracerProvider(),
+		Registerer: prometheus.NewRegistry(),
+		GetServiceData: func(name string) (any, error) {
+			require.Equal(t, livedebugging.ServiceName, name)
+			return livedebugging.NewLiveDebugging(), nil
+		},
+	}
+}
+
+func TestLiveDebugging(t *testing.T) {
+	publisher := &livedebuggingtest.Publisher{}
+	opts := testOptions(t)
+	opts.GetServiceData = func(string) (any, error) { return publisher, nil }
+
+	port, err := freeport.GetFreePort()
+	require.NoError(t, err)
+	comp, err := New(opts, Arguments{
+		Server: &fnet.ServerConfig{
+			HTTP: &fnet.HTTPConfig{ListenAddress: "localhost", ListenPort: port},
+		},
+		ForwardTo: []pyroscope.Appendable{testAppendable(nil)},
+	})
+	require.NoError(t, err)
+	t.Cleanup(comp.shutdownServer)
+
+	_, err = comp.Push(t.Context(), connect.NewRequest(&pushv1.PushRequest{
+		Series: []*pushv1.RawProfileSeries{{
+			Labels:  []*typesv1.LabelPair{{Name: "__name__", Value: "app"}},
+			Samples: []*pushv1.RawSample{{RawProfile: []byte("profile")}},
+		}},
+	}))
+	require.NoError(t, err)
+
+	published := publisher.Data()
+	require.Len(t, published, 1)
+	data := published[0]
+	require.Equal(t, livedebugging.ComponentID("pyroscope.receive_http.test"), data.ComponentID)
+	require.Equal(t, livedebugging.Profile, data.Type)
+	require.Equal(t, `{__name__="app", service_name="app"} size=7`, data.DataFunc())
+	require.JSONEq(t, `{"labels":{"__name__":"app","service_name":"app"},"size":7}`, string(data.Payload()))
+}
+
 func startComponent(t *testing.T, appendables []pyroscope.Appendable) int {
 	port, err := freeport.GetFreePort()
 	require.NoError(t, err)
@@ -427,12 +476,7 @@ func startComponent(t *testing.T, appendables []pyroscope.Appendable) int {
 		ForwardTo: appendables,
 	}
 
-	comp, err := New(
-		util.TestAlloyLogger(t),
-		noop.Tracer{},
-		prometheus.NewRegistry(),
-		args,
-	)
+	comp, err := New(testOptions(t), args)
 	require.NoError(t, err)
 
 	ctx, cancel := context.WithCancel(t.Context())
@@ -572,12 +616,7 @@ func TestUpdateArgs(t *testing.T) {
 		ForwardTo: forwardTo,
 	}
 
-	comp, err := New(
-		util.TestAlloyLogger(t),
-		noop.Tracer{},
-		prometheus.NewRegistry(),
-		args,
-	)
+	comp, err := New(testOptions(t), args)
 	require.NoError(t, err)
 
 	ctx, cancel := context.WithCancel(t.Context())
@@ -26,6 +26,7 @@ import (
 	"github.com/grafana/alloy/internal/component/pyroscope"
 	"github.com/grafana/alloy/internal/featuregate"
 	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/service/livedebugging"
 
 	lru "github.com/hashicorp/golang-lru/v2"
 	"github.com/prometheus/common/model"
@@ -81,10 +82,13 @@ type Component struct {
 	cache        *lru.Cache[model.Fingerprint, []cacheItem]
 	maxCacheSize int
 	exited       atomic.Bool
+
+	debugDataPublisher livedebugging.DebugDataPublisher
 }
 
 var (
-	_ component.Component = (*Component)(nil)
+	_ component.Component     = (*Component)(nil)
+	_ component.LiveDebugging = (*Component)(nil)
 )
 
 // New creates a new pyroscope.relabel component.
@@ -94,11 +98,17 @@ func New(o component.Options, args Arguments) (*Component, error) {
 		return nil, err
 	}
 
+	debugDataPublisher, err := o.GetServiceData(livedebugging.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+
 	c := &Component{
-		opts:         o,
-		metrics:      newMetrics(o.Registerer),
-		cache:        cache,
-		maxCacheSize: args.MaxCacheSize,
+		opts:               o,
+		metrics:            newMetrics(o.Registerer),
+		cache:              cache,
+		maxCacheSize:       args.MaxCacheSize,
+		debugDataPublisher: debugDataPublisher.(livedebugging.DebugDataPublisher),
 	}
 
 	c.fanout = pyroscope.NewFanout(args.ForwardTo, o.ID, o.Registerer)
@@ -170,6 +180,7 @@ func (c *Component) Append(ctx context.Context, lbls labels.Labels, samples []*p
 	}
 
 	newLabels, keep := c.relabel(lbls)
+	c.publishDebugData(lbls, newLabels, keep, len(samples))
 	if !keep {
 		c.metrics.profilesDropped.Inc()
 		level.Debug(c.opts.Logger).Log("msg", "profile dropped by relabel rules", "labels", lbls.String())
@@ -196,6 +207,7 @@ func (c *Component) AppendIngest(ctx context.Context, profile *pyroscope.Incomin
 	}
 
 	newLabels, keep := c.relabel(profile.Labels)
+	c.publishDebugData(profile.Labels, newLabels, keep, 1)
 	if !keep {
 		c.metrics.profilesDropped.Inc()
 		level.Debug(c.opts.Logger).Log("msg", "profile dropped by relabel rules")
@@ -211,6 +223,30 @@ func (c *Component) Appender() pyroscope.Appender {
 	return c
 }
 
+func (c *Component) publishDebugData(lbls, newLabels labels.Labels, keep bool, count int) {
+	c.debugDataPublisher.PublishIfActive(livedebugging.NewData(
+		livedebugging.ComponentID(c.opts.ID),
+		livedebugging.Profile,
+		uint64(count),
+		func() string {
+			if !keep {
+				return fmt.Sprintf("%s => dropped", lbls.String())
+			}
+			return fmt.Sprintf("%s => %s", lbls.String(), newLabels.String())
+		},
+		livedebugging.WithAttributes(lbls.Map),
+		livedebugging.WithPayload(func() any {
+			diff := livedebugging.LabelsDiff{Before: lbls.Map(), Dropped: !keep}
+			if keep {
+				diff.After = newLabels.Map()
+			}
+			return diff
+		}),
+	))
+}
+
+func (c *Component) LiveDebugging() {}
+
 type cacheItem struct {
 	original  model.LabelSet
 	relabeled model.LabelSet
@@ -3,6 +3,7 @@ package relabel
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 	"sync"
 	"testing"
@@ -11,6 +12,8 @@ import (
 	alloy_relabel "github.com/grafana/alloy/internal/component/common/relabel"
 	"github.com/grafana/alloy/internal/component/pyroscope"
 	"github.com/grafana/alloy/internal/component/pyroscope/write/debuginfo"
+	"github.com/grafana/alloy/internal/service/livedebugging"
+	"github.com/grafana/alloy/internal/service/livedebugging/livedebuggingtest"
 	"github.com/grafana/alloy/internal/util"
 	"github.com/grafana/pyroscope/api/gen/proto/go/debuginfo/v1alpha1/debuginfov1alpha1connect"
 	"github.com/grafana/pyroscope/api/model/labelset"
@@ -174,9 +177,10 @@ func TestRelabeling(t *testing.T) {
 			app := NewTestAppender()
 
 			c, err := New(component.Options{
-				Logger:        util.TestLogger(t),
-				Registerer:    prometheus.NewRegistry(),
-				OnStateChange: func(e component.Exports) {},
+				Logger:         util.TestLogger(t),
+				Registerer:     prometheus.NewRegistry(),
+				OnStateChange:  func(e component.Exports) {},
+				GetServiceData: getServiceData,
 			}, Arguments{
 				ForwardTo:      []pyroscope.Appendable{app},
 				RelabelConfigs: tt.rules,
@@ -211,9 +215,10 @@ func TestRelabeling(t *testing.T) {
 func TestCache(t *testing.T) {
 	app := NewTestAppender()
 	c, err := New(component.Options{
-		Logger:        util.TestLogger(t),
-		Registerer:    prometheus.NewRegistry(),
-		OnStateChange: func(e component.Exports) {},
+		Logger:         util.TestLogger(t),
+		Registerer:     prometheus.NewRegistry(),
+		OnStateChange:  func(e component.Exports) {},
+		GetServiceData: getServiceData,
 	}, Arguments{
 		ForwardTo: []pyroscope.Appendable{app},
 		RelabelConfigs: []*alloy_relabel.Config{{
@@ -242,9 +247,10 @@ func TestCache(t *testing.T) {
 func TestCacheCollisions(t *testing.T) {
 	app := NewTestAppender()
 	c, err := New(component.Options{
-		Logger:        util.TestLogger(t),
-		Registerer:    prometheus.NewRegistry(),
-		OnStateChange: func(e component.Exports) {},
+		Logger:         util.TestLogger(t),
+		Registerer:     prometheus.NewRegistry(),
+		OnStateChange:  func(e component.Exports) {},
+		GetServiceData: getServiceData,
 	}, Arguments{
 		ForwardTo:      []pyroscope.Appendable{app},
 		RelabelConfigs: []*alloy_relabel.Config{},
@@ -280,9 +286,10 @@ func TestCacheCollisions(t *testing.T) {
 func TestCacheLRU(t *testing.T) {
 	app := NewTestAppender()
 	c, err := New(component.Options{
-		Logger:        util.TestLogger(t),
-		Registerer:    prometheus.NewRegistry(),
-		OnStateChange: func(e component.Exports) {},
+		Logger:         util.TestLogger(t),
+		Registerer:     prometheus.NewRegistry(),
+		OnStateChange:  func(e component.Exports) {},
+		GetServiceData: getServiceData,
 	}, Arguments{
 		ForwardTo:      []pyroscope.Appendable{app},
 		RelabelConfigs: []*alloy_relabel.Config{},
@@ -313,9 +320,10 @@ func TestCacheLRU(t *testing.T) {
 func TestCachePurge(t *testing.T) {
 	app := NewTestAppender()
 	c, err := New(component.Options{
-		Logger:        util.TestLogger(t),
-		Registerer:    prometheus.NewRegistry(),
-		OnStateChange: func(e component.Exports) {},
+		Logger:         util.TestLogger(t),
+		Registerer:     prometheus.NewRegistry(),
+		OnStateChange:  func(e component.Exports) {},
+		GetServiceData: getServiceData,
 	}, Arguments{
 		ForwardTo: []pyroscope.Appendable{app},
 		RelabelConfigs: []*alloy_relabel.Config{{
@@ -361,9 +369,10 @@ func TestMetricsWithRelabeling(t *testing.T) {
 
 	// Create component with relabel rules that will trigger different metrics
 	c, err := New(component.Options{
-		Logger:        util.TestLogger(t),
-		Registerer:    reg,
-		OnStateChange: func(e component.Exports) {},
+		Logger:         util.TestLogger(t),
+		Registerer:     reg,
+		OnStateChange:  func(e component.Exports) {},
+		GetServiceData: getServiceData,
 	}, Arguments{
 		ForwardTo: []pyroscope.Appendable{app},
 		RelabelConfigs: []*alloy_relabel.Config{{
@@ -448,6 +457,59 @@ type TestAppender struct {
 	profiles []*pyroscope.IncomingProfile
 }
 
+func TestLiveDebugging(t *testing.T) {
+	publisher := &livedebuggingtest.Publisher{}
+	c, err := New(component.Options{
+		ID:            "pyroscope.relabel.default",
+		Logger:        util.TestLogger(t),
+		Registerer:    prometheus.NewRegistry(),
+		OnStateChange: func(e component.Exports) {},
+		GetServiceData: func(name string) (any, error) {
+			require.Equal(t, livedebugging.ServiceName, name)
+			return publisher, nil
+		},
+	}, Arguments{
+		ForwardTo: []pyroscope.Appendable{NewTestAppender()},
+		RelabelConfigs: []*alloy_relabel.Config{{
+			SourceLabels: []string{"env"},
+			Action:       "drop",
+			Regex:        alloy_relabel.Regexp{Regexp: regexp.MustCompile("dev")},
+		}, {
+			Action:      "replace",
+			TargetLabel: "team",
+			Replacement: "profiling",
+			Regex:       alloy_relabel.Regexp{Regexp: regexp.MustCompile("(.*)")},
+		}},
+		MaxCacheSize: 10,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, c.Append(t.Context(), labels.FromStrings("env", "prod"), []*pyroscope.RawSample{{}, {}}))
+	require.NoError(t, c.AppendIngest(t.Context(), &pyroscope.IncomingProfile{Labels: labels.FromStrings("env", "dev")}))
+
+	data := publisher.Data()
+	require.Len(t, data, 2)
+
+	kept := data[0]
+	require.Equal(t, livedebugging.Profile, kept.Type)
+	require.Equal(t, uint64(2), kept.Count)
+	require.Equal(t, `{env="prod"} => {env="prod", team="profiling"}`, kept.DataFunc())
+	require.JSONEq(t, `{"before":{"env":"prod"},"after":{"env":"prod","team":"profiling"}}`, string(kept.Payload()))
+
+	dropped := data[1]
+	require.Equal(t, `{env="dev"} => dropped`, dropped.DataFunc())
+	require.JSONEq(t, `{"before":{"env":"dev"},"dropped":true}`, string(dropped.Payload()))
+}
+
+func getServiceData(name string) (any, error) {
+	switch name {
+	case livedebugging.ServiceName:
+		return livedebugging.NewLiveDebugging(), nil
+	default:
+		return nil, fmt.Errorf("service not found %s", name)
+	}
+}
+
 func NewTestAppender() *TestAppender {
 	return &TestAppender{
 		profiles: make([]*pyroscope.IncomingProfile, 0),
@@ -1,12 +1,17 @@
 package glue
 
 import (
+	"context"
+
 	"github.com/grafana/alloy/internal/alloyseed"
 	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/component/pyroscope"
 	"github.com/grafana/alloy/internal/component/pyroscope/util/glue"
 	"github.com/grafana/alloy/internal/component/pyroscope/write"
 	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/service/livedebugging"
 	"github.com/grafana/alloy/internal/useragent"
+	"github.com/prometheus/prometheus/model/labels"
 )
 
 func init() {
@@ -21,11 +26,21 @@ func init() {
 			userAgent := useragent.Get()
 			uid := alloyseed.Get().UID
 
+			debugDataPublisher, err := o.GetServiceData(livedebugging.ServiceName)
+			if err != nil {
+				return nil, err
+			}
+
 			gc, err := write.New(
 				o.Logger,
 				tracer,
 				o.Registerer,
 				func(exports write.Exports) {
+					exports.Receiver = &debugAppendable{
+						Appendable:         exports.Receiver,
+						componentID:        o.ID,
+						debugDataPublisher: debugDataPublisher.(livedebugging.DebugDataPublisher),
+					}
 					o.OnStateChange(exports)
 				},
 				userAgent,
@@ -36,7 +51,45 @@ func init() {
 			if err != nil {
 				return nil, err
 			}
-			return &glue.GenericComponentGlue[write.Arguments]{Impl: gc}, nil
+			return &liveDebuggingGlue{GenericComponentGlue: glue.GenericComponentGlue[write.Arguments]{Impl: gc}}, nil
 		},
 	})
 }
+
+// liveDebuggingGlue marks pyroscope.write as supporting live debugging.
+type liveDebuggingGlue struct {
+	glue.GenericComponentGlue[write.Arguments]
+}
+
+var _ component.LiveDebugging = (*liveDebuggingGlue)(nil)
+
+func (c *liveDebuggingGlue) LiveDebugging() {}
+
+// debugAppendable publishes the profiles appended to the receiver of
+// pyroscope.write as live debugging data.
+type debugAppendable struct {
+	pyroscope.Appendable
+	componentID        string
+	debugDataPublisher livedebugging.DebugDataPublisher
+}
+
+func (a *debugAppendable) Appender() pyroscope.Appender {
+	return &debugAppender{Appender: a.Appendable.Appender(), parent: a}
+}
+
+type debugAppender struct {
+	pyroscope.Appender
+	parent *debugAppendable
+}
+
+func (a *debugAppender) Append(ctx context.Context, lbls labels.Labels, samples []*pyroscope.RawSample) error {
+	for _, sample := range samples {
+		a.parent.debugDataPublisher.PublishIfActive(pyroscope.NewProfileData(a.parent.componentID, lbls, sample.RawProfile))
+	}
+	return a.Appender.Append(ctx, lbls, samples)
+}
+
+func (a *debugAppender) AppendIngest(ctx context.Context, profile *pyroscope.IncomingProfile) error {
+	a.parent.debugDataPublisher.PublishIfActive(pyroscope.NewProfileData(a.parent.componentID, profile.Labels, profile.RawBody))
+	return a.Appender.AppendIngest(ctx, profile)
+}
@@ -0,0 +1,45 @@
+// Package schema generates JSON Schema documents describing the arguments
+// and exports of components.
+package schema
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/syntax/encoding/jsonschema"
+)
+
+// Component returns the JSON Schema of a component. The schema describes an
+// object with an arguments property, holding the schema of the body of the
+// component, and an exports property, holding the schema of its exports.
+func Component(reg component.Registration) *jsonschema.Schema {
+	s := &jsonschema.Schema{
+		Schema:      jsonschema.Draft,
+		Title:       reg.Name,
+		Description: "Arguments and exports of the " + reg.Name + " component.",
+		Type:        "object",
+		Properties:  map[string]*jsonschema.Schema{},
+		Community:   reg.Community,
+	}
+	if !reg.Community {
+		s.Stability = strings.Trim(reg.Stability.String(), `"`)
+	}
+
+	if isStruct(reg.Args) {
+		s.Properties["arguments"] = jsonschema.ForBody(reg.Args)
+		s.Required = append(s.Required, "arguments")
+	}
+	if isStruct(reg.Exports) {
+		s.Properties["exports"] = jsonschema.ForBody(reg.Exports)
+	}
+	return s
+}
+
+func isStruct(v any) bool {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t != nil && t.Kind() == reflect.Struct
+}
@@ -0,0 +1,100 @@
+package schema_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/component"
+	_ "github.com/grafana/alloy/internal/component/all" // import all components to export their schemas
+	"github.com/grafana/alloy/internal/component/schema"
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/syntax/encoding/jsonschema"
+)
+
+type testArgs struct {
+	URL string `alloy:"url,attr"`
+}
+
+type testExports struct {
+	Receiver any `alloy:"receiver,attr"`
+}
+
+func TestComponent(t *testing.T) {
+	reg := component.Registration{
+		Name:      "test.component",
+		Stability: featuregate.StabilityPublicPreview,
+		Args:      testArgs{},
+		Exports:   testExports{},
+	}
+
+	expect := `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title": "test.component",
+		"description": "Arguments and exports of the test.component component.",
+		"type": "object",
+		"properties": {
+			"arguments": {
+				"type": "object",
+				"properties": {
+					"url": { "type": "string", "x-alloy-kind": "attr" }
+				},
+				"required": ["url"],
+				"additionalProperties": false
+			},
+			"exports": {
+				"type": "object",
+				"properties": {
+					"receiver": { "x-alloy-kind": "attr" }
+				},
+				"required": ["receiver"],
+				"additionalProperties": false
+			}
+		},
+		"required": ["arguments"],
+		"x-alloy-stability": "public-preview"
+	}`
+
+	actual, err := json.Marshal(schema.Component(reg))
+	require.NoError(t, err)
+	require.JSONEq(t, expect, string(actual))
+}
+
+func TestComponent_Community(t *testing.T) {
+	reg := component.Registration{
+		Name:      "test.community",
+		Community: true,
+		Args:      testArgs{},
+	}
+
+	s := schema.Component(reg)
+	require.True(t, s.Community)
+	require.Empty(t, s.Stability)
+	require.NotContains(t, s.Properties, "exports")
+}
+
+// TestComponent_All exports the schema of every registered component, as
+// `alloy tools schema` does.
+func TestComponent_All(t *testing.T) {
+	registry := component.NewDefaultRegistry(featuregate.StabilityExperimental, true)
+
+	names := component.AllNames()
+	require.NotEmpty(t, names)
+
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			reg, err := registry.Get(name)
+			require.NoError(t, err)
+
+			var s *jsonschema.Schema
+			require.NotPanics(t, func() { s = schema.Component(reg) })
+			if reg.Args != nil {
+				require.Contains(t, s.Properties, "arguments", "arguments of %s aren't a struct", name)
+			}
+
+			_, err = json.Marshal(s)
+			require.NoError(t, err)
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 
 	"github.com/grafana/alloy/internal/converter/diag"
+	"github.com/grafana/alloy/internal/converter/internal/alloyjsonconvert"
 	"github.com/grafana/alloy/internal/converter/internal/otelcolconvert"
 	"github.com/grafana/alloy/internal/converter/internal/prometheusconvert"
 	"github.com/grafana/alloy/internal/converter/internal/promtailconvert"
@@ -24,6 +25,9 @@ const (
 	InputPromtail Input = "promtail"
 	// InputStatic indicates that the input file is a grafana agent static YAML file.
 	InputStatic Input = "static"
+	// InputJSON indicates that the input file is the JSON representation of an
+	// Alloy configuration file, as written by alloy fmt --output=json.
+	InputJSON Input = "json"
 )
 
 var SupportedFormats = []string{
@@ -31,6 +35,7 @@ var SupportedFormats = []string{
 	string(InputPrometheus),
 	string(InputPromtail),
 	string(InputStatic),
+	string(InputJSON),
 }
 
 // Convert generates a Grafana Alloy config given an input configuration file.
@@ -58,6 +63,8 @@ func Convert(in []byte, kind Input, extraArgs []string) ([]byte, diag.Diagnostic
 		return promtailconvert.Convert(in, extraArgs)
 	case InputStatic:
 		return staticconvert.Convert(in, extraArgs)
+	case InputJSON:
+		return alloyjsonconvert.Convert(in, extraArgs)
 	}
 
 	var diags diag.Diagnostics
@@ -0,0 +1,99 @@
+// Package alloyjsonconvert converts the JSON representation of Alloy
+// configuration files back to the Alloy syntax.
+package alloyjsonconvert
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+
+	"github.com/grafana/alloy/internal/converter/diag"
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/encoding/alloyjson"
+	"github.com/grafana/alloy/syntax/printer"
+	"github.com/grafana/alloy/syntax/token"
+)
+
+// Convert implements a JSON to Alloy converter. The input is the JSON
+// representation of an Alloy configuration file produced by
+// [alloyjson.MarshalFile].
+func Convert(in []byte, extraArgs []string) ([]byte, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	if len(extraArgs) > 0 {
+		diags.Add(diag.SeverityLevelCritical, fmt.Sprintf("extra arguments are not supported for the json converter: %s", extraArgs))
+		return nil, diags
+	}
+
+	f, err := alloyjson.UnmarshalFile("", in)
+	if err != nil {
+		diags.Add(diag.SeverityLevelCritical, fmt.Sprintf("failed to parse JSON config: %s", err))
+		return nil, diags
+	}
+
+	compactLines(f)
+
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, f); err != nil {
+		diags.Add(diag.SeverityLevelCritical, fmt.Sprintf("failed to render Alloy config: %s", err))
+		return nil, diags
+	}
+	// Add a newline at the end of the file.
+	_ = buf.WriteByte('\n')
+	return buf.Bytes(), diags
+}
+
+var posType = reflect.TypeFor[token.Pos]()
+
+// compactLines moves the positions of f, which point to the JSON document in,
+// to a file with a line for each statement. The layout of the JSON document
+// isn't meaningful for the Alloy syntax, so that the printer writes each
+// statement on a single line, without blank lines between them.
+func compactLines(f *ast.File) {
+	var (
+		starts    []int
+		addStarts func(body ast.Body)
+	)
+	addStarts = func(body ast.Body) {
+		for _, stmt := range body {
+			starts = append(starts, ast.StartPos(stmt).Position().Offset)
+			if block, ok := stmt.(*ast.BlockStmt); ok {
+				addStarts(block.Body)
+			}
+		}
+	}
+	addStarts(f.Body)
+
+	file := token.NewFile(f.Name)
+	for _, start := range starts {
+		file.AddLine(start)
+	}
+	movePositions(reflect.ValueOf(f), func(p token.Pos) token.Pos { return file.Pos(p.Offset()) })
+}
+
+// movePositions replaces all the valid positions of the AST node v with the
+// result of move.
+func movePositions(v reflect.Value, move func(token.Pos) token.Pos) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			movePositions(v.Elem(), move)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			movePositions(v.Index(i), move)
+		}
+	case reflect.Struct:
+		if v.Type() == posType {
+			if p := v.Interface().(token.Pos); p.Valid() {
+				v.Set(reflect.ValueOf(move(p)))
+			}
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				movePositions(v.Field(i), move)
+			}
+		}
+	}
+}
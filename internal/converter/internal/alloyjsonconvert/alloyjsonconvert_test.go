@@ -0,0 +1,86 @@
+package alloyjsonconvert_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/converter/diag"
+	"github.com/grafana/alloy/internal/converter/internal/alloyjsonconvert"
+	"github.com/grafana/alloy/syntax/encoding/alloyjson"
+	"github.com/grafana/alloy/syntax/parser"
+)
+
+func TestConvert(t *testing.T) {
+	in := `[
+		{
+			"name": "prometheus.scrape",
+			"type": "block",
+			"label": "default",
+			"body": [
+				{ "name": "scrape_interval", "type": "attr", "value": { "type": "string", "value": "10s" } },
+				{
+					"name": "forward_to",
+					"type": "attr",
+					"value": {
+						"type": "array",
+						"value": [{ "type": "expr", "value": "prometheus.remote_write.default.receiver" }]
+					}
+				}
+			]
+		}
+	]`
+
+	out, diags := alloyjsonconvert.Convert([]byte(in), nil)
+	require.Empty(t, diags)
+	require.Equal(t, `prometheus.scrape "default" {
+	scrape_interval = "10s"
+	forward_to      = [prometheus.remote_write.default.receiver]
+}
+`, string(out))
+}
+
+func TestConvert_RoundTrip(t *testing.T) {
+	src := `logging {
+	level = "debug"
+}
+
+discovery.relabel "default" {
+	targets = [{__address__ = "a:80", app = "web"}, {__address__ = "b:80", app = "db"}]
+
+	rule {
+		action        = "keep"
+		source_labels = ["app"]
+		regex         = "web"
+	}
+
+	rule {
+		target_label = "env"
+		replacement  = string.to_lower("PROD")
+	}
+}
+`
+	f, err := parser.ParseFile("", []byte(src))
+	require.NoError(t, err)
+	bb, err := alloyjson.MarshalFile(f)
+	require.NoError(t, err)
+	var indented bytes.Buffer
+	require.NoError(t, json.Indent(&indented, bb, "", "  "))
+
+	out, diags := alloyjsonconvert.Convert(indented.Bytes(), nil)
+	require.Empty(t, diags)
+	require.Equal(t, src, string(out))
+}
+
+func TestConvert_Errors(t *testing.T) {
+	_, diags := alloyjsonconvert.Convert([]byte(`{`), nil)
+	require.Len(t, diags, 1)
+	require.Equal(t, diag.SeverityLevelCritical, diags[0].Severity)
+	require.Contains(t, diags[0].Summary, "failed to parse JSON config")
+
+	_, diags = alloyjsonconvert.Convert([]byte(`[]`), []string{"-enable-feature"})
+	require.Len(t, diags, 1)
+	require.Contains(t, diags[0].Summary, "extra arguments are not supported")
+}
@@ -227,7 +227,7 @@ func attemptLoadingAlloyConfig(t *testing.T, bb []byte) {
 			clusterService,
 			labelstore.New(nil, prometheus.DefaultRegisterer),
 			remotecfgService,
-			livedebugging.New(),
+			livedebugging.New(livedebugging.Options{}),
 		},
 		EnableCommunityComps: true,
 	})
@@ -0,0 +1,161 @@
+package lsp
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/syntax/token"
+)
+
+// completion returns the completion proposals at offset:
+//
+//   - The names of components at the start of a top-level statement.
+//   - The names of arguments and blocks at the start of a statement inside a
+//     component.
+//   - References to components and their exports inside expressions.
+func (s *Server) completion(d *document, offset int) CompletionList {
+	// Find the traversal being typed before the cursor. It's empty if the
+	// cursor doesn't follow an identifier or a dot.
+	first := slices.IndexFunc(d.tokens, func(t tokenInfo) bool { return t.start >= offset })
+	if first < 0 {
+		first = len(d.tokens)
+	}
+	if i, ok := d.tokenAt(offset); ok && (d.tokens[i].tok == token.IDENT || d.tokens[i].tok == token.DOT) {
+		first, _ = d.traversalAround(i)
+		if d.tokens[first].tok != token.IDENT {
+			first = i
+		}
+	}
+
+	start := offset
+	if first < len(d.tokens) && d.tokens[first].start < offset {
+		start = d.tokens[first].start
+	}
+	prefix := d.text[start:offset]
+
+	var (
+		state     = d.stateBefore(first)
+		stmtStart = state.depth == 0 && state.stmtStart == first
+
+		items []CompletionItem
+	)
+	switch {
+	case stmtStart && state.block == nil:
+		items = s.componentItems()
+	case stmtStart:
+		items = s.fieldItems(state.block)
+	case state.object && first > 0 && isKeyStart(d.tokens[first-1].tok):
+		// Keys of objects can't be references.
+	default:
+		items = s.referenceItems(d, state.block, prefix)
+	}
+
+	res := CompletionList{Items: []CompletionItem{}}
+	for _, item := range items {
+		if !strings.HasPrefix(item.Label, prefix) {
+			continue
+		}
+		item.FilterText = item.Label
+		item.TextEdit = &TextEdit{Range: d.Range(start, offset), NewText: item.Label}
+		res.Items = append(res.Items, item)
+	}
+	return res
+}
+
+func (s *Server) componentItems() []CompletionItem {
+	var items []CompletionItem
+	for _, name := range s.opts.ComponentNames {
+		reg, err := s.opts.ComponentRegistry.Get(name)
+		if err != nil {
+			continue
+		}
+		items = append(items, CompletionItem{
+			Label:  name,
+			Kind:   KindModule,
+			Detail: stabilityName(reg),
+		})
+	}
+	return items
+}
+
+// fieldItems returns the arguments and blocks of the block being written.
+func (s *Server) fieldItems(block *blockInfo) []CompletionItem {
+	reg, err := s.opts.ComponentRegistry.Get(block.Root().name)
+	if err != nil {
+		return nil
+	}
+	t, ok := nestedType(reflect.TypeOf(reg.Args), block.Path())
+	if !ok {
+		return nil
+	}
+
+	var items []CompletionItem
+	for _, f := range structFields(t) {
+		kind := KindField
+		if f.Block {
+			kind = KindClass
+		}
+		items = append(items, CompletionItem{
+			Label:  f.Name,
+			Kind:   kind,
+			Detail: f.Describe(),
+		})
+	}
+	return items
+}
+
+func isKeyStart(prev token.Token) bool {
+	return prev == token.LCURLY || prev == token.COMMA || prev == token.TERMINATOR
+}
+
+// referenceItems returns the components in scope and the exports of the
+// component referenced by prefix. The component being written is excluded,
+// as it can't reference itself.
+func (s *Server) referenceItems(d *document, block *blockInfo, prefix string) []CompletionItem {
+	var items []CompletionItem
+	for _, b := range d.scopeBlocks(block) {
+		if b.label == "" || isAncestor(b, block) {
+			continue
+		}
+		reg, err := s.opts.ComponentRegistry.Get(b.name)
+		if err != nil {
+			continue
+		}
+
+		if !strings.HasPrefix(prefix, b.ID()+".") {
+			items = append(items, CompletionItem{
+				Label:  b.ID(),
+				Kind:   KindModule,
+				Detail: b.name,
+			})
+			continue
+		}
+		for _, f := range structFields(reflect.TypeOf(reg.Exports)) {
+			items = append(items, CompletionItem{
+				Label:  b.ID() + "." + f.Name,
+				Kind:   KindProperty,
+				Detail: f.TypeName(),
+			})
+		}
+	}
+	return items
+}
+
+// isAncestor reports whether b is block or one of its parents.
+func isAncestor(b, block *blockInfo) bool {
+	for ; block != nil; block = block.parent {
+		if block == b {
+			return true
+		}
+	}
+	return false
+}
+
+func stabilityName(reg component.Registration) string {
+	if reg.Community {
+		return "community"
+	}
+	return strings.Trim(reg.Stability.String(), `"`)
+}
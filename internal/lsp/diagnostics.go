@@ -0,0 +1,100 @@
+package lsp
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/grafana/alloy/internal/validator"
+	"github.com/grafana/alloy/syntax/diag"
+	"github.com/grafana/alloy/syntax/parser"
+	"github.com/grafana/alloy/syntax/printer"
+)
+
+// diagnostics returns the syntax errors of a document or, if the document
+// is syntactically valid, the errors reported by the validator.
+func (s *Server) diagnostics(d *document) []Diagnostic {
+	name := filename(d.uri)
+	sources := map[string][]byte{name: []byte(d.text)}
+
+	err := validator.Validate(validator.Options{
+		Sources:            sources,
+		ServiceDefinitions: s.opts.ServiceDefinitions,
+		ComponentRegistry:  s.opts.ComponentRegistry,
+		MinStability:       s.opts.MinStability,
+	})
+	if err == nil {
+		return []Diagnostic{}
+	}
+
+	var diags diag.Diagnostics
+	if !errors.As(err, &diags) {
+		return []Diagnostic{{
+			Range:    d.Range(0, 0),
+			Severity: SeverityError,
+			Source:   "alloy",
+			Message:  err.Error(),
+		}}
+	}
+
+	res := make([]Diagnostic, 0, len(diags))
+	for _, dg := range diags {
+		if dg.StartPos.Filename != "" && dg.StartPos.Filename != name {
+			continue
+		}
+		res = append(res, Diagnostic{
+			Range:    d.diagRange(dg),
+			Severity: severity(dg.Severity),
+			Source:   "alloy",
+			Message:  dg.Message,
+		})
+	}
+	return res
+}
+
+// diagRange returns the range of a diagnostic. Diagnostics without an end
+// position cover the token at their start position.
+func (d *document) diagRange(dg diag.Diagnostic) Range {
+	if !dg.StartPos.Valid() {
+		return d.Range(0, 0)
+	}
+
+	start, end := dg.StartPos.Offset, dg.EndPos.Offset+1
+	if !dg.EndPos.Valid() {
+		end = start
+		if i, ok := d.tokenAt(start); ok {
+			end = d.tokens[i].end
+		}
+	}
+	return d.Range(start, max(start, end))
+}
+
+func severity(s diag.Severity) DiagnosticSeverity {
+	if s == diag.SeverityLevelWarn {
+		return SeverityWarning
+	}
+	return SeverityError
+}
+
+// format returns the edits which format a document. No edits are returned
+// if the document has syntax errors.
+func (s *Server) format(d *document) ([]TextEdit, error) {
+	f, err := parser.ParseFile(filename(d.uri), []byte(d.text))
+	if err != nil {
+		return []TextEdit{}, nil
+	}
+
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, f); err != nil {
+		return nil, err
+	}
+	// Add a newline at the end of the file, like alloy fmt.
+	_ = buf.WriteByte('\n')
+
+	if buf.String() == d.text {
+		return []TextEdit{}, nil
+	}
+	return []TextEdit{{
+		Range:   d.Range(0, len(d.text)),
+		NewText: buf.String(),
+	}}, nil
+}
@@ -0,0 +1,327 @@
+package lsp
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/grafana/alloy/syntax/scanner"
+	"github.com/grafana/alloy/syntax/token"
+)
+
+// document is an open text document.
+//
+// Documents being edited are rarely valid, so instead of relying on the
+// parser, the structure of the document is recovered from its tokens: blocks
+// are found by looking for a block header followed by a curly brace. This is
+// enough to provide completion and navigation in incomplete documents.
+type document struct {
+	uri  string
+	text string
+
+	tokens []tokenInfo
+	states []scanState // states[i] is the state after tokens[i].
+	blocks []*blockInfo
+}
+
+type tokenInfo struct {
+	tok        token.Token
+	lit        string
+	start, end int // Byte offsets; end is exclusive.
+}
+
+// scanState is the structural state of the document between two tokens.
+type scanState struct {
+	block     *blockInfo // Innermost block, nil at the top level.
+	depth     int        // Number of open brackets, parentheses and objects within block.
+	object    bool       // Whether the innermost open bracket is an object.
+	stmtStart int        // Index of the first token of the current statement.
+}
+
+// blockInfo is a block found in a document.
+type blockInfo struct {
+	name  string // Full name of the block, such as "prometheus.scrape".
+	label string
+
+	nameStart, nameEnd int // Byte offsets of the name.
+	end                int // Byte offset after the closing curly brace.
+
+	parent   *blockInfo
+	children []*blockInfo
+}
+
+// ID returns the name of the block followed by its label, if any.
+func (b *blockInfo) ID() string {
+	if b.label == "" {
+		return b.name
+	}
+	return b.name + "." + b.label
+}
+
+// Root returns the top-level block containing b.
+func (b *blockInfo) Root() *blockInfo {
+	for b.parent != nil {
+		b = b.parent
+	}
+	return b
+}
+
+// Path returns the names of the nested blocks from the top-level block
+// containing b, excluding the top-level block.
+func (b *blockInfo) Path() []string {
+	var path []string
+	for ; b.parent != nil; b = b.parent {
+		path = append([]string{b.name}, path...)
+	}
+	return path
+}
+
+func newDocument(uri, text string) *document {
+	d := &document{uri: uri, text: text}
+
+	s := scanner.New(token.NewFile(uri), []byte(text), nil, 0)
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		// The scanner doesn't return the literal of operators.
+		size := len(lit)
+		if tok.IsOperator() {
+			size = len(tok.String())
+		}
+		d.tokens = append(d.tokens, tokenInfo{
+			tok:   tok,
+			lit:   lit,
+			start: pos.Offset(),
+			end:   pos.Offset() + size,
+		})
+	}
+
+	d.scan()
+	return d
+}
+
+// scan computes the state after each token and builds the block tree.
+func (d *document) scan() {
+	type frame struct {
+		block  *blockInfo // nil for brackets, parentheses and objects.
+		object bool
+	}
+
+	var (
+		frames []frame
+		state  scanState
+	)
+
+	d.states = make([]scanState, len(d.tokens))
+	for i, t := range d.tokens {
+		switch t.tok {
+		case token.TERMINATOR:
+			if state.depth == 0 {
+				state.stmtStart = i + 1
+			}
+
+		case token.LCURLY:
+			if name, label, ok := d.blockHeader(state.stmtStart, i); state.depth == 0 && ok {
+				b := &blockInfo{
+					name:      name,
+					label:     label,
+					nameStart: d.tokens[state.stmtStart].start,
+					nameEnd:   d.tokens[state.stmtStart].start + len(name),
+					end:       len(d.text),
+					parent:    state.block,
+				}
+				if b.parent != nil {
+					b.parent.children = append(b.parent.children, b)
+				} else {
+					d.blocks = append(d.blocks, b)
+				}
+
+				frames = append(frames, frame{block: b})
+				state = scanState{block: b, stmtStart: i + 1}
+			} else {
+				frames = append(frames, frame{object: true})
+				state.depth++
+				state.object = true
+			}
+
+		case token.LBRACK, token.LPAREN:
+			frames = append(frames, frame{})
+			state.depth++
+			state.object = false
+
+		case token.RCURLY, token.RBRACK, token.RPAREN:
+			if len(frames) == 0 {
+				break
+			}
+			f := frames[len(frames)-1]
+			frames = frames[:len(frames)-1]
+
+			if f.block != nil {
+				f.block.end = t.end
+				state = scanState{block: f.block.parent, stmtStart: i + 1}
+			} else {
+				state.depth--
+				state.object = state.depth > 0 && frames[len(frames)-1].object
+			}
+		}
+
+		d.states[i] = state
+	}
+}
+
+// blockHeader reports whether the tokens in [from, to) are a block header, of
+// the form IDENT { "." IDENT } [ STRING ].
+func (d *document) blockHeader(from, to int) (name, label string, ok bool) {
+	toks := d.tokens[from:to]
+	if len(toks) > 0 && toks[len(toks)-1].tok == token.STRING {
+		unquoted, err := strconv.Unquote(toks[len(toks)-1].lit)
+		if err != nil {
+			return "", "", false
+		}
+		label = unquoted
+		toks = toks[:len(toks)-1]
+	}
+
+	var fragments []string
+	for i, t := range toks {
+		switch {
+		case i%2 == 0 && t.tok == token.IDENT:
+			fragments = append(fragments, t.lit)
+		case i%2 == 1 && t.tok == token.DOT:
+		default:
+			return "", "", false
+		}
+	}
+	if len(toks) == 0 || len(toks)%2 == 0 {
+		return "", "", false
+	}
+	return strings.Join(fragments, "."), label, true
+}
+
+// stateBefore returns the state before the token at index i.
+func (d *document) stateBefore(i int) scanState {
+	if i == 0 {
+		return scanState{}
+	}
+	return d.states[i-1]
+}
+
+// tokenAt returns the index of the token containing offset. A token contains
+// the offset right after its last character, so that the word being typed is
+// found at the cursor. Identifiers are preferred over operators they
+// immediately follow.
+func (d *document) tokenAt(offset int) (int, bool) {
+	for i, t := range d.tokens {
+		if t.start <= offset && offset <= t.end && t.tok != token.TERMINATOR {
+			if t.tok != token.IDENT && i+1 < len(d.tokens) && d.tokens[i+1].start == offset && d.tokens[i+1].tok == token.IDENT {
+				return i + 1, true
+			}
+			return i, true
+		}
+		if t.start > offset {
+			break
+		}
+	}
+	return 0, false
+}
+
+// traversalAround returns the indexes of the first and last identifiers of
+// the traversal containing the token at index i, such as
+// prometheus.remote_write.default.receiver. The traversal is empty (last <
+// first) if the token at index i isn't part of a traversal.
+func (d *document) traversalAround(i int) (first, last int) {
+	if d.tokens[i].tok == token.DOT {
+		// Start from the identifier before the dot, if any.
+		if i == 0 || d.tokens[i-1].tok != token.IDENT {
+			return i, i - 1
+		}
+		i--
+	}
+	first, last = i, i
+
+	for first >= 2 && d.tokens[first-1].tok == token.DOT && d.tokens[first-2].tok == token.IDENT && d.tokens[first-1].start == d.tokens[first-2].end {
+		first -= 2
+	}
+	for last+2 < len(d.tokens) && d.tokens[last+1].tok == token.DOT && d.tokens[last+2].tok == token.IDENT && d.tokens[last+1].end == d.tokens[last+2].start {
+		last += 2
+	}
+	return first, last
+}
+
+// identNames returns the names of the identifiers in the traversal of tokens
+// [first, last].
+func (d *document) identNames(first, last int) []string {
+	var names []string
+	for i := first; i <= last; i += 2 {
+		names = append(names, d.tokens[i].lit)
+	}
+	return names
+}
+
+// blockNameAt returns the block whose name contains offset.
+func (d *document) blockNameAt(offset int) *blockInfo {
+	var find func(blocks []*blockInfo) *blockInfo
+	find = func(blocks []*blockInfo) *blockInfo {
+		for _, b := range blocks {
+			if b.nameStart <= offset && offset <= b.nameEnd {
+				return b
+			}
+			if found := find(b.children); found != nil {
+				return found
+			}
+		}
+		return nil
+	}
+	return find(d.blocks)
+}
+
+// scopeBlocks returns the blocks which can be referenced from block: the
+// top-level blocks of the document, or the blocks of the enclosing declare
+// block.
+func (d *document) scopeBlocks(block *blockInfo) []*blockInfo {
+	for b := block; b != nil; b = b.parent {
+		if b.name == "declare" {
+			return b.children
+		}
+	}
+	return d.blocks
+}
+
+// Offset converts an LSP position into a byte offset.
+func (d *document) Offset(pos Position) int {
+	offset := 0
+	for line := 0; line < pos.Line; line++ {
+		next := strings.IndexByte(d.text[offset:], '\n')
+		if next < 0 {
+			return len(d.text)
+		}
+		offset += next + 1
+	}
+
+	for units := 0; units < pos.Character && offset < len(d.text) && d.text[offset] != '\n'; {
+		r, size := utf8.DecodeRuneInString(d.text[offset:])
+		units += utf16.RuneLen(r)
+		offset += size
+	}
+	return offset
+}
+
+// Position converts a byte offset into an LSP position.
+func (d *document) Position(offset int) Position {
+	offset = min(offset, len(d.text))
+
+	lineStart := strings.LastIndexByte(d.text[:offset], '\n') + 1
+	pos := Position{Line: strings.Count(d.text[:lineStart], "\n")}
+	for _, r := range d.text[lineStart:offset] {
+		pos.Character += utf16.RuneLen(r)
+	}
+	return pos
+}
+
+// Range converts byte offsets into an LSP range.
+func (d *document) Range(start, end int) Range {
+	return Range{Start: d.Position(start), End: d.Position(end)}
+}
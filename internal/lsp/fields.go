@@ -0,0 +1,173 @@
+package lsp
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/grafana/alloy/syntax/alloytypes"
+)
+
+// field is an attribute or a block of an Arguments or Exports type, as
+// described by its alloy struct tag.
+type field struct {
+	Name     string
+	Block    bool
+	Optional bool
+	Type     reflect.Type
+}
+
+// TypeName returns the name of the Alloy type of the field. Blocks don't have
+// a type name.
+func (f field) TypeName() string {
+	if f.Block {
+		return "block"
+	}
+	return typeName(f.Type)
+}
+
+// Describe returns a one-line description of the field.
+func (f field) Describe() string {
+	var sb strings.Builder
+	if f.Block {
+		fmt.Fprintf(&sb, "block %s", f.Name)
+	} else {
+		fmt.Fprintf(&sb, "%s %s", f.Name, f.TypeName())
+	}
+	if f.Optional {
+		sb.WriteString(" (optional)")
+	} else {
+		sb.WriteString(" (required)")
+	}
+	return sb.String()
+}
+
+// structFields returns the fields of the struct type t, or of the struct t
+// points to. Squashed structs are flattened and the blocks of enums are
+// returned as individual blocks. It returns nil if t isn't a struct.
+func structFields(t reflect.Type) []field {
+	t = derefType(t)
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup("alloy")
+		if !ok || !sf.IsExported() || sf.Anonymous {
+			continue
+		}
+
+		name, rawFlags, _ := strings.Cut(tag, ",")
+		flags := strings.Split(rawFlags, ",")
+		optional := slices.Contains(flags, "optional")
+
+		switch {
+		case slices.Contains(flags, "squash"):
+			fields = append(fields, structFields(sf.Type)...)
+
+		case slices.Contains(flags, "enum"):
+			// Enums are slices of structs where each field is a block.
+			for _, f := range structFields(derefType(sf.Type).Elem()) {
+				f.Optional = true
+				fields = append(fields, f)
+			}
+
+		case slices.Contains(flags, "block"):
+			fields = append(fields, field{Name: name, Block: true, Optional: optional, Type: sf.Type})
+
+		case slices.Contains(flags, "attr"):
+			fields = append(fields, field{Name: name, Optional: optional, Type: sf.Type})
+		}
+	}
+	return fields
+}
+
+// lookupField returns the field named name of the struct type t.
+func lookupField(t reflect.Type, name string) (field, bool) {
+	for _, f := range structFields(t) {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return field{}, false
+}
+
+// nestedType returns the type of the block found by following path from the
+// struct type t.
+func nestedType(t reflect.Type, path []string) (reflect.Type, bool) {
+	for _, name := range path {
+		f, ok := lookupField(t, name)
+		if !ok || !f.Block {
+			return nil, false
+		}
+		t = blockType(f.Type)
+	}
+	return t, true
+}
+
+// blockType returns the struct type of a block field, which may be a struct,
+// a pointer to a struct, or a slice of either for repeatable blocks.
+func blockType(t reflect.Type) reflect.Type {
+	t = derefType(t)
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = derefType(t.Elem())
+	}
+	return t
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+var (
+	durationType = reflect.TypeFor[time.Duration]()
+	secretType   = reflect.TypeFor[alloytypes.Secret]()
+	optSecret    = reflect.TypeFor[alloytypes.OptionalSecret]()
+)
+
+// typeName returns the name of the Alloy type a Go type is decoded from.
+func typeName(t reflect.Type) string {
+	switch t {
+	case durationType:
+		return "duration"
+	case secretType:
+		return "secret"
+	case optSecret:
+		return "string | secret"
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return typeName(t.Elem())
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return fmt.Sprintf("list(%s)", typeName(t.Elem()))
+	case reflect.Map:
+		return fmt.Sprintf("map(%s)", typeName(t.Elem()))
+	case reflect.Func:
+		return "function"
+	case reflect.Interface:
+		if t.NumMethod() == 0 {
+			return "any"
+		}
+	case reflect.Struct:
+		if len(structFields(t)) > 0 {
+			return "object"
+		}
+	}
+	return fmt.Sprintf("capsule(%s)", t)
+}
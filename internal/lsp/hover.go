@@ -0,0 +1,175 @@
+package lsp
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/grafana/alloy/syntax/token"
+)
+
+// hover returns the documentation of the component, argument, block or
+// export at offset, built from the alloy struct tags of the Arguments and
+// Exports types of components.
+func (s *Server) hover(d *document, offset int) *Hover {
+	i, ok := d.tokenAt(offset)
+	if !ok || d.tokens[i].tok != token.IDENT {
+		return nil
+	}
+
+	// Names of components and nested blocks.
+	if b := d.blockNameAt(offset); b != nil {
+		var text string
+		if b.parent == nil {
+			text = s.componentDoc(b.name)
+		} else if t, ok := s.blockType(b.parent); ok {
+			if f, ok := lookupField(t, b.name); ok {
+				text = fieldDoc(f)
+			}
+		}
+		return newHover(d, text, b.nameStart, b.nameEnd)
+	}
+
+	// Names of arguments.
+	state := d.stateBefore(i)
+	if state.block != nil && state.depth == 0 && state.stmtStart == i && i+1 < len(d.tokens) && d.tokens[i+1].tok == token.ASSIGN {
+		t, ok := s.blockType(state.block)
+		if !ok {
+			return nil
+		}
+		f, ok := lookupField(t, d.tokens[i].lit)
+		if !ok {
+			return nil
+		}
+		return newHover(d, fieldDoc(f), d.tokens[i].start, d.tokens[i].end)
+	}
+
+	// References to components and their exports.
+	first, last := d.traversalAround(i)
+	b, n := resolveReference(d, state.block, d.identNames(first, last))
+	if b == nil {
+		return nil
+	}
+	if idx := (i - first) / 2; idx < n {
+		return newHover(d, s.componentDoc(b.name), d.tokens[first].start, d.tokens[first+2*(n-1)].end)
+	} else if idx == n {
+		reg, err := s.opts.ComponentRegistry.Get(b.name)
+		if err != nil {
+			return nil
+		}
+		if f, ok := lookupField(reflect.TypeOf(reg.Exports), d.tokens[i].lit); ok {
+			text := fmt.Sprintf("`%s %s`\n\nExported by `%s`.\n", f.Name, f.TypeName(), b.name)
+			return newHover(d, text, d.tokens[i].start, d.tokens[i].end)
+		}
+	}
+	return nil
+}
+
+// definition returns the location of the component referenced at offset. For
+// the name of a custom component, it returns the location of the declare
+// block defining it.
+func (s *Server) definition(d *document, offset int) []Location {
+	i, ok := d.tokenAt(offset)
+	if !ok || d.tokens[i].tok != token.IDENT {
+		return []Location{}
+	}
+
+	var target *blockInfo
+	if b := d.blockNameAt(offset); b != nil {
+		for _, decl := range d.scopeBlocks(b.parent) {
+			if decl.name == "declare" && decl.label == b.name {
+				target = decl
+			}
+		}
+	} else {
+		first, last := d.traversalAround(i)
+		target, _ = resolveReference(d, d.stateBefore(i).block, d.identNames(first, last))
+	}
+
+	if target == nil {
+		return []Location{}
+	}
+	return []Location{{
+		URI:   d.uri,
+		Range: d.Range(target.nameStart, target.nameEnd),
+	}}
+}
+
+// resolveReference finds the labeled block referenced by a traversal such as
+// prometheus.remote_write.default.receiver. It returns the block and the
+// number of names of the traversal used by its ID.
+func resolveReference(d *document, block *blockInfo, names []string) (*blockInfo, int) {
+	for n := len(names); n > 0; n-- {
+		id := strings.Join(names[:n], ".")
+		for _, b := range d.scopeBlocks(block) {
+			if b.label != "" && b.ID() == id {
+				return b, n
+			}
+		}
+	}
+	return nil, 0
+}
+
+// blockType returns the Arguments type of block, which is either a
+// component or a block nested in a component.
+func (s *Server) blockType(block *blockInfo) (reflect.Type, bool) {
+	reg, err := s.opts.ComponentRegistry.Get(block.Root().name)
+	if err != nil {
+		return nil, false
+	}
+	return nestedType(reflect.TypeOf(reg.Args), block.Path())
+}
+
+// componentDoc returns the documentation of a component. It returns an empty
+// string if the component isn't registered.
+func (s *Server) componentDoc(name string) string {
+	reg, err := s.opts.ComponentRegistry.Get(name)
+	if err != nil {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "**%s** (%s)\n", name, stabilityName(reg))
+
+	if fields := structFields(reflect.TypeOf(reg.Args)); len(fields) > 0 {
+		sb.WriteString("\nArguments:\n")
+		for _, f := range fields {
+			fmt.Fprintf(&sb, "* `%s`\n", f.Describe())
+		}
+	}
+	if fields := structFields(reflect.TypeOf(reg.Exports)); len(fields) > 0 {
+		sb.WriteString("\nExports:\n")
+		for _, f := range fields {
+			fmt.Fprintf(&sb, "* `%s %s`\n", f.Name, f.TypeName())
+		}
+	}
+	return sb.String()
+}
+
+// fieldDoc returns the documentation of an argument or a block. The fields
+// of blocks are listed as well.
+func fieldDoc(f field) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "`%s`\n", f.Describe())
+
+	if f.Block {
+		if fields := structFields(blockType(f.Type)); len(fields) > 0 {
+			sb.WriteString("\nFields:\n")
+			for _, inner := range fields {
+				fmt.Fprintf(&sb, "* `%s`\n", inner.Describe())
+			}
+		}
+	}
+	return sb.String()
+}
+
+func newHover(d *document, text string, start, end int) *Hover {
+	if text == "" {
+		return nil
+	}
+	r := d.Range(start, end)
+	return &Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: text},
+		Range:    &r,
+	}
+}
@@ -0,0 +1,153 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+	"sync"
+)
+
+// JSON-RPC error codes used by the server.
+const (
+	codeParseError     = -32700
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+)
+
+// maxMessageSize is the maximum size of the body of messages sent by the
+// client, in bytes.
+const maxMessageSize = 32 << 20
+
+// request is an incoming JSON-RPC request or notification. Notifications
+// don't have an ID.
+type request struct {
+	ID     json.RawMessage `json:"id,omitempty"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// IsNotification returns true if the client doesn't expect a response to r.
+func (r *request) IsNotification() bool { return len(r.ID) == 0 }
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *responseError  `json:"error,omitempty"`
+}
+
+type responseError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *responseError) Error() string { return e.Message }
+
+type notification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+// conn reads and writes JSON-RPC messages framed with a Content-Length
+// header, as done by the base protocol of LSP.
+type conn struct {
+	r *textproto.Reader
+
+	mut sync.Mutex
+	w   io.Writer
+}
+
+func newConn(r io.Reader, w io.Writer) *conn {
+	return &conn{
+		r: textproto.NewReader(bufio.NewReader(r)),
+		w: w,
+	}
+}
+
+// Read reads the next message. It returns io.EOF when the client closed the
+// stream.
+func (c *conn) Read() (*request, error) {
+	body, err := readMessage(c.r)
+	if err != nil {
+		return nil, err
+	}
+
+	var req request
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, &responseError{Code: codeParseError, Message: err.Error()}
+	}
+	return &req, nil
+}
+
+// readMessage reads the body of the next message from r.
+func readMessage(r *textproto.Reader) ([]byte, error) {
+	header, err := r.ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	length, err := strconv.Atoi(header.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Length header: %w", err)
+	}
+	if length < 0 || length > maxMessageSize {
+		return nil, fmt.Errorf("invalid Content-Length header: must be between 0 and %d, got %d", maxMessageSize, length)
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r.R, body); err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// Reply sends the response to the request with the given ID. err is sent to
+// the client instead of result if non-nil.
+func (c *conn) Reply(id json.RawMessage, result any, err error) error {
+	resp := response{JSONRPC: "2.0", ID: id}
+
+	if err != nil {
+		respErr, ok := err.(*responseError)
+		if !ok {
+			respErr = &responseError{Code: codeInternalError, Message: err.Error()}
+		}
+		resp.Error = respErr
+	} else {
+		bb, err := json.Marshal(result)
+		if err != nil {
+			return err
+		}
+		resp.Result = bb
+	}
+
+	if resp.ID == nil {
+		resp.ID = json.RawMessage("null")
+	}
+	return c.write(resp)
+}
+
+// Notify sends a notification to the client.
+func (c *conn) Notify(method string, params any) error {
+	return c.write(notification{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (c *conn) write(msg any) error {
+	bb, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if _, err := fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n", len(bb)); err != nil {
+		return err
+	}
+	_, err = c.w.Write(bb)
+	return err
+}
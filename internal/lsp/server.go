@@ -0,0 +1,241 @@
+// Package lsp implements a Language Server Protocol server for Alloy
+// configuration files.
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sync"
+
+	"github.com/grafana/alloy/internal/build"
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/service"
+)
+
+// Options configure a Server.
+type Options struct {
+	// ComponentRegistry is used to validate documents and to look up the
+	// arguments and exports of components.
+	ComponentRegistry component.Registry
+
+	// ComponentNames are the names of the components proposed for completion.
+	// Names which can't be found in ComponentRegistry are ignored.
+	ComponentNames []string
+
+	// ServiceDefinitions is used to validate the configuration blocks of
+	// services.
+	ServiceDefinitions []service.Definition
+
+	// MinStability is the minimum stability level of features that can be
+	// used in documents.
+	MinStability featuregate.Stability
+}
+
+// Server is a language server for Alloy configuration files. Documents are
+// synchronized in full on each change, and requests are handled one at a
+// time in the order they are received.
+type Server struct {
+	opts Options
+
+	mut       sync.Mutex
+	documents map[string]*document
+	shutdown  bool
+}
+
+// NewServer creates a new Server.
+func NewServer(opts Options) *Server {
+	return &Server{
+		opts:      opts,
+		documents: make(map[string]*document),
+	}
+}
+
+// errExit is returned by handlers when the client asks the server to exit.
+var errExit = errors.New("exit")
+
+// Serve reads requests from r and writes responses and notifications to w
+// until the client sends the exit notification, r is closed, or ctx is
+// canceled.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	c := newConn(r, w)
+
+	for ctx.Err() == nil {
+		req, err := c.Read()
+		var respErr *responseError
+		switch {
+		case errors.Is(err, io.EOF):
+			return nil
+		case errors.As(err, &respErr):
+			if err := c.Reply(nil, nil, respErr); err != nil {
+				return err
+			}
+			continue
+		case err != nil:
+			return err
+		}
+
+		result, err := s.handle(c, req)
+		if errors.Is(err, errExit) {
+			if !s.shutdown {
+				return fmt.Errorf("exit requested without a shutdown request")
+			}
+			return nil
+		}
+		if req.IsNotification() {
+			continue
+		}
+		if err := c.Reply(req.ID, result, err); err != nil {
+			return err
+		}
+	}
+	return ctx.Err()
+}
+
+func (s *Server) handle(c *conn, req *request) (any, error) {
+	switch req.Method {
+	case "initialize":
+		return InitializeResult{
+			Capabilities: ServerCapabilities{
+				TextDocumentSync:           textDocumentSyncFull,
+				CompletionProvider:         &CompletionOptions{TriggerCharacters: []string{"."}},
+				HoverProvider:              true,
+				DefinitionProvider:         true,
+				DocumentFormattingProvider: true,
+			},
+			ServerInfo: ServerInfo{Name: "alloy", Version: build.Version},
+		}, nil
+
+	case "initialized", "$/cancelRequest", "$/setTrace":
+		return nil, nil
+
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+
+	case "exit":
+		return nil, errExit
+
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if err := decodeParams(req, &params); err != nil {
+			return nil, err
+		}
+		return nil, s.update(c, params.TextDocument.URI, params.TextDocument.Text)
+
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if err := decodeParams(req, &params); err != nil {
+			return nil, err
+		}
+		if len(params.ContentChanges) == 0 {
+			return nil, nil
+		}
+		text := params.ContentChanges[len(params.ContentChanges)-1].Text
+		return nil, s.update(c, params.TextDocument.URI, text)
+
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if err := decodeParams(req, &params); err != nil {
+			return nil, err
+		}
+		s.mut.Lock()
+		delete(s.documents, params.TextDocument.URI)
+		s.mut.Unlock()
+		// Clear the diagnostics of the closed document.
+		return nil, c.Notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+			URI:         params.TextDocument.URI,
+			Diagnostics: []Diagnostic{},
+		})
+
+	case "textDocument/completion":
+		var params TextDocumentPositionParams
+		d, err := s.positionParams(req, &params)
+		if err != nil {
+			return nil, err
+		}
+		return s.completion(d, d.Offset(params.Position)), nil
+
+	case "textDocument/hover":
+		var params TextDocumentPositionParams
+		d, err := s.positionParams(req, &params)
+		if err != nil {
+			return nil, err
+		}
+		return s.hover(d, d.Offset(params.Position)), nil
+
+	case "textDocument/definition":
+		var params TextDocumentPositionParams
+		d, err := s.positionParams(req, &params)
+		if err != nil {
+			return nil, err
+		}
+		return s.definition(d, d.Offset(params.Position)), nil
+
+	case "textDocument/formatting":
+		var params DocumentFormattingParams
+		if err := decodeParams(req, &params); err != nil {
+			return nil, err
+		}
+		d, err := s.document(params.TextDocument.URI)
+		if err != nil {
+			return nil, err
+		}
+		return s.format(d)
+
+	default:
+		return nil, &responseError{Code: codeMethodNotFound, Message: fmt.Sprintf("method %q not supported", req.Method)}
+	}
+}
+
+func decodeParams(req *request, v any) error {
+	if err := json.Unmarshal(req.Params, v); err != nil {
+		return &responseError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+func (s *Server) positionParams(req *request, params *TextDocumentPositionParams) (*document, error) {
+	if err := decodeParams(req, params); err != nil {
+		return nil, err
+	}
+	return s.document(params.TextDocument.URI)
+}
+
+func (s *Server) document(uri string) (*document, error) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	d, ok := s.documents[uri]
+	if !ok {
+		return nil, &responseError{Code: codeInvalidParams, Message: fmt.Sprintf("document %q is not open", uri)}
+	}
+	return d, nil
+}
+
+// update stores the new content of a document and publishes its
+// diagnostics.
+func (s *Server) update(c *conn, uri, text string) error {
+	d := newDocument(uri, text)
+
+	s.mut.Lock()
+	s.documents[uri] = d
+	s.mut.Unlock()
+
+	return c.Notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{
+		URI:         uri,
+		Diagnostics: s.diagnostics(d),
+	})
+}
+
+// filename returns the name used for a document in diagnostics.
+func filename(uri string) string {
+	if u, err := url.Parse(uri); err == nil && u.Scheme == "file" {
+		return u.Path
+	}
+	return uri
+}
@@ -0,0 +1,353 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/featuregate"
+)
+
+type testArgs struct {
+	Targets  []map[string]string `alloy:"targets,attr"`
+	Interval time.Duration       `alloy:"interval,attr,optional"`
+	Endpoint []testEndpoint      `alloy:"endpoint,block,optional"`
+}
+
+type testEndpoint struct {
+	URL string `alloy:"url,attr"`
+}
+
+type testExports struct {
+	Output string `alloy:"output,attr"`
+}
+
+func newTestServer() *Server {
+	registrations := map[string]component.Registration{
+		"test.source": {
+			Name:      "test.source",
+			Stability: featuregate.StabilityGenerallyAvailable,
+			Args:      testArgs{},
+			Exports:   testExports{},
+		},
+		"test.experimental": {
+			Name:      "test.experimental",
+			Stability: featuregate.StabilityExperimental,
+			Args:      testArgs{},
+		},
+	}
+
+	return NewServer(Options{
+		ComponentRegistry: component.NewRegistryMap(featuregate.StabilityGenerallyAvailable, false, registrations),
+		ComponentNames:    []string{"test.experimental", "test.source"},
+		MinStability:      featuregate.StabilityGenerallyAvailable,
+	})
+}
+
+const testConfig = `test.source "a" {
+  targets = []
+  endpoint {
+    url = "http://localhost"
+  }
+}
+
+test.source "b" {
+  targets = [{"__address__" = test.source.a.output}]
+}
+`
+
+func TestServer_Diagnostics(t *testing.T) {
+	c := startServer(t)
+
+	c.Notify("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: "file:///config.alloy", Text: testConfig},
+	})
+	var diags PublishDiagnosticsParams
+	c.ReadNotification("textDocument/publishDiagnostics", &diags)
+	require.Empty(t, diags.Diagnostics)
+
+	c.Notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument: TextDocumentIdentifier{URI: "file:///config.alloy"},
+		ContentChanges: []TextDocumentContentChangeEvent{{
+			Text: "test.source \"a\" {\n  targets = []\n  unknown = 1\n}\n\ntest.experimental \"b\" {\n  targets = []\n}\n",
+		}},
+	})
+	c.ReadNotification("textDocument/publishDiagnostics", &diags)
+	require.Len(t, diags.Diagnostics, 2)
+	require.Equal(t, Range{Start: Position{Line: 2, Character: 2}, End: Position{Line: 2, Character: 13}}, diags.Diagnostics[0].Range)
+	require.Contains(t, diags.Diagnostics[0].Message, `unrecognized attribute name "unknown"`)
+	require.Contains(t, diags.Diagnostics[1].Message, `is at stability level "experimental"`)
+
+	c.Notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   TextDocumentIdentifier{URI: "file:///config.alloy"},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: "test.source \"a\" {\n  targets = [\n}\n"}},
+	})
+	c.ReadNotification("textDocument/publishDiagnostics", &diags)
+	require.NotEmpty(t, diags.Diagnostics)
+	require.Equal(t, SeverityError, diags.Diagnostics[0].Severity)
+}
+
+func TestServer_Completion(t *testing.T) {
+	tt := []struct {
+		name   string
+		text   string
+		expect []string
+	}{
+		{
+			name:   "component names",
+			text:   "test.s|",
+			expect: []string{"test.source"},
+		},
+		{
+			name:   "arguments",
+			text:   "test.source \"a\" {\n  |\n}",
+			expect: []string{"targets", "interval", "endpoint"},
+		},
+		{
+			name:   "arguments of nested blocks",
+			text:   "test.source \"a\" {\n  endpoint {\n    u|\n  }\n}",
+			expect: []string{"url"},
+		},
+		{
+			name:   "components in expressions",
+			text:   testConfig + "test.source \"c\" {\n  targets = [test.|]\n}",
+			expect: []string{"test.source.a", "test.source.b"},
+		},
+		{
+			name:   "exports",
+			text:   testConfig + "test.source \"c\" {\n  targets = test.source.a.|\n}",
+			expect: []string{"test.source.a.output"},
+		},
+		{
+			name:   "nothing in object keys",
+			text:   "test.source \"a\" {\n  targets = [{\n    |\n  }]\n}",
+			expect: []string{},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			c := startServer(t)
+			pos := c.Open(tc.text)
+
+			var list CompletionList
+			c.Call("textDocument/completion", TextDocumentPositionParams{
+				TextDocument: TextDocumentIdentifier{URI: "file:///config.alloy"},
+				Position:     pos,
+			}, &list)
+
+			labels := []string{}
+			for _, item := range list.Items {
+				labels = append(labels, item.Label)
+			}
+			require.Equal(t, tc.expect, labels)
+		})
+	}
+}
+
+func TestServer_Hover(t *testing.T) {
+	tt := []struct {
+		name   string
+		text   string
+		expect string
+	}{
+		{
+			name:   "component",
+			text:   "test.so|urce \"a\" {\n  targets = []\n}",
+			expect: "**test.source** (generally-available)\n\nArguments:\n* `targets list(map(string)) (required)`\n* `interval duration (optional)`\n* `block endpoint (optional)`\n\nExports:\n* `output string`\n",
+		},
+		{
+			name:   "argument",
+			text:   "test.source \"a\" {\n  inter|val = \"1s\"\n}",
+			expect: "`interval duration (optional)`\n",
+		},
+		{
+			name:   "block",
+			text:   "test.source \"a\" {\n  endp|oint {\n  }\n}",
+			expect: "`block endpoint (optional)`\n\nFields:\n* `url string (required)`\n",
+		},
+		{
+			name:   "export",
+			text:   testConfig + "test.source \"c\" {\n  targets = test.source.a.out|put\n}",
+			expect: "`output string`\n\nExported by `test.source`.\n",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			c := startServer(t)
+			pos := c.Open(tc.text)
+
+			var hover Hover
+			c.Call("textDocument/hover", TextDocumentPositionParams{
+				TextDocument: TextDocumentIdentifier{URI: "file:///config.alloy"},
+				Position:     pos,
+			}, &hover)
+			require.Equal(t, tc.expect, hover.Contents.Value)
+		})
+	}
+}
+
+func TestServer_Definition(t *testing.T) {
+	c := startServer(t)
+	pos := c.Open(strings.Replace(testConfig, "test.source.a.output", "test.source.|a.output", 1))
+
+	var locations []Location
+	c.Call("textDocument/definition", TextDocumentPositionParams{
+		TextDocument: TextDocumentIdentifier{URI: "file:///config.alloy"},
+		Position:     pos,
+	}, &locations)
+	require.Equal(t, []Location{{
+		URI:   "file:///config.alloy",
+		Range: Range{Start: Position{Line: 0, Character: 0}, End: Position{Line: 0, Character: 11}},
+	}}, locations)
+}
+
+func TestServer_Formatting(t *testing.T) {
+	c := startServer(t)
+	c.Open("test.source \"a\" {\ntargets=[]\n    interval = \"1s\"\n}|")
+
+	var edits []TextEdit
+	c.Call("textDocument/formatting", DocumentFormattingParams{
+		TextDocument: TextDocumentIdentifier{URI: "file:///config.alloy"},
+	}, &edits)
+	require.Equal(t, []TextEdit{{
+		Range:   Range{Start: Position{Line: 0, Character: 0}, End: Position{Line: 3, Character: 1}},
+		NewText: "test.source \"a\" {\n\ttargets  = []\n\tinterval = \"1s\"\n}\n",
+	}}, edits)
+}
+
+func TestServer_Shutdown(t *testing.T) {
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+
+	done := make(chan error, 1)
+	go func() { done <- newTestServer().Serve(t.Context(), serverR, serverW) }()
+
+	c := &testClient{t: t, r: textproto.NewReader(bufio.NewReader(clientR)), w: clientW}
+	c.Call("shutdown", nil, nil)
+	c.Notify("exit", nil)
+	require.NoError(t, <-done)
+}
+
+type testClient struct {
+	t      *testing.T
+	r      *textproto.Reader
+	w      io.Writer
+	nextID int
+}
+
+// startServer starts a server and initializes it.
+func startServer(t *testing.T) *testClient {
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = newTestServer().Serve(ctx, serverR, serverW)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		clientW.Close()
+		serverW.Close()
+		<-done
+	})
+
+	c := &testClient{t: t, r: textproto.NewReader(bufio.NewReader(clientR)), w: clientW}
+
+	var res InitializeResult
+	c.Call("initialize", map[string]any{}, &res)
+	require.True(t, res.Capabilities.HoverProvider)
+	c.Notify("initialized", map[string]any{})
+	return c
+}
+
+// Open opens a document. The position of the cursor is marked with a | in
+// text, and is returned.
+func (c *testClient) Open(text string) Position {
+	offset := strings.Index(text, "|")
+	require.GreaterOrEqual(c.t, offset, 0, "missing cursor in text")
+	text = text[:offset] + text[offset+1:]
+
+	c.Notify("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: "file:///config.alloy", Text: text},
+	})
+	c.ReadNotification("textDocument/publishDiagnostics", &PublishDiagnosticsParams{})
+
+	return (&document{text: text}).Position(offset)
+}
+
+func (c *testClient) Notify(method string, params any) {
+	c.write(map[string]any{"jsonrpc": "2.0", "method": method, "params": params})
+}
+
+func (c *testClient) Call(method string, params any, result any) {
+	c.nextID++
+	c.write(map[string]any{"jsonrpc": "2.0", "id": c.nextID, "method": method, "params": params})
+
+	msg := c.read()
+	require.Equal(c.t, fmt.Sprint(c.nextID), string(msg.ID))
+	require.Nil(c.t, msg.Error)
+	if result != nil {
+		require.NoError(c.t, json.Unmarshal(msg.Result, result))
+	}
+}
+
+func (c *testClient) ReadNotification(method string, params any) {
+	msg := c.read()
+	require.Equal(c.t, method, msg.Method)
+	require.NoError(c.t, json.Unmarshal(msg.Params, params))
+}
+
+type testMessage struct {
+	ID     json.RawMessage `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *responseError  `json:"error"`
+}
+
+func (c *testClient) read() testMessage {
+	body, err := readMessage(c.r)
+	require.NoError(c.t, err)
+
+	var msg testMessage
+	require.NoError(c.t, json.Unmarshal(body, &msg))
+	return msg
+}
+
+func (c *testClient) write(msg any) {
+	bb, err := json.Marshal(msg)
+	require.NoError(c.t, err)
+	_, err = fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n%s", len(bb), bb)
+	require.NoError(c.t, err)
+}
+
+func TestReadMessage_InvalidLength(t *testing.T) {
+	tt := []struct {
+		name   string
+		length string
+	}{
+		{name: "negative", length: "-1"},
+		{name: "too large", length: fmt.Sprint(maxMessageSize + 1)},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			r := textproto.NewReader(bufio.NewReader(strings.NewReader("Content-Length: " + tc.length + "\r\n\r\n{}")))
+			_, err := readMessage(r)
+			require.ErrorContains(t, err, "invalid Content-Length header: must be between 0 and")
+		})
+	}
+}
@@ -0,0 +1,10 @@
+package function
+
+import "github.com/grafana/alloy/internal/featuregate"
+
+const (
+	// BlockName is the block name for function blocks.
+	BlockName = "function"
+	// StabilityLevel for function blocks.
+	StabilityLevel = featuregate.StabilityExperimental
+)
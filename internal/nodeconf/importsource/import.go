@@ -15,6 +15,7 @@ const (
 	String
 	Git
 	HTTP
+	OCI
 )
 
 const (
@@ -22,6 +23,7 @@ const (
 	BlockNameString = "import.string"
 	BlockNameHTTP   = "import.http"
 	BlockNameGit    = "import.git"
+	BlockNameOCI    = "import.oci"
 )
 
 const ModulePath = "module_path"
@@ -52,6 +54,8 @@ func NewImportSource(sourceType SourceType, managedOpts component.Options, eval
 		return NewImportHTTP(managedOpts, eval, onContentChange)
 	case Git:
 		return NewImportGit(managedOpts, eval, onContentChange)
+	case OCI:
+		return NewImportOCI(managedOpts, eval, onContentChange)
 	}
 	panic(fmt.Errorf("unsupported source type: %v", sourceType))
 }
@@ -67,6 +71,8 @@ func GetSourceType(fullName string) SourceType {
 		return HTTP
 	case BlockNameGit:
 		return Git
+	case BlockNameOCI:
+		return OCI
 	}
 	panic(fmt.Errorf("name does not map to a known source type: %v", fullName))
 }
@@ -0,0 +1,319 @@
+package importsource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/oci"
+	"github.com/grafana/alloy/internal/runtime/equality"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/syntax"
+	"github.com/grafana/alloy/syntax/vm"
+)
+
+// OCIStabilityLevel is the stability level of import.oci blocks.
+const OCIStabilityLevel = featuregate.StabilityExperimental
+
+// ImportOCI imports a module from an artifact stored in an OCI registry.
+type ImportOCI struct {
+	opts            component.Options
+	log             log.Logger
+	eval            *vm.Evaluator
+	mut             sync.RWMutex
+	artifact        *oci.Artifact
+	artifactOpts    oci.ArtifactOptions
+	args            OCIArguments
+	artifactPath    string
+	onContentChange func(map[string]string)
+
+	argsChanged chan struct{}
+
+	healthMut sync.RWMutex
+	health    component.Health
+}
+
+var (
+	_ ImportSource              = (*ImportOCI)(nil)
+	_ component.Component       = (*ImportOCI)(nil)
+	_ component.HealthComponent = (*ImportOCI)(nil)
+)
+
+type OCIArguments struct {
+	Reference     string         `alloy:"reference,attr"`
+	Path          string         `alloy:"path,attr,optional"`
+	PullFrequency time.Duration  `alloy:"pull_frequency,attr,optional"`
+	Insecure      bool           `alloy:"insecure,attr,optional"`
+	Auth          oci.AuthConfig `alloy:",squash"`
+}
+
+var DefaultOCIArguments = OCIArguments{
+	Path:          ".",
+	PullFrequency: time.Minute,
+}
+
+var (
+	_ syntax.Validator = (*OCIArguments)(nil)
+	_ syntax.Defaulter = (*OCIArguments)(nil)
+)
+
+// Validate implements syntax.Validator.
+func (args *OCIArguments) Validate() error {
+	if _, err := oci.ParseReference(args.Reference); err != nil {
+		return err
+	}
+	return args.Auth.Validate()
+}
+
+// SetToDefault implements syntax.Defaulter.
+func (args *OCIArguments) SetToDefault() {
+	*args = DefaultOCIArguments
+}
+
+func NewImportOCI(managedOpts component.Options, eval *vm.Evaluator, onContentChange func(map[string]string)) *ImportOCI {
+	return &ImportOCI{
+		opts:            managedOpts,
+		log:             managedOpts.Logger,
+		eval:            eval,
+		argsChanged:     make(chan struct{}, 1),
+		onContentChange: onContentChange,
+	}
+}
+
+func (im *ImportOCI) Evaluate(scope *vm.Scope) error {
+	var arguments OCIArguments
+	if err := im.eval.Evaluate(scope, &arguments); err != nil {
+		return fmt.Errorf("decoding configuration: %w", err)
+	}
+
+	if equality.DeepEqual(im.args, arguments) {
+		return nil
+	}
+
+	if err := im.Update(arguments); err != nil {
+		return fmt.Errorf("updating component: %w", err)
+	}
+	return nil
+}
+
+func (im *ImportOCI) Run(ctx context.Context) error {
+	var (
+		ticker  *time.Ticker
+		tickerC <-chan time.Time
+	)
+	defer func() {
+		if ticker != nil {
+			ticker.Stop()
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case <-im.argsChanged:
+			im.mut.Lock()
+			pullFrequency := im.args.PullFrequency
+			im.mut.Unlock()
+			ticker, tickerC = im.updateTicker(pullFrequency, ticker, tickerC)
+
+		case <-tickerC:
+			level.Info(im.log).Log("msg", "updating artifact")
+			im.tickPollFile(ctx)
+		}
+	}
+}
+
+func (im *ImportOCI) updateTicker(pullFrequency time.Duration, ticker *time.Ticker, tickerC <-chan time.Time) (*time.Ticker, <-chan time.Time) {
+	level.Info(im.log).Log("msg", "updating artifact pull frequency, next pull attempt will be done according to the pullFrequency", "new_frequency", pullFrequency)
+
+	if pullFrequency > 0 {
+		if ticker == nil {
+			ticker = time.NewTicker(pullFrequency)
+			tickerC = ticker.C
+		} else {
+			ticker.Reset(pullFrequency)
+		}
+		return ticker, tickerC
+	}
+
+	if ticker != nil {
+		ticker.Stop()
+	}
+	return nil, nil
+}
+
+func (im *ImportOCI) tickPollFile(ctx context.Context) {
+	im.mut.Lock()
+	err := im.pollFile(ctx, im.args)
+	pullFrequency := im.args.PullFrequency
+	im.mut.Unlock()
+
+	im.updateHealth(err)
+
+	if err != nil {
+		level.Error(im.log).Log("msg", "failed to update artifact", "pullFrequency", pullFrequency, "err", err)
+	}
+}
+
+func (im *ImportOCI) updateHealth(err error) {
+	im.healthMut.Lock()
+	defer im.healthMut.Unlock()
+
+	if err != nil {
+		im.health = component.Health{
+			Health:     component.HealthTypeUnhealthy,
+			Message:    err.Error(),
+			UpdateTime: time.Now(),
+		}
+	} else {
+		im.health = component.Health{
+			Health:     component.HealthTypeHealthy,
+			Message:    "module updated",
+			UpdateTime: time.Now(),
+		}
+	}
+}
+
+// Update implements component.Component.
+// Errors from pulling the artifact are only returned if no copy of the
+// artifact is stored on disk; an oci.UpdateFailedError means that the stored
+// copy is used until a later pull succeeds.
+func (im *ImportOCI) Update(args component.Arguments) (err error) {
+	defer func() {
+		im.updateHealth(err)
+	}()
+	im.mut.Lock()
+	defer im.mut.Unlock()
+
+	newArgs := args.(OCIArguments)
+
+	im.artifactPath = filepath.Join(im.opts.DataPath, "artifact")
+
+	artifactOpts := oci.ArtifactOptions{
+		Reference: newArgs.Reference,
+		Client: oci.ClientOptions{
+			Insecure: newArgs.Insecure,
+			Auth:     newArgs.Auth,
+		},
+	}
+
+	// Create or update the artifact field.
+	// Failure to update the artifact makes the module loader temporarily use
+	// the copy stored on disk.
+	if im.artifact == nil || !equality.DeepEqual(artifactOpts, im.artifactOpts) {
+		a, err := oci.NewArtifact(context.Background(), im.artifactPath, artifactOpts)
+		if err != nil {
+			if errors.As(err, &oci.UpdateFailedError{}) {
+				level.Error(im.log).Log("msg", "failed to update artifact, using the copy stored on disk", "err", err)
+				im.updateHealth(err)
+			} else {
+				return err
+			}
+		}
+		im.artifact = a
+		im.artifactOpts = artifactOpts
+	}
+
+	if err = im.pollFile(context.Background(), newArgs); err != nil {
+		if errors.As(err, &oci.UpdateFailedError{}) {
+			level.Error(im.log).Log("msg", "failed to poll file from artifact", "err", err)
+		} else {
+			return err
+		}
+	}
+
+	// Schedule an update for handling the changed arguments.
+	select {
+	case im.argsChanged <- struct{}{}:
+	default:
+	}
+
+	im.args = newArgs
+	return nil
+}
+
+// pollFile fetches the latest content from the artifact and updates the
+// controller. pollFile must only be called with im.mut held.
+//
+// The module is still read from the stored copy of the artifact when it
+// can't be updated, in which case the oci.UpdateFailedError is returned after
+// the content is sent.
+func (im *ImportOCI) pollFile(ctx context.Context, args OCIArguments) error {
+	updateErr := im.artifact.Update(ctx)
+
+	info, err := im.artifact.Stat(args.Path)
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() {
+		err = im.handleDirectory(args.Path)
+	} else {
+		err = im.handleFile(args.Path)
+	}
+	if err != nil {
+		return err
+	}
+	return updateErr
+}
+
+func (im *ImportOCI) handleDirectory(path string) error {
+	filesInfo, err := im.artifact.ReadDir(path)
+	if err != nil {
+		return err
+	}
+
+	content := make(map[string]string)
+	for _, fi := range filesInfo {
+		if fi.IsDir() || !strings.HasSuffix(fi.Name(), ".alloy") {
+			continue
+		}
+		bb, err := im.artifact.ReadFile(filepath.Join(path, fi.Name()))
+		if err != nil {
+			return err
+		}
+		content[fi.Name()] = string(bb)
+	}
+	im.onContentChange(content)
+	return nil
+}
+
+func (im *ImportOCI) handleFile(path string) error {
+	bb, err := im.artifact.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	im.onContentChange(map[string]string{path: string(bb)})
+	return nil
+}
+
+// CurrentHealth implements component.HealthComponent.
+func (im *ImportOCI) CurrentHealth() component.Health {
+	im.healthMut.RLock()
+	defer im.healthMut.RUnlock()
+	return im.health
+}
+
+// Update the evaluator.
+func (im *ImportOCI) SetEval(eval *vm.Evaluator) {
+	im.eval = eval
+}
+
+// ModulePath returns the directory holding the content of the artifact, so
+// that nested imports can refer to other files of the artifact.
+func (im *ImportOCI) ModulePath() string {
+	if im.artifact == nil {
+		return ""
+	}
+	return im.artifact.Path()
+}
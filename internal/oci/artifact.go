@@ -0,0 +1,316 @@
+package oci
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// annotationUnpack is set by ORAS on layers holding a directory packed as a
+// tarball.
+const annotationUnpack = "io.deis.oras.content.unpack"
+
+const (
+	// stateFile is the file in the storage path recording the artifact
+	// currently stored on disk.
+	stateFile = "state.json"
+	// contentDir is the directory in the storage path holding the content of
+	// the artifact. It doesn't change when the artifact is updated, so that
+	// files can be watched.
+	contentDir = "content"
+)
+
+// ArtifactOptions configures an Artifact.
+type ArtifactOptions struct {
+	// Reference to the artifact, of the form
+	// [registry/]repository[:tag][@digest].
+	Reference string
+	Client    ClientOptions
+}
+
+// Artifact manages a local copy of an artifact pulled from an OCI registry
+// for the purposes of retrieving files from it.
+//
+// Each layer of the artifact is a file named by its
+// org.opencontainers.image.title annotation, as pushed by tools such as
+// ORAS. Layers holding a tarball of a directory are unpacked.
+type Artifact struct {
+	opts        ArtifactOptions
+	ref         Reference
+	client      *Client
+	storagePath string
+
+	digest digest.Digest // Digest of the manifest of the artifact on disk.
+}
+
+// state is the content of the state file.
+type state struct {
+	Reference string        `json:"reference"`
+	Digest    digest.Digest `json:"digest"`
+}
+
+// NewArtifact creates a new instance of an Artifact, where the artifact is
+// stored at storagePath.
+//
+// If the artifact was previously stored at storagePath, it's used when the
+// registry can't be reached, in which case both the Artifact and an
+// UpdateFailedError are returned. Artifacts referenced by digest aren't
+// pulled again once stored.
+func NewArtifact(ctx context.Context, storagePath string, opts ArtifactOptions) (*Artifact, error) {
+	ref, err := ParseReference(opts.Reference)
+	if err != nil {
+		return nil, DownloadFailedError{Reference: opts.Reference, Inner: err}
+	}
+
+	a := &Artifact{
+		opts:        opts,
+		ref:         ref,
+		client:      NewClient(opts.Client),
+		storagePath: storagePath,
+	}
+
+	if bb, err := os.ReadFile(filepath.Join(storagePath, stateFile)); err == nil {
+		var st state
+		if json.Unmarshal(bb, &st) == nil && st.Reference == ref.String() && st.Digest.Validate() == nil {
+			if _, err := os.Stat(a.Path()); err == nil {
+				a.digest = st.Digest
+			}
+		}
+	}
+
+	if err := a.Update(ctx); err != nil {
+		if a.digest == "" {
+			return nil, DownloadFailedError{Reference: opts.Reference, Inner: errors.Unwrap(err)}
+		}
+		return a, err
+	}
+	return a, nil
+}
+
+// Update pulls the artifact if the digest of its manifest changed since it
+// was last pulled.
+func (a *Artifact) Update(ctx context.Context) error {
+	if a.ref.Digest != "" && a.digest == a.ref.Digest {
+		// Artifacts referenced by digest are immutable.
+		return nil
+	}
+
+	desc, manifest, err := a.client.Resolve(ctx, a.ref)
+	if err != nil {
+		return UpdateFailedError{Reference: a.opts.Reference, Inner: err}
+	}
+	if desc.Digest == a.digest {
+		return nil
+	}
+
+	if err := a.pull(ctx, desc.Digest, manifest); err != nil {
+		return UpdateFailedError{Reference: a.opts.Reference, Inner: err}
+	}
+	return nil
+}
+
+// pull stores the layers of the artifact with the given manifest on disk and
+// makes it the current artifact.
+func (a *Artifact) pull(ctx context.Context, d digest.Digest, manifest ocispec.Manifest) error {
+	if err := os.MkdirAll(a.storagePath, 0o750); err != nil {
+		return err
+	}
+	tmp, err := os.MkdirTemp(a.storagePath, "pull-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	for _, layer := range manifest.Layers {
+		bb, err := a.client.FetchBlob(ctx, a.ref, layer)
+		if err != nil {
+			return err
+		}
+		if err := extractLayer(tmp, layer, bb); err != nil {
+			return fmt.Errorf("extracting layer %s: %w", layer.Digest, err)
+		}
+	}
+
+	// Swap the content directory, keeping the previous content until the
+	// new one is in place.
+	var (
+		dst = a.Path()
+		old = dst + ".old"
+	)
+	if err := os.RemoveAll(old); err != nil {
+		return err
+	}
+	if err := os.Rename(dst, old); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		return err
+	}
+	_ = os.RemoveAll(old)
+
+	bb, err := json.Marshal(state{Reference: a.ref.String(), Digest: d})
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(a.storagePath, stateFile), bb); err != nil {
+		return err
+	}
+
+	a.digest = d
+	return nil
+}
+
+// extractLayer writes the content of layer to dir.
+func extractLayer(dir string, layer ocispec.Descriptor, content []byte) error {
+	title := layer.Annotations[ocispec.AnnotationTitle]
+	if title == "" {
+		return fmt.Errorf("layer has no %s annotation", ocispec.AnnotationTitle)
+	}
+	path, err := securePath(dir, title)
+	if err != nil {
+		return err
+	}
+
+	if layer.Annotations[annotationUnpack] != "true" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			return err
+		}
+		return os.WriteFile(path, content, 0o640)
+	}
+
+	var r io.Reader = bytes.NewReader(content)
+	if strings.HasSuffix(layer.MediaType, "+gzip") {
+		gr, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer gr.Close()
+		r = gr
+	}
+	return untar(path, r)
+}
+
+// untar extracts the regular files and directories of the tarball r into
+// dir. The tarball may hold dir itself, as ORAS packs directories with their
+// name.
+func untar(dir string, r io.Reader) error {
+	var (
+		tr    = tar.NewReader(r)
+		base  = filepath.Base(dir)
+		total int64
+	)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		name := filepath.ToSlash(filepath.Clean(hdr.Name))
+		if name == base {
+			continue
+		}
+		name = strings.TrimPrefix(name, base+"/")
+
+		path, err := securePath(dir, name)
+		if err != nil {
+			return err
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, 0o750); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if total += hdr.Size; total > maxBlobSize {
+				return fmt.Errorf("unpacked content exceeds %d bytes", maxBlobSize)
+			}
+			if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+				return err
+			}
+			bb, err := readAll(tr, hdr.Size)
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(path, bb, 0o640); err != nil {
+				return err
+			}
+		default:
+			// Links and special files are ignored.
+		}
+	}
+}
+
+// securePath returns the path of name within dir, failing if it would be
+// outside of dir.
+func securePath(dir, name string) (string, error) {
+	if filepath.IsAbs(name) || !filepath.IsLocal(name) {
+		return "", fmt.Errorf("invalid path %q", name)
+	}
+	return filepath.Join(dir, name), nil
+}
+
+func writeFileAtomic(path string, bb []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, bb, 0o640); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Path returns the directory holding the content of the artifact.
+func (a *Artifact) Path() string {
+	return filepath.Join(a.storagePath, contentDir)
+}
+
+// Digest returns the digest of the manifest of the artifact.
+func (a *Artifact) Digest() digest.Digest {
+	return a.digest
+}
+
+// ReadFile returns a file from the artifact specified by path.
+func (a *Artifact) ReadFile(path string) ([]byte, error) {
+	return os.ReadFile(a.resolve(path))
+}
+
+// Stat returns info from the artifact specified by path.
+func (a *Artifact) Stat(path string) (fs.FileInfo, error) {
+	return os.Stat(a.resolve(path))
+}
+
+// ReadDir returns info about the content of the directory in the artifact.
+func (a *Artifact) ReadDir(path string) ([]fs.FileInfo, error) {
+	entries, err := os.ReadDir(a.resolve(path))
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]fs.FileInfo, 0, len(entries))
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+// resolve returns the path on disk of path in the artifact. Paths can't
+// refer to files outside of the artifact.
+func (a *Artifact) resolve(path string) string {
+	return filepath.Join(a.Path(), filepath.FromSlash(filepath.Clean("/"+path)))
+}
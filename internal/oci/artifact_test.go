@@ -0,0 +1,183 @@
+package oci_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/grafana/alloy/internal/oci"
+	"github.com/grafana/alloy/internal/oci/ocitest"
+	"github.com/grafana/alloy/syntax/alloytypes"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArtifact_Tag(t *testing.T) {
+	registry := ocitest.NewRegistry()
+	defer registry.Close()
+
+	registry.Push("modules", "v1", map[string]string{"module.alloy": "first"})
+
+	a, err := oci.NewArtifact(t.Context(), t.TempDir(), artifactOptions(registry.Host()+"/modules:v1"))
+	require.NoError(t, err)
+	requireFile(t, a, "module.alloy", "first")
+
+	// Updating without changes mustn't pull the artifact again.
+	first := a.Digest()
+	require.NoError(t, a.Update(t.Context()))
+	require.Equal(t, first, a.Digest())
+
+	registry.Push("modules", "v1", map[string]string{"module.alloy": "second"})
+	require.NoError(t, a.Update(t.Context()))
+	require.NotEqual(t, first, a.Digest())
+	requireFile(t, a, "module.alloy", "second")
+}
+
+func TestArtifact_Digest(t *testing.T) {
+	registry := ocitest.NewRegistry()
+	defer registry.Close()
+
+	d := registry.Push("modules", "", map[string]string{"module.alloy": "pinned"})
+
+	a, err := oci.NewArtifact(t.Context(), t.TempDir(), artifactOptions(registry.Host()+"/modules@"+d.String()))
+	require.NoError(t, err)
+	require.Equal(t, d, a.Digest())
+	requireFile(t, a, "module.alloy", "pinned")
+
+	// Artifacts referenced by digest are never pulled again.
+	pulls := registry.Pulls()
+	require.NoError(t, a.Update(t.Context()))
+	require.Equal(t, pulls, registry.Pulls())
+}
+
+func TestArtifact_DigestMismatch(t *testing.T) {
+	registry := ocitest.NewRegistry()
+	defer registry.Close()
+
+	layer := registry.PushBlob("text/plain", []byte("content"))
+	layer.Annotations = map[string]string{ocispec.AnnotationTitle: "module.alloy"}
+	d := registry.Push("modules", "", map[string]string{"module.alloy": "other"})
+
+	// Make the registry serve another manifest for the pinned digest.
+	registry.PushManifest("modules", d.String(), manifest(registry, layer))
+
+	_, err := oci.NewArtifact(t.Context(), t.TempDir(), artifactOptions(registry.Host()+"/modules@"+d.String()))
+	require.ErrorAs(t, err, &oci.DownloadFailedError{})
+	require.ErrorContains(t, err, "doesn't match digest")
+}
+
+func TestArtifact_CorruptBlob(t *testing.T) {
+	registry := ocitest.NewRegistry()
+	defer registry.Close()
+
+	layer := registry.PushBlob("text/plain", []byte("content"))
+	layer.Annotations = map[string]string{ocispec.AnnotationTitle: "module.alloy"}
+	registry.PushManifest("modules", "v1", manifest(registry, layer))
+	registry.CorruptBlob(layer.Digest)
+
+	_, err := oci.NewArtifact(t.Context(), t.TempDir(), artifactOptions(registry.Host()+"/modules:v1"))
+	require.ErrorContains(t, err, "doesn't match digest")
+}
+
+func TestArtifact_Offline(t *testing.T) {
+	registry := ocitest.NewRegistry()
+
+	storage := t.TempDir()
+	opts := artifactOptions(registry.Host() + "/modules:v1")
+
+	registry.Push("modules", "v1", map[string]string{"module.alloy": "cached"})
+	_, err := oci.NewArtifact(t.Context(), storage, opts)
+	require.NoError(t, err)
+
+	registry.Close()
+
+	// The cached artifact is used when the registry is unavailable.
+	a, err := oci.NewArtifact(t.Context(), storage, opts)
+	require.ErrorAs(t, err, &oci.UpdateFailedError{})
+	require.NotNil(t, a)
+	requireFile(t, a, "module.alloy", "cached")
+
+	// Nothing is cached for another reference.
+	opts.Reference = registry.Host() + "/modules:v2"
+	_, err = oci.NewArtifact(t.Context(), storage, opts)
+	require.ErrorAs(t, err, &oci.DownloadFailedError{})
+}
+
+func TestArtifact_Auth(t *testing.T) {
+	registry := ocitest.NewRegistry()
+	defer registry.Close()
+
+	registry.RequireAuth("user", "pass")
+	registry.Push("modules", "v1", map[string]string{"module.alloy": "private"})
+
+	opts := artifactOptions(registry.Host() + "/modules:v1")
+	_, err := oci.NewArtifact(t.Context(), t.TempDir(), opts)
+	require.ErrorContains(t, err, "401")
+
+	opts.Client.Auth.BasicAuth = &oci.BasicAuth{Username: "user", Password: alloytypes.Secret("pass")}
+	a, err := oci.NewArtifact(t.Context(), t.TempDir(), opts)
+	require.NoError(t, err)
+	requireFile(t, a, "module.alloy", "private")
+}
+
+func TestArtifact_Directory(t *testing.T) {
+	registry := ocitest.NewRegistry()
+	defer registry.Close()
+
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, content := range map[string]string{
+		"modules/a.alloy":     "a",
+		"modules/sub/b.alloy": "b",
+	} {
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(content)), Typeflag: tar.TypeReg}))
+		_, err := tw.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+
+	layer := registry.PushBlob(ocispec.MediaTypeImageLayer, buf.Bytes())
+	layer.Annotations = map[string]string{
+		ocispec.AnnotationTitle:       "modules",
+		"io.deis.oras.content.unpack": "true",
+	}
+	registry.PushManifest("modules", "v1", manifest(registry, layer))
+
+	a, err := oci.NewArtifact(t.Context(), t.TempDir(), artifactOptions(registry.Host()+"/modules:v1"))
+	require.NoError(t, err)
+	requireFile(t, a, "modules/a.alloy", "a")
+	requireFile(t, a, "modules/sub/b.alloy", "b")
+
+	infos, err := a.ReadDir("modules")
+	require.NoError(t, err)
+	require.Len(t, infos, 2)
+
+	// Paths can't escape the artifact.
+	_, err = a.ReadFile("../state.json")
+	require.ErrorIs(t, err, os.ErrNotExist)
+}
+
+func artifactOptions(ref string) oci.ArtifactOptions {
+	return oci.ArtifactOptions{
+		Reference: ref,
+		Client:    oci.ClientOptions{Insecure: true},
+	}
+}
+
+func manifest(registry *ocitest.Registry, layers ...ocispec.Descriptor) ocispec.Manifest {
+	m := ocispec.Manifest{
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    registry.PushBlob(ocispec.MediaTypeEmptyJSON, []byte("{}")),
+		Layers:    layers,
+	}
+	m.SchemaVersion = 2
+	return m
+}
+
+func requireFile(t *testing.T, a *oci.Artifact, path, expect string) {
+	t.Helper()
+	bb, err := a.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, expect, string(bb))
+}
@@ -0,0 +1,28 @@
+package oci
+
+import (
+	"errors"
+
+	"github.com/grafana/alloy/syntax/alloytypes"
+)
+
+// AuthConfig configures authenticating to a registry.
+type AuthConfig struct {
+	BasicAuth   *BasicAuth        `alloy:"basic_auth,block,optional"`
+	BearerToken alloytypes.Secret `alloy:"bearer_token,attr,optional"`
+}
+
+// BasicAuth holds the credentials used with registries which request basic
+// authentication, or to request tokens from their token service.
+type BasicAuth struct {
+	Username string            `alloy:"username,attr"`
+	Password alloytypes.Secret `alloy:"password,attr"`
+}
+
+// Validate checks that at most one authentication method is configured.
+func (a *AuthConfig) Validate() error {
+	if a.BasicAuth != nil && a.BearerToken != "" {
+		return errors.New("at most one of basic_auth and bearer_token can be set")
+	}
+	return nil
+}
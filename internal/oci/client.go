@@ -0,0 +1,317 @@
+package oci
+
+import (
+	"context"
+	_ "crypto/sha256" // Register SHA-256 for digests.
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	// maxManifestSize is the maximum size of manifests.
+	maxManifestSize = 4 << 20
+	// maxBlobSize is the maximum size of the layers of artifacts.
+	maxBlobSize = 64 << 20
+
+	mediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+)
+
+// ClientOptions configures a Client.
+type ClientOptions struct {
+	// Insecure uses plain HTTP instead of HTTPS to connect to registries.
+	Insecure bool
+	Auth     AuthConfig
+
+	// HTTPClient is used to send requests. http.DefaultClient is used if
+	// HTTPClient is nil.
+	HTTPClient *http.Client
+}
+
+// Client pulls artifacts from OCI registries using the OCI distribution API.
+type Client struct {
+	opts ClientOptions
+
+	mut    sync.Mutex
+	tokens map[string]string // Bearer tokens by repository.
+}
+
+// NewClient returns a new Client.
+func NewClient(opts ClientOptions) *Client {
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = http.DefaultClient
+	}
+	return &Client{opts: opts, tokens: map[string]string{}}
+}
+
+// Resolve returns the descriptor and the content of the manifest of the
+// artifact referenced by ref. If ref has a digest, the digest of the manifest
+// is verified.
+func (c *Client) Resolve(ctx context.Context, ref Reference) (ocispec.Descriptor, ocispec.Manifest, error) {
+	var manifest ocispec.Manifest
+
+	resp, err := c.get(ctx, ref, "manifests/"+ref.manifestReference(), strings.Join([]string{
+		ocispec.MediaTypeImageManifest,
+		mediaTypeDockerManifest,
+	}, ", "))
+	if err != nil {
+		return ocispec.Descriptor{}, manifest, err
+	}
+	defer resp.Body.Close()
+
+	bb, err := readAll(resp.Body, maxManifestSize)
+	if err != nil {
+		return ocispec.Descriptor{}, manifest, fmt.Errorf("reading manifest: %w", err)
+	}
+
+	desc := ocispec.Descriptor{
+		MediaType: mediaType(resp.Header.Get("Content-Type")),
+		Digest:    digest.FromBytes(bb),
+		Size:      int64(len(bb)),
+	}
+	if ref.Digest != "" {
+		if err := verify(ref.Digest, bb); err != nil {
+			return ocispec.Descriptor{}, manifest, fmt.Errorf("verifying manifest: %w", err)
+		}
+		desc.Digest = ref.Digest
+	} else if header := resp.Header.Get("Docker-Content-Digest"); header != "" {
+		d, err := digest.Parse(header)
+		if err != nil {
+			return ocispec.Descriptor{}, manifest, fmt.Errorf("invalid manifest digest %q: %w", header, err)
+		}
+		if err := verify(d, bb); err != nil {
+			return ocispec.Descriptor{}, manifest, fmt.Errorf("verifying manifest: %w", err)
+		}
+	}
+
+	if err := json.Unmarshal(bb, &manifest); err != nil {
+		return ocispec.Descriptor{}, manifest, fmt.Errorf("decoding manifest: %w", err)
+	}
+	if manifest.MediaType != "" {
+		desc.MediaType = manifest.MediaType
+	}
+	switch desc.MediaType {
+	case ocispec.MediaTypeImageManifest, mediaTypeDockerManifest, "":
+	default:
+		return ocispec.Descriptor{}, manifest, fmt.Errorf("unsupported manifest media type %q", desc.MediaType)
+	}
+	return desc, manifest, nil
+}
+
+// FetchBlob returns the content of the blob described by desc, verifying its
+// size and digest.
+func (c *Client) FetchBlob(ctx context.Context, ref Reference, desc ocispec.Descriptor) ([]byte, error) {
+	if desc.Size > maxBlobSize {
+		return nil, fmt.Errorf("blob %s is too large: %d bytes", desc.Digest, desc.Size)
+	}
+
+	resp, err := c.get(ctx, ref, "blobs/"+desc.Digest.String(), "")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	bb, err := readAll(resp.Body, desc.Size)
+	if err != nil {
+		return nil, fmt.Errorf("reading blob %s: %w", desc.Digest, err)
+	}
+	if int64(len(bb)) != desc.Size {
+		return nil, fmt.Errorf("blob %s has size %d, expected %d", desc.Digest, len(bb), desc.Size)
+	}
+	if err := verify(desc.Digest, bb); err != nil {
+		return nil, fmt.Errorf("verifying blob: %w", err)
+	}
+	return bb, nil
+}
+
+// get sends a GET request for path under the repository of ref,
+// authenticating if the registry requests it.
+func (c *Client) get(ctx context.Context, ref Reference, path, accept string) (*http.Response, error) {
+	scheme := "https"
+	if c.opts.Insecure {
+		scheme = "http"
+	}
+	u := fmt.Sprintf("%s://%s/v2/%s/%s", scheme, ref.host(), ref.Repository, path)
+
+	newRequest := func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return nil, err
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		return req, nil
+	}
+
+	req, err := newRequest()
+	if err != nil {
+		return nil, err
+	}
+	c.authorize(req, ref)
+
+	resp, err := c.opts.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+
+		if err := c.authenticate(ctx, ref, challenge); err != nil {
+			return nil, err
+		}
+		if req, err = newRequest(); err != nil {
+			return nil, err
+		}
+		c.authorize(req, ref)
+		if resp, err = c.opts.HTTPClient.Do(req); err != nil {
+			return nil, err
+		}
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status code %s from %s", resp.Status, u)
+	}
+	return resp, nil
+}
+
+// authorize sets the Authorization header of req.
+func (c *Client) authorize(req *http.Request, ref Reference) {
+	c.mut.Lock()
+	token := c.tokens[ref.Registry+"/"+ref.Repository]
+	c.mut.Unlock()
+
+	switch {
+	case c.opts.Auth.BearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+string(c.opts.Auth.BearerToken))
+	case token != "":
+		req.Header.Set("Authorization", "Bearer "+token)
+	case c.opts.Auth.BasicAuth != nil:
+		req.SetBasicAuth(c.opts.Auth.BasicAuth.Username, string(c.opts.Auth.BasicAuth.Password))
+	}
+}
+
+// authenticate handles the authentication challenge of a registry. For
+// bearer challenges, a token allowing to pull from the repository of ref is
+// requested from the token service of the registry.
+func (c *Client) authenticate(ctx context.Context, ref Reference, challenge string) error {
+	scheme, params := parseChallenge(challenge)
+	if !strings.EqualFold(scheme, "bearer") || c.opts.Auth.BearerToken != "" {
+		return fmt.Errorf("unauthorized to pull %s", ref)
+	}
+
+	realm, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return fmt.Errorf("invalid authentication realm %q", params["realm"])
+	}
+	query := realm.Query()
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	query.Set("scope", fmt.Sprintf("repository:%s:pull", ref.Repository))
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return err
+	}
+	if basic := c.opts.Auth.BasicAuth; basic != nil {
+		req.SetBasicAuth(basic.Username, string(basic.Password))
+	}
+
+	resp, err := c.opts.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("requesting token: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("requesting token: unexpected status code %s", resp.Status)
+	}
+
+	var tokenResp struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxManifestSize)).Decode(&tokenResp); err != nil {
+		return fmt.Errorf("decoding token: %w", err)
+	}
+	token := tokenResp.Token
+	if token == "" {
+		token = tokenResp.AccessToken
+	}
+	if token == "" {
+		return fmt.Errorf("token service returned no token")
+	}
+
+	c.mut.Lock()
+	c.tokens[ref.Registry+"/"+ref.Repository] = token
+	c.mut.Unlock()
+	return nil
+}
+
+// parseChallenge parses the scheme and parameters of a WWW-Authenticate
+// header, such as `Bearer realm="https://auth.example.com/token",service="registry"`.
+func parseChallenge(header string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	params := map[string]string{}
+
+	for rest = strings.TrimSpace(rest); rest != ""; rest = strings.TrimLeft(rest, ", ") {
+		key, value, ok := strings.Cut(rest, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+
+		if strings.HasPrefix(value, `"`) {
+			end := strings.Index(value[1:], `"`)
+			if end < 0 {
+				break
+			}
+			params[key] = value[1 : end+1]
+			rest = value[end+2:]
+		} else {
+			value, rest, _ = strings.Cut(value, ",")
+			params[key] = strings.TrimSpace(value)
+		}
+	}
+	return scheme, params
+}
+
+// readAll reads r, failing if it holds more than limit bytes.
+func readAll(r io.Reader, limit int64) ([]byte, error) {
+	bb, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(bb)) > limit {
+		return nil, fmt.Errorf("content exceeds %d bytes", limit)
+	}
+	return bb, nil
+}
+
+func verify(d digest.Digest, bb []byte) error {
+	if err := d.Validate(); err != nil {
+		return err
+	}
+	verifier := d.Verifier()
+	_, _ = verifier.Write(bb)
+	if !verifier.Verified() {
+		return fmt.Errorf("content doesn't match digest %s", d)
+	}
+	return nil
+}
+
+func mediaType(contentType string) string {
+	mt, _, _ := strings.Cut(contentType, ";")
+	return strings.TrimSpace(mt)
+}
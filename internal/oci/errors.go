@@ -0,0 +1,39 @@
+package oci
+
+import "fmt"
+
+// DownloadFailedError represents a failure to download an artifact which
+// isn't cached on disk.
+type DownloadFailedError struct {
+	Reference string
+	Inner     error
+}
+
+// Error returns the error string, denoting the failed artifact.
+func (err DownloadFailedError) Error() string {
+	if err.Inner == nil {
+		return fmt.Sprintf("failed to download artifact %q", err.Reference)
+	}
+	return fmt.Sprintf("failed to download artifact %q: %s", err.Reference, err.Inner)
+}
+
+// Unwrap returns the inner error. It returns nil if there is no inner error.
+func (err DownloadFailedError) Unwrap() error { return err.Inner }
+
+// UpdateFailedError represents a failure to update an artifact. The
+// previously downloaded artifact is still available.
+type UpdateFailedError struct {
+	Reference string
+	Inner     error
+}
+
+// Error returns the error string, denoting the failed artifact.
+func (err UpdateFailedError) Error() string {
+	if err.Inner == nil {
+		return fmt.Sprintf("failed to update artifact %q", err.Reference)
+	}
+	return fmt.Sprintf("failed to update artifact %q: %s", err.Reference, err.Inner)
+}
+
+// Unwrap returns the inner error. It returns nil if there is no inner error.
+func (err UpdateFailedError) Unwrap() error { return err.Inner }
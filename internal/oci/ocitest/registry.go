@@ -0,0 +1,173 @@
+// Package ocitest provides an in-memory OCI registry for tests.
+package ocitest
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// Registry is an in-memory OCI registry serving the pull endpoints of the
+// OCI distribution API over plain HTTP.
+type Registry struct {
+	server *httptest.Server
+
+	mut       sync.Mutex
+	manifests map[string][]byte // Manifests by repository and reference, joined by a colon.
+	blobs     map[digest.Digest][]byte
+	username  string
+	password  string
+	pulls     int
+}
+
+// token is the bearer token issued by registries requiring authentication.
+const token = "ocitest-token"
+
+// NewRegistry starts a new Registry. Callers must close it with Close.
+func NewRegistry() *Registry {
+	r := &Registry{
+		manifests: map[string][]byte{},
+		blobs:     map[digest.Digest][]byte{},
+	}
+	r.server = httptest.NewServer(http.HandlerFunc(r.serveHTTP))
+	return r
+}
+
+// Host returns the host and port of the registry, to use in references.
+func (r *Registry) Host() string {
+	return strings.TrimPrefix(r.server.URL, "http://")
+}
+
+// Close stops the registry.
+func (r *Registry) Close() { r.server.Close() }
+
+// RequireAuth makes the registry require bearer tokens, which are issued by
+// its token service to clients using the given basic auth credentials.
+func (r *Registry) RequireAuth(username, password string) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.username, r.password = username, password
+}
+
+// Pulls returns the number of manifests which were served.
+func (r *Registry) Pulls() int {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	return r.pulls
+}
+
+// Push pushes an artifact holding files to the repository, tagged with tag,
+// in the way ORAS pushes files. It returns the digest of the manifest.
+func (r *Registry) Push(repository, tag string, files map[string]string) digest.Digest {
+	manifest := ocispec.Manifest{
+		MediaType:    ocispec.MediaTypeImageManifest,
+		ArtifactType: "application/vnd.grafana.alloy.module",
+		Config:       r.PushBlob(ocispec.MediaTypeEmptyJSON, []byte("{}")),
+	}
+	manifest.SchemaVersion = 2
+
+	for _, name := range slices.Sorted(maps.Keys(files)) {
+		layer := r.PushBlob("application/vnd.grafana.alloy.config", []byte(files[name]))
+		layer.Annotations = map[string]string{ocispec.AnnotationTitle: name}
+		manifest.Layers = append(manifest.Layers, layer)
+	}
+	return r.PushManifest(repository, tag, manifest)
+}
+
+// PushBlob stores a blob and returns its descriptor.
+func (r *Registry) PushBlob(mediaType string, content []byte) ocispec.Descriptor {
+	d := digest.FromBytes(content)
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.blobs[d] = content
+	return ocispec.Descriptor{MediaType: mediaType, Digest: d, Size: int64(len(content))}
+}
+
+// PushManifest stores a manifest in the repository, tagged with tag if it's
+// not empty, and returns its digest.
+func (r *Registry) PushManifest(repository, tag string, manifest ocispec.Manifest) digest.Digest {
+	bb, err := json.Marshal(manifest)
+	if err != nil {
+		panic(err)
+	}
+	d := digest.FromBytes(bb)
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	r.manifests[repository+":"+d.String()] = bb
+	if tag != "" {
+		r.manifests[repository+":"+tag] = bb
+	}
+	return d
+}
+
+// CorruptBlob replaces the content of a blob without changing its digest.
+func (r *Registry) CorruptBlob(d digest.Digest) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+	bb := append([]byte(nil), r.blobs[d]...)
+	if len(bb) > 0 {
+		bb[0] ^= 0xff
+	}
+	r.blobs[d] = bb
+}
+
+func (r *Registry) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	if req.URL.Path == "/token" {
+		user, pass, ok := req.BasicAuth()
+		if !ok || user != r.username || pass != r.password {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"token": token})
+		return
+	}
+
+	if r.username != "" && req.Header.Get("Authorization") != "Bearer "+token {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="`+r.server.URL+`/token",service="ocitest"`)
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	path, ok := strings.CutPrefix(req.URL.Path, "/v2/")
+	if !ok || req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if i := strings.LastIndex(path, "/manifests/"); i >= 0 {
+		bb, ok := r.manifests[path[:i]+":"+path[i+len("/manifests/"):]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		r.pulls++
+		w.Header().Set("Content-Type", ocispec.MediaTypeImageManifest)
+		w.Header().Set("Docker-Content-Digest", digest.FromBytes(bb).String())
+		_, _ = w.Write(bb)
+		return
+	}
+
+	if i := strings.LastIndex(path, "/blobs/"); i >= 0 {
+		bb, ok := r.blobs[digest.Digest(path[i+len("/blobs/"):])]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(bb)
+		return
+	}
+
+	w.WriteHeader(http.StatusNotFound)
+}
@@ -0,0 +1,107 @@
+package oci
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+)
+
+const (
+	// defaultRegistry is the registry of references which don't name one.
+	defaultRegistry = "docker.io"
+	// defaultTag is the tag of references which set neither a tag nor a
+	// digest.
+	defaultTag = "latest"
+)
+
+var (
+	repositoryRegexp = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*$`)
+	tagRegexp        = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
+)
+
+// Reference is a reference to an artifact in an OCI registry.
+type Reference struct {
+	Registry   string
+	Repository string
+
+	// Tag and Digest identify the artifact in the repository. If Digest is
+	// set, the artifact must have this digest, regardless of Tag.
+	Tag    string
+	Digest digest.Digest
+}
+
+// ParseReference parses a reference of the form
+// [registry/]repository[:tag][@digest].
+func ParseReference(s string) (Reference, error) {
+	var ref Reference
+
+	rest := s
+	if name, dgst, ok := strings.Cut(rest, "@"); ok {
+		d, err := digest.Parse(dgst)
+		if err != nil {
+			return Reference{}, fmt.Errorf("invalid digest in reference %q: %w", s, err)
+		}
+		ref.Digest = d
+		rest = name
+	}
+
+	// The registry is the first path component if it looks like a host name.
+	if host, path, ok := strings.Cut(rest, "/"); ok && (strings.ContainsAny(host, ".:") || host == "localhost") {
+		ref.Registry = host
+		rest = path
+	} else {
+		ref.Registry = defaultRegistry
+	}
+
+	if i := strings.LastIndex(rest, ":"); i >= 0 {
+		ref.Tag = rest[i+1:]
+		rest = rest[:i]
+		if !tagRegexp.MatchString(ref.Tag) {
+			return Reference{}, fmt.Errorf("invalid tag in reference %q", s)
+		}
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = defaultTag
+	}
+
+	if ref.Registry == defaultRegistry && !strings.Contains(rest, "/") {
+		rest = "library/" + rest
+	}
+	if !repositoryRegexp.MatchString(rest) {
+		return Reference{}, fmt.Errorf("invalid repository in reference %q", s)
+	}
+	ref.Repository = rest
+
+	return ref, nil
+}
+
+// String returns the reference in the form parsed by ParseReference.
+func (ref Reference) String() string {
+	s := ref.Registry + "/" + ref.Repository
+	if ref.Tag != "" {
+		s += ":" + ref.Tag
+	}
+	if ref.Digest != "" {
+		s += "@" + ref.Digest.String()
+	}
+	return s
+}
+
+// manifestReference returns the tag or digest used to fetch the manifest of
+// the artifact.
+func (ref Reference) manifestReference() string {
+	if ref.Digest != "" {
+		return ref.Digest.String()
+	}
+	return ref.Tag
+}
+
+// host returns the host serving the registry API of the registry.
+func (ref Reference) host() string {
+	if ref.Registry == defaultRegistry {
+		return "registry-1.docker.io"
+	}
+	return ref.Registry
+}
@@ -0,0 +1,68 @@
+package oci
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReference(t *testing.T) {
+	const d = "sha256:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae"
+
+	tt := []struct {
+		input  string
+		expect Reference
+		err    string
+	}{
+		{
+			input:  "modules",
+			expect: Reference{Registry: "docker.io", Repository: "library/modules", Tag: "latest"},
+		},
+		{
+			input:  "grafana/modules:v1",
+			expect: Reference{Registry: "docker.io", Repository: "grafana/modules", Tag: "v1"},
+		},
+		{
+			input:  "ghcr.io/grafana/alloy-modules:v1.2.0",
+			expect: Reference{Registry: "ghcr.io", Repository: "grafana/alloy-modules", Tag: "v1.2.0"},
+		},
+		{
+			input:  "localhost:5000/modules@" + d,
+			expect: Reference{Registry: "localhost:5000", Repository: "modules", Digest: d},
+		},
+		{
+			input:  "localhost/modules:v1@" + d,
+			expect: Reference{Registry: "localhost", Repository: "modules", Tag: "v1", Digest: d},
+		},
+		{input: "ghcr.io/Grafana/modules", err: "invalid repository"},
+		{input: "ghcr.io/grafana/modules:", err: "invalid tag"},
+		{input: "ghcr.io/grafana/modules@sha256:abc", err: "invalid digest"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.input, func(t *testing.T) {
+			ref, err := ParseReference(tc.input)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, ref)
+
+			// References must round-trip through String.
+			again, err := ParseReference(ref.String())
+			require.NoError(t, err)
+			require.Equal(t, ref, again)
+		})
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	scheme, params := parseChallenge(`Bearer realm="https://auth.example.com/token",service="registry.example.com",scope="repository:a/b:pull"`)
+	require.Equal(t, "Bearer", scheme)
+	require.Equal(t, map[string]string{
+		"realm":   "https://auth.example.com/token",
+		"service": "registry.example.com",
+		"scope":   "repository:a/b:pull",
+	}, params)
+}
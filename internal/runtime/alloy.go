@@ -48,6 +48,7 @@ package runtime
 import (
 	"context"
 	"fmt"
+	"maps"
 	"sync"
 	"time"
 
@@ -118,6 +119,28 @@ type Options struct {
 
 	// TaskShutdownDeadline is the maximum duration to wait for a component to shut down before giving up and logging an error.
 	TaskShutdownDeadline time.Duration
+
+	// RollbackGracePeriod is how long to watch the health of components after
+	// LoadSource is called. If a component is unhealthy at the end of the
+	// grace period which was healthy or didn't exist with the last config
+	// source which didn't degrade component health, the controller reverts to
+	// that source. Automatic rollback is disabled if RollbackGracePeriod is
+	// zero.
+	RollbackGracePeriod time.Duration
+
+	// ResourceUsageInterval is how often to estimate the CPU time, heap
+	// allocations, and goroutines of each running component. Resource
+	// accounting is disabled if ResourceUsageInterval is zero.
+	//
+	// ResourceUsageInterval is ignored for modules, which share the resource
+	// accounting of their root controller.
+	ResourceUsageInterval time.Duration
+
+	// Variables are additional variables available to the expressions of the
+	// root configuration and of its declare blocks. Variables are ignored for
+	// modules. Tools which load configurations use Variables to expose their
+	// own values, such as the receivers of a test harness.
+	Variables map[string]any
 }
 
 // Runtime is the Alloy system.
@@ -130,6 +153,8 @@ type Runtime struct {
 	sched       *controller.Scheduler
 	loader      *controller.Loader
 	modules     *moduleRegistry
+	rollback    *rollbackWatcher    // nil when automatic rollback is disabled.
+	resources   *resourceAccountant // nil when resource accounting is disabled.
 
 	loadFinished chan struct{}
 
@@ -161,6 +186,9 @@ type controllerOptions struct {
 	WorkerPool worker.Pool
 	// TaskShutdownDeadline is the maximum duration to wait for a component to shut down before giving up and logging an error.
 	TaskShutdownDeadline time.Duration
+	// ResourceAccountant estimates the resource usage of components. Module
+	// controllers share the resource accountant of their root controller.
+	ResourceAccountant *resourceAccountant
 }
 
 // newController creates a new, unstarted Alloy controller with a specific
@@ -200,6 +228,15 @@ func newController(o controllerOptions) (*Runtime, error) {
 		loadFinished: make(chan struct{}, 1),
 	}
 
+	if o.RollbackGracePeriod > 0 {
+		f.rollback = newRollbackWatcher(o)
+	}
+
+	f.resources = o.ResourceAccountant
+	if !o.IsModule && o.ResourceUsageInterval > 0 {
+		f.resources = newResourceAccountant(o, logger)
+	}
+
 	serviceMap := controller.NewServiceMap(o.Services)
 
 	loader, err := controller.NewLoader(controller.LoaderOptions{
@@ -237,6 +274,7 @@ func newController(o controllerOptions) (*Runtime, error) {
 					ID:                   opts.Id,
 					ServiceMap:           serviceMap,
 					WorkerPool:           workerPool,
+					ResourceAccountant:   f.resources,
 				})
 			},
 			GetServiceData: func(name string) (any, error) {
@@ -267,12 +305,19 @@ func newController(o controllerOptions) (*Runtime, error) {
 func (f *Runtime) Run(ctx context.Context) {
 	defer func() {
 		level.Debug(f.log).Log("msg", "Alloy controller exiting")
+		if f.rollback != nil {
+			f.rollback.cancel()
+		}
 		f.loader.Cleanup(!f.opts.IsModule)
 		f.sched.Stop()
 	}()
 
 	level.Debug(f.log).Log("msg", "Running alloy controller")
 
+	if f.resources != nil && !f.opts.IsModule {
+		go f.resources.run(ctx)
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -301,20 +346,39 @@ func (f *Runtime) Run(ctx context.Context) {
 // The controller will only start running components after Load is called once
 // without any configuration errors.
 // LoadSource uses default loader configuration.
+//
+// If Options.RollbackGracePeriod is set, the controller reverts to the
+// last-known-good source when component health degrades after the reload.
 func (f *Runtime) LoadSource(source *Source, args map[string]any, configPath string) error {
+	req := loadRequest{source: source, args: args, configPath: configPath}
+	if f.rollback != nil {
+		return f.loadSourceWithRollback(req)
+	}
+	return f.loadRequest(req)
+}
+
+func (f *Runtime) loadRequest(req loadRequest) error {
+	return f.applyLoaderConfig(f.rootApplyOptions(req.source, req.args, req.configPath))
+}
+
+// rootApplyOptions returns the options to apply source to the root loader
+// with.
+func (f *Runtime) rootApplyOptions(source *Source, args map[string]any, configPath string) controller.ApplyOptions {
 	modulePath, err := util.ExtractDirPath(configPath)
 	if err != nil {
 		level.Warn(f.log).Log("msg", "failed to extract directory path from configPath", "configPath", configPath, "err", err)
 	}
-	return f.applyLoaderConfig(controller.ApplyOptions{
+	variables := make(map[string]any, len(f.opts.Variables)+1)
+	maps.Copy(variables, f.opts.Variables)
+	variables[importsource.ModulePath] = modulePath
+
+	return controller.ApplyOptions{
 		Args:            args,
 		ComponentBlocks: source.Components(),
 		ConfigBlocks:    source.Configs(),
 		DeclareBlocks:   source.Declares(),
-		ArgScope: vm.NewScope(map[string]any{
-			importsource.ModulePath: modulePath,
-		}),
-	})
+		ArgScope:        vm.NewScope(variables),
+	}
 }
 
 // Same as above but with a customComponentRegistry that provides custom component definitions.
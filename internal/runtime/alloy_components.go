@@ -129,6 +129,9 @@ func (f *Runtime) getComponentDetail(cn controller.ComponentNode, graph *dag.Gra
 		if opts.GetDebugInfo {
 			componentInfo.DebugInfo = builtinComponent.DebugInfo()
 		}
+		if opts.GetResourceUsage && f.resources != nil {
+			componentInfo.ResourceUsage = f.resources.Usage(componentInfo.ID.String())
+		}
 	}
 
 	_, liveDebuggingEnabled := componentInfo.Component.(component.LiveDebugging)
@@ -81,11 +81,13 @@ func (f *Runtime) NewController(id string) (service.Controller, error) {
 			Reg:                  f.opts.Reg,
 			Services:             f.opts.Services,
 			EnableCommunityComps: f.opts.EnableCommunityComps,
+			RollbackGracePeriod:  f.opts.RollbackGracePeriod,
 			OnExportsChange:      nil, // NOTE(@tpaschalis, @wildum) The isolated controller shouldn't be able to export any values.
 		},
-		IsModule:       true,
-		ModuleRegistry: newModuleRegistry(),
-		WorkerPool:     f.opts.WorkerPool, // NOTE(@tpaschalis) Reuse the worker pool since the worker cleanup is triggered from the root controller.
+		IsModule:           true,
+		ModuleRegistry:     newModuleRegistry(),
+		WorkerPool:         f.opts.WorkerPool, // NOTE(@tpaschalis) Reuse the worker pool since the worker cleanup is triggered from the root controller.
+		ResourceAccountant: f.resources,
 	})
 	if err != nil {
 		return ServiceController{}, fmt.Errorf("failed to create new service controller: %w", err)
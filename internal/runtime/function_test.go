@@ -0,0 +1,373 @@
+package runtime_test
+
+import (
+	"context"
+	"io"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/runtime"
+	"github.com/grafana/alloy/internal/runtime/internal/testcomponents"
+	"github.com/grafana/alloy/internal/runtime/logging"
+	"github.com/grafana/alloy/internal/service"
+)
+
+func TestFunction(t *testing.T) {
+	tt := []testCase{
+		{
+			name: "LocalFunction",
+			config: `
+			function "double" {
+				args   = ["x"]
+				result = x * 2
+			}
+			testcomponents.count "inc" {
+				frequency = "10ms"
+				max = 10
+			}
+			testcomponents.summation "sum" {
+				input = double(testcomponents.count.inc.count)
+			}
+			`,
+			expected: 20,
+		},
+		{
+			name: "FunctionCallingFunction",
+			config: `
+			function "double" {
+				args   = ["x"]
+				result = x * 2
+			}
+			function "quadruple" {
+				args   = ["x"]
+				result = double(double(x))
+			}
+			testcomponents.count "inc" {
+				frequency = "10ms"
+				max = 10
+			}
+			testcomponents.summation "sum" {
+				input = quadruple(testcomponents.count.inc.count)
+			}
+			`,
+			expected: 40,
+		},
+		{
+			name: "FunctionInDeclare",
+			config: `
+			function "double" {
+				args   = ["x"]
+				result = x * 2
+			}
+			declare "test" {
+				argument "input" {
+					optional = false
+				}
+				function "increment" {
+					args   = ["x"]
+					result = x + 1
+				}
+				export "output" {
+					value = increment(double(argument.input.value))
+				}
+			}
+			testcomponents.count "inc" {
+				frequency = "10ms"
+				max = 10
+			}
+			test "myModule" {
+				input = testcomponents.count.inc.count
+			}
+			testcomponents.summation "sum" {
+				input = test.myModule.output
+			}
+			`,
+			expected: 21,
+		},
+		{
+			name: "ImportedFunction",
+			config: `
+			import.string "utils" {
+				content = ` + "`" + `
+					function "triple" {
+						args   = ["x"]
+						result = add(x, add(x, x))
+					}
+					function "add" {
+						args   = ["a", "b"]
+						result = a + b
+					}
+				` + "`" + `
+			}
+			testcomponents.count "inc" {
+				frequency = "10ms"
+				max = 10
+			}
+			testcomponents.summation "sum" {
+				input = utils.triple(testcomponents.count.inc.count)
+			}
+			`,
+			expected: 30,
+		},
+		{
+			name: "ImportedFunctionInImportedDeclare",
+			config: `
+			import.string "utils" {
+				content = ` + "`" + `
+					function "double" {
+						args   = ["x"]
+						result = x * 2
+					}
+					declare "test" {
+						argument "input" {
+							optional = false
+						}
+						export "output" {
+							value = double(argument.input.value)
+						}
+					}
+				` + "`" + `
+			}
+			testcomponents.count "inc" {
+				frequency = "10ms"
+				max = 10
+			}
+			utils.test "myModule" {
+				input = testcomponents.count.inc.count
+			}
+			testcomponents.summation "sum" {
+				input = utils.test.myModule.output
+			}
+			`,
+			expected: 20,
+		},
+		{
+			name: "LambdaInComponent",
+			config: `
+			function "scale" {
+				args   = ["list", "factor"]
+				result = array.map(list, (x) => x * factor)
+			}
+			testcomponents.count "inc" {
+				frequency = "10ms"
+				max = 10
+			}
+			testcomponents.summation "sum" {
+				input = array.map(scale([testcomponents.count.inc.count], 3), (x) => x + 1)[0]
+			}
+			`,
+			expected: 31,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl, err := runtime.New(functionTestOptions(t, featuregate.StabilityExperimental))
+			require.NoError(t, err)
+			f, err := runtime.ParseSource(t.Name(), []byte(tc.config))
+			require.NoError(t, err)
+			require.NotNil(t, f)
+
+			err = ctrl.LoadSource(f, nil, "")
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithCancel(t.Context())
+			done := make(chan struct{})
+			go func() {
+				ctrl.Run(ctx)
+				close(done)
+			}()
+			defer func() {
+				cancel()
+				<-done
+			}()
+
+			require.Eventually(t, func() bool {
+				return ctrl.LoadComplete()
+			}, 3*time.Second, 10*time.Millisecond)
+
+			require.Eventually(t, func() bool {
+				export := getExport[testcomponents.SummationExports](t, ctrl, "", "testcomponents.summation.sum")
+				return export.LastAdded == tc.expected
+			}, 3*time.Second, 10*time.Millisecond)
+		})
+	}
+}
+
+func TestFunctionError(t *testing.T) {
+	tt := []errorTestCase{
+		{
+			name: "SelfReference",
+			config: `
+			function "f" {
+				args   = ["x"]
+				result = f(x)
+			}
+			`,
+			expectedError: regexp.MustCompile(`self reference: function\.f`),
+		},
+		{
+			name: "CircleDependencyBetweenFunctions",
+			config: `
+			function "a" {
+				args   = ["x"]
+				result = b(x)
+			}
+			function "b" {
+				args   = ["x"]
+				result = a(x)
+			}
+			`,
+			expectedError: regexp.MustCompile(`cycle: function\.(a|b), function\.(a|b)`),
+		},
+		{
+			name: "UnknownIdentifier",
+			config: `
+			testcomponents.count "inc" {
+				frequency = "10ms"
+				max = 10
+			}
+			function "f" {
+				args   = ["x"]
+				result = x + testcomponents.count.inc.count
+			}
+			`,
+			expectedError: regexp.MustCompile(`identifier "testcomponents" is neither a parameter of function "f" nor a function in scope`),
+		},
+		{
+			name: "ConflictWithImport",
+			config: `
+			import.string "utils" {
+				content = ` + "`" + `
+					function "f" {
+						result = 1
+					}
+				` + "`" + `
+			}
+			function "utils" {
+				result = 1
+			}
+			`,
+			expectedError: regexp.MustCompile(`function "utils" conflicts with the namespace of an import block with the same label`),
+		},
+		{
+			name: "ConflictWithComponent",
+			config: `
+			testcomponents.count "inc" {
+				frequency = "10ms"
+				max = 10
+			}
+			function "testcomponents" {
+				result = 1
+			}
+			`,
+			expectedError: regexp.MustCompile(`function "testcomponents" conflicts with the namespace of components with the same name`),
+		},
+		{
+			name: "ConflictWithCustomComponent",
+			config: `
+			declare "answer" {
+				export "value" {
+					value = 42
+				}
+			}
+			answer "default" {}
+			function "answer" {
+				result = 1
+			}
+			`,
+			expectedError: regexp.MustCompile(`function "answer" conflicts with the namespace of components with the same name`),
+		},
+		{
+			name: "ConflictWithArgument",
+			config: `
+			declare "answer" {
+				argument "value" {}
+				function "argument" {
+					result = 1
+				}
+			}
+			answer "default" {
+				value = 1
+			}
+			`,
+			expectedError: regexp.MustCompile(`function "argument" conflicts with the namespace of module arguments`),
+		},
+		{
+			name: "ConflictWithStdlib",
+			config: `
+			function "string" {
+				result = 1
+			}
+			`,
+			expectedError: regexp.MustCompile(`function "string" conflicts with a standard library identifier with the same name`),
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl, err := runtime.New(functionTestOptions(t, featuregate.StabilityExperimental))
+			require.NoError(t, err)
+			f, err := runtime.ParseSource(t.Name(), []byte(tc.config))
+			require.NoError(t, err)
+			require.NotNil(t, f)
+
+			err = ctrl.LoadSource(f, nil, "")
+			if err == nil {
+				t.Errorf("Expected error to match regex %q, but got: nil", tc.expectedError)
+			} else if !tc.expectedError.MatchString(err.Error()) {
+				t.Errorf("Expected error to match regex %q, but got: %v", tc.expectedError, err)
+			}
+
+			ctx, cancel := context.WithCancel(t.Context())
+			done := make(chan struct{})
+			go func() {
+				ctrl.Run(ctx)
+				close(done)
+			}()
+			cancel()
+			<-done
+		})
+	}
+}
+
+func TestFunctionStability(t *testing.T) {
+	ctrl, err := runtime.New(functionTestOptions(t, featuregate.StabilityPublicPreview))
+	require.NoError(t, err)
+	f, err := runtime.ParseSource(t.Name(), []byte(`
+		function "f" {
+			result = 1
+		}
+	`))
+	require.NoError(t, err)
+
+	err = ctrl.LoadSource(f, nil, "")
+	require.ErrorContains(t, err, `config block "function" is at stability level "experimental"`)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		ctrl.Run(ctx)
+		close(done)
+	}()
+	cancel()
+	<-done
+}
+
+func functionTestOptions(t *testing.T, stability featuregate.Stability) runtime.Options {
+	t.Helper()
+	s, err := logging.New(io.Discard, logging.DefaultOptions)
+	require.NoError(t, err)
+
+	return runtime.Options{
+		Logger:       s,
+		DataPath:     t.TempDir(),
+		MinStability: stability,
+		Reg:          nil,
+		Services:     []service.Service{},
+	}
+}
@@ -0,0 +1,155 @@
+package runtime_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/oci/ocitest"
+	alloy_runtime "github.com/grafana/alloy/internal/runtime"
+	"github.com/stretchr/testify/require"
+)
+
+const ociModule = `declare "add" {
+	argument "a" {}
+	argument "b" {}
+
+	export "sum" {
+		value = argument.a.value + argument.b.value
+	}
+}`
+
+const ociModuleMore = `declare "add" {
+	argument "a" {}
+	argument "b" {}
+
+	export "sum" {
+		value = argument.a.value + argument.b.value + 1
+	}
+}`
+
+func TestImportOCI(t *testing.T) {
+	defer verifyNoGoroutineLeaks(t)
+	registry := ocitest.NewRegistry()
+	defer registry.Close()
+
+	registry.Push("modules/math", "v1", map[string]string{"math.alloy": ociModule})
+
+	main := `
+import.oci "testImport" {
+	reference      = "` + registry.Host() + `/modules/math:v1"
+	path           = "math.alloy"
+	insecure       = true
+	pull_frequency = "100ms"
+}
+
+testImport.add "cc" {
+	a = 1
+	b = 1
+}
+`
+	ctrl, cancel := runImportOCI(t, main)
+	defer cancel()
+
+	require.Eventually(t, func() bool {
+		export := getExport[map[string]any](t, ctrl, "", "testImport.add.cc")
+		return export["sum"] == 2
+	}, 5*time.Second, 100*time.Millisecond)
+
+	registry.Push("modules/math", "v1", map[string]string{"math.alloy": ociModuleMore})
+
+	require.Eventually(t, func() bool {
+		export := getExport[map[string]any](t, ctrl, "", "testImport.add.cc")
+		return export["sum"] == 3
+	}, 5*time.Second, 100*time.Millisecond)
+}
+
+func TestImportOCI_Nested(t *testing.T) {
+	defer verifyNoGoroutineLeaks(t)
+	registry := ocitest.NewRegistry()
+	defer registry.Close()
+
+	d := registry.Push("modules/math", "", map[string]string{
+		"main.alloy": `
+import.file "math" {
+	filename = file.path_join(module_path, "lib/math.alloy")
+}
+
+declare "add" {
+	argument "a" {}
+	argument "b" {}
+
+	math.add "inner" {
+		a = argument.a.value
+		b = argument.b.value
+	}
+
+	export "sum" {
+		value = math.add.inner.sum
+	}
+}`,
+		"lib/math.alloy": ociModule,
+	})
+
+	main := `
+import.oci "testImport" {
+	reference = "` + registry.Host() + `/modules/math@` + d.String() + `"
+	path      = "main.alloy"
+	insecure  = true
+}
+
+testImport.add "cc" {
+	a = 2
+	b = 3
+}
+`
+	ctrl, cancel := runImportOCI(t, main)
+	defer cancel()
+
+	require.Eventually(t, func() bool {
+		export := getExport[map[string]any](t, ctrl, "", "testImport.add.cc")
+		return export["sum"] == 5
+	}, 5*time.Second, 100*time.Millisecond)
+}
+
+func TestImportOCI_Stability(t *testing.T) {
+	main := `
+import.oci "testImport" {
+	reference = "localhost/modules/math:v1"
+}
+`
+	defer verifyNoGoroutineLeaks(t)
+	ctrl, f := setup(t, main, nil, featuregate.StabilityPublicPreview)
+	err := ctrl.LoadSource(f, nil, "")
+
+	// Running the controller with a canceled context shuts it down.
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+	ctrl.Run(ctx)
+
+	require.ErrorContains(t, err, `config block "import.oci" is at stability level "experimental"`)
+}
+
+// runImportOCI loads and runs main with experimental features enabled.
+func runImportOCI(t *testing.T, main string) (*alloy_runtime.Runtime, func()) {
+	t.Helper()
+
+	ctrl, f := setup(t, main, nil, featuregate.StabilityExperimental)
+	require.NoError(t, ctrl.LoadSource(f, nil, ""))
+
+	ctx, cancel := context.WithCancel(t.Context())
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ctrl.Run(ctx)
+	}()
+
+	return ctrl, func() {
+		cancel()
+		wg.Wait()
+	}
+}
@@ -11,23 +11,26 @@ import (
 // CustomComponentRegistry holds custom component definitions that are available in the context.
 // The definitions are either imported, declared locally, or declared in a parent registry.
 // Imported definitions are stored inside of the corresponding import registry.
+// Functions follow the same rules and are exposed to the custom components through the scope.
 type CustomComponentRegistry struct {
 	parent *CustomComponentRegistry // nil if root config
 
-	mut      sync.RWMutex
-	scope    *vm.Scope
-	imports  map[string]*CustomComponentRegistry // importNamespace: importScope
-	declares map[string]ast.Body                 // customComponentName: template
+	mut       sync.RWMutex
+	scope     *vm.Scope
+	imports   map[string]*CustomComponentRegistry // importNamespace: importScope
+	declares  map[string]ast.Body                 // customComponentName: template
+	functions map[string]any                      // functionName or importNamespace: function value or map of function values
 }
 
 // NewCustomComponentRegistry creates a new CustomComponentRegistry with a parent.
 // parent can be nil.
 func NewCustomComponentRegistry(parent *CustomComponentRegistry, scope *vm.Scope) *CustomComponentRegistry {
 	return &CustomComponentRegistry{
-		parent:   parent,
-		scope:    scope,
-		declares: make(map[string]ast.Body),
-		imports:  make(map[string]*CustomComponentRegistry),
+		parent:    parent,
+		scope:     scope,
+		declares:  make(map[string]ast.Body),
+		imports:   make(map[string]*CustomComponentRegistry),
+		functions: make(map[string]any),
 	}
 }
 
@@ -45,10 +48,64 @@ func (s *CustomComponentRegistry) getImport(name string) (*CustomComponentRegist
 	return im, ok
 }
 
+// Scope returns the scope for the custom components of the registry. The
+// functions available in the registry are added to the variables of the scope.
 func (s *CustomComponentRegistry) Scope() *vm.Scope {
+	functions := s.Functions()
+
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	if len(functions) == 0 {
+		return s.scope
+	}
+
+	vars := make(map[string]any, len(functions))
+	if s.scope != nil {
+		for k, v := range s.scope.Variables {
+			vars[k] = v
+		}
+	}
+	for k, v := range functions {
+		vars[k] = v
+	}
+	return vm.NewScope(vars)
+}
+
+// Functions returns the functions available in the registry, including the
+// functions of the parent registries. Functions of a registry shadow the
+// functions of its parents with the same name.
+func (s *CustomComponentRegistry) Functions() map[string]any {
+	var functions map[string]any
+	if s.parent != nil {
+		functions = s.parent.Functions()
+	} else {
+		functions = make(map[string]any)
+	}
+
 	s.mut.RLock()
 	defer s.mut.RUnlock()
-	return s.scope
+	for k, v := range s.functions {
+		functions[k] = v
+	}
+	return functions
+}
+
+func (s *CustomComponentRegistry) getFunction(name string) (any, bool) {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	fn, ok := s.functions[name]
+	return fn, ok
+}
+
+// registerFunction stores a local function. A nil value removes the function.
+func (s *CustomComponentRegistry) registerFunction(name string, value any) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if value == nil {
+		delete(s.functions, name)
+		return
+	}
+	s.functions[name] = value
 }
 
 // registerDeclare stores a local declare block.
@@ -80,8 +137,14 @@ func (s *CustomComponentRegistry) updateImportContent(importNode *ImportConfigNo
 	}
 	importScope := NewCustomComponentRegistry(nil, importNode.Scope())
 	importScope.declares = importNode.ImportedDeclares()
+	importScope.functions = importNode.ImportedFunctions()
 	importScope.updateImportContentChildren(importNode)
 	s.imports[importNode.label] = importScope
+	if len(importScope.functions) > 0 {
+		s.functions[importNode.label] = importScope.functions
+	} else {
+		delete(s.functions, importNode.label)
+	}
 }
 
 // updateImportContentChildren recurse through the children of an import node
@@ -90,6 +153,7 @@ func (s *CustomComponentRegistry) updateImportContentChildren(importNode *Import
 	for _, child := range importNode.ImportConfigNodesChildren() {
 		childScope := NewCustomComponentRegistry(nil, child.Scope())
 		childScope.declares = child.ImportedDeclares()
+		childScope.functions = child.ImportedFunctions()
 		childScope.updateImportContentChildren(child)
 		s.imports[child.label] = childScope
 	}
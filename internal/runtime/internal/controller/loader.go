@@ -56,6 +56,7 @@ type Loader struct {
 	declareNodes         map[string]*DeclareNode
 	importConfigNodes    map[string]*ImportConfigNode
 	forEachNodes         map[string]*ForeachConfigNode
+	functionNodes        map[string]*FunctionConfigNode
 	serviceNodes         []*ServiceNode
 	cache                *valueCache
 	blocks               []*ast.BlockStmt // Most recently loaded blocks, used for writing
@@ -208,6 +209,7 @@ func (l *Loader) Apply(options ApplyOptions) diag.Diagnostics {
 	}()
 
 	l.cache.ClearModuleExports()
+	l.cache.ClearFunctions()
 
 	// Evaluate all the components.
 	_ = dag.WalkTopological(&newGraph, newGraph.Leaves(), func(n dag.Node) error {
@@ -334,7 +336,7 @@ func (l *Loader) loadNewGraph(args map[string]any, componentBlocks []*ast.BlockS
 	diags = append(diags, declareDiags...)
 
 	// Fill our graph with config blocks.
-	configBlockDiags := l.populateConfigBlockNodes(args, &g, configBlocks)
+	configBlockDiags := l.populateConfigBlockNodes(args, componentNamespaces(componentBlocks), &g, configBlocks)
 	diags = append(diags, configBlockDiags...)
 
 	// Fill our graph with components.
@@ -512,8 +514,18 @@ func (l *Loader) populateServiceNodes(g *dag.Graph, serviceBlocks []*ast.BlockSt
 	return diags
 }
 
+// componentNamespaces returns the first part of the names of the component
+// blocks, under which the exports of the components are available.
+func componentNamespaces(componentBlocks []*ast.BlockStmt) map[string]struct{} {
+	namespaces := make(map[string]struct{}, len(componentBlocks))
+	for _, block := range componentBlocks {
+		namespaces[block.Name[0]] = struct{}{}
+	}
+	return namespaces
+}
+
 // populateConfigBlockNodes adds any config blocks to the graph.
-func (l *Loader) populateConfigBlockNodes(args map[string]any, g *dag.Graph, configBlocks []*ast.BlockStmt) diag.Diagnostics {
+func (l *Loader) populateConfigBlockNodes(args map[string]any, componentNamespaces map[string]struct{}, g *dag.Graph, configBlocks []*ast.BlockStmt) diag.Diagnostics {
 	var (
 		diags    diag.Diagnostics
 		nodeMap  = NewConfigNodeMap()
@@ -556,7 +568,7 @@ func (l *Loader) populateConfigBlockNodes(args map[string]any, g *dag.Graph, con
 		g.Add(node)
 	}
 
-	validateDiags := nodeMap.Validate(!l.isRootController(), args)
+	validateDiags := nodeMap.Validate(!l.isRootController(), args, componentNamespaces)
 	diags = append(diags, validateDiags...)
 
 	// If a logging config block is not provided, we create an empty node which uses defaults.
@@ -573,6 +585,7 @@ func (l *Loader) populateConfigBlockNodes(args map[string]any, g *dag.Graph, con
 
 	l.importConfigNodes = nodeMap.importMap
 	l.forEachNodes = nodeMap.foreachMap
+	l.functionNodes = nodeMap.functionMap
 
 	return diags
 }
@@ -653,8 +666,24 @@ func (l *Loader) wireGraphEdges(g *dag.Graph) diag.Diagnostics {
 			for ref := range refs {
 				g.AddEdge(dag.Edge{From: n, To: ref})
 			}
+			// Functions used in the declare block must be built before the
+			// custom components using it.
+			for ref := range l.findFunctionReferences(n.Block()) {
+				g.AddEdge(dag.Edge{From: n, To: ref})
+			}
 			// skip here because for now Declare nodes can't reference component nodes.
 			continue
+		case *FunctionConfigNode:
+			// Functions can only reference other functions. Wiring them
+			// detects recursive functions as cycles in the graph.
+			for _, name := range functionReferences(n.Block()) {
+				if fn, ok := l.functionNodes[name]; ok {
+					g.AddEdge(dag.Edge{From: n, To: fn})
+				} else if importNode, ok := l.importConfigNodes[name]; ok {
+					g.AddEdge(dag.Edge{From: n, To: importNode})
+				}
+			}
+			continue
 		case *CustomComponentNode:
 			l.wireCustomComponentNode(g, n)
 		case *ForeachConfigNode:
@@ -663,7 +692,7 @@ func (l *Loader) wireGraphEdges(g *dag.Graph) diag.Diagnostics {
 
 		// Finally, wire component references.
 		l.cache.mut.RLock()
-		refs, nodeDiags := ComponentReferences(n, g, l.log, l.cache.GetContext(), l.globals.MinStability)
+		refs, nodeDiags := ComponentReferences(n, l.functionGraph(g), l.log, l.cache.GetContext(), l.globals.MinStability)
 		l.cache.mut.RUnlock()
 		setDataFlowEdges(n, refs)
 		for _, ref := range refs {
@@ -688,6 +717,10 @@ func (l *Loader) wireCustomComponentNode(g *dag.Graph, cc *CustomComponentNode)
 			// add edges between the custom component and declare/import nodes.
 			g.AddEdge(dag.Edge{From: cc, To: ref})
 		}
+		for ref := range l.findFunctionReferences(declare.Block()) {
+			// add edges between the custom component and the functions it uses.
+			g.AddEdge(dag.Edge{From: cc, To: ref})
+		}
 	}
 }
 
@@ -773,7 +806,7 @@ func (l *Loader) EvaluateDependants(ctx context.Context, updatedNodes []*QueuedN
 			}
 		case *ImportConfigNode:
 			// Update the scope with the imported content.
-			l.componentNodeManager.customComponentReg.updateImportContent(parentNode)
+			l.updateImportContent(parentNode)
 		}
 		// We collect all nodes directly incoming to parent.
 		_ = dag.WalkIncomingNodes(l.graph, parent.Node, func(n dag.Node) error {
@@ -862,7 +895,7 @@ func (l *Loader) concurrentEvalFn(n dag.Node, spanCtx context.Context, tracer tr
 
 		// RLock before evaluate to prevent Evaluating while the config is being reloaded
 		l.mut.RLock()
-		ectx := l.cache.GetContext()
+		ectx := l.evaluationScope(n)
 		evalErr := n.Evaluate(ectx)
 
 		err = l.postEvaluate(l.log, n, evalErr)
@@ -898,11 +931,21 @@ func (l *Loader) concurrentEvalFn(n dag.Node, spanCtx context.Context, tracer tr
 // evaluate constructs the final context for the BlockNode and
 // evaluates it. mut must be held when calling evaluate.
 func (l *Loader) evaluate(logger log.Logger, bn BlockNode) error {
-	ectx := l.cache.GetContext()
+	ectx := l.evaluationScope(bn)
 	err := bn.Evaluate(ectx)
 	return l.postEvaluate(logger, bn, err)
 }
 
+// evaluationScope returns the scope to evaluate the BlockNode with. Function
+// blocks are evaluated with a scope containing only the functions available
+// in the module so that functions stay pure.
+func (l *Loader) evaluationScope(bn BlockNode) *vm.Scope {
+	if _, ok := bn.(*FunctionConfigNode); ok {
+		return vm.NewScope(l.componentNodeManager.customComponentReg.Functions())
+	}
+	return l.cache.GetContext()
+}
+
 // postEvaluate is called after a node has been evaluated. It updates the caches and logs any errors.
 // mut must be held when calling postEvaluate.
 // The evaluation err is passed as an argument to allow shadowing it with an error that could be more relevant to the user
@@ -934,7 +977,12 @@ func (l *Loader) postEvaluate(logger log.Logger, bn BlockNode, err error) error
 			}
 		}
 	case *ImportConfigNode:
-		l.componentNodeManager.customComponentReg.updateImportContent(c)
+		l.updateImportContent(c)
+	case *FunctionConfigNode:
+		// A function which failed to evaluate is removed so that calling it
+		// fails instead of calling an outdated definition.
+		l.componentNodeManager.customComponentReg.registerFunction(c.Label(), c.Value())
+		l.cache.CacheFunction(c.Label(), c.Value())
 	}
 
 	if err != nil {
@@ -956,6 +1004,15 @@ func multierrToDiags(errors error) diag.Diagnostics {
 	return diags
 }
 
+// updateImportContent updates the custom component registry and the cached
+// functions with the content of the import node.
+func (l *Loader) updateImportContent(in *ImportConfigNode) {
+	reg := l.componentNodeManager.customComponentReg
+	reg.updateImportContent(in)
+	functions, _ := reg.getFunction(in.Label())
+	l.cache.CacheFunction(in.Label(), functions)
+}
+
 // isRootController returns true if the loader is for the root Alloy controller.
 func (l *Loader) isRootController() bool {
 	return l.globals.ControllerID == ""
@@ -994,6 +1051,65 @@ func (l *Loader) collectCustomComponentReferences(stmts ast.Body, uniqueReferenc
 	}
 }
 
+// findFunctionReferences returns references to function nodes and to the
+// import nodes of imported functions in a block.
+func (l *Loader) findFunctionReferences(block *ast.BlockStmt) map[BlockNode]struct{} {
+	uniqueReferences := make(map[BlockNode]struct{})
+	for _, t := range astutil.TraversalsFromBody(block.Body) {
+		if fn, ok := l.functionNodes[t[0].Name]; ok {
+			uniqueReferences[fn] = struct{}{}
+		} else if importNode, ok := l.importConfigNodes[t[0].Name]; ok {
+			uniqueReferences[importNode] = struct{}{}
+		}
+	}
+	return uniqueReferences
+}
+
+// functionGraph wraps g so that references to functions and to the namespaces
+// of imported functions resolve to their nodes.
+func (l *Loader) functionGraph(g *dag.Graph) astutil.Graph {
+	return functionGraph{
+		Graph:     g,
+		functions: l.functionNodes,
+		imports:   l.importConfigNodes,
+	}
+}
+
+type functionGraph struct {
+	*dag.Graph
+	functions map[string]*FunctionConfigNode
+	imports   map[string]*ImportConfigNode
+}
+
+// GetByID returns the node with the given ID. If there's no such node, the ID
+// is looked up as the name of a function or as a function of an import
+// namespace.
+//
+// Custom components instantiated from an import share its namespace, so the
+// import node is only returned when no node of the graph has id as a prefix.
+// This lets longer references such as "ns.declare.label.export" resolve to
+// the custom component rather than to the import node.
+func (g functionGraph) GetByID(id string) dag.Node {
+	if n := g.Graph.GetByID(id); n != nil {
+		return n
+	}
+	if fn, ok := g.functions[id]; ok {
+		return fn
+	}
+
+	namespace, _, _ := strings.Cut(id, ".")
+	importNode, ok := g.imports[namespace]
+	if !ok {
+		return nil
+	}
+	for _, n := range g.Graph.Nodes() {
+		if strings.HasPrefix(n.NodeID(), id+".") {
+			return nil
+		}
+	}
+	return importNode
+}
+
 func splitPath(id string) (string, string) {
 	parent, id := path.Split(id)
 	parent, _ = strings.CutSuffix(parent, "/")
@@ -8,6 +8,7 @@ import (
 	"path"
 	"path/filepath"
 	"reflect"
+	"runtime/pprof"
 	"strings"
 	"sync"
 	"time"
@@ -329,7 +330,13 @@ func (cn *BuiltinComponentNode) Run(ctx context.Context) error {
 	}
 
 	cn.setRunHealth(component.HealthTypeHealthy, "started component")
-	err := cn.managed.Run(ctx)
+
+	// Label the goroutines of the component so that profiles can be filtered
+	// by component. Goroutines started by the component inherit the label.
+	var err error
+	pprof.Do(ctx, pprof.Labels(component.ProfileLabel, cn.globalID), func(ctx context.Context) {
+		err = managed.Run(ctx)
+	})
 
 	// Note: logging of this error is handled by the scheduler.
 	if err != nil {
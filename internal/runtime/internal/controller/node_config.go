@@ -5,9 +5,11 @@ import (
 
 	"github.com/grafana/alloy/internal/featuregate"
 	"github.com/grafana/alloy/internal/nodeconf/foreach"
+	"github.com/grafana/alloy/internal/nodeconf/function"
 	"github.com/grafana/alloy/internal/nodeconf/importsource"
 	"github.com/grafana/alloy/syntax/ast"
 	"github.com/grafana/alloy/syntax/diag"
+	"github.com/grafana/alloy/syntax/vm"
 )
 
 const (
@@ -19,7 +21,9 @@ const (
 
 // Add config blocks that are not GA. Config blocks that are not specified here are considered GA.
 var configBlocksUnstable = map[string]featuregate.Stability{
-	foreach.BlockName: foreach.StabilityLevel,
+	foreach.BlockName:         foreach.StabilityLevel,
+	function.BlockName:        function.StabilityLevel,
+	importsource.BlockNameOCI: importsource.OCIStabilityLevel,
 }
 
 // NewConfigNode creates a new ConfigNode from an initial ast.BlockStmt.
@@ -46,10 +50,12 @@ func NewConfigNode(block *ast.BlockStmt, globals ComponentGlobals, customReg *Cu
 		return NewLoggingConfigNode(block, globals), nil
 	case tracingBlockID:
 		return NewTracingConfigNode(block, globals), nil
-	case importsource.BlockNameFile, importsource.BlockNameString, importsource.BlockNameHTTP, importsource.BlockNameGit:
+	case importsource.BlockNameFile, importsource.BlockNameString, importsource.BlockNameHTTP, importsource.BlockNameGit, importsource.BlockNameOCI:
 		return NewImportConfigNode(block, globals, importsource.GetSourceType(block.GetBlockName())), nil
 	case foreach.BlockName:
 		return NewForeachConfigNode(block, globals, customReg), nil
+	case function.BlockName:
+		return NewFunctionConfigNode(block, globals), nil
 	default:
 		diags.Add(diag.Diagnostic{
 			Severity: diag.SeverityLevelError,
@@ -79,6 +85,7 @@ type ConfigNodeMap struct {
 	exportMap   map[string]*ExportConfigNode
 	importMap   map[string]*ImportConfigNode
 	foreachMap  map[string]*ForeachConfigNode
+	functionMap map[string]*FunctionConfigNode
 }
 
 // NewConfigNodeMap will create an initial ConfigNodeMap. Append must be called
@@ -91,6 +98,7 @@ func NewConfigNodeMap() *ConfigNodeMap {
 		exportMap:   map[string]*ExportConfigNode{},
 		importMap:   map[string]*ImportConfigNode{},
 		foreachMap:  map[string]*ForeachConfigNode{},
+		functionMap: map[string]*FunctionConfigNode{},
 	}
 }
 
@@ -112,6 +120,8 @@ func (nodeMap *ConfigNodeMap) Append(configNode BlockNode) diag.Diagnostics {
 		nodeMap.importMap[n.Label()] = n
 	case *ForeachConfigNode:
 		nodeMap.foreachMap[n.Label()] = n
+	case *FunctionConfigNode:
+		nodeMap.functionMap[n.Label()] = n
 	default:
 		diags.Add(diag.Diagnostic{
 			Severity: diag.SeverityLevelError,
@@ -124,8 +134,9 @@ func (nodeMap *ConfigNodeMap) Append(configNode BlockNode) diag.Diagnostics {
 	return diags
 }
 
-// Validate wraps all validators for ConfigNodeMap.
-func (nodeMap *ConfigNodeMap) Validate(isInModule bool, args map[string]any) diag.Diagnostics {
+// Validate wraps all validators for ConfigNodeMap. componentNamespaces holds
+// the first part of the names of the components in the same module.
+func (nodeMap *ConfigNodeMap) Validate(isInModule bool, args map[string]any, componentNamespaces map[string]struct{}) diag.Diagnostics {
 	var diags diag.Diagnostics
 
 	newDiags := nodeMap.ValidateModuleConstraints(isInModule)
@@ -134,6 +145,9 @@ func (nodeMap *ConfigNodeMap) Validate(isInModule bool, args map[string]any) dia
 	newDiags = nodeMap.ValidateUnsupportedArguments(args)
 	diags = append(diags, newDiags...)
 
+	newDiags = nodeMap.ValidateFunctionNames(componentNamespaces)
+	diags = append(diags, newDiags...)
+
 	return diags
 }
 
@@ -201,3 +215,33 @@ func (nodeMap *ConfigNodeMap) ValidateUnsupportedArguments(args map[string]any)
 
 	return diags
 }
+
+// ValidateFunctionNames will make sure function names don't conflict with the
+// namespaces of imported functions, the namespaces of components, the module
+// arguments or the standard library.
+func (nodeMap *ConfigNodeMap) ValidateFunctionNames(componentNamespaces map[string]struct{}) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	for label, fn := range nodeMap.functionMap {
+		var conflict string
+		if _, found := nodeMap.importMap[label]; found {
+			conflict = "the namespace of an import block with the same label"
+		} else if _, found := componentNamespaces[label]; found {
+			conflict = "the namespace of components with the same name"
+		} else if label == argumentLabel {
+			conflict = "the namespace of module arguments"
+		} else if vm.NewScope(nil).IsStdlibIdentifiers(label) {
+			conflict = "a standard library identifier with the same name"
+		} else {
+			continue
+		}
+		diags.Add(diag.Diagnostic{
+			Severity: diag.SeverityLevelError,
+			Message:  fmt.Sprintf("function %q conflicts with %s", label, conflict),
+			StartPos: ast.StartPos(fn.Block()).Position(),
+			EndPos:   ast.EndPos(fn.Block()).Position(),
+		})
+	}
+
+	return diags
+}
@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+
+	astutil "github.com/grafana/alloy/internal/util/ast"
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/typecheck"
+	"github.com/grafana/alloy/syntax/vm"
+)
+
+// FunctionConfigNode represents a function block in the DAG. Expressions call
+// the function by the label of the block.
+type FunctionConfigNode struct {
+	label         string
+	nodeID        string
+	componentName string
+
+	mut   sync.RWMutex
+	block *ast.BlockStmt // Current Alloy blocks to derive the function from
+	value any            // Callable value of the function, nil until evaluated
+}
+
+var _ BlockNode = (*FunctionConfigNode)(nil)
+
+// NewFunctionConfigNode creates a new FunctionConfigNode from an initial ast.BlockStmt.
+// The function isn't built until Evaluate is called.
+func NewFunctionConfigNode(block *ast.BlockStmt, globals ComponentGlobals) *FunctionConfigNode {
+	return &FunctionConfigNode{
+		label:         block.Label,
+		nodeID:        BlockComponentID(block).String(),
+		componentName: block.GetBlockName(),
+
+		block: block,
+	}
+}
+
+// Evaluate implements BlockNode and builds the function from the managed
+// block. The scope must only contain the other functions available to the
+// function, so that calling the function never depends on the state of
+// components.
+//
+// Evaluate doesn't evaluate the result expression of the function, which is
+// only evaluated when the function is called. Evaluate returns an error if
+// the block isn't a valid function block or if the result expression uses
+// identifiers which are neither parameters of the function nor in scope.
+func (cn *FunctionConfigNode) Evaluate(scope *vm.Scope) error {
+	cn.mut.Lock()
+	defer cn.mut.Unlock()
+
+	if diags := typecheck.Function(cn.block, scope); diags.HasErrors() {
+		cn.value = nil
+		return fmt.Errorf("invalid function: %w", diags)
+	}
+
+	fn, err := vm.NewFunction(cn.block, scope)
+	if err != nil {
+		cn.value = nil
+		return fmt.Errorf("invalid function: %w", err)
+	}
+	cn.value = fn.Value()
+	return nil
+}
+
+// Value returns the callable value of the function. Value returns nil if the
+// last call to Evaluate failed.
+func (cn *FunctionConfigNode) Value() any {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+	return cn.value
+}
+
+func (cn *FunctionConfigNode) Label() string { return cn.label }
+
+// Block implements BlockNode and returns the current block of the managed config node.
+func (cn *FunctionConfigNode) Block() *ast.BlockStmt {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+	return cn.block
+}
+
+// NodeID implements dag.Node and returns the unique ID for the config node.
+func (cn *FunctionConfigNode) NodeID() string { return cn.nodeID }
+
+// UpdateBlock updates the Alloy block used to build the function.
+// The new block isn't used until the next time Evaluate is invoked.
+//
+// UpdateBlock will panic if the block does not match the component ID of the
+// FunctionConfigNode.
+func (cn *FunctionConfigNode) UpdateBlock(b *ast.BlockStmt) {
+	if !BlockComponentID(b).Equals(strings.Split(cn.nodeID, ".")) {
+		panic("UpdateBlock called with an Alloy block with a different ID")
+	}
+
+	cn.mut.Lock()
+	defer cn.mut.Unlock()
+	cn.block = b
+}
+
+// checkFunctionCycles returns an error if some of the provided functions call
+// each other recursively. Since the syntax has no conditionals, a call to a
+// recursive function would never return.
+func checkFunctionCycles(functions map[string]*ast.BlockStmt) error {
+	const (
+		visiting = iota + 1
+		visited
+	)
+
+	var (
+		state = make(map[string]int, len(functions))
+		visit func(name string, path []string) error
+	)
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf("function %q calls itself recursively: %s", name, strings.Join(append(path, name), " -> "))
+		case visited:
+			return nil
+		}
+
+		state[name] = visiting
+		for _, callee := range functionReferences(functions[name]) {
+			if _, found := functions[callee]; !found {
+				continue
+			}
+			if err := visit(callee, append(path, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		return nil
+	}
+
+	names := make([]string, 0, len(functions))
+	for name := range functions {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// functionReferences returns the sorted names of the identifiers used by the
+// result of a function block which aren't parameters of the function.
+func functionReferences(b *ast.BlockStmt) []string {
+	fn, err := vm.NewFunction(b, nil)
+	if err != nil {
+		return nil
+	}
+
+	var refs []string
+	for _, t := range astutil.TraversalsFromBody(ast.Body{&ast.AttributeStmt{
+		Name:  &ast.Ident{Name: vm.FunctionResultAttr},
+		Value: fn.Result(),
+	}}) {
+		if name := t[0].Name; !slices.Contains(fn.Params(), name) && !slices.Contains(refs, name) {
+			refs = append(refs, name)
+		}
+	}
+	slices.Sort(refs)
+	return refs
+}
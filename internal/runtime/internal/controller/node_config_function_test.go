@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/parser"
+)
+
+func TestCheckFunctionCycles(t *testing.T) {
+	tt := []struct {
+		name   string
+		config string
+		err    string
+	}{
+		{
+			name: "no cycle",
+			config: `
+				function "a" {
+					args   = ["x"]
+					result = b(x) + c(x)
+				}
+				function "b" {
+					args   = ["x"]
+					result = c(x)
+				}
+				function "c" {
+					args   = ["x"]
+					result = x
+				}
+			`,
+		},
+		{
+			name: "parameter shadowing a function",
+			config: `
+				function "a" {
+					args   = ["a"]
+					result = a + 1
+				}
+			`,
+		},
+		{
+			name: "self reference",
+			config: `
+				function "a" {
+					args   = ["x"]
+					result = a(x)
+				}
+			`,
+			err: `function "a" calls itself recursively: a -> a`,
+		},
+		{
+			name: "cycle",
+			config: `
+				function "a" {
+					args   = ["x"]
+					result = b(x)
+				}
+				function "b" {
+					args   = ["x"]
+					result = c(x)
+				}
+				function "c" {
+					args   = ["x"]
+					result = a(x)
+				}
+			`,
+			err: `function "a" calls itself recursively: a -> b -> c -> a`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			file, err := parser.ParseFile(t.Name(), []byte(tc.config))
+			require.NoError(t, err)
+
+			functions := make(map[string]*ast.BlockStmt)
+			for _, stmt := range file.Body {
+				b := stmt.(*ast.BlockStmt)
+				functions[b.Label] = b
+			}
+
+			err = checkFunctionCycles(functions)
+			if tc.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.err)
+			}
+		})
+	}
+}
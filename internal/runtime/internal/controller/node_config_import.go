@@ -17,17 +17,20 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/featuregate"
+	"github.com/grafana/alloy/internal/nodeconf/function"
 	"github.com/grafana/alloy/internal/nodeconf/importsource"
 	"github.com/grafana/alloy/internal/runner"
 	"github.com/grafana/alloy/internal/runtime/logging/level"
 	"github.com/grafana/alloy/internal/runtime/tracing"
 	"github.com/grafana/alloy/syntax/ast"
 	"github.com/grafana/alloy/syntax/parser"
+	"github.com/grafana/alloy/syntax/typecheck"
 	"github.com/grafana/alloy/syntax/vm"
 )
 
-// ImportConfigNode imports declare and import blocks via a managed import source.
-// The imported declare are stored in importedDeclares.
+// ImportConfigNode imports declare, function and import blocks via a managed import source.
+// The imported declare are stored in importedDeclares and the imported functions in importedFunctions.
 // For every imported import block, the ImportConfigNode will create ImportConfigNode children.
 // The children are evaluated and ran by the parent.
 // When an ImportConfigNode receives new content from its source, it updates its importedDeclares and recreates its children.
@@ -53,6 +56,7 @@ type ImportConfigNode struct {
 	importConfigNodesChildren map[string]*ImportConfigNode
 	importChildrenRunning     bool
 	importedDeclares          map[string]ast.Body
+	importedFunctions         map[string]*ast.BlockStmt
 
 	// NOTE: To avoid deadlocks, whenever we need both locks we must always first lock the mut, then healthMut.
 	healthMut     sync.RWMutex
@@ -212,6 +216,7 @@ func (cn *ImportConfigNode) onContentUpdate(importedContent map[string]string) {
 		cn.importedContent[k] = v
 	}
 	cn.importedDeclares = make(map[string]ast.Body)
+	cn.importedFunctions = make(map[string]*ast.BlockStmt)
 	cn.importConfigNodesChildren = make(map[string]*ImportConfigNode)
 
 	for f, ic := range importedContent {
@@ -231,8 +236,15 @@ func (cn *ImportConfigNode) onContentUpdate(importedContent map[string]string) {
 		}
 	}
 
+	err := cn.checkImportedFunctions()
+	if err != nil {
+		level.Error(cn.logger).Log("msg", "failed to process imported functions", "err", err)
+		cn.setContentHealth(component.HealthTypeUnhealthy, fmt.Sprintf("imported content is invalid: %s", err))
+		return
+	}
+
 	// evaluate the importConfigNodesChildren that have been created
-	err := cn.evaluateChildren()
+	err = cn.evaluateChildren()
 	if err != nil {
 		level.Error(cn.logger).Log("msg", "failed to evaluate nested import", "err", err)
 		cn.setContentHealth(component.HealthTypeUnhealthy, fmt.Sprintf("nested import block failed to evaluate: %s", err))
@@ -251,25 +263,30 @@ func (cn *ImportConfigNode) onContentUpdate(importedContent map[string]string) {
 	cn.OnBlockNodeUpdate(cn)
 }
 
-// processImportedContent processes declare and import blocks of the provided ast content.
+// processImportedContent processes declare, function and import blocks of the provided ast content.
 func (cn *ImportConfigNode) processImportedContent(content *ast.File) error {
 	for _, stmt := range content.Body {
 		blockStmt, ok := stmt.(*ast.BlockStmt)
 		if !ok {
-			return fmt.Errorf("only declare and import blocks are allowed in a module")
+			return fmt.Errorf("only declare, function and import blocks are allowed in a module")
 		}
 
 		componentName := strings.Join(blockStmt.Name, ".")
 		switch componentName {
 		case declareType:
 			cn.processDeclareBlock(blockStmt)
-		case importsource.BlockNameFile, importsource.BlockNameString, importsource.BlockNameHTTP, importsource.BlockNameGit:
+		case function.BlockName:
+			err := cn.processFunctionBlock(blockStmt)
+			if err != nil {
+				return err
+			}
+		case importsource.BlockNameFile, importsource.BlockNameString, importsource.BlockNameHTTP, importsource.BlockNameGit, importsource.BlockNameOCI:
 			err := cn.processImportBlock(blockStmt, componentName)
 			if err != nil {
 				return err
 			}
 		default:
-			return fmt.Errorf("only declare and import blocks are allowed in a module, got %s", componentName)
+			return fmt.Errorf("only declare, function and import blocks are allowed in a module, got %s", componentName)
 		}
 	}
 	return nil
@@ -284,8 +301,49 @@ func (cn *ImportConfigNode) processDeclareBlock(stmt *ast.BlockStmt) {
 	cn.importedDeclares[stmt.Label] = stmt.Body
 }
 
+// processFunctionBlock stores the function definition in the importedFunctions.
+func (cn *ImportConfigNode) processFunctionBlock(stmt *ast.BlockStmt) error {
+	if err := featuregate.CheckAllowed(function.StabilityLevel, cn.globals.MinStability, fmt.Sprintf("config block %q", function.BlockName)); err != nil {
+		return err
+	}
+	if _, ok := cn.importedFunctions[stmt.Label]; ok {
+		return fmt.Errorf("function block redefined %s", stmt.Label)
+	}
+	cn.importedFunctions[stmt.Label] = stmt
+	return nil
+}
+
+// checkImportedFunctions type checks the imported functions and makes sure
+// that they don't call each other recursively. Imported functions can call
+// each other and the functions of the nested imports.
+func (cn *ImportConfigNode) checkImportedFunctions() error {
+	// Only the names matter for type checking.
+	names := make(map[string]any, len(cn.importedFunctions)+len(cn.importConfigNodesChildren))
+	for name := range cn.importedFunctions {
+		names[name] = struct{}{}
+	}
+	for label := range cn.importConfigNodesChildren {
+		if _, found := names[label]; found {
+			return fmt.Errorf("function %q conflicts with the namespace of an import block with the same label", label)
+		}
+		names[label] = struct{}{}
+	}
+
+	scope := vm.NewScope(names)
+	for _, b := range cn.importedFunctions {
+		if diags := typecheck.Function(b, scope); diags.HasErrors() {
+			return fmt.Errorf("invalid function %q: %w", b.Label, diags)
+		}
+	}
+
+	return checkFunctionCycles(cn.importedFunctions)
+}
+
 // processDeclareBlock creates an ImportConfigNode child from the provided import block.
 func (cn *ImportConfigNode) processImportBlock(stmt *ast.BlockStmt, fullName string) error {
+	if err := checkFeatureStability(fullName, cn.globals.MinStability); err != nil {
+		return err
+	}
 	sourceType := importsource.GetSourceType(fullName)
 	if _, ok := cn.importConfigNodesChildren[stmt.Label]; ok {
 		return fmt.Errorf("import block redefined %s", stmt.Label)
@@ -434,6 +492,35 @@ func (cn *ImportConfigNode) ImportedDeclares() map[string]ast.Body {
 	return cn.importedDeclares
 }
 
+// ImportedFunctions returns the callable values of the imported functions,
+// along with the functions of the nested imports under their namespace.
+func (cn *ImportConfigNode) ImportedFunctions() map[string]any {
+	cn.mut.RLock()
+	defer cn.mut.RUnlock()
+
+	functions := make(map[string]any, len(cn.importedFunctions)+len(cn.importConfigNodesChildren))
+	for label, child := range cn.importConfigNodesChildren {
+		if childFunctions := child.ImportedFunctions(); len(childFunctions) > 0 {
+			functions[label] = childFunctions
+		}
+	}
+
+	// The imported functions share the same scope so that they can call each
+	// other. The scope is complete before any function can be called.
+	scope := vm.NewScope(functions)
+	for name, b := range cn.importedFunctions {
+		fn, err := vm.NewFunction(b, scope)
+		if err != nil {
+			// This can't happen since the functions are type checked when
+			// the content is updated.
+			level.Error(cn.logger).Log("msg", "failed to build imported function", "name", name, "err", err)
+			continue
+		}
+		functions[name] = fn.Value()
+	}
+	return functions
+}
+
 // Scope returns the scope associated with the import source.
 func (cn *ImportConfigNode) Scope() *vm.Scope {
 	return vm.NewScope(map[string]any{
@@ -0,0 +1,422 @@
+package controller
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/grafana/alloy/internal/dag"
+	"github.com/grafana/alloy/internal/nodeconf/export"
+	"github.com/grafana/alloy/internal/nodeconf/function"
+	"github.com/grafana/alloy/internal/runtime/equality"
+	"github.com/grafana/alloy/internal/runtime/logging"
+	astutil "github.com/grafana/alloy/internal/util/ast"
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/diag"
+	"github.com/grafana/alloy/syntax/printer"
+	"github.com/grafana/alloy/syntax/vm"
+)
+
+// PlanAction is the action that applying a new config would take on a node.
+type PlanAction string
+
+const (
+	PlanActionCreate     PlanAction = "create"     // The node would be created.
+	PlanActionDelete     PlanAction = "delete"     // The node would be removed.
+	PlanActionUpdate     PlanAction = "update"     // The node would be updated with new arguments.
+	PlanActionReevaluate PlanAction = "reevaluate" // The node is unchanged but depends on a changed node.
+)
+
+// PlanChange describes the action that would be taken on a single node.
+type PlanChange struct {
+	ID      string     `json:"id"`
+	Action  PlanAction `json:"action"`
+	Message string     `json:"message,omitempty"`
+}
+
+// PlanDiagnostic is a problem found by dry running a new config.
+type PlanDiagnostic struct {
+	Severity string `json:"severity"`
+	Position string `json:"position,omitempty"`
+	Message  string `json:"message"`
+}
+
+// Plan describes the changes that applying a new config would make to a
+// graph. Changes are sorted by node ID.
+type Plan struct {
+	// Components holds changes to component, service and config blocks other
+	// than export blocks.
+	Components []PlanChange `json:"components"`
+	// Exports holds changes to export blocks.
+	Exports []PlanChange `json:"exports"`
+	// Diagnostics holds the problems found by dry running the new config.
+	Diagnostics []PlanDiagnostic `json:"diagnostics,omitempty"`
+}
+
+// Empty returns true if the plan contains no changes.
+func (p Plan) Empty() bool {
+	return len(p.Components) == 0 && len(p.Exports) == 0
+}
+
+// HasErrors returns true if dry running the new config reported errors.
+func (p Plan) HasErrors() bool {
+	return slices.ContainsFunc(p.Diagnostics, func(d PlanDiagnostic) bool {
+		return d.Severity == "error"
+	})
+}
+
+// DiffBlocks computes a Plan by statically comparing two sets of blocks.
+// Blocks are never evaluated, so any textual change to a block is reported as
+// an update.
+func DiffBlocks(oldBlocks, newBlocks []*ast.BlockStmt) Plan {
+	return diffBlocks(oldBlocks, newBlocks, nil)
+}
+
+// Plan computes the changes that Apply would make with the provided options.
+// Plan does not modify the Loader or any of its running components.
+//
+// Blocks of existing builtin components and exports which changed are
+// evaluated against the current state of the graph, so that blocks which were
+// only rewritten without changing their values are not reported as updated.
+func (l *Loader) Plan(options ApplyOptions) Plan {
+	l.mut.RLock()
+	defer l.mut.RUnlock()
+
+	var oldBlocks []*ast.BlockStmt
+	for _, n := range l.graph.Nodes() {
+		bn, ok := n.(BlockNode)
+		if !ok || bn.Block() == nil {
+			continue
+		}
+		oldBlocks = append(oldBlocks, bn.Block())
+	}
+
+	newBlocks := slices.Concat(options.ComponentBlocks, options.ConfigBlocks, options.DeclareBlocks)
+	plan := diffBlocks(oldBlocks, newBlocks, l.valueUnchanged)
+	plan.Diagnostics = l.DryRun(options)
+	return plan
+}
+
+// DryRun builds the graph described by options with a new Loader, the same
+// way as Apply, and evaluates it without building, updating or running any
+// component. It returns the problems that applying options would report.
+// DryRun does not modify the Loader or any of its running components.
+//
+// Components aren't built, so their exports hold zero values. Evaluation
+// errors of blocks which reference the exports of other components are
+// reported as warnings, since they may go away once the components run.
+// Blocks which depend on custom components, imports or foreach blocks can't be
+// evaluated without running them, and are only checked when building the
+// graph.
+func (l *Loader) DryRun(options ApplyOptions) []PlanDiagnostic {
+	globals := l.globals
+	globals.Logger = logging.NewNop()
+	globals.Registerer = nil
+	globals.OnBlockNodeUpdate = nil
+	globals.OnExportsChange = nil
+
+	dl, err := NewLoader(LoaderOptions{
+		ComponentGlobals:  globals,
+		Services:          l.services,
+		Host:              l.host,
+		ComponentRegistry: l.componentNodeManager.builtinComponentReg,
+	})
+	if err != nil {
+		return newPlanDiagnostics(diag.Diagnostics{{
+			Severity: diag.SeverityLevelError,
+			Message:  fmt.Sprintf("Failed to create a loader for the dry run: %s", err),
+		}})
+	}
+
+	if options.ArgScope != nil {
+		dl.cache.UpdateScopeVariables(options.ArgScope.Variables)
+	}
+	for key, value := range options.Args {
+		dl.cache.CacheModuleArgument(key, value)
+	}
+	dl.componentNodeManager.setCustomComponentRegistry(NewCustomComponentRegistry(options.CustomComponentRegistry, options.ArgScope))
+
+	g, diags := dl.loadNewGraph(options.Args, options.ComponentBlocks, options.ConfigBlocks, options.DeclareBlocks)
+	if diags.HasErrors() {
+		return newPlanDiagnostics(diags)
+	}
+
+	// Nodes whose values can't be known without running them.
+	unknown := make(map[dag.Node]struct{})
+
+	_ = dag.WalkTopological(&g, g.Leaves(), func(n dag.Node) error {
+		usesExports := false
+		for _, dep := range g.Dependencies(n) {
+			if _, found := unknown[dep]; found {
+				unknown[n] = struct{}{}
+				return nil
+			}
+			if _, ok := dep.(*BuiltinComponentNode); ok {
+				usesExports = true
+			}
+		}
+
+		var err error
+		switch n := n.(type) {
+		case *BuiltinComponentNode:
+			argsPointer := n.reg.CloneArguments()
+			if err = vm.New(n.Block().Body).Evaluate(dl.cache.GetContext(), argsPointer); err != nil {
+				err = fmt.Errorf("decoding configuration: %w", err)
+			}
+			// Expose the zero values of the exports to the dependants.
+			if cacheErr := dl.cache.CacheExports(n.ID(), n.Exports()); cacheErr != nil {
+				err = errors.Join(err, cacheErr)
+			}
+		case *ServiceNode:
+			if n.Block() != nil {
+				if n.Definition().ConfigType == nil {
+					err = fmt.Errorf("service %q does not support being configured", n.NodeID())
+				} else if err = vm.New(n.Block().Body).Evaluate(dl.cache.GetContext(), n.Definition().CloneConfig()); err != nil {
+					err = fmt.Errorf("decoding configuration: %w", err)
+				}
+			}
+		case *ArgumentConfigNode, *ExportConfigNode, *FunctionConfigNode, *DeclareNode:
+			// These blocks only evaluate expressions, so evaluating them has no
+			// side effects.
+			err = dl.evaluate(dl.log, n.(BlockNode))
+		default:
+			unknown[n] = struct{}{}
+			return nil
+		}
+
+		if err != nil {
+			severity := diag.SeverityLevelError
+			if usesExports {
+				severity = diag.SeverityLevelWarn
+			}
+			diags = append(diags, dryRunDiags(n.(BlockNode), err, severity)...)
+		}
+		return nil
+	})
+
+	return newPlanDiagnostics(diags)
+}
+
+// dryRunDiags converts the evaluation error of a node during a dry run to
+// diagnostics with the given severity.
+func dryRunDiags(n BlockNode, err error, severity diag.Severity) diag.Diagnostics {
+	var evalDiags diag.Diagnostics
+	if errors.As(err, &evalDiags) {
+		evalDiags = slices.Clone(evalDiags)
+	} else {
+		d := diag.Diagnostic{Message: fmt.Sprintf("Failed to evaluate %s: %s", n.NodeID(), err)}
+		if b := n.Block(); b != nil {
+			d.StartPos = ast.StartPos(b).Position()
+			d.EndPos = ast.EndPos(b).Position()
+		}
+		evalDiags = diag.Diagnostics{d}
+	}
+
+	for i := range evalDiags {
+		evalDiags[i].Severity = severity
+		if severity == diag.SeverityLevelWarn {
+			evalDiags[i].Message += " (evaluated with the zero values of the exports of other components)"
+		}
+	}
+	return evalDiags
+}
+
+func newPlanDiagnostics(diags diag.Diagnostics) []PlanDiagnostic {
+	res := make([]PlanDiagnostic, 0, len(diags))
+	for _, d := range diags {
+		pd := PlanDiagnostic{Severity: "error", Message: d.Message}
+		if d.Severity == diag.SeverityLevelWarn {
+			pd.Severity = "warning"
+		}
+		if d.StartPos.Valid() {
+			pd.Position = d.StartPos.String()
+		}
+		res = append(res, pd)
+	}
+	return res
+}
+
+// valueUnchanged reports whether evaluating block would leave the arguments
+// (or value, for exports) of the existing node with the same ID unchanged.
+// mut must be held when calling valueUnchanged.
+func (l *Loader) valueUnchanged(block *ast.BlockStmt) (bool, error) {
+	scope := l.cache.GetContext()
+
+	switch n := l.graph.GetByID(BlockComponentID(block).String()).(type) {
+	case *BuiltinComponentNode:
+		argsPointer := n.Registration().CloneArguments()
+		if err := vm.New(block.Body).Evaluate(scope, argsPointer); err != nil {
+			return false, err
+		}
+		return equality.DeepEqual(n.Arguments(), reflect.ValueOf(argsPointer).Elem().Interface()), nil
+
+	case *ExportConfigNode:
+		var args export.Arguments
+		if err := vm.New(block.Body).Evaluate(scope, &args); err != nil {
+			return false, err
+		}
+		return equality.DeepEqual(n.Value(), args.Value), nil
+	}
+
+	return false, nil
+}
+
+// diffBlocks compares oldBlocks and newBlocks. If unchanged is non-nil, it is
+// invoked for blocks which exist in both sets but whose text differs.
+func diffBlocks(oldBlocks, newBlocks []*ast.BlockStmt, unchanged func(*ast.BlockStmt) (bool, error)) Plan {
+	var (
+		oldByID = blocksByID(oldBlocks)
+		newByID = blocksByID(newBlocks)
+		changes = make(map[string]PlanChange)
+	)
+
+	for id, nb := range newByID {
+		ob, found := oldByID[id]
+		switch {
+		case !found:
+			changes[id] = PlanChange{ID: id, Action: PlanActionCreate}
+
+		case blockText(ob) != blockText(nb):
+			change := PlanChange{ID: id, Action: PlanActionUpdate}
+			if unchanged != nil {
+				same, err := unchanged(nb)
+				if err != nil {
+					change.Message = fmt.Sprintf("evaluation would fail: %s", err)
+				} else if same {
+					continue
+				}
+			}
+			changes[id] = change
+		}
+	}
+	for id := range oldByID {
+		if _, found := newByID[id]; !found {
+			changes[id] = PlanChange{ID: id, Action: PlanActionDelete}
+		}
+	}
+
+	// Walk the dependants of every changed node. Dependants which don't change
+	// themselves will be re-evaluated once the change is applied.
+	var (
+		dependants = blockDependants(newByID)
+		queue      = make([]string, 0, len(changes))
+	)
+	for id := range changes {
+		queue = append(queue, id)
+	}
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+
+		for _, dep := range dependants[id] {
+			if _, seen := changes[dep]; seen {
+				continue
+			}
+			changes[dep] = PlanChange{
+				ID:      dep,
+				Action:  PlanActionReevaluate,
+				Message: fmt.Sprintf("depends on %s", id),
+			}
+			queue = append(queue, dep)
+		}
+	}
+
+	var plan Plan
+	for id, change := range changes {
+		b, found := newByID[id]
+		if !found {
+			b = oldByID[id]
+		}
+		if b.GetBlockName() == export.BlockName {
+			plan.Exports = append(plan.Exports, change)
+		} else {
+			plan.Components = append(plan.Components, change)
+		}
+	}
+	sortChanges(plan.Components)
+	sortChanges(plan.Exports)
+	return plan
+}
+
+func blocksByID(blocks []*ast.BlockStmt) map[string]*ast.BlockStmt {
+	res := make(map[string]*ast.BlockStmt, len(blocks))
+	for _, b := range blocks {
+		res[BlockComponentID(b).String()] = b
+	}
+	return res
+}
+
+// blockDependants returns a map of block IDs to the IDs of the blocks which
+// reference them, either through expressions or by instantiating a custom
+// component defined by a declare or import block.
+func blockDependants(blocks map[string]*ast.BlockStmt) map[string][]string {
+	// Custom components are instantiated by the label of the declare or
+	// import block defining them, and functions are called by the label of
+	// the function or import block defining them.
+	var (
+		namespaces = make(map[string]string)
+		functions  = make(map[string]string)
+	)
+	for id, b := range blocks {
+		name := b.GetBlockName()
+		switch {
+		case name == declareType:
+			namespaces[b.Label] = id
+		case strings.HasPrefix(name, "import."):
+			namespaces[b.Label] = id
+			functions[b.Label] = id
+		case name == function.BlockName:
+			functions[b.Label] = id
+		}
+	}
+
+	dependants := make(map[string][]string)
+	for id, b := range blocks {
+		deps := make(map[string]struct{})
+
+		if target, found := namespaces[b.Name[0]]; found && target != id {
+			deps[target] = struct{}{}
+		}
+		for _, t := range astutil.TraversalsFromBody(b.Body) {
+			if target, found := functions[t[0].Name]; found && target != id {
+				deps[target] = struct{}{}
+				continue
+			}
+			for i := range t {
+				target := t[:i+1].String()
+				if _, found := blocks[target]; found && target != id {
+					deps[target] = struct{}{}
+					break
+				}
+			}
+		}
+
+		for target := range deps {
+			dependants[target] = append(dependants[target], id)
+		}
+	}
+	for _, ids := range dependants {
+		sort.Strings(ids)
+	}
+	return dependants
+}
+
+// blockText returns the formatted text of a block, which is used to detect
+// whether a block changed.
+func blockText(b *ast.BlockStmt) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, b); err != nil {
+		// Printing to a buffer should never fail, but force the block to be
+		// treated as changed if it does.
+		return fmt.Sprintf("%p", b)
+	}
+	return buf.String()
+}
+
+func sortChanges(changes []PlanChange) {
+	sort.Slice(changes, func(i, j int) bool { return changes[i].ID < changes[j].ID })
+}
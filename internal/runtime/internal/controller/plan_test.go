@@ -0,0 +1,144 @@
+package controller_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/runtime/internal/controller"
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/parser"
+)
+
+func TestDiffBlocks(t *testing.T) {
+	oldConfig := `
+		testcomponents.tick "ticker" {
+			frequency = "1s"
+		}
+
+		testcomponents.passthrough "static" {
+			input = "hello, world!"
+		}
+
+		testcomponents.passthrough "ticker" {
+			input = testcomponents.tick.ticker.tick_time
+		}
+
+		testcomponents.passthrough "forwarded" {
+			input = testcomponents.passthrough.ticker.output
+		}
+
+		testcomponents.passthrough "removed" {
+			input = "bye"
+		}
+
+		declare "mod" {
+			testcomponents.tick "inner" {
+				frequency = "1s"
+			}
+		}
+
+		mod "instance" {}
+	`
+
+	newConfig := `
+		testcomponents.tick "ticker" {
+			frequency = "5s"
+		}
+
+		testcomponents.passthrough "static" {
+			input    =    "hello, world!"
+		}
+
+		testcomponents.passthrough "ticker" {
+			input = testcomponents.tick.ticker.tick_time
+		}
+
+		testcomponents.passthrough "forwarded" {
+			input = testcomponents.passthrough.ticker.output
+		}
+
+		testcomponents.passthrough "added" {
+			input = "hi"
+		}
+
+		declare "mod" {
+			testcomponents.tick "inner" {
+				frequency = "2s"
+			}
+		}
+
+		mod "instance" {}
+	`
+
+	plan := controller.DiffBlocks(parseBlocks(t, oldConfig), parseBlocks(t, newConfig))
+	require.Equal(t, []controller.PlanChange{
+		{ID: "declare.mod", Action: controller.PlanActionUpdate},
+		{ID: "mod.instance", Action: controller.PlanActionReevaluate, Message: "depends on declare.mod"},
+		{ID: "testcomponents.passthrough.added", Action: controller.PlanActionCreate},
+		{ID: "testcomponents.passthrough.forwarded", Action: controller.PlanActionReevaluate, Message: "depends on testcomponents.passthrough.ticker"},
+		{ID: "testcomponents.passthrough.removed", Action: controller.PlanActionDelete},
+		{ID: "testcomponents.passthrough.ticker", Action: controller.PlanActionReevaluate, Message: "depends on testcomponents.tick.ticker"},
+		{ID: "testcomponents.tick.ticker", Action: controller.PlanActionUpdate},
+	}, plan.Components)
+	require.Empty(t, plan.Exports)
+}
+
+func TestDiffBlocks_Exports(t *testing.T) {
+	oldConfig := `
+		testcomponents.passthrough "pt" {
+			input = "a"
+		}
+
+		export "output" {
+			value = testcomponents.passthrough.pt.output
+		}
+
+		export "static" {
+			value = "static"
+		}
+	`
+
+	newConfig := `
+		testcomponents.passthrough "pt" {
+			input = "b"
+		}
+
+		export "output" {
+			value = testcomponents.passthrough.pt.output
+		}
+	`
+
+	plan := controller.DiffBlocks(parseBlocks(t, oldConfig), parseBlocks(t, newConfig))
+	require.Equal(t, []controller.PlanChange{
+		{ID: "testcomponents.passthrough.pt", Action: controller.PlanActionUpdate},
+	}, plan.Components)
+	require.Equal(t, []controller.PlanChange{
+		{ID: "export.output", Action: controller.PlanActionReevaluate, Message: "depends on testcomponents.passthrough.pt"},
+		{ID: "export.static", Action: controller.PlanActionDelete},
+	}, plan.Exports)
+}
+
+func TestDiffBlocks_NoChanges(t *testing.T) {
+	config := `
+		testcomponents.tick "ticker" {
+			frequency = "1s"
+		}
+	`
+
+	plan := controller.DiffBlocks(parseBlocks(t, config), parseBlocks(t, config))
+	require.True(t, plan.Empty())
+}
+
+func parseBlocks(t *testing.T, config string) []*ast.BlockStmt {
+	t.Helper()
+
+	f, err := parser.ParseFile(t.Name(), []byte(config))
+	require.NoError(t, err)
+
+	blocks := make([]*ast.BlockStmt, 0, len(f.Body))
+	for _, stmt := range f.Body {
+		blocks = append(blocks, stmt.(*ast.BlockStmt))
+	}
+	return blocks
+}
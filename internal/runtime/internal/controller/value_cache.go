@@ -20,6 +20,7 @@ type valueCache struct {
 	componentIds       map[string]ComponentID // NodeID -> ComponentID
 	moduleExports      map[string]any         // Export label -> Export value
 	moduleArguments    map[string]any         // Argument label -> Map with the key "value" that points to the Argument value
+	functions          map[string]any         // Function name or import namespace -> Function value or map of function values
 	moduleChangedIndex int                    // Everytime a change occurs this is incremented
 	scope              *vm.Scope              // scope provides additional context for the nodes in the module
 }
@@ -30,6 +31,7 @@ func newValueCache() *valueCache {
 		componentIds:    make(map[string]ComponentID, 0),
 		moduleExports:   make(map[string]any),
 		moduleArguments: make(map[string]any),
+		functions:       make(map[string]any),
 		scope:           vm.NewScope(make(map[string]any)),
 	}
 }
@@ -87,6 +89,26 @@ func (vc *valueCache) CacheModuleArgument(key string, value any) {
 	vc.moduleArguments[key] = keyMap
 }
 
+// CacheFunction caches a function value, or a map of function values for the
+// functions of an import block, to expose it to expressions under name. A nil
+// value removes the cached function.
+func (vc *valueCache) CacheFunction(name string, value any) {
+	vc.mut.Lock()
+	defer vc.mut.Unlock()
+	if value == nil {
+		delete(vc.functions, name)
+		return
+	}
+	vc.functions[name] = value
+}
+
+// ClearFunctions removes all the cached functions.
+func (vc *valueCache) ClearFunctions() {
+	vc.mut.Lock()
+	defer vc.mut.Unlock()
+	vc.functions = make(map[string]any)
+}
+
 // CacheModuleExportValue saves the value to the map
 func (vc *valueCache) CacheModuleExportValue(name string, value any) {
 	vc.mut.Lock()
@@ -215,6 +237,27 @@ func (vc *valueCache) GetContext() *vm.Scope {
 		vars[argumentLabel] = deepCopyMap(vc.moduleArguments)
 	}
 
+	// Add the functions defined in the module. Functions of an import block
+	// share the namespace of the custom components instantiated from it.
+	// Functions never replace other values: conflicting names are rejected
+	// when loading the graph.
+	for name, fn := range vc.functions {
+		if _, found := vars[name]; !found {
+			vars[name] = fn
+			continue
+		}
+		existing, ok := vars[name].(map[string]any)
+		imported, isNamespace := fn.(map[string]any)
+		if !ok || !isNamespace {
+			continue
+		}
+		for k, v := range imported {
+			if _, found := existing[k]; !found {
+				existing[k] = v
+			}
+		}
+	}
+
 	return vm.NewScope(vars)
 }
 
@@ -163,6 +163,23 @@ func TestModuleArgumentCache(t *testing.T) {
 	}
 }
 
+func TestFunctionsDontOverrideScope(t *testing.T) {
+	vc := newValueCache()
+	vc.CacheModuleArgument("arg", 1)
+	require.NoError(t, vc.CacheExports(ComponentID{"foo", "bar"}, barArgs{Number: 12}))
+	vc.CacheFunction("argument", "argument function")
+	vc.CacheFunction("foo", "foo function")
+	vc.CacheFunction("f", "f function")
+
+	res := vc.GetContext()
+	expected := map[string]any{
+		"argument": map[string]any{"arg": map[string]any{"value": 1}},
+		"foo":      map[string]any{"bar": barArgs{Number: 12}},
+		"f":        "f function",
+	}
+	require.Equal(t, expected, res.Variables)
+}
+
 func TestScope(t *testing.T) {
 	vc := newValueCache()
 	vc.scope = vm.NewScope(
@@ -126,10 +126,11 @@ var (
 // newModule creates a module instance for a specific component.
 func newModule(o *moduleOptions) (*module, error) {
 	c, err := newController(controllerOptions{
-		IsModule:          true,
-		ModuleRegistry:    o.ModuleRegistry,
-		ComponentRegistry: o.ComponentRegistry,
-		WorkerPool:        o.WorkerPool,
+		IsModule:           true,
+		ModuleRegistry:     o.ModuleRegistry,
+		ComponentRegistry:  o.ComponentRegistry,
+		WorkerPool:         o.WorkerPool,
+		ResourceAccountant: o.ResourceAccountant,
 		Options: Options{
 			ControllerID:         o.ID,
 			Tracer:               o.Tracer,
@@ -228,4 +229,8 @@ type moduleControllerOptions struct {
 
 	// EnableCommunityComps enables the use of community components.
 	EnableCommunityComps bool
+
+	// ResourceAccountant estimates the resource usage of components. It is
+	// nil if resource accounting is disabled.
+	ResourceAccountant *resourceAccountant
 }
@@ -0,0 +1,63 @@
+package runtime
+
+import (
+	"slices"
+
+	"github.com/grafana/alloy/internal/runtime/internal/controller"
+	"github.com/grafana/alloy/syntax/ast"
+)
+
+// Plan describes the changes that loading a new config source would make to
+// the components of a controller.
+type Plan = controller.Plan
+
+// PlanChange describes the action that would be taken on a single component
+// or config block.
+type PlanChange = controller.PlanChange
+
+// PlanAction is the action that loading a new config source would take on a
+// component or config block.
+type PlanAction = controller.PlanAction
+
+const (
+	PlanActionCreate     = controller.PlanActionCreate
+	PlanActionDelete     = controller.PlanActionDelete
+	PlanActionUpdate     = controller.PlanActionUpdate
+	PlanActionReevaluate = controller.PlanActionReevaluate
+)
+
+// PlanDiagnostic is a problem found by dry running a config source.
+type PlanDiagnostic = controller.PlanDiagnostic
+
+// Plan computes the changes that calling LoadSource with source would make,
+// without modifying any running components. Arguments of existing components
+// are evaluated against the current state of the controller to detect whether
+// they would actually change, and source is dry run to report the problems
+// that loading it would find.
+func (f *Runtime) Plan(source *Source, configPath string) Plan {
+	f.loadMut.RLock()
+	defer f.loadMut.RUnlock()
+
+	return f.loader.Plan(f.rootApplyOptions(source, nil, configPath))
+}
+
+// DryRun builds and evaluates the graph of source like LoadSource, without
+// building, updating or running any component, and returns the problems that
+// loading source would report. See Plan for the changes it would make.
+func (f *Runtime) DryRun(source *Source, configPath string) []PlanDiagnostic {
+	f.loadMut.RLock()
+	defer f.loadMut.RUnlock()
+
+	return f.loader.DryRun(f.rootApplyOptions(source, nil, configPath))
+}
+
+// DiffSources statically computes the changes between two config sources.
+// Unlike Runtime.Plan, blocks are not evaluated, so any change to the
+// contents of a block is reported as an update.
+func DiffSources(oldSource, newSource *Source) Plan {
+	return controller.DiffBlocks(sourceBlocks(oldSource), sourceBlocks(newSource))
+}
+
+func sourceBlocks(s *Source) []*ast.BlockStmt {
+	return slices.Concat(s.Components(), s.Configs(), s.Declares())
+}
@@ -0,0 +1,213 @@
+package runtime
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/runtime/internal/testcomponents"
+)
+
+func TestRuntimePlan(t *testing.T) {
+	defer verifyNoGoroutineLeaks(t)
+
+	cfg := `
+		testcomponents.passthrough "static" {
+			input = "hello, world!"
+		}
+
+		testcomponents.passthrough "forwarded" {
+			input = testcomponents.passthrough.static.output
+		}
+
+		testcomponents.passthrough "removed" {
+			input = "bye"
+		}
+	`
+
+	ctrl, err := New(testOptions(t))
+	require.NoError(t, err)
+	defer cleanUpController(context.Background(), ctrl)
+
+	source, err := ParseSource(t.Name(), []byte(cfg))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.LoadSource(source, nil, ""))
+
+	t.Run("reformatted arguments are not reported", func(t *testing.T) {
+		newSource, err := ParseSource(t.Name(), []byte(`
+			testcomponents.passthrough "static" {
+				input = "hello, " + "world!"
+			}
+
+			testcomponents.passthrough "forwarded" {
+				input = testcomponents.passthrough.static.output
+			}
+
+			testcomponents.passthrough "removed" {
+				input = "bye"
+			}
+		`))
+		require.NoError(t, err)
+
+		// The static diff reports an update, but evaluating the new block
+		// shows that the arguments don't change.
+		require.False(t, DiffSources(source, newSource).Empty())
+		require.True(t, ctrl.Plan(newSource, "").Empty())
+	})
+
+	t.Run("changes are reported", func(t *testing.T) {
+		newSource, err := ParseSource(t.Name(), []byte(`
+			testcomponents.passthrough "static" {
+				input = "goodbye, world!"
+			}
+
+			testcomponents.passthrough "forwarded" {
+				input = testcomponents.passthrough.static.output
+			}
+
+			testcomponents.passthrough "added" {
+				input = 1 + "a"
+			}
+		`))
+		require.NoError(t, err)
+
+		plan := ctrl.Plan(newSource, "")
+		require.Len(t, plan.Components, 4)
+		require.Equal(t, PlanChange{ID: "testcomponents.passthrough.added", Action: PlanActionCreate}, plan.Components[0])
+		require.Equal(t, PlanChange{
+			ID:      "testcomponents.passthrough.forwarded",
+			Action:  PlanActionReevaluate,
+			Message: "depends on testcomponents.passthrough.static",
+		}, plan.Components[1])
+		require.Equal(t, PlanChange{ID: "testcomponents.passthrough.removed", Action: PlanActionDelete}, plan.Components[2])
+		require.Equal(t, PlanChange{ID: "testcomponents.passthrough.static", Action: PlanActionUpdate}, plan.Components[3])
+
+		// Dry running the new config finds the invalid arguments of the new
+		// component.
+		require.True(t, plan.HasErrors())
+		require.Len(t, plan.Diagnostics, 1)
+		require.Contains(t, plan.Diagnostics[0].Message, "should be number, got string")
+	})
+
+	t.Run("evaluation errors are reported", func(t *testing.T) {
+		newSource, err := ParseSource(t.Name(), []byte(`
+			testcomponents.passthrough "static" {
+				input = 1 + "a"
+			}
+
+			testcomponents.passthrough "forwarded" {
+				input = testcomponents.passthrough.static.output
+			}
+
+			testcomponents.passthrough "removed" {
+				input = "bye"
+			}
+		`))
+		require.NoError(t, err)
+
+		plan := ctrl.Plan(newSource, "")
+		require.Len(t, plan.Components, 2)
+		require.Equal(t, "testcomponents.passthrough.static", plan.Components[1].ID)
+		require.Equal(t, PlanActionUpdate, plan.Components[1].Action)
+		require.Contains(t, plan.Components[1].Message, "evaluation would fail")
+	})
+
+	// The running components must not be affected by planning.
+	for _, cn := range ctrl.loader.Components() {
+		if cn.NodeID() == "testcomponents.passthrough.static" {
+			require.Equal(t, "hello, world!", cn.Arguments().(testcomponents.PassthroughConfig).Input)
+		}
+	}
+}
+
+func TestRuntimeDryRun(t *testing.T) {
+	defer verifyNoGoroutineLeaks(t)
+
+	ctrl, err := New(testOptions(t))
+	require.NoError(t, err)
+	defer cleanUpController(context.Background(), ctrl)
+
+	tt := []struct {
+		name     string
+		cfg      string
+		severity string
+		message  string
+	}{
+		{
+			name: "valid config",
+			cfg: `
+				testcomponents.passthrough "static" {
+					input = "hello"
+				}
+
+				testcomponents.passthrough "forwarded" {
+					input = testcomponents.passthrough.static.output
+				}
+			`,
+		},
+		{
+			name: "unknown component",
+			cfg: `
+				testcomponents.does_not_exist "static" {
+					input = "hello"
+				}
+			`,
+			severity: "error",
+			message:  `cannot find the definition of component name "testcomponents.does_not_exist"`,
+		},
+		{
+			name: "unknown reference",
+			cfg: `
+				testcomponents.passthrough "forwarded" {
+					input = testcomponents.passthrough.missing.output
+				}
+			`,
+			severity: "error",
+			message:  `component "testcomponents.passthrough.missing.output" does not exist or is out of scope`,
+		},
+		{
+			name: "type error",
+			cfg: `
+				testcomponents.summation "sum" {
+					input = [1]
+				}
+			`,
+			severity: "error",
+			message:  "should be number, got array",
+		},
+		{
+			name: "error depending on exports",
+			cfg: `
+				testcomponents.passthrough "static" {
+					input = "hello"
+				}
+
+				testcomponents.passthrough "forwarded" {
+					input = testcomponents.passthrough.static.output + 1
+				}
+			`,
+			severity: "warning",
+			message:  "evaluated with the zero values of the exports of other components",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			source, err := ParseSource(t.Name(), []byte(tc.cfg))
+			require.NoError(t, err)
+
+			diags := ctrl.DryRun(source, "")
+			if tc.message == "" {
+				require.Empty(t, diags)
+				return
+			}
+			require.Len(t, diags, 1)
+			require.Equal(t, tc.severity, diags[0].Severity)
+			require.Contains(t, diags[0].Message, tc.message)
+		})
+	}
+
+	// Dry runs never build components.
+	require.Empty(t, ctrl.loader.Components())
+}
@@ -0,0 +1,290 @@
+package runtime
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"runtime/metrics"
+	"runtime/pprof"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/google/pprof/profile"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/util"
+)
+
+// maxCPUProfileWindow is the longest duration the CPU is profiled for in each
+// resource accounting interval.
+const maxCPUProfileWindow = time.Second
+
+// resourceAccountant periodically estimates the resources used by each
+// running component from profiles filtered by component.ProfileLabel.
+//
+// A single resourceAccountant is shared between a root controller and all of
+// its modules, since profiles are collected for the whole process.
+type resourceAccountant struct {
+	log       log.Logger
+	interval  time.Duration
+	cpuWindow time.Duration
+
+	goroutinesDesc     *prometheus.Desc
+	cpuSecondsDesc     *prometheus.Desc
+	allocatedBytesDesc *prometheus.Desc
+
+	mut        sync.RWMutex
+	usage      map[string]component.ResourceUsage // Keyed by global component ID.
+	lastAllocs uint64
+}
+
+var _ prometheus.Collector = (*resourceAccountant)(nil)
+
+func newResourceAccountant(o controllerOptions, logger log.Logger) *resourceAccountant {
+	labels := []string{"component_path", "component_id"}
+	ra := &resourceAccountant{
+		log:       logger,
+		interval:  o.ResourceUsageInterval,
+		cpuWindow: min(maxCPUProfileWindow, o.ResourceUsageInterval/2),
+
+		goroutinesDesc: prometheus.NewDesc(
+			"alloy_component_goroutines",
+			"Number of goroutines started by the component.",
+			labels, nil,
+		),
+		cpuSecondsDesc: prometheus.NewDesc(
+			"alloy_component_cpu_seconds_total",
+			"Estimated CPU time used by the component, in seconds.",
+			labels, nil,
+		),
+		allocatedBytesDesc: prometheus.NewDesc(
+			"alloy_component_allocated_bytes_total",
+			"Estimated number of heap bytes allocated by the component.",
+			labels, nil,
+		),
+
+		usage: make(map[string]component.ResourceUsage),
+	}
+	if o.Reg != nil {
+		util.MustRegisterOrGet(o.Reg, ra)
+	}
+	return ra
+}
+
+// run estimates the resources used by components every interval until ctx is
+// canceled.
+func (ra *resourceAccountant) run(ctx context.Context) {
+	ra.mut.Lock()
+	ra.lastAllocs = allocatedBytes()
+	ra.mut.Unlock()
+
+	t := time.NewTicker(ra.interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if err := ra.sample(ctx); err != nil {
+				level.Warn(ra.log).Log("msg", "failed to estimate component resource usage", "err", err)
+			}
+		}
+	}
+}
+
+// sample profiles the CPU for the CPU window, and updates the resource usage
+// of each running component.
+//
+// CPU time is extrapolated from the CPU window to the whole interval. Heap
+// allocations are split between components based on the CPU time they spent
+// allocating memory during the CPU window.
+func (ra *resourceAccountant) sample(ctx context.Context) error {
+	cpu, cpuErr := profileCPU(ctx, ra.cpuWindow)
+	if cpuErr != nil {
+		// Another CPU profile may be running, for example from the
+		// /debug/pprof/profile endpoint. CPU usage is skipped for this
+		// interval.
+		level.Debug(ra.log).Log("msg", "skipping CPU resource accounting", "err", cpuErr)
+	}
+
+	goroutines, err := goroutinesByComponent()
+	if err != nil {
+		return err
+	}
+
+	ra.mut.Lock()
+	defer ra.mut.Unlock()
+
+	allocs := allocatedBytes()
+	allocDelta := allocs - ra.lastAllocs
+	ra.lastAllocs = allocs
+
+	scale := float64(ra.interval) / float64(ra.cpuWindow)
+
+	// Every running component has at least one goroutine, so components
+	// without goroutines have exited and are removed.
+	usage := make(map[string]component.ResourceUsage, len(goroutines))
+	for id, n := range goroutines {
+		u := ra.usage[id]
+		u.Goroutines = n
+		if cpuErr == nil {
+			u.CPUSeconds += time.Duration(cpu.cpu[id]).Seconds() * scale
+			if cpu.totalMalloc > 0 {
+				u.AllocatedBytes += float64(allocDelta) * float64(cpu.malloc[id]) / float64(cpu.totalMalloc)
+			}
+		}
+		usage[id] = u
+	}
+	ra.usage = usage
+	return nil
+}
+
+// Usage returns the resource usage of the component with the given global ID,
+// or nil if the component isn't running.
+func (ra *resourceAccountant) Usage(id string) *component.ResourceUsage {
+	ra.mut.RLock()
+	defer ra.mut.RUnlock()
+
+	u, ok := ra.usage[id]
+	if !ok {
+		return nil
+	}
+	return &u
+}
+
+// Describe implements prometheus.Collector.
+func (ra *resourceAccountant) Describe(ch chan<- *prometheus.Desc) {
+	ch <- ra.goroutinesDesc
+	ch <- ra.cpuSecondsDesc
+	ch <- ra.allocatedBytesDesc
+}
+
+// Collect implements prometheus.Collector.
+func (ra *resourceAccountant) Collect(ch chan<- prometheus.Metric) {
+	ra.mut.RLock()
+	defer ra.mut.RUnlock()
+
+	for id, u := range ra.usage {
+		globalID := component.ParseID(id)
+		labels := []string{"/" + globalID.ModuleID, globalID.LocalID}
+
+		ch <- prometheus.MustNewConstMetric(ra.goroutinesDesc, prometheus.GaugeValue, float64(u.Goroutines), labels...)
+		ch <- prometheus.MustNewConstMetric(ra.cpuSecondsDesc, prometheus.CounterValue, u.CPUSeconds, labels...)
+		ch <- prometheus.MustNewConstMetric(ra.allocatedBytesDesc, prometheus.CounterValue, u.AllocatedBytes, labels...)
+	}
+}
+
+// cpuSample is the CPU time spent by each component during a CPU profile.
+type cpuSample struct {
+	cpu         map[string]int64 // CPU nanoseconds, keyed by component.
+	malloc      map[string]int64 // CPU nanoseconds spent allocating memory, keyed by component.
+	totalMalloc int64            // CPU nanoseconds spent allocating memory by the whole process.
+}
+
+// profileCPU profiles the CPU for the given duration, or until ctx is
+// canceled.
+func profileCPU(ctx context.Context, d time.Duration) (cpuSample, error) {
+	var buf bytes.Buffer
+	if err := pprof.StartCPUProfile(&buf); err != nil {
+		return cpuSample{}, err
+	}
+
+	t := time.NewTimer(d)
+	select {
+	case <-ctx.Done():
+	case <-t.C:
+	}
+	t.Stop()
+	pprof.StopCPUProfile()
+
+	p, err := profile.Parse(&buf)
+	if err != nil {
+		return cpuSample{}, fmt.Errorf("failed to parse CPU profile: %w", err)
+	}
+	return newCPUSample(p), nil
+}
+
+func newCPUSample(p *profile.Profile) cpuSample {
+	res := cpuSample{
+		cpu:    make(map[string]int64),
+		malloc: make(map[string]int64),
+	}
+
+	valueIndex := len(p.SampleType) - 1
+	for i, st := range p.SampleType {
+		if st.Type == "cpu" {
+			valueIndex = i
+		}
+	}
+
+	for _, s := range p.Sample {
+		v := s.Value[valueIndex]
+		id, labeled := sampleComponent(s)
+		if labeled {
+			res.cpu[id] += v
+		}
+		if !isMalloc(s) {
+			continue
+		}
+		res.totalMalloc += v
+		if labeled {
+			res.malloc[id] += v
+		}
+	}
+	return res
+}
+
+// isMalloc returns whether the sample was taken while allocating heap memory.
+func isMalloc(s *profile.Sample) bool {
+	for _, loc := range s.Location {
+		for _, line := range loc.Line {
+			if line.Function != nil && line.Function.Name == "runtime.mallocgc" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// goroutinesByComponent returns the number of goroutines of each component.
+func goroutinesByComponent() (map[string]int, error) {
+	var buf bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&buf, 0); err != nil {
+		return nil, err
+	}
+	p, err := profile.Parse(&buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse goroutine profile: %w", err)
+	}
+
+	res := make(map[string]int)
+	for _, s := range p.Sample {
+		if id, ok := sampleComponent(s); ok {
+			res[id] += int(s.Value[0])
+		}
+	}
+	return res, nil
+}
+
+func sampleComponent(s *profile.Sample) (string, bool) {
+	ids := s.Label[component.ProfileLabel]
+	if len(ids) == 0 {
+		return "", false
+	}
+	return ids[0], true
+}
+
+// allocatedBytes returns the cumulative number of heap bytes allocated by the
+// process.
+func allocatedBytes() uint64 {
+	s := []metrics.Sample{{Name: "/gc/heap/allocs:bytes"}}
+	metrics.Read(s)
+	if s[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return s[0].Value.Uint64()
+}
@@ -0,0 +1,89 @@
+package runtime
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/pprof/profile"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/component"
+)
+
+func TestResourceUsage(t *testing.T) {
+	defer verifyNoGoroutineLeaks(t)
+
+	reg := prometheus.NewRegistry()
+	opts := testOptions(t)
+	opts.Reg = reg
+	opts.ResourceUsageInterval = 100 * time.Millisecond
+
+	ctrl, err := New(opts)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		ctrl.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	source, err := ParseSource(t.Name(), []byte(`
+		testcomponents.tick "ticker" {
+			frequency = "10ms"
+		}
+	`))
+	require.NoError(t, err)
+	require.NoError(t, ctrl.LoadSource(source, nil, ""))
+
+	var info *component.Info
+	require.Eventually(t, func() bool {
+		info, err = ctrl.GetComponent(component.ID{LocalID: "testcomponents.tick.ticker"}, component.InfoOptions{GetResourceUsage: true})
+		require.NoError(t, err)
+		return info.ResourceUsage != nil
+	}, 5*time.Second, 10*time.Millisecond)
+	require.GreaterOrEqual(t, info.ResourceUsage.Goroutines, 1)
+
+	for _, name := range []string{"alloy_component_goroutines", "alloy_component_cpu_seconds_total", "alloy_component_allocated_bytes_total"} {
+		n, err := testutil.GatherAndCount(reg, name)
+		require.NoError(t, err)
+		require.Equal(t, 1, n, name)
+	}
+
+	// Resource usage isn't set unless requested.
+	info, err = ctrl.GetComponent(component.ID{LocalID: "testcomponents.tick.ticker"}, component.InfoOptions{})
+	require.NoError(t, err)
+	require.Nil(t, info.ResourceUsage)
+}
+
+func TestCPUSample(t *testing.T) {
+	var (
+		mallocgc = &profile.Function{ID: 1, Name: "runtime.mallocgc"}
+		work     = &profile.Function{ID: 2, Name: "main.work"}
+
+		mallocLoc = &profile.Location{ID: 1, Line: []profile.Line{{Function: mallocgc}, {Function: work}}}
+		workLoc   = &profile.Location{ID: 2, Line: []profile.Line{{Function: work}}}
+	)
+
+	p := &profile.Profile{
+		SampleType: []*profile.ValueType{{Type: "samples", Unit: "count"}, {Type: "cpu", Unit: "nanoseconds"}},
+		Sample: []*profile.Sample{
+			{Location: []*profile.Location{workLoc}, Value: []int64{1, 100}, Label: map[string][]string{component.ProfileLabel: {"a"}}},
+			{Location: []*profile.Location{mallocLoc}, Value: []int64{1, 30}, Label: map[string][]string{component.ProfileLabel: {"a"}}},
+			{Location: []*profile.Location{mallocLoc}, Value: []int64{1, 10}, Label: map[string][]string{component.ProfileLabel: {"module/b"}}},
+			{Location: []*profile.Location{mallocLoc}, Value: []int64{1, 60}},
+		},
+	}
+
+	s := newCPUSample(p)
+	require.Equal(t, map[string]int64{"a": 130, "module/b": 10}, s.cpu)
+	require.Equal(t, map[string]int64{"a": 30, "module/b": 10}, s.malloc)
+	require.Equal(t, int64(100), s.totalMalloc)
+}
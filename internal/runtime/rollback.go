@@ -0,0 +1,190 @@
+package runtime
+
+import (
+	"context"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/runtime/internal/controller"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/util"
+)
+
+// loadRequest holds the parameters of a call to LoadSource.
+type loadRequest struct {
+	source     *Source
+	args       map[string]any
+	configPath string
+}
+
+// knownGood is a config source which didn't degrade component health, with
+// the health of the components once its grace period passed.
+type knownGood struct {
+	req    loadRequest
+	health componentHealth
+}
+
+// componentHealth maps the global IDs of components to whether they're
+// healthy.
+type componentHealth map[string]bool
+
+// rollbackWatcher tracks the last-known-good config source of a controller
+// and the pending health check of the most recent reload.
+type rollbackWatcher struct {
+	gracePeriod time.Duration
+	rollbacks   prometheus.Counter
+
+	// ctx is canceled when the controller stops running.
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mut           sync.Mutex // Serializes reloads and health checks.
+	lastGood      *knownGood
+	cancelPending context.CancelFunc // Cancels the pending health check.
+}
+
+func newRollbackWatcher(o controllerOptions) *rollbackWatcher {
+	rollbacks := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "alloy_config_rollbacks_total",
+		Help: "Number of times the config was reverted to the last-known-good config because component health degraded after a reload.",
+	}, []string{"controller_id"})
+	if o.Reg != nil {
+		rollbacks = util.MustRegisterOrGet(o.Reg, rollbacks).(*prometheus.CounterVec)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &rollbackWatcher{
+		gracePeriod: o.RollbackGracePeriod,
+		rollbacks:   rollbacks.WithLabelValues(o.ControllerID),
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// loadSourceWithRollback loads req and schedules a health check after the
+// grace period. The check reverts to the last-known-good source if a
+// component is unhealthy which was healthy or didn't exist with the
+// last-known-good source, and otherwise makes req the last-known-good source.
+// The pending health check of a previous reload is canceled, so that reloads
+// are always compared with the last-known-good source.
+func (f *Runtime) loadSourceWithRollback(req loadRequest) error {
+	rw := f.rollback
+
+	rw.mut.Lock()
+	defer rw.mut.Unlock()
+
+	if rw.cancelPending != nil {
+		rw.cancelPending()
+		rw.cancelPending = nil
+	}
+
+	err := f.loadRequest(req)
+	if err != nil && rw.lastGood == nil {
+		// There is nothing to revert to, and a source which failed to load
+		// can't become the last-known-good source.
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(rw.ctx)
+	rw.cancelPending = cancel
+	go f.checkReload(ctx, req)
+
+	return err
+}
+
+// checkReload waits for the grace period and then compares the health of
+// components with the last-known-good source. checkReload exits without
+// checking anything if ctx is canceled before the check runs.
+func (f *Runtime) checkReload(ctx context.Context, req loadRequest) {
+	rw := f.rollback
+
+	t := time.NewTimer(rw.gracePeriod)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return
+	case <-t.C:
+	}
+
+	rw.mut.Lock()
+	defer rw.mut.Unlock()
+
+	// Another reload may have happened while we were waiting for the lock.
+	if ctx.Err() != nil {
+		return
+	}
+	rw.cancelPending = nil
+
+	health := f.componentHealth()
+	if rw.lastGood == nil {
+		// The first source is known-good once it's loaded; there is nothing
+		// to compare it with.
+		rw.lastGood = &knownGood{req: req, health: health}
+		return
+	}
+
+	degraded := degradedComponents(rw.lastGood.health, health)
+	if len(degraded) == 0 {
+		rw.lastGood = &knownGood{req: req, health: health}
+		return
+	}
+
+	level.Warn(f.log).Log(
+		"msg", "component health degraded after reload, reverting to last-known-good config",
+		"grace_period", rw.gracePeriod,
+		"degraded_components", strings.Join(degraded, ","),
+	)
+	rw.rollbacks.Inc()
+
+	if err := f.loadRequest(rw.lastGood.req); err != nil {
+		level.Error(f.log).Log("msg", "failed to revert to last-known-good config", "err", err)
+		return
+	}
+	level.Info(f.log).Log("msg", "reverted to last-known-good config")
+}
+
+// degradedComponents returns the sorted IDs of the unhealthy components in
+// current which were healthy or didn't exist in good. Components which were
+// already unhealthy in good don't count as degraded.
+func degradedComponents(good, current componentHealth) []string {
+	var degraded []string
+	for id, healthy := range current {
+		if healthy {
+			continue
+		}
+		if wasHealthy, existed := good[id]; existed && !wasHealthy {
+			continue
+		}
+		degraded = append(degraded, id)
+	}
+	slices.Sort(degraded)
+	return degraded
+}
+
+// componentHealth returns the health of the components in the controller and
+// its modules.
+func (f *Runtime) componentHealth() componentHealth {
+	health := make(componentHealth)
+	addComponentHealth(health, "", f.loader.Components())
+	for _, mod := range f.modules.List() {
+		addComponentHealth(health, mod.o.ID, mod.f.loader.Components())
+	}
+	return health
+}
+
+func addComponentHealth(health componentHealth, moduleID string, components []controller.ComponentNode) {
+	for _, cn := range components {
+		id := cn.NodeID()
+		if moduleID != "" {
+			id = path.Join(moduleID, id)
+		}
+		health[id] = cn.CurrentHealth().Health != component.HealthTypeUnhealthy
+	}
+}
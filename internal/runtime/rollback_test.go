@@ -0,0 +1,118 @@
+package runtime
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/runtime/internal/testcomponents"
+)
+
+func TestRollback(t *testing.T) {
+	defer verifyNoGoroutineLeaks(t)
+
+	var (
+		goodA = `testcomponents.passthrough "pt" { input = "a" }`
+		goodB = `testcomponents.passthrough "pt" { input = "b" }`
+		bad   = `testcomponents.passthrough "pt" { input = {} }`
+	)
+
+	reg := prometheus.NewRegistry()
+	opts := testOptions(t)
+	opts.Reg = reg
+	opts.RollbackGracePeriod = 100 * time.Millisecond
+
+	ctrl, err := New(opts)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		ctrl.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	load := func(cfg string) error {
+		source, err := ParseSource(t.Name(), []byte(cfg))
+		require.NoError(t, err)
+		return ctrl.LoadSource(source, nil, "")
+	}
+	input := func() string {
+		args := ctrl.loader.Components()[0].Arguments()
+		return args.(testcomponents.PassthroughConfig).Input
+	}
+	health := func() component.HealthType {
+		return ctrl.loader.Components()[0].CurrentHealth().Health
+	}
+	rollbacks := func() float64 {
+		return testutil.ToFloat64(ctrl.rollback.rollbacks)
+	}
+
+	require.NoError(t, load(goodA))
+	require.NoError(t, load(goodB))
+
+	// Wait for goodB to be confirmed as the last-known-good config.
+	require.Eventually(t, func() bool {
+		ctrl.rollback.mut.Lock()
+		defer ctrl.rollback.mut.Unlock()
+		return ctrl.rollback.lastGood != nil && string(ctrl.rollback.lastGood.req.source.RawConfigs()[t.Name()]) == goodB
+	}, time.Second, 10*time.Millisecond)
+
+	require.Error(t, load(bad))
+	require.Equal(t, component.HealthTypeUnhealthy, health())
+
+	require.Eventually(t, func() bool { return rollbacks() == 1 }, time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool {
+		return health() != component.HealthTypeUnhealthy
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, "b", input())
+
+	// Reloading a degraded config within the grace period of the previous
+	// reload is still compared with the last-known-good config.
+	require.Error(t, load(bad))
+	require.Error(t, load(bad))
+	require.Eventually(t, func() bool { return rollbacks() == 2 }, time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool {
+		return health() != component.HealthTypeUnhealthy
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, "b", input())
+
+	// A reload superseding a pending health check cancels it.
+	require.Error(t, load(bad))
+	require.NoError(t, load(goodA))
+	time.Sleep(3 * opts.RollbackGracePeriod)
+	require.Equal(t, float64(2), rollbacks())
+	require.Equal(t, "a", input())
+}
+
+func TestDegradedComponents(t *testing.T) {
+	good := componentHealth{"a": true, "b": false, "c": true}
+
+	tt := []struct {
+		name     string
+		current  componentHealth
+		expected []string
+	}{
+		{"unchanged", componentHealth{"a": true, "b": false, "c": true}, nil},
+		{"recovered", componentHealth{"a": true, "b": true, "c": true}, nil},
+		{"removed", componentHealth{"a": true}, nil},
+		{"broken", componentHealth{"a": false, "b": false, "c": true}, []string{"a"}},
+		{"one recovers and another breaks", componentHealth{"a": true, "b": true, "c": false}, []string{"c"}},
+		{"new unhealthy component", componentHealth{"a": true, "b": false, "c": true, "d": false}, []string{"d"}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, degradedComponents(good, tc.current))
+		})
+	}
+}
@@ -9,13 +9,26 @@ import (
 	"github.com/grafana/alloy/internal/nodeconf/argument"
 	"github.com/grafana/alloy/internal/nodeconf/export"
 	"github.com/grafana/alloy/internal/nodeconf/foreach"
+	"github.com/grafana/alloy/internal/nodeconf/function"
 	"github.com/grafana/alloy/internal/nodeconf/importsource"
 	"github.com/grafana/alloy/internal/static/config/encoder"
 	"github.com/grafana/alloy/syntax/ast"
 	"github.com/grafana/alloy/syntax/diag"
+	"github.com/grafana/alloy/syntax/encoding/alloyjson"
 	"github.com/grafana/alloy/syntax/parser"
 )
 
+// SourceFormat is the format of a configuration source.
+type SourceFormat string
+
+const (
+	// SourceFormatAlloy is the Alloy configuration syntax.
+	SourceFormatAlloy SourceFormat = "alloy"
+	// SourceFormatJSON is the JSON representation of Alloy files produced by
+	// [alloyjson.MarshalFile]. Diagnostics point to the JSON document.
+	SourceFormatJSON SourceFormat = "json"
+)
+
 // A Source holds the contents of a parsed Alloy configuration source module.
 type Source struct {
 	sourceMap map[string][]byte // Map that links parsed Alloy source's name with its content.
@@ -33,11 +46,16 @@ type Source struct {
 //
 // bb must not be modified after passing to ParseSource.
 func ParseSource(name string, bb []byte) (*Source, error) {
+	return ParseSourceFormat(name, bb, SourceFormatAlloy)
+}
+
+// ParseSourceFormat is like ParseSource, but parses bb in the given format.
+func ParseSourceFormat(name string, bb []byte, format SourceFormat) (*Source, error) {
 	bb, err := encoder.EnsureUTF8(bb, true)
 	if err != nil {
 		return nil, err
 	}
-	node, err := parser.ParseFile(name, bb)
+	node, err := parseFile(name, bb, format)
 	if err != nil {
 		return nil, err
 	}
@@ -50,6 +68,18 @@ func ParseSource(name string, bb []byte) (*Source, error) {
 	return source, nil
 }
 
+// parseFile parses an Alloy file in the given format.
+func parseFile(name string, bb []byte, format SourceFormat) (*ast.File, error) {
+	switch format {
+	case SourceFormatAlloy:
+		return parser.ParseFile(name, bb)
+	case SourceFormatJSON:
+		return alloyjson.UnmarshalFile(name, bb)
+	default:
+		return nil, fmt.Errorf("unsupported source format %q", format)
+	}
+}
+
 // sourceFromBody creates a Source from an existing AST. This must only be used
 // internally as there will be no sourceMap or hash.
 func sourceFromBody(body ast.Body) (*Source, error) {
@@ -79,8 +109,8 @@ func sourceFromBody(body ast.Body) (*Source, error) {
 			switch fullName {
 			case "declare":
 				declares = append(declares, stmt)
-			case "logging", "tracing", argument.BlockName, export.BlockName, foreach.BlockName,
-				importsource.BlockNameFile, importsource.BlockNameString, importsource.BlockNameHTTP, importsource.BlockNameGit:
+			case "logging", "tracing", argument.BlockName, export.BlockName, foreach.BlockName, function.BlockName,
+				importsource.BlockNameFile, importsource.BlockNameString, importsource.BlockNameHTTP, importsource.BlockNameGit, importsource.BlockNameOCI:
 				configs = append(configs, stmt)
 			default:
 				components = append(components, stmt)
@@ -111,6 +141,12 @@ type namedSource struct {
 // ParseSources parses the map of sources and combines them into a single
 // Source. sources must not be modified after calling ParseSources.
 func ParseSources(sources map[string][]byte) (*Source, error) {
+	return ParseSourcesFormat(sources, SourceFormatAlloy)
+}
+
+// ParseSourcesFormat is like ParseSources, but parses all the sources in the
+// given format.
+func ParseSourcesFormat(sources map[string][]byte, format SourceFormat) (*Source, error) {
 	var (
 		// Collect diagnostic errors from several sources.
 		mergedDiags diag.Diagnostics
@@ -135,7 +171,7 @@ func ParseSources(sources map[string][]byte) (*Source, error) {
 
 	// Parse each .alloy source and compute new hash for the whole sourceMap
 	for _, namedSource := range sortedSources {
-		sourceFragment, err := ParseSource(namedSource.Name, namedSource.Content)
+		sourceFragment, err := ParseSourceFormat(namedSource.Name, namedSource.Content, format)
 		if err != nil {
 			// If we encounter diagnostic errors we combine them and
 			// later return all of them
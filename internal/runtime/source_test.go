@@ -61,6 +61,49 @@ func TestParseSource_Defaults(t *testing.T) {
 	require.Len(t, f.components, 0)
 }
 
+func TestParseSource_JSON(t *testing.T) {
+	content := `[
+		{
+			"name": "testcomponents.tick",
+			"type": "block",
+			"label": "ticker_a",
+			"body": [
+				{ "name": "frequency", "type": "attr", "value": { "type": "string", "value": "1s" } }
+			]
+		},
+		{
+			"name": "testcomponents.passthrough",
+			"type": "block",
+			"label": "static",
+			"body": [
+				{ "name": "input", "type": "attr", "value": { "type": "expr", "value": "testcomponents.tick.missing.tick_time" } }
+			]
+		}
+	]`
+
+	// The format is explicit: the name of the file doesn't select it.
+	_, err := ParseSource("config.json", []byte(content))
+	require.Error(t, err)
+
+	f, err := ParseSourceFormat("config.json", []byte(content), SourceFormatJSON)
+	require.NoError(t, err)
+
+	require.Len(t, f.components, 2)
+	require.Equal(t, "testcomponents.tick.ticker_a", getBlockID(f.components[0]))
+	require.Equal(t, "testcomponents.passthrough.static", getBlockID(f.components[1]))
+
+	// Diagnostics point to the JSON document.
+	ctrl, err := New(testOptions(t))
+	require.NoError(t, err)
+	defer cleanUpController(t.Context(), ctrl)
+	err = ctrl.LoadSource(f, nil, "")
+	var diags diag.Diagnostics
+	require.ErrorAs(t, err, &diags)
+	require.Equal(t, "config.json", diags[0].StartPos.Filename)
+	require.Equal(t, 15, diags[0].StartPos.Line)
+	require.Equal(t, strings.Index(content, "testcomponents.tick.missing"), diags[0].StartPos.Offset)
+}
+
 func TestParseSources_DuplicateComponent(t *testing.T) {
 	content := `
         logging {
@@ -56,6 +56,15 @@ const (
 	//	512 tokens per node: min 96.1%, median 99.9%, max 103.2% (stddev: 197.9 hashes)
 	tokensPerNode = 512
 
+	// MaxNodeWeight is the maximum weight of a node. A node with weight w gets
+	// w times as many tokens in the hash ring as a node with weight 1.
+	MaxNodeWeight = 100
+
+	// kvRebalanceInterval is how often entries of the KV store are sent to
+	// the nodes which should hold them, in addition to when the cluster
+	// changes.
+	kvRebalanceInterval = time.Minute
+
 	// maxPeersToLog is the maximum number of peers to log on info level. All peers are logged on debug level.
 	maxPeersToLog = 10
 
@@ -89,6 +98,18 @@ type Options struct {
 	MinimumClusterSize     int           // Minimum cluster size before admitting traffic to components that use clustering.
 	MinimumSizeWaitTimeout time.Duration // Maximum duration to wait for minimum cluster size before proceeding; 0 means no timeout.
 
+	// NodeWeight is the share of work assigned to this node relative to other
+	// nodes. A node with weight 2 owns twice as many keys as a node with weight
+	// 1. Zero means a weight of 1.
+	NodeWeight float64
+	// NodeZone is the zone, such as the availability zone, of this node. When
+	// a key has multiple owners, owners are picked from distinct zones when
+	// possible.
+	NodeZone string
+	// ReplicationFactor is the number of nodes owning each target distributed
+	// across the cluster. Zero means a replication factor of 1.
+	ReplicationFactor int
+
 	// Function to discover peers to join. If this function is nil or returns an
 	// empty slice, no peers will be joined.
 	DiscoverPeers discovery.DiscoverFn
@@ -100,9 +121,12 @@ type Service struct {
 	tracer trace.TracerProvider
 	opts   Options
 
-	sharder shard.Sharder
-	node    *ckit.Node
-	randGen *rand.Rand
+	sharder     shard.Sharder
+	nodeInfo    nodeInfo
+	infoFetcher *nodeInfoFetcher
+	kv          *kvStore
+	node        *ckit.Node
+	randGen     *rand.Rand
 
 	// alloyCluster is given to components via calls to Data() and implements Cluster.
 	alloyCluster *alloyCluster
@@ -147,11 +171,28 @@ func New(opts Options) (*Service, error) {
 		t = noop.NewTracerProvider()
 	}
 
+	if opts.NodeWeight == 0 {
+		opts.NodeWeight = 1
+	}
+	if opts.ReplicationFactor == 0 {
+		opts.ReplicationFactor = 1
+	}
+	info := nodeInfo{Weight: opts.NodeWeight, Zone: opts.NodeZone}
+	if err := info.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.ReplicationFactor < 0 {
+		return nil, fmt.Errorf("replication factor must be at least 1, got %d", opts.ReplicationFactor)
+	}
+
+	sharder := newWeightedSharder(tokensPerNode)
+	sharder.SetNodeInfo(opts.NodeName, info)
+
 	ckitConfig := ckit.Config{
 		Name:          opts.NodeName,
 		AdvertiseAddr: opts.AdvertiseAddress,
 		Log:           l,
-		Sharder:       shard.Ring(tokensPerNode),
+		Sharder:       sharder,
 		Label:         opts.ClusterName,
 		EnableTLS:     opts.EnableTLS,
 	}
@@ -199,12 +240,25 @@ func New(opts Options) (*Service, error) {
 		tracer: t,
 		opts:   opts,
 
-		sharder:             ckitConfig.Sharder,
+		sharder:             sharder,
+		nodeInfo:            info,
 		node:                node,
 		randGen:             rand.New(rand.NewSource(time.Now().UnixNano())),
 		notifyClusterChange: make(chan struct{}, 1),
 	}
-	s.alloyCluster = newAlloyCluster(ckitConfig.Sharder, s.triggerClusterChangeNotification, opts, l)
+	s.alloyCluster = newAlloyCluster(sharder, s.triggerClusterChangeNotification, opts, l)
+
+	// Peers expose their weight and zone next to the ckit transport.
+	scheme := "http"
+	if opts.EnableTLS {
+		scheme = "https"
+	}
+	base, _ := node.Handler()
+	s.infoFetcher = newNodeInfoFetcher(l, httpClient, scheme, base+nodeInfoEndpoint, sharder, s.triggerClusterChangeNotification)
+	sharder.onPeers = s.infoFetcher.Sync
+
+	s.kv = newKVStore(log.With(l, "subcomponent", "kv"), httpClient, scheme, base+kvEndpoint, sharder.Lookup)
+	s.alloyCluster.kv = s.kv
 
 	return s, nil
 }
@@ -267,7 +321,13 @@ func (s *Service) Definition() service.Definition {
 // ServiceHandler returns the service handler for the clustering service. The
 // resulting handler always returns 404 when clustering is disabled.
 func (s *Service) ServiceHandler(_ service.Host) (base string, handler http.Handler) {
-	base, handler = s.node.Handler()
+	base, ckitHandler := s.node.Handler()
+
+	mux := http.NewServeMux()
+	mux.Handle(base, ckitHandler)
+	mux.Handle(base+nodeInfoEndpoint, nodeInfoHandler(s.nodeInfo))
+	mux.Handle(base+kvEndpoint, s.kv)
+	handler = mux
 
 	if !s.opts.EnableClustering {
 		handler = http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
@@ -323,6 +383,9 @@ func (s *Service) Run(ctx context.Context, host service.Host) error {
 		"peers_count", len(peers),
 		"peers", strings.Join(peers, ","),
 		"advertise_addr", s.opts.AdvertiseAddress,
+		"node_weight", s.nodeInfo.Weight,
+		"node_zone", s.nodeInfo.Zone,
+		"replication_factor", s.opts.ReplicationFactor,
 		"minimum_cluster_size", s.opts.MinimumClusterSize,
 		"minimum_size_wait_timeout", s.opts.MinimumSizeWaitTimeout,
 	)
@@ -353,6 +416,23 @@ func (s *Service) Run(ctx context.Context, host service.Host) error {
 		}
 	}()
 
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+
+		t := time.NewTicker(kvRebalanceInterval)
+		defer t.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				s.kv.rebalance(ctx)
+			}
+		}
+	}()
+
 	if s.opts.EnableClustering && s.opts.RejoinInterval > 0 {
 		wg.Add(1)
 
@@ -410,6 +490,9 @@ func (s *Service) notifyComponentsOfClusterChanges(ctx context.Context, limiter
 	}
 	spanWait.End()
 
+	// Hand off entries of the KV store before components look them up.
+	s.kv.rebalance(ctx)
+
 	peers := s.node.Peers()
 	s.logPeers("peers changed", toStringSlice(peers))
 	span.SetAttributes(attribute.Int("peers_count", len(peers)))
@@ -498,6 +581,10 @@ func (s *Service) stop() {
 	if err := s.node.Stop(); err != nil {
 		level.Error(s.log).Log("msg", "failed to gracefully stop node", "err", err)
 	}
+
+	if s.infoFetcher != nil {
+		s.infoFetcher.Stop()
+	}
 }
 
 // Update implements [service.Service]. It returns an error since the cluster
@@ -52,6 +52,14 @@ type Cluster interface {
 	// - there is a minimum size requirement and the cluster size is >= that size
 	// - there is a minimum size requirement and cluster size is too small, but the configured wait deadline has passed.
 	Ready() bool
+
+	// ReplicationFactor returns the number of nodes which should own each
+	// target distributed across the cluster. It's always at least 1.
+	ReplicationFactor() int
+
+	// KV returns the key/value store shared by all nodes of the cluster. When
+	// clustering is disabled, the store is only visible to the local node.
+	KV() KV
 }
 
 // alloyCluster implements the Cluster interface and manages the admission control logic.
@@ -59,6 +67,7 @@ type alloyCluster struct {
 	log     log.Logger
 	sharder shard.Sharder
 	opts    Options
+	kv      KV
 
 	clusterChangeCallback func()
 	clusterReadyGauge     prometheus.Gauge
@@ -129,6 +138,14 @@ func (c *alloyCluster) Lookup(key shard.Key, replicationFactor int, op shard.Op)
 	return c.sharder.Lookup(key, replicationFactor, op)
 }
 
+func (c *alloyCluster) ReplicationFactor() int {
+	return max(1, c.opts.ReplicationFactor)
+}
+
+func (c *alloyCluster) KV() KV {
+	return c.kv
+}
+
 func (c *alloyCluster) Peers() []peer.Peer {
 	return c.sharder.Peers()
 }
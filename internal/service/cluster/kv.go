@@ -0,0 +1,541 @@
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/ckit/peer"
+	"github.com/grafana/ckit/shard"
+
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+)
+
+const (
+	// kvEndpoint is the endpoint serving the KV store of the node, relative to
+	// the base route of the cluster service.
+	kvEndpoint = "kv"
+
+	// MaxKVKeySize is the maximum size of keys in the KV store, in bytes.
+	MaxKVKeySize = 256
+	// MaxKVValueSize is the maximum size of values in the KV store, in bytes.
+	MaxKVValueSize = 64 << 10
+
+	// kvReplicas is the number of nodes holding each entry: the owner of the
+	// key and the nodes it replicates the entry to.
+	kvReplicas = 2
+	// kvTombstoneTTL is how long deleted entries are remembered, so that
+	// replicas holding older versions don't resurrect them.
+	kvTombstoneTTL = time.Minute
+	// kvReplicateBatchSize is the maximum number of entries sent to a peer in
+	// a single request when rebalancing.
+	kvReplicateBatchSize = 100
+	// kvRequestTimeout is the timeout of requests to other peers.
+	kvRequestTimeout = 10 * time.Second
+	// kvMaxClockSkew is how far ahead of the local clock the version of a
+	// replicated record can be. Versions are derived from the clock of the
+	// writer, so a record with a version far in the future would reject the
+	// writes to its key until the clock catches up.
+	kvMaxClockSkew = time.Hour
+)
+
+var (
+	// ErrKeyNotFound is returned when a key doesn't exist in the KV store, or
+	// has expired.
+	ErrKeyNotFound = errors.New("key not found")
+	// ErrVersionConflict is returned when the version given to a conditional
+	// operation doesn't match the current version of the key.
+	ErrVersionConflict = errors.New("version conflict")
+)
+
+// KV is a key/value store shared by all nodes of the cluster. It's meant for
+// small pieces of state, such as checkpoints, leases or deduplication keys.
+//
+// Each key is owned by the node owning the key in the hash ring, which serves
+// all operations on the key and replicates the entry to the next node of the
+// ring. Entries are handed off to their new owners when the cluster changes.
+// Operations on a key are linearizable as long as all nodes agree on its
+// owner; entries can be lost if both nodes holding them leave the cluster
+// abruptly.
+type KV interface {
+	// Get returns the entry for key. ErrKeyNotFound is returned if the key
+	// doesn't exist or has expired.
+	Get(ctx context.Context, key string) (KVEntry, error)
+
+	// Put sets the value of key, regardless of its current version. If ttl is
+	// greater than zero, the entry expires after ttl.
+	Put(ctx context.Context, key string, value []byte, ttl time.Duration) (KVEntry, error)
+
+	// CompareAndSwap sets the value of key only if its current version is
+	// version. A version of zero means that key must not exist. If the
+	// version doesn't match, ErrVersionConflict is returned.
+	CompareAndSwap(ctx context.Context, key string, version uint64, value []byte, ttl time.Duration) (KVEntry, error)
+
+	// Delete deletes key. If version is not zero, key is only deleted if its
+	// current version is version, otherwise ErrVersionConflict is returned.
+	// Deleting a key which doesn't exist isn't an error.
+	Delete(ctx context.Context, key string, version uint64) error
+}
+
+// KVEntry is an entry of a KV store.
+type KVEntry struct {
+	Key   string `json:"key"`
+	Value []byte `json:"value"`
+	// Version increases every time the entry is written.
+	Version uint64 `json:"version"`
+	// Expires is when the entry expires. It's zero for entries without a TTL.
+	Expires time.Time `json:"expires,omitzero"`
+}
+
+// NewLocalKV returns a KV which isn't shared with other nodes, for use where
+// clustering isn't available.
+func NewLocalKV() KV {
+	self := peer.Peer{Name: "self", Self: true, State: peer.StateParticipant}
+	return newKVStore(log.NewNopLogger(), nil, "", "", func(shard.Key, int, shard.Op) ([]peer.Peer, error) {
+		return []peer.Peer{self}, nil
+	})
+}
+
+// kvRecord is an entry held by a node, which can be a tombstone.
+type kvRecord struct {
+	KVEntry
+	Deleted bool `json:"deleted,omitempty"`
+}
+
+func (r kvRecord) expired(now time.Time) bool {
+	return !r.Expires.IsZero() && !now.Before(r.Expires)
+}
+
+// live reports whether r holds a value at time now.
+func (r kvRecord) live(now time.Time) bool {
+	return !r.Deleted && !r.expired(now)
+}
+
+type kvOp string
+
+const (
+	kvOpGet       kvOp = "get"
+	kvOpPut       kvOp = "put"
+	kvOpCAS       kvOp = "cas"
+	kvOpDelete    kvOp = "delete"
+	kvOpReplicate kvOp = "replicate"
+)
+
+// kvRequest is an operation sent to the owner of a key, or a set of records
+// sent to replicas.
+type kvRequest struct {
+	Op      kvOp          `json:"op"`
+	Key     string        `json:"key,omitempty"`
+	Value   []byte        `json:"value,omitempty"`
+	Version uint64        `json:"version,omitempty"`
+	TTL     time.Duration `json:"ttl,omitempty"`
+
+	Records []kvRecord `json:"records,omitempty"`
+}
+
+type kvResponse struct {
+	Entry KVEntry `json:"entry"`
+	Error string  `json:"error,omitempty"`
+}
+
+// kvStore implements KV. It holds the entries owned or replicated by the
+// local node, and forwards operations on other keys to their owners.
+type kvStore struct {
+	log    log.Logger
+	client *http.Client
+	scheme string
+	path   string
+	lookup func(key shard.Key, numOwners int, op shard.Op) ([]peer.Peer, error)
+	now    func() time.Time
+
+	mut     sync.Mutex
+	records map[string]kvRecord
+}
+
+var _ KV = (*kvStore)(nil)
+
+func newKVStore(l log.Logger, client *http.Client, scheme, path string, lookup func(shard.Key, int, shard.Op) ([]peer.Peer, error)) *kvStore {
+	return &kvStore{
+		log:     l,
+		client:  client,
+		scheme:  scheme,
+		path:    path,
+		lookup:  lookup,
+		now:     time.Now,
+		records: map[string]kvRecord{},
+	}
+}
+
+// Get implements KV.
+func (kv *kvStore) Get(ctx context.Context, key string) (KVEntry, error) {
+	return kv.do(ctx, kvRequest{Op: kvOpGet, Key: key})
+}
+
+// Put implements KV.
+func (kv *kvStore) Put(ctx context.Context, key string, value []byte, ttl time.Duration) (KVEntry, error) {
+	return kv.do(ctx, kvRequest{Op: kvOpPut, Key: key, Value: value, TTL: ttl})
+}
+
+// CompareAndSwap implements KV.
+func (kv *kvStore) CompareAndSwap(ctx context.Context, key string, version uint64, value []byte, ttl time.Duration) (KVEntry, error) {
+	return kv.do(ctx, kvRequest{Op: kvOpCAS, Key: key, Version: version, Value: value, TTL: ttl})
+}
+
+// Delete implements KV.
+func (kv *kvStore) Delete(ctx context.Context, key string, version uint64) error {
+	_, err := kv.do(ctx, kvRequest{Op: kvOpDelete, Key: key, Version: version})
+	return err
+}
+
+// do runs req on the owner of its key.
+func (kv *kvStore) do(ctx context.Context, req kvRequest) (KVEntry, error) {
+	if err := validateKVRequest(req); err != nil {
+		return KVEntry{}, err
+	}
+
+	owners, err := kv.owners(req.Key)
+	if err != nil {
+		return KVEntry{}, err
+	}
+	if !owners[0].Self {
+		return kv.forward(ctx, owners[0], req)
+	}
+	return kv.apply(ctx, req, owners[1:])
+}
+
+func validateKVRequest(req kvRequest) error {
+	switch {
+	case req.Key == "":
+		return fmt.Errorf("key must not be empty")
+	case len(req.Key) > MaxKVKeySize:
+		return fmt.Errorf("key must be at most %d bytes, got %d", MaxKVKeySize, len(req.Key))
+	case len(req.Value) > MaxKVValueSize:
+		return fmt.Errorf("value must be at most %d bytes, got %d", MaxKVValueSize, len(req.Value))
+	case req.TTL < 0:
+		return fmt.Errorf("ttl must not be negative, got %s", req.TTL)
+	}
+	return nil
+}
+
+// validateKVRecords validates records received from another node.
+func validateKVRecords(records []kvRecord, now time.Time) error {
+	if len(records) > kvReplicateBatchSize {
+		return fmt.Errorf("at most %d records can be replicated at once, got %d", kvReplicateBatchSize, len(records))
+	}
+
+	maxVersion := uint64(now.Add(kvMaxClockSkew).UnixNano())
+	for i, r := range records {
+		if err := validateKVRequest(kvRequest{Key: r.Key, Value: r.Value}); err != nil {
+			return fmt.Errorf("record %d: %w", i, err)
+		}
+		if r.Version == 0 || r.Version > maxVersion {
+			return fmt.Errorf("record %d: version must be between 1 and %d, got %d", i, maxVersion, r.Version)
+		}
+	}
+	return nil
+}
+
+// owners returns the nodes holding key, starting with its owner.
+func (kv *kvStore) owners(key string) ([]peer.Peer, error) {
+	var (
+		owners []peer.Peer
+		err    error
+	)
+	// Clusters smaller than kvReplicas can't hold as many replicas.
+	for n := kvReplicas; n > 0; n-- {
+		owners, err = kv.lookup(shard.StringKey(key), n, shard.OpReadWrite)
+		if err == nil && len(owners) > 0 {
+			return owners, nil
+		}
+	}
+	if err == nil {
+		err = fmt.Errorf("no peers")
+	}
+	return nil, fmt.Errorf("finding owner of key %q: %w", key, err)
+}
+
+// apply runs req against the local records and replicates the result to
+// replicas.
+func (kv *kvStore) apply(ctx context.Context, req kvRequest, replicas []peer.Peer) (KVEntry, error) {
+	now := kv.now()
+
+	kv.mut.Lock()
+	cur, found := kv.records[req.Key]
+	live := found && cur.live(now)
+
+	var next kvRecord
+	switch req.Op {
+	case kvOpGet:
+		kv.mut.Unlock()
+		if !live {
+			return KVEntry{}, ErrKeyNotFound
+		}
+		return cur.KVEntry, nil
+
+	case kvOpPut, kvOpCAS:
+		if req.Op == kvOpCAS && !versionMatches(cur, live, req.Version) {
+			kv.mut.Unlock()
+			return KVEntry{}, ErrVersionConflict
+		}
+		next = kvRecord{KVEntry: KVEntry{Key: req.Key, Value: req.Value}}
+		if req.TTL > 0 {
+			// Expiry times are sent to other nodes, so they're kept in UTC.
+			next.Expires = now.Add(req.TTL).UTC().Round(0)
+		}
+
+	case kvOpDelete:
+		if req.Version != 0 && !versionMatches(cur, live, req.Version) {
+			kv.mut.Unlock()
+			return KVEntry{}, ErrVersionConflict
+		}
+		if !live {
+			kv.mut.Unlock()
+			return KVEntry{}, nil
+		}
+		next = kvRecord{KVEntry: KVEntry{Key: req.Key, Expires: now.Add(kvTombstoneTTL)}, Deleted: true}
+
+	default:
+		kv.mut.Unlock()
+		return KVEntry{}, fmt.Errorf("unknown operation %q", req.Op)
+	}
+
+	// Versions are derived from the clock so that they keep increasing when
+	// the key moves to a node which didn't hold it.
+	next.Version = max(cur.Version+1, uint64(now.UnixNano()))
+	kv.records[req.Key] = next
+	kv.mut.Unlock()
+
+	// Replication is best-effort: rebalancing eventually brings replicas up to
+	// date.
+	for _, p := range replicas {
+		if err := kv.send(ctx, p, kvRequest{Op: kvOpReplicate, Records: []kvRecord{next}}, nil); err != nil {
+			level.Warn(kv.log).Log("msg", "failed to replicate key", "key", req.Key, "peer", p.Name, "err", err)
+		}
+	}
+
+	if next.Deleted {
+		return KVEntry{}, nil
+	}
+	return next.KVEntry, nil
+}
+
+func versionMatches(cur kvRecord, live bool, version uint64) bool {
+	if !live {
+		return version == 0
+	}
+	return cur.Version == version
+}
+
+// merge stores records received from other nodes, keeping the newest version
+// of each key.
+func (kv *kvStore) merge(records []kvRecord) {
+	kv.mut.Lock()
+	defer kv.mut.Unlock()
+
+	for _, r := range records {
+		if cur, found := kv.records[r.Key]; found && cur.Version >= r.Version {
+			continue
+		}
+		kv.records[r.Key] = r
+	}
+}
+
+// rebalance sends the records held by the local node to the nodes which
+// should hold them, and forgets records which the local node no longer has to
+// hold. Expired records are removed.
+func (kv *kvStore) rebalance(ctx context.Context) {
+	now := kv.now()
+
+	kv.mut.Lock()
+	records := make([]kvRecord, 0, len(kv.records))
+	for key, r := range kv.records {
+		if r.expired(now) {
+			delete(kv.records, key)
+			continue
+		}
+		records = append(records, r)
+	}
+	kv.mut.Unlock()
+
+	// Group records by the peers which should hold them.
+	var (
+		peers    = map[string]peer.Peer{}
+		batches  = map[string][]kvRecord{}
+		handOffs = map[string][]string{} // Keys which are no longer held locally, by peer they're handed off to.
+	)
+	for _, r := range records {
+		owners, err := kv.owners(r.Key)
+		if err != nil {
+			level.Debug(kv.log).Log("msg", "not rebalancing key", "key", r.Key, "err", err)
+			continue
+		}
+		held := false
+		for _, p := range owners {
+			if p.Self {
+				held = true
+				continue
+			}
+			peers[p.Name] = p
+			batches[p.Name] = append(batches[p.Name], r)
+		}
+		if !held {
+			handOffs[owners[0].Name] = append(handOffs[owners[0].Name], r.Key)
+		}
+	}
+
+	names := make([]string, 0, len(batches))
+	for name := range batches {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if ctx.Err() != nil {
+			return
+		}
+		if err := kv.replicate(ctx, peers[name], batches[name]); err != nil {
+			level.Warn(kv.log).Log("msg", "failed to send keys to peer", "peer", name, "err", err)
+			continue
+		}
+
+		// The new owner holds the records, so they can be forgotten.
+		kv.mut.Lock()
+		for _, key := range handOffs[name] {
+			delete(kv.records, key)
+		}
+		kv.mut.Unlock()
+	}
+}
+
+func (kv *kvStore) replicate(ctx context.Context, p peer.Peer, records []kvRecord) error {
+	for len(records) > 0 {
+		n := min(len(records), kvReplicateBatchSize)
+		if err := kv.send(ctx, p, kvRequest{Op: kvOpReplicate, Records: records[:n]}, nil); err != nil {
+			return err
+		}
+		records = records[n:]
+	}
+	return nil
+}
+
+// forward runs req on p.
+func (kv *kvStore) forward(ctx context.Context, p peer.Peer, req kvRequest) (KVEntry, error) {
+	var resp kvResponse
+	if err := kv.send(ctx, p, req, &resp); err != nil {
+		return KVEntry{}, err
+	}
+	return resp.Entry, nil
+}
+
+// send sends req to p, decoding the response into resp if it's not nil.
+func (kv *kvStore) send(ctx context.Context, p peer.Peer, req kvRequest, resp *kvResponse) error {
+	if kv.client == nil {
+		return fmt.Errorf("cannot reach peer %s", p.Name)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, kvRequestTimeout)
+	defer cancel()
+
+	bb, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, fmt.Sprintf("%s://%s%s", kv.scheme, p.Addr, kv.path), bytes.NewReader(bb))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := kv.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	var body kvResponse
+	if err := json.NewDecoder(io.LimitReader(httpResp.Body, 1<<20)).Decode(&body); err != nil {
+		return fmt.Errorf("decoding response from peer %s (status %s): %w", p.Name, httpResp.Status, err)
+	}
+
+	switch httpResp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return ErrKeyNotFound
+	case http.StatusConflict:
+		return ErrVersionConflict
+	default:
+		return fmt.Errorf("peer %s: %s", p.Name, body.Error)
+	}
+
+	if resp != nil {
+		*resp = body
+	}
+	return nil
+}
+
+// ServeHTTP serves operations forwarded by other nodes.
+func (kv *kvStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req kvRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 32<<20)).Decode(&req); err != nil {
+		writeKVResponse(w, http.StatusBadRequest, kvResponse{Error: err.Error()})
+		return
+	}
+
+	if req.Op == kvOpReplicate {
+		if err := validateKVRecords(req.Records, kv.now()); err != nil {
+			writeKVResponse(w, http.StatusBadRequest, kvResponse{Error: err.Error()})
+			return
+		}
+		kv.merge(req.Records)
+		writeKVResponse(w, http.StatusOK, kvResponse{})
+		return
+	}
+
+	if err := validateKVRequest(req); err != nil {
+		writeKVResponse(w, http.StatusBadRequest, kvResponse{Error: err.Error()})
+		return
+	}
+
+	// The sender believes that this node owns the key, so the operation is
+	// applied locally even if the local view of the ring differs. Views
+	// converge once membership changes have propagated.
+	var replicas []peer.Peer
+	if owners, err := kv.owners(req.Key); err == nil {
+		for _, p := range owners {
+			if !p.Self {
+				replicas = append(replicas, p)
+			}
+		}
+	}
+
+	entry, err := kv.apply(r.Context(), req, replicas)
+	switch {
+	case errors.Is(err, ErrKeyNotFound):
+		writeKVResponse(w, http.StatusNotFound, kvResponse{Error: err.Error()})
+	case errors.Is(err, ErrVersionConflict):
+		writeKVResponse(w, http.StatusConflict, kvResponse{Error: err.Error()})
+	case err != nil:
+		writeKVResponse(w, http.StatusInternalServerError, kvResponse{Error: err.Error()})
+	default:
+		writeKVResponse(w, http.StatusOK, kvResponse{Entry: entry})
+	}
+}
+
+func writeKVResponse(w http.ResponseWriter, status int, resp kvResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,329 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/ckit/peer"
+	"github.com/stretchr/testify/require"
+)
+
+// kvTestCluster is a set of KV stores talking to each other over HTTP.
+type kvTestCluster struct {
+	t       *testing.T
+	servers map[string]*httptest.Server
+	stores  map[string]*kvStore
+	addrs   map[string]string
+
+	mut         sync.Mutex
+	now         time.Time
+	sharders    map[string]*weightedSharder
+	terminating map[string]bool
+}
+
+func newKVTestCluster(t *testing.T, names ...string) *kvTestCluster {
+	c := &kvTestCluster{
+		t:           t,
+		servers:     map[string]*httptest.Server{},
+		stores:      map[string]*kvStore{},
+		addrs:       map[string]string{},
+		now:         time.Now(),
+		sharders:    map[string]*weightedSharder{},
+		terminating: map[string]bool{},
+	}
+	for _, name := range names {
+		c.addNode(name)
+	}
+	c.setPeers(names...)
+	return c
+}
+
+func (c *kvTestCluster) addNode(name string) {
+	sharder := newWeightedSharder(tokensPerNode)
+	var store *kvStore
+	srv := httptest.NewServer(nil)
+	store = newKVStore(log.NewNopLogger(), srv.Client(), "http", "/", sharder.Lookup)
+	store.now = c.clock
+	srv.Config.Handler = store
+	c.t.Cleanup(srv.Close)
+
+	c.servers[name] = srv
+	c.stores[name] = store
+	c.addrs[name] = strings.TrimPrefix(srv.URL, "http://")
+	c.sharders[name] = sharder
+}
+
+// setPeers sets the members of the cluster, as seen by all nodes.
+func (c *kvTestCluster) setPeers(names ...string) {
+	for self, sharder := range c.sharders {
+		peers := make([]peer.Peer, 0, len(names))
+		for _, name := range names {
+			state := peer.StateParticipant
+			if c.terminating[name] {
+				state = peer.StateTerminating
+			}
+			peers = append(peers, peer.Peer{Name: name, Addr: c.addrs[name], Self: name == self, State: state})
+		}
+		sharder.SetPeers(peers)
+	}
+}
+
+func (c *kvTestCluster) clock() time.Time {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	return c.now
+}
+
+func (c *kvTestCluster) advance(d time.Duration) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// holders returns the nodes holding a record for key.
+func (c *kvTestCluster) holders(key string) []string {
+	var res []string
+	for _, name := range []string{"node-a", "node-b", "node-c", "node-d"} {
+		store, ok := c.stores[name]
+		if !ok {
+			continue
+		}
+		store.mut.Lock()
+		if _, ok := store.records[key]; ok {
+			res = append(res, name)
+		}
+		store.mut.Unlock()
+	}
+	return res
+}
+
+func TestKV_SharedAcrossNodes(t *testing.T) {
+	var (
+		ctx = t.Context()
+		c   = newKVTestCluster(t, "node-a", "node-b", "node-c")
+	)
+
+	for i := range 20 {
+		key := fmt.Sprintf("key-%d", i)
+		entry, err := c.stores["node-a"].Put(ctx, key, []byte("value"), 0)
+		require.NoError(t, err)
+		require.Equal(t, key, entry.Key)
+
+		for _, name := range []string{"node-a", "node-b", "node-c"} {
+			actual, err := c.stores[name].Get(ctx, key)
+			require.NoError(t, err)
+			require.Equal(t, entry, actual)
+		}
+
+		// The entry is held by its owner and replicated to one other node.
+		require.Len(t, c.holders(key), kvReplicas)
+	}
+}
+
+func TestKV_CompareAndSwap(t *testing.T) {
+	var (
+		ctx = t.Context()
+		c   = newKVTestCluster(t, "node-a", "node-b", "node-c")
+		a   = c.stores["node-a"]
+		b   = c.stores["node-b"]
+	)
+
+	// Version 0 creates the key only if it doesn't exist.
+	created, err := a.CompareAndSwap(ctx, "lease", 0, []byte("node-a"), 0)
+	require.NoError(t, err)
+	_, err = b.CompareAndSwap(ctx, "lease", 0, []byte("node-b"), 0)
+	require.ErrorIs(t, err, ErrVersionConflict)
+
+	updated, err := b.CompareAndSwap(ctx, "lease", created.Version, []byte("node-b"), 0)
+	require.NoError(t, err)
+	require.Greater(t, updated.Version, created.Version)
+
+	_, err = a.CompareAndSwap(ctx, "lease", created.Version, []byte("node-a"), 0)
+	require.ErrorIs(t, err, ErrVersionConflict)
+
+	require.ErrorIs(t, a.Delete(ctx, "lease", created.Version), ErrVersionConflict)
+	require.NoError(t, a.Delete(ctx, "lease", updated.Version))
+	_, err = b.Get(ctx, "lease")
+	require.ErrorIs(t, err, ErrKeyNotFound)
+	require.NoError(t, a.Delete(ctx, "lease", 0))
+
+	// Deleted keys can be created again.
+	recreated, err := b.CompareAndSwap(ctx, "lease", 0, []byte("node-b"), 0)
+	require.NoError(t, err)
+	require.Greater(t, recreated.Version, updated.Version)
+}
+
+func TestKV_TTL(t *testing.T) {
+	var (
+		ctx = t.Context()
+		c   = newKVTestCluster(t, "node-a", "node-b")
+		a   = c.stores["node-a"]
+	)
+
+	entry, err := a.Put(ctx, "checkpoint", []byte("42"), time.Minute)
+	require.NoError(t, err)
+	require.True(t, c.clock().Add(time.Minute).Equal(entry.Expires))
+
+	c.advance(59 * time.Second)
+	_, err = a.Get(ctx, "checkpoint")
+	require.NoError(t, err)
+
+	c.advance(time.Second)
+	_, err = a.Get(ctx, "checkpoint")
+	require.ErrorIs(t, err, ErrKeyNotFound)
+
+	// Expired keys can be created with CompareAndSwap, and are removed when
+	// rebalancing.
+	_, err = a.Put(ctx, "other", []byte("1"), time.Second)
+	require.NoError(t, err)
+	c.advance(time.Second)
+	for _, store := range c.stores {
+		store.rebalance(ctx)
+	}
+	require.Empty(t, c.holders("other"))
+
+	_, err = a.CompareAndSwap(ctx, "checkpoint", 0, []byte("43"), 0)
+	require.NoError(t, err)
+}
+
+func TestKV_Rebalance(t *testing.T) {
+	var (
+		ctx = t.Context()
+		c   = newKVTestCluster(t, "node-a", "node-b")
+	)
+
+	entries := map[string]KVEntry{}
+	for i := range 50 {
+		key := fmt.Sprintf("key-%d", i)
+		entry, err := c.stores["node-a"].Put(ctx, key, []byte(key), 0)
+		require.NoError(t, err)
+		entries[key] = entry
+	}
+
+	// Add two nodes: keys they now own are handed off to them.
+	c.addNode("node-c")
+	c.addNode("node-d")
+	c.setPeers("node-a", "node-b", "node-c", "node-d")
+	for _, store := range c.stores {
+		store.rebalance(ctx)
+	}
+
+	for key, entry := range entries {
+		require.Len(t, c.holders(key), kvReplicas, "key %s", key)
+		actual, err := c.stores["node-d"].Get(ctx, key)
+		require.NoError(t, err)
+		require.Equal(t, entry, actual)
+	}
+
+	// Shut down the original nodes: they hand off their keys while
+	// terminating.
+	c.terminating["node-a"] = true
+	c.terminating["node-b"] = true
+	c.setPeers("node-a", "node-b", "node-c", "node-d")
+	for _, name := range []string{"node-a", "node-b"} {
+		c.stores[name].rebalance(ctx)
+	}
+	require.Empty(t, c.stores["node-a"].records)
+	require.Empty(t, c.stores["node-b"].records)
+	c.setPeers("node-c", "node-d")
+
+	for key, entry := range entries {
+		actual, err := c.stores["node-c"].Get(ctx, key)
+		require.NoError(t, err, "key %s", key)
+		require.Equal(t, entry, actual)
+	}
+}
+
+func TestKV_Validation(t *testing.T) {
+	var (
+		ctx = t.Context()
+		kv  = NewLocalKV()
+	)
+
+	_, err := kv.Put(ctx, "", nil, 0)
+	require.ErrorContains(t, err, "key must not be empty")
+	_, err = kv.Put(ctx, strings.Repeat("k", MaxKVKeySize+1), nil, 0)
+	require.ErrorContains(t, err, "key must be at most")
+	_, err = kv.Put(ctx, "key", make([]byte, MaxKVValueSize+1), 0)
+	require.ErrorContains(t, err, "value must be at most")
+	_, err = kv.Put(ctx, "key", nil, -time.Second)
+	require.ErrorContains(t, err, "ttl must not be negative")
+
+	_, err = kv.Get(context.Background(), "key")
+	require.ErrorIs(t, err, ErrKeyNotFound)
+}
+
+func TestKV_ReplicateValidation(t *testing.T) {
+	var (
+		ctx   = t.Context()
+		c     = newKVTestCluster(t, "node-a")
+		store = c.stores["node-a"]
+	)
+
+	entry, err := store.Put(ctx, "key", []byte("value"), 0)
+	require.NoError(t, err)
+
+	record := func(key string, value []byte, version uint64) kvRecord {
+		return kvRecord{KVEntry: KVEntry{Key: key, Value: value, Version: version}}
+	}
+
+	tt := []struct {
+		name    string
+		records []kvRecord
+		expect  string
+	}{
+		{
+			name:    "empty key",
+			records: []kvRecord{record("", nil, entry.Version+1)},
+			expect:  "record 0: key must not be empty",
+		},
+		{
+			name:    "key too large",
+			records: []kvRecord{record(strings.Repeat("k", MaxKVKeySize+1), nil, entry.Version+1)},
+			expect:  "record 0: key must be at most",
+		},
+		{
+			name:    "value too large",
+			records: []kvRecord{record("key", make([]byte, MaxKVValueSize+1), entry.Version+1)},
+			expect:  "record 0: value must be at most",
+		},
+		{
+			name:    "zero version",
+			records: []kvRecord{record("other", nil, 0)},
+			expect:  "record 0: version must be between 1 and",
+		},
+		{
+			name:    "version in the future",
+			records: []kvRecord{record("other", nil, 1), record("key", []byte("stolen"), math.MaxUint64)},
+			expect:  "record 1: version must be between 1 and",
+		},
+		{
+			name:    "too many records",
+			records: make([]kvRecord, kvReplicateBatchSize+1),
+			expect:  "at most 100 records can be replicated at once",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			err := store.send(ctx, peer.Peer{Name: "node-a", Addr: c.addrs["node-a"]}, kvRequest{Op: kvOpReplicate, Records: tc.records}, nil)
+			require.ErrorContains(t, err, tc.expect)
+		})
+	}
+
+	// None of the records were merged, so the key can still be written.
+	require.Equal(t, []string(nil), c.holders("other"))
+	got, err := store.Get(ctx, "key")
+	require.NoError(t, err)
+	require.Equal(t, entry, got)
+
+	_, err = store.CompareAndSwap(ctx, "key", entry.Version, []byte("new"), 0)
+	require.NoError(t, err)
+}
@@ -9,9 +9,11 @@ import (
 )
 
 // Mock returns a mock implementation of the Cluster interface.
-func Mock() Cluster { return mockCluster{} }
+func Mock() Cluster { return mockCluster{kv: NewLocalKV()} }
 
-type mockCluster struct{}
+type mockCluster struct {
+	kv KV
+}
 
 func (mockCluster) Lookup(key shard.Key, replicationFactor int, op shard.Op) ([]peer.Peer, error) {
 	return []peer.Peer{{
@@ -35,6 +37,14 @@ func (mockCluster) Ready() bool {
 	return true
 }
 
+func (mockCluster) ReplicationFactor() int {
+	return 1
+}
+
+func (c mockCluster) KV() KV {
+	return c.kv
+}
+
 func (mockCluster) Observe(ckit.Observer) {
 	// no-op
 }
@@ -0,0 +1,196 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/ckit/peer"
+
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+)
+
+const (
+	// nodeInfoEndpoint is the endpoint serving the nodeInfo of the node,
+	// relative to the base route of the cluster service.
+	nodeInfoEndpoint = "node"
+
+	nodeInfoMinBackoff = time.Second
+	nodeInfoMaxBackoff = 30 * time.Second
+
+	// nodeInfoRefreshInterval is how often the nodeInfo of peers is fetched
+	// again, so that peers restarting with a different weight or version
+	// converge to the same ring.
+	nodeInfoRefreshInterval = time.Minute
+)
+
+// nodeInfoFetcher retrieves the nodeInfo of peers from their HTTP endpoint,
+// and refreshes it periodically.
+type nodeInfoFetcher struct {
+	log             log.Logger
+	client          *http.Client
+	scheme          string
+	path            string
+	sharder         *weightedSharder
+	refreshInterval time.Duration
+
+	// onChange is called when the nodeInfo of a peer changes the ring.
+	onChange func()
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mut      sync.Mutex
+	watchers map[string]*nodeInfoWatcher // By peer name.
+}
+
+// nodeInfoWatcher fetches the nodeInfo of a peer.
+type nodeInfoWatcher struct {
+	addr   string
+	cancel context.CancelFunc
+}
+
+func newNodeInfoFetcher(l log.Logger, client *http.Client, scheme, path string, sharder *weightedSharder, onChange func()) *nodeInfoFetcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &nodeInfoFetcher{
+		log:             l,
+		client:          client,
+		scheme:          scheme,
+		path:            path,
+		sharder:         sharder,
+		refreshInterval: nodeInfoRefreshInterval,
+		onChange:        onChange,
+		ctx:             ctx,
+		cancel:          cancel,
+		watchers:        map[string]*nodeInfoWatcher{},
+	}
+}
+
+// Sync starts watching the nodeInfo of new peers, and stops watching the
+// nodeInfo of peers which left the cluster.
+func (f *nodeInfoFetcher) Sync(peers []peer.Peer) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	if f.ctx.Err() != nil {
+		return
+	}
+
+	present := make(map[string]struct{}, len(peers))
+	for _, p := range peers {
+		if p.Self || p.State == peer.StateViewer {
+			continue
+		}
+		present[p.Name] = struct{}{}
+		if w, ok := f.watchers[p.Name]; ok {
+			if w.addr == p.Addr {
+				continue
+			}
+			// The peer restarted with another address.
+			w.cancel()
+		}
+
+		ctx, cancel := context.WithCancel(f.ctx)
+		f.watchers[p.Name] = &nodeInfoWatcher{addr: p.Addr, cancel: cancel}
+		f.wg.Add(1)
+		go func(p peer.Peer) {
+			defer f.wg.Done()
+			defer cancel()
+			f.watch(ctx, p)
+		}(p)
+	}
+
+	for name, w := range f.watchers {
+		if _, ok := present[name]; !ok {
+			w.cancel()
+			delete(f.watchers, name)
+		}
+	}
+}
+
+// watch fetches the nodeInfo of p every refreshInterval until ctx is
+// canceled. Failed fetches are retried with a backoff; the last known
+// nodeInfo of p is kept in the meantime.
+func (f *nodeInfoFetcher) watch(ctx context.Context, p peer.Peer) {
+	backoff := nodeInfoMinBackoff
+	for {
+		wait := f.refreshInterval
+
+		info, err := f.fetch(ctx, p)
+		switch {
+		case err == nil:
+			backoff = nodeInfoMinBackoff
+			if f.sharder.SetNodeInfo(p.Name, info) {
+				level.Debug(f.log).Log("msg", "updated peer weight and zone", "peer", p.Name, "weight", info.Weight, "zone", info.Zone)
+				f.onChange()
+			}
+		case ctx.Err() != nil:
+			return
+		default:
+			level.Warn(f.log).Log("msg", "failed to get peer weight and zone; will retry", "peer", p.Name, "backoff", backoff, "err", err)
+			wait = backoff
+			backoff = min(2*backoff, nodeInfoMaxBackoff)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+func (f *nodeInfoFetcher) fetch(ctx context.Context, p peer.Peer) (nodeInfo, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s://%s%s", f.scheme, p.Addr, f.path), nil)
+	if err != nil {
+		return nodeInfo{}, err
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nodeInfo{}, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		// Peers running older versions don't expose their nodeInfo.
+		return defaultNodeInfo, nil
+	default:
+		return nodeInfo{}, fmt.Errorf("unexpected status code %s", resp.Status)
+	}
+
+	var info nodeInfo
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&info); err != nil {
+		return nodeInfo{}, fmt.Errorf("decoding node info: %w", err)
+	}
+	if err := info.Validate(); err != nil {
+		return nodeInfo{}, err
+	}
+	return info, nil
+}
+
+// Stop stops all fetches and waits for them to exit.
+func (f *nodeInfoFetcher) Stop() {
+	f.mut.Lock()
+	f.cancel()
+	f.mut.Unlock()
+	f.wg.Wait()
+}
+
+// nodeInfoHandler serves info as JSON.
+func nodeInfoHandler(info nodeInfo) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(info)
+	})
+}
@@ -0,0 +1,304 @@
+package cluster
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/grafana/ckit/peer"
+	"github.com/grafana/ckit/shard"
+)
+
+// nodeInfo describes how a node takes part in sharding. Nodes exchange their
+// nodeInfo over HTTP, as ckit doesn't propagate metadata about peers.
+type nodeInfo struct {
+	// Weight scales the number of tokens of the node in the hash ring, and so
+	// the share of keys owned by the node.
+	Weight float64 `json:"weight"`
+	// Zone is the failure domain of the node, such as an availability zone.
+	// Owners of a key are picked from distinct zones when possible.
+	Zone string `json:"zone,omitempty"`
+}
+
+// defaultNodeInfo is used for peers whose nodeInfo isn't known yet, or which
+// don't expose it.
+var defaultNodeInfo = nodeInfo{Weight: 1}
+
+// Validate checks that the nodeInfo can be used for sharding.
+func (ni nodeInfo) Validate() error {
+	if math.IsNaN(ni.Weight) || ni.Weight <= 0 || ni.Weight > MaxNodeWeight {
+		return fmt.Errorf("node weight must be greater than 0 and at most %d, got %v", MaxNodeWeight, ni.Weight)
+	}
+	return nil
+}
+
+// weightedSharder is a ring sharder where the number of tokens of each node
+// is proportional to its weight, and where the owners of a key are spread
+// across zones.
+//
+// The tokens of a node with weight 1 are the same as the ones of
+// shard.Ring(tokensPerNode), so that nodes which don't set weights own the
+// same keys as nodes running older versions.
+type weightedSharder struct {
+	numTokens int // Number of tokens of nodes with weight 1.
+
+	// onPeers is called with the peers given to SetPeers, after the ring has
+	// been updated.
+	onPeers func(ps []peer.Peer)
+
+	mut             sync.RWMutex
+	allPeers        []peer.Peer          // Peers given to SetPeers.
+	peers           map[string]peer.Peer // Non-viewer peers.
+	infos           map[string]nodeInfo  // nodeInfo of peers, by name.
+	read, readWrite *ring
+}
+
+var _ shard.Sharder = (*weightedSharder)(nil)
+
+func newWeightedSharder(numTokens int) *weightedSharder {
+	return &weightedSharder{
+		numTokens: numTokens,
+		peers:     map[string]peer.Peer{},
+		infos:     map[string]nodeInfo{},
+		read:      &ring{},
+		readWrite: &ring{},
+	}
+}
+
+// Lookup implements shard.Sharder.
+func (s *weightedSharder) Lookup(key shard.Key, numOwners int, op shard.Op) ([]peer.Peer, error) {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+
+	var r *ring
+	switch op {
+	case shard.OpRead:
+		r = s.read
+	case shard.OpReadWrite:
+		r = s.readWrite
+	default:
+		return nil, fmt.Errorf("unknown op %s", op)
+	}
+
+	names, err := r.get(uint64(key), numOwners)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]peer.Peer, len(names))
+	for i, name := range names {
+		res[i] = s.peers[name]
+	}
+	return res, nil
+}
+
+// Peers implements shard.Sharder.
+func (s *weightedSharder) Peers() []peer.Peer {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+
+	ps := make([]peer.Peer, 0, len(s.peers))
+	for _, p := range s.peers {
+		ps = append(ps, p)
+	}
+	sort.Slice(ps, func(i, j int) bool { return ps[i].Name < ps[j].Name })
+	return ps
+}
+
+// SetPeers implements shard.Sharder. The nodeInfo of peers which are no
+// longer part of the cluster is forgotten.
+func (s *weightedSharder) SetPeers(ps []peer.Peer) {
+	ps = append([]peer.Peer(nil), ps...)
+
+	s.mut.Lock()
+	s.allPeers = ps
+	present := make(map[string]struct{}, len(ps))
+	for _, p := range ps {
+		present[p.Name] = struct{}{}
+	}
+	for name := range s.infos {
+		if _, ok := present[name]; !ok {
+			delete(s.infos, name)
+		}
+	}
+	s.rebuild()
+	onPeers := s.onPeers
+	s.mut.Unlock()
+
+	if onPeers != nil {
+		onPeers(ps)
+	}
+}
+
+// SetNodeInfo sets the nodeInfo of the peer with the given name. It reports
+// whether the ring changed.
+func (s *weightedSharder) SetNodeInfo(name string, info nodeInfo) bool {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	prev, known := s.infos[name]
+	s.infos[name] = info
+	if known && prev == info {
+		return false
+	}
+	if _, ok := s.peers[name]; !ok {
+		return false
+	}
+	s.rebuild()
+	return true
+}
+
+// HasNodeInfo reports whether the nodeInfo of the peer with the given name is
+// known.
+func (s *weightedSharder) HasNodeInfo(name string) bool {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	_, ok := s.infos[name]
+	return ok
+}
+
+// rebuild recomputes the rings from the current peers. s.mut must be held.
+func (s *weightedSharder) rebuild() {
+	var (
+		peers     = make(map[string]peer.Peer, len(s.allPeers))
+		read      = make([]ringNode, 0, len(s.allPeers))
+		readWrite = make([]ringNode, 0, len(s.allPeers))
+	)
+	for _, p := range s.allPeers {
+		info, ok := s.infos[p.Name]
+		if !ok {
+			info = defaultNodeInfo
+		}
+		node := ringNode{name: p.Name, zone: info.Zone, numTokens: s.tokensFor(info.Weight)}
+
+		switch p.State {
+		case peer.StateParticipant:
+			read = append(read, node)
+			readWrite = append(readWrite, node)
+			peers[p.Name] = p
+		case peer.StateTerminating:
+			read = append(read, node)
+			peers[p.Name] = p
+		}
+	}
+
+	s.peers = peers
+	s.read = newRing(read)
+	s.readWrite = newRing(readWrite)
+}
+
+func (s *weightedSharder) tokensFor(weight float64) int {
+	return max(1, int(math.Round(float64(s.numTokens)*weight)))
+}
+
+type ringNode struct {
+	name      string
+	zone      string
+	numTokens int
+}
+
+type ringToken struct {
+	node  string
+	token uint64
+}
+
+type ring struct {
+	tokens   []ringToken       // Sorted by token, then by node.
+	zones    map[string]string // Zone of each node.
+	numNodes int
+	numZones int
+}
+
+func newRing(nodes []ringNode) *ring {
+	var (
+		numTokens int
+		zones     = make(map[string]string, len(nodes))
+		zoneSet   = make(map[string]struct{})
+	)
+	for _, n := range nodes {
+		numTokens += n.numTokens
+		zones[n.name] = n.zone
+		zoneSet[n.zone] = struct{}{}
+	}
+
+	tokens := make([]ringToken, 0, numTokens)
+	for _, n := range nodes {
+		// Tokens are generated the same way as by shard.Ring: the digest of the
+		// node name is extended with the token number truncated to a byte.
+		dig := xxhash.New()
+		_, _ = dig.WriteString(n.name)
+		tokData := []byte{0}
+		for t := 0; t < n.numTokens; t++ {
+			tokData[0] = byte(t)
+			_, _ = dig.Write(tokData)
+			tokens = append(tokens, ringToken{node: n.name, token: dig.Sum64()})
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		if tokens[i].token == tokens[j].token {
+			return tokens[i].node < tokens[j].node
+		}
+		return tokens[i].token < tokens[j].token
+	})
+
+	return &ring{
+		tokens:   tokens,
+		zones:    zones,
+		numNodes: len(nodes),
+		numZones: len(zoneSet),
+	}
+}
+
+// get returns n owners of key. The owners are the first nodes found walking
+// the ring clockwise from key, skipping nodes in zones which already own key
+// until as many zones as possible own it.
+func (r *ring) get(key uint64, n int) ([]string, error) {
+	if n > r.numNodes {
+		return nil, fmt.Errorf("not enough nodes: need at least %d, have %d", n, r.numNodes)
+	} else if n == 0 {
+		return []string{}, nil
+	}
+
+	start := sort.Search(len(r.tokens), func(i int) bool {
+		return r.tokens[i].token >= key
+	})
+	if start == len(r.tokens) {
+		// Wrap around if we hit the end of the list.
+		start = 0
+	}
+
+	var (
+		res     = make([]string, 0, n)
+		owners  = make(map[string]struct{}, n)
+		zones   = make(map[string]struct{}, n)
+		inZones = min(n, r.numZones)
+	)
+
+	// Pick owners from distinct zones first.
+	for i := start; len(res) < inZones; i = (i + 1) % len(r.tokens) {
+		node := r.tokens[i].node
+		if _, found := owners[node]; found {
+			continue
+		}
+		if _, found := zones[r.zones[node]]; found {
+			continue
+		}
+		res = append(res, node)
+		owners[node] = struct{}{}
+		zones[r.zones[node]] = struct{}{}
+	}
+
+	// Fill the remaining owners regardless of their zone.
+	for i := start; len(res) < n; i = (i + 1) % len(r.tokens) {
+		node := r.tokens[i].node
+		if _, found := owners[node]; found {
+			continue
+		}
+		res = append(res, node)
+		owners[node] = struct{}{}
+	}
+
+	return res, nil
+}
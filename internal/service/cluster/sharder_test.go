@@ -0,0 +1,198 @@
+package cluster
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/ckit/peer"
+	"github.com/grafana/ckit/shard"
+	"github.com/stretchr/testify/require"
+)
+
+func testPeers(names ...string) []peer.Peer {
+	ps := make([]peer.Peer, len(names))
+	for i, name := range names {
+		ps[i] = peer.Peer{Name: name, Addr: name + ":12345", State: peer.StateParticipant}
+	}
+	return ps
+}
+
+func TestWeightedSharder_MatchesRing(t *testing.T) {
+	peers := testPeers("node-a", "node-b", "node-c", "node-d")
+
+	expect := shard.Ring(tokensPerNode)
+	expect.SetPeers(peers)
+
+	actual := newWeightedSharder(tokensPerNode)
+	actual.SetPeers(peers)
+
+	for i := range 10_000 {
+		key := shard.StringKey(fmt.Sprintf("target-%d", i))
+		for _, numOwners := range []int{1, 2} {
+			expectOwners, err := expect.Lookup(key, numOwners, shard.OpReadWrite)
+			require.NoError(t, err)
+			actualOwners, err := actual.Lookup(key, numOwners, shard.OpReadWrite)
+			require.NoError(t, err)
+			require.Equal(t, expectOwners, actualOwners, "key %d", i)
+		}
+	}
+}
+
+func TestWeightedSharder_Weights(t *testing.T) {
+	s := newWeightedSharder(tokensPerNode)
+	s.SetPeers(testPeers("node-a", "node-b", "node-c"))
+	require.True(t, s.SetNodeInfo("node-a", nodeInfo{Weight: 2}))
+	require.False(t, s.SetNodeInfo("node-a", nodeInfo{Weight: 2}))
+
+	counts := countOwners(t, s, 100_000, 1)
+	// node-a should own about half of the keys.
+	require.InDelta(t, 0.5, float64(counts["node-a"])/100_000, 0.05)
+	require.InDelta(t, 0.25, float64(counts["node-b"])/100_000, 0.05)
+	require.InDelta(t, 0.25, float64(counts["node-c"])/100_000, 0.05)
+}
+
+func TestWeightedSharder_Zones(t *testing.T) {
+	s := newWeightedSharder(tokensPerNode)
+	s.SetPeers(testPeers("a-1", "a-2", "a-3", "b-1", "c-1"))
+	for _, name := range []string{"a-1", "a-2", "a-3", "b-1", "c-1"} {
+		s.SetNodeInfo(name, nodeInfo{Weight: 1, Zone: strings.Split(name, "-")[0]})
+	}
+
+	for i := range 1_000 {
+		key := shard.StringKey(fmt.Sprintf("target-%d", i))
+
+		owners, err := s.Lookup(key, 3, shard.OpReadWrite)
+		require.NoError(t, err)
+		zones := map[byte]struct{}{}
+		for _, o := range owners {
+			zones[o.Name[0]] = struct{}{}
+		}
+		require.Len(t, zones, 3, "owners of key %d should be in distinct zones: %v", i, owners)
+
+		// With more owners than zones, the remaining owners come from any zone.
+		owners, err = s.Lookup(key, 4, shard.OpReadWrite)
+		require.NoError(t, err)
+		names := map[string]struct{}{}
+		for _, o := range owners {
+			names[o.Name] = struct{}{}
+		}
+		require.Len(t, names, 4)
+	}
+
+	_, err := s.Lookup(shard.StringKey("target"), 6, shard.OpReadWrite)
+	require.Error(t, err)
+}
+
+func TestWeightedSharder_States(t *testing.T) {
+	s := newWeightedSharder(tokensPerNode)
+	peers := testPeers("node-a", "node-b", "node-c")
+	peers[1].State = peer.StateTerminating
+	peers[2].State = peer.StateViewer
+	s.SetPeers(peers)
+
+	require.Equal(t, peers[:2], s.Peers())
+
+	counts := countOwners(t, s, 1_000, 1)
+	require.Equal(t, map[string]int{"node-a": 1_000}, counts)
+
+	// Terminating peers still own keys for reads.
+	_, err := s.Lookup(shard.StringKey("target"), 2, shard.OpRead)
+	require.NoError(t, err)
+	_, err = s.Lookup(shard.StringKey("target"), 2, shard.OpReadWrite)
+	require.Error(t, err)
+}
+
+func TestWeightedSharder_ForgetsLeftPeers(t *testing.T) {
+	s := newWeightedSharder(tokensPerNode)
+	s.SetPeers(testPeers("node-a", "node-b"))
+	s.SetNodeInfo("node-b", nodeInfo{Weight: 3})
+	require.True(t, s.HasNodeInfo("node-b"))
+
+	s.SetPeers(testPeers("node-a"))
+	require.False(t, s.HasNodeInfo("node-b"))
+}
+
+func TestNodeInfoFetcher(t *testing.T) {
+	srv := httptest.NewServer(nodeInfoHandler(nodeInfo{Weight: 4, Zone: "zone-b"}))
+	defer srv.Close()
+	oldSrv := httptest.NewServer(http.NotFoundHandler())
+	defer oldSrv.Close()
+
+	s := newWeightedSharder(tokensPerNode)
+	changed := make(chan struct{}, 2)
+	f := newNodeInfoFetcher(log.NewNopLogger(), srv.Client(), "http", "/", s, func() { changed <- struct{}{} })
+	defer f.Stop()
+	s.onPeers = f.Sync
+
+	peers := []peer.Peer{
+		{Name: "self", Addr: "127.0.0.1:0", Self: true, State: peer.StateParticipant},
+		{Name: "new", Addr: strings.TrimPrefix(srv.URL, "http://"), State: peer.StateParticipant},
+		{Name: "old", Addr: strings.TrimPrefix(oldSrv.URL, "http://"), State: peer.StateParticipant},
+	}
+	s.SetNodeInfo("self", nodeInfo{Weight: 1, Zone: "zone-a"})
+	s.SetPeers(peers)
+
+	require.Eventually(t, func() bool {
+		return s.HasNodeInfo("new") && s.HasNodeInfo("old")
+	}, 5*time.Second, 10*time.Millisecond)
+
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	require.Equal(t, nodeInfo{Weight: 4, Zone: "zone-b"}, s.infos["new"])
+	require.Equal(t, defaultNodeInfo, s.infos["old"])
+}
+
+func TestNodeInfoFetcher_Refresh(t *testing.T) {
+	// The peer runs an older version which doesn't expose its nodeInfo, and
+	// is then upgraded.
+	var upgraded atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !upgraded.Load() {
+			http.NotFound(w, r)
+			return
+		}
+		nodeInfoHandler(nodeInfo{Weight: 4}).ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	s := newWeightedSharder(tokensPerNode)
+	f := newNodeInfoFetcher(log.NewNopLogger(), srv.Client(), "http", "/", s, func() {})
+	f.refreshInterval = 10 * time.Millisecond
+	defer f.Stop()
+	s.onPeers = f.Sync
+
+	s.SetPeers([]peer.Peer{
+		{Name: "self", Addr: "127.0.0.1:0", Self: true, State: peer.StateParticipant},
+		{Name: "peer", Addr: strings.TrimPrefix(srv.URL, "http://"), State: peer.StateParticipant},
+	})
+
+	infoOf := func(name string) nodeInfo {
+		s.mut.RLock()
+		defer s.mut.RUnlock()
+		return s.infos[name]
+	}
+	require.Eventually(t, func() bool { return infoOf("peer") == defaultNodeInfo }, 5*time.Second, 10*time.Millisecond)
+
+	upgraded.Store(true)
+	require.Eventually(t, func() bool { return infoOf("peer") == nodeInfo{Weight: 4} }, 5*time.Second, 10*time.Millisecond)
+}
+
+func countOwners(t *testing.T, s shard.Sharder, numKeys, numOwners int) map[string]int {
+	t.Helper()
+
+	counts := map[string]int{}
+	for i := range numKeys {
+		owners, err := s.Lookup(shard.StringKey(fmt.Sprintf("target-%d", i)), numOwners, shard.OpReadWrite)
+		require.NoError(t, err)
+		for _, o := range owners {
+			counts[o.Name]++
+		}
+	}
+	return counts
+}
@@ -0,0 +1,381 @@
+package cluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/go-kit/log"
+	"github.com/grafana/ckit/peer"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/util"
+)
+
+const (
+	// ModeNone runs a component on every node of the cluster.
+	ModeNone = "none"
+	// ModeSingleton runs a component on a single node of the cluster.
+	ModeSingleton = "singleton"
+
+	// singletonLeaseTTL is how long the leader of a singleton component
+	// keeps its lease without renewing it.
+	singletonLeaseTTL = 15 * time.Second
+	// singletonRenewInterval is how often the leader of a singleton component
+	// renews its lease, and how often other nodes check whether the lease is
+	// free.
+	singletonRenewInterval = singletonLeaseTTL / 3
+	// singletonReleaseTimeout is the timeout to release the lease on exit.
+	singletonReleaseTimeout = 5 * time.Second
+)
+
+// SingletonBlock holds arguments for components which can run on a single
+// node of the cluster. SingletonBlock is intended to be exposed as a block
+// called "clustering".
+type SingletonBlock struct {
+	Mode string `alloy:"mode,attr"`
+}
+
+// Validate implements syntax.Validator.
+func (b *SingletonBlock) Validate() error {
+	switch b.Mode {
+	case ModeNone, ModeSingleton:
+		return nil
+	default:
+		return fmt.Errorf("mode must be %q or %q, got %q", ModeNone, ModeSingleton, b.Mode)
+	}
+}
+
+// SingletonOptions configures a Singleton.
+type SingletonOptions struct {
+	Logger     log.Logger
+	Registerer prometheus.Registerer
+
+	// ID identifies the work across the cluster, such as the ID of a
+	// component. At most one node of a converged cluster runs the work of a
+	// given ID.
+	ID string
+
+	// OnChange is called with whether the local node should run the work,
+	// every time it changes. Calls to OnChange are serialized.
+	OnChange func(leading bool)
+}
+
+// Singleton elects a single node of the cluster to run some work, such as
+// the work of a component which mustn't be duplicated.
+//
+// The leader holds a lease in the KV store of the cluster, which it renews
+// while it's a participant of the cluster. Other nodes take the lease over
+// when it expires, or as soon as they find out that the leader left the
+// cluster.
+//
+// The lease is stored under a key of the KV store, which is only consistent
+// while nodes agree on the owner of the key. While the cluster converges, for
+// example after nodes joined or left or changed their weight, more than one
+// node can briefly hold the lease and run the work. Work run by a Singleton
+// must tolerate being duplicated for a short time.
+//
+// A Singleton is disabled until SetEnabled is called, in which case the local
+// node always runs the work.
+type Singleton struct {
+	log      log.Logger
+	cluster  Cluster
+	key      string
+	onChange func(bool)
+
+	isLeader      prometheus.Gauge
+	leaderChanges prometheus.Counter
+
+	changed chan struct{}
+
+	// stepMut serializes elections and calls to onChange.
+	stepMut  sync.Mutex
+	version  uint64    // Version of the lease held by the local node, if any.
+	renewed  time.Time // Last time the lease was renewed.
+	notified *bool     // Last value given to onChange.
+
+	mut        sync.RWMutex
+	enabled    bool
+	leading    bool
+	leader     string // Empty if unknown.
+	lastLeader string // Last known leader, to count leader changes.
+	err        error
+}
+
+// NewSingleton creates a new Singleton. The election doesn't start until Run
+// is called.
+func NewSingleton(c Cluster, opts SingletonOptions) *Singleton {
+	s := &Singleton{
+		log:      opts.Logger,
+		cluster:  c,
+		key:      singletonKey(opts.ID),
+		onChange: opts.OnChange,
+		changed:  make(chan struct{}, 1),
+
+		isLeader: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "cluster_singleton_leader",
+			Help: "Reports 1 when the local node runs the singleton component, 0 otherwise.",
+		}),
+		leaderChanges: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "cluster_singleton_leader_changes_total",
+			Help: "Number of times the node running the singleton component changed.",
+		}),
+	}
+	if s.log == nil {
+		s.log = log.NewNopLogger()
+	}
+	if s.onChange == nil {
+		s.onChange = func(bool) {}
+	}
+
+	if opts.Registerer != nil {
+		s.isLeader = util.MustRegisterOrGet(opts.Registerer, s.isLeader).(prometheus.Gauge)
+		s.leaderChanges = util.MustRegisterOrGet(opts.Registerer, s.leaderChanges).(prometheus.Counter)
+	}
+	return s
+}
+
+// singletonKey returns the key of the lease for id.
+func singletonKey(id string) string {
+	key := "singleton/" + id
+	if len(key) > MaxKVKeySize {
+		key = fmt.Sprintf("singleton/%x", xxhash.Sum64String(id))
+	}
+	return key
+}
+
+// SetEnabled enables or disables the election. While the election is
+// disabled, the local node always runs the work.
+func (s *Singleton) SetEnabled(enabled bool) {
+	s.mut.Lock()
+	s.enabled = enabled
+	s.mut.Unlock()
+
+	s.trigger()
+}
+
+// Leading reports whether the local node should run the work.
+func (s *Singleton) Leading() bool {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	return !s.enabled || s.leading
+}
+
+// Leader returns the name of the node running the work, or an empty string
+// if it's unknown or the election is disabled.
+func (s *Singleton) Leader() string {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	if !s.enabled {
+		return ""
+	}
+	return s.leader
+}
+
+// NotifyClusterChange makes the Singleton check the lease immediately, so
+// that the work fails over quickly when the leader leaves the cluster.
+func (s *Singleton) NotifyClusterChange() {
+	s.trigger()
+}
+
+func (s *Singleton) trigger() {
+	select {
+	case s.changed <- struct{}{}:
+	default:
+	}
+}
+
+// CurrentHealth reports the state of the election.
+func (s *Singleton) CurrentHealth() component.Health {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+
+	health := component.Health{
+		Health:     component.HealthTypeHealthy,
+		UpdateTime: time.Now(),
+	}
+	switch {
+	case !s.enabled:
+		health.Message = "running on every node"
+	case s.err != nil:
+		health.Health = component.HealthTypeUnhealthy
+		health.Message = fmt.Sprintf("failed to elect the node running the component: %s", s.err)
+	case s.leading:
+		health.Message = "running on this node"
+	case s.leader != "":
+		health.Message = fmt.Sprintf("running on node %s", s.leader)
+	default:
+		health.Message = "waiting to elect the node running the component"
+	}
+	return health
+}
+
+// Run runs the election until ctx is canceled. The lease is released on
+// exit if the local node holds it.
+func (s *Singleton) Run(ctx context.Context) {
+	t := time.NewTicker(singletonRenewInterval)
+	defer t.Stop()
+
+	for {
+		s.step(ctx)
+
+		select {
+		case <-ctx.Done():
+			s.release()
+			return
+		case <-t.C:
+		case <-s.changed:
+		}
+	}
+}
+
+// step runs a round of the election.
+func (s *Singleton) step(ctx context.Context) {
+	s.stepMut.Lock()
+	defer s.stepMut.Unlock()
+
+	s.mut.RLock()
+	enabled := s.enabled
+	s.mut.RUnlock()
+
+	if !enabled {
+		s.releaseLocked()
+		s.setState(false, "", nil)
+		return
+	}
+
+	var self *peer.Peer
+	participants := map[string]struct{}{}
+	for _, p := range s.cluster.Peers() {
+		if p.Self {
+			self = &p
+		}
+		if p.State == peer.StateParticipant {
+			participants[p.Name] = struct{}{}
+		}
+	}
+	// Only participants can run work: nodes are viewers while they start, and
+	// are terminating while they shut down.
+	if self == nil || self.State != peer.StateParticipant {
+		s.releaseLocked()
+		s.setState(false, "", nil)
+		return
+	}
+
+	kv := s.cluster.KV()
+	entry, err := kv.Get(ctx, s.key)
+	switch {
+	case errors.Is(err, ErrKeyNotFound):
+		entry = KVEntry{}
+	case err != nil:
+		s.fail(err)
+		return
+	}
+
+	leader := string(entry.Value)
+	if _, ok := participants[leader]; ok && leader != self.Name {
+		s.version = 0
+		s.setState(false, leader, nil)
+		return
+	}
+
+	// The lease is free, expired, held by a node which left the cluster, or
+	// held by the local node: acquire or renew it.
+	acquired, err := kv.CompareAndSwap(ctx, s.key, entry.Version, []byte(self.Name), singletonLeaseTTL)
+	if errors.Is(err, ErrVersionConflict) {
+		// Another node acquired the lease first. The next round finds out which.
+		s.version = 0
+		s.setState(false, "", nil)
+		s.trigger()
+		return
+	} else if err != nil {
+		s.fail(err)
+		return
+	}
+
+	if leader != self.Name {
+		level.Info(s.log).Log("msg", "acquired singleton lease, running the component on this node", "previous_leader", leader)
+	}
+	s.version = acquired.Version
+	s.renewed = time.Now()
+	s.setState(true, self.Name, nil)
+}
+
+// fail records an error of the election. The local node keeps running the
+// work if it holds the lease, until the lease would have expired.
+func (s *Singleton) fail(err error) {
+	level.Warn(s.log).Log("msg", "failed to check singleton lease", "err", err)
+
+	s.mut.RLock()
+	leading, leader := s.leading, s.leader
+	s.mut.RUnlock()
+
+	if leading && time.Since(s.renewed) >= singletonLeaseTTL-singletonRenewInterval {
+		level.Warn(s.log).Log("msg", "could not renew singleton lease in time, stopping the component on this node")
+		s.version = 0
+		leading, leader = false, ""
+	}
+	s.setState(leading, leader, err)
+}
+
+// setState updates the state of the election, calling onChange if needed.
+// stepMut must be held.
+func (s *Singleton) setState(leading bool, leader string, err error) {
+	s.mut.Lock()
+	if leader != "" && leader != s.lastLeader {
+		if s.lastLeader != "" {
+			s.leaderChanges.Inc()
+		}
+		s.lastLeader = leader
+	}
+	s.leading = leading
+	s.leader = leader
+	s.err = err
+	shouldRun := !s.enabled || s.leading
+	s.mut.Unlock()
+
+	if leading {
+		s.isLeader.Set(1)
+	} else {
+		s.isLeader.Set(0)
+	}
+
+	if s.notified == nil || *s.notified != shouldRun {
+		s.notified = &shouldRun
+		s.onChange(shouldRun)
+	}
+}
+
+// release releases the lease if the local node holds it.
+func (s *Singleton) release() {
+	s.stepMut.Lock()
+	defer s.stepMut.Unlock()
+	s.releaseLocked()
+
+	s.mut.Lock()
+	s.leading = false
+	s.leader = ""
+	s.mut.Unlock()
+	s.isLeader.Set(0)
+}
+
+// releaseLocked releases the lease if the local node holds it. stepMut must
+// be held.
+func (s *Singleton) releaseLocked() {
+	if s.version == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), singletonReleaseTimeout)
+	defer cancel()
+
+	if err := s.cluster.KV().Delete(ctx, s.key, s.version); err != nil && !errors.Is(err, ErrVersionConflict) {
+		level.Warn(s.log).Log("msg", "failed to release singleton lease", "err", err)
+	}
+	s.version = 0
+}
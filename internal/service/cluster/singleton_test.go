@@ -0,0 +1,207 @@
+package cluster
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grafana/ckit/peer"
+	"github.com/grafana/ckit/shard"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+// singletonTestCluster is the view of a cluster from one node. All nodes
+// share the same KV store and peers.
+type singletonTestCluster struct {
+	self   string
+	shared *singletonTestState
+}
+
+type singletonTestState struct {
+	kv KV
+
+	mut   sync.Mutex
+	peers map[string]peer.State
+}
+
+func (c *singletonTestCluster) Lookup(shard.Key, int, shard.Op) ([]peer.Peer, error) {
+	return nil, nil
+}
+
+func (c *singletonTestCluster) Peers() []peer.Peer {
+	c.shared.mut.Lock()
+	defer c.shared.mut.Unlock()
+
+	var res []peer.Peer
+	for name, state := range c.shared.peers {
+		res = append(res, peer.Peer{Name: name, Self: name == c.self, State: state})
+	}
+	return res
+}
+
+func (c *singletonTestCluster) Ready() bool            { return true }
+func (c *singletonTestCluster) ReplicationFactor() int { return 1 }
+func (c *singletonTestCluster) KV() KV                 { return c.shared.kv }
+
+type singletonTestNode struct {
+	singleton *Singleton
+	leading   *atomic.Bool
+	reg       *prometheus.Registry
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+func startSingleton(t *testing.T, shared *singletonTestState, name string) *singletonTestNode {
+	t.Helper()
+
+	n := &singletonTestNode{
+		leading: atomic.NewBool(false),
+		reg:     prometheus.NewRegistry(),
+		done:    make(chan struct{}),
+	}
+	n.singleton = NewSingleton(&singletonTestCluster{self: name, shared: shared}, SingletonOptions{
+		Registerer: n.reg,
+		ID:         "loki.source.kubernetes_events.default",
+		OnChange:   n.leading.Store,
+	})
+	n.singleton.SetEnabled(true)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	n.cancel = cancel
+	go func() {
+		defer close(n.done)
+		n.singleton.Run(ctx)
+	}()
+	t.Cleanup(n.stop)
+	return n
+}
+
+func (n *singletonTestNode) stop() {
+	n.cancel()
+	<-n.done
+}
+
+func (s *singletonTestState) setPeer(name string, state peer.State) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	s.peers[name] = state
+}
+
+func (s *singletonTestState) removePeer(name string) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	delete(s.peers, name)
+}
+
+func notifyAll(nodes ...*singletonTestNode) {
+	for _, n := range nodes {
+		n.singleton.NotifyClusterChange()
+	}
+}
+
+func TestSingleton_Failover(t *testing.T) {
+	shared := &singletonTestState{
+		kv: NewLocalKV(),
+		peers: map[string]peer.State{
+			"node-a": peer.StateParticipant,
+			"node-b": peer.StateParticipant,
+		},
+	}
+
+	a := startSingleton(t, shared, "node-a")
+	require.Eventually(t, a.leading.Load, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, "node-a", a.singleton.Leader())
+
+	b := startSingleton(t, shared, "node-b")
+	require.Eventually(t, func() bool {
+		return b.singleton.Leader() == "node-a"
+	}, 5*time.Second, 10*time.Millisecond)
+	require.False(t, b.leading.Load())
+	require.Equal(t, "running on node node-a", b.singleton.CurrentHealth().Message)
+
+	// node-a leaves the cluster without releasing its lease: node-b takes over
+	// as soon as it's notified, without waiting for the lease to expire.
+	shared.removePeer("node-a")
+	notifyAll(b)
+	require.Eventually(t, b.leading.Load, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, "node-b", b.singleton.Leader())
+	require.Equal(t, 1.0, testutil.ToFloat64(b.singleton.leaderChanges))
+
+	// node-a notices it lost the lease when it's notified again.
+	shared.setPeer("node-a", peer.StateParticipant)
+	notifyAll(a)
+	require.Eventually(t, func() bool { return !a.leading.Load() }, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, "node-b", a.singleton.Leader())
+	require.Equal(t, 0.0, testutil.ToFloat64(a.singleton.isLeader))
+	require.Equal(t, 1.0, testutil.ToFloat64(b.singleton.isLeader))
+}
+
+func TestSingleton_ShutdownReleasesLease(t *testing.T) {
+	shared := &singletonTestState{
+		kv: NewLocalKV(),
+		peers: map[string]peer.State{
+			"node-a": peer.StateParticipant,
+			"node-b": peer.StateParticipant,
+		},
+	}
+
+	a := startSingleton(t, shared, "node-a")
+	require.Eventually(t, a.leading.Load, 5*time.Second, 10*time.Millisecond)
+	b := startSingleton(t, shared, "node-b")
+	require.Eventually(t, func() bool {
+		return b.singleton.Leader() == "node-a"
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// Terminating nodes stop running the work and release the lease.
+	shared.setPeer("node-a", peer.StateTerminating)
+	notifyAll(a)
+	require.Eventually(t, func() bool { return !a.leading.Load() }, 5*time.Second, 10*time.Millisecond)
+
+	a.stop()
+	_, err := shared.kv.Get(t.Context(), singletonKey("loki.source.kubernetes_events.default"))
+	require.ErrorIs(t, err, ErrKeyNotFound)
+
+	notifyAll(b)
+	require.Eventually(t, b.leading.Load, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestSingleton_Disabled(t *testing.T) {
+	shared := &singletonTestState{
+		kv:    NewLocalKV(),
+		peers: map[string]peer.State{"node-a": peer.StateViewer},
+	}
+
+	var calls []bool
+	s := NewSingleton(&singletonTestCluster{self: "node-a", shared: shared}, SingletonOptions{
+		ID:       "prometheus.exporter.cloudwatch.default",
+		OnChange: func(leading bool) { calls = append(calls, leading) },
+	})
+
+	// Work runs on every node while the election is disabled, even before
+	// the node is a participant.
+	require.True(t, s.Leading())
+	s.step(t.Context())
+	require.Equal(t, []bool{true}, calls)
+	require.Empty(t, s.Leader())
+
+	s.SetEnabled(true)
+	require.False(t, s.Leading())
+	s.step(t.Context())
+	require.Equal(t, []bool{true, false}, calls)
+	require.Equal(t, "waiting to elect the node running the component", s.CurrentHealth().Message)
+
+	shared.setPeer("node-a", peer.StateParticipant)
+	s.step(t.Context())
+	require.Equal(t, []bool{true, false, true}, calls)
+	require.Equal(t, "running on this node", s.CurrentHealth().Message)
+}
+
+func TestSingletonBlock_Validate(t *testing.T) {
+	require.NoError(t, (&SingletonBlock{Mode: ModeSingleton}).Validate())
+	require.NoError(t, (&SingletonBlock{Mode: ModeNone}).Validate())
+	require.EqualError(t, (&SingletonBlock{Mode: "leader"}).Validate(), `mode must be "none" or "singleton", got "leader"`)
+}
@@ -0,0 +1,41 @@
+package cluster
+
+import (
+	"encoding/json"
+
+	"github.com/grafana/ckit/peer"
+)
+
+// SupportBundle returns the state of the cluster as seen by this node, to add
+// to support bundles.
+func (s *Service) SupportBundle() (map[string][]byte, error) {
+	state := struct {
+		Enabled            bool        `json:"enabled"`
+		Name               string      `json:"name"`
+		ClusterName        string      `json:"cluster_name,omitempty"`
+		AdvertiseAddress   string      `json:"advertise_address"`
+		Ready              bool        `json:"ready"`
+		MinimumClusterSize int         `json:"minimum_cluster_size"`
+		ReplicationFactor  int         `json:"replication_factor"`
+		Weight             float64     `json:"weight"`
+		Zone               string      `json:"zone,omitempty"`
+		Peers              []peer.Peer `json:"peers"`
+	}{
+		Enabled:            s.opts.EnableClustering,
+		Name:               s.opts.NodeName,
+		ClusterName:        s.opts.ClusterName,
+		AdvertiseAddress:   s.opts.AdvertiseAddress,
+		Ready:              s.alloyCluster.Ready(),
+		MinimumClusterSize: s.opts.MinimumClusterSize,
+		ReplicationFactor:  s.alloyCluster.ReplicationFactor(),
+		Weight:             s.nodeInfo.Weight,
+		Zone:               s.nodeInfo.Zone,
+		Peers:              s.alloyCluster.Peers(),
+	}
+
+	bb, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{"cluster.json": bb}, nil
+}
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"context"
 	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net"
@@ -12,6 +13,7 @@ import (
 	_ "net/http/pprof" // Register pprof handlers
 	"os"
 	"path"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
@@ -22,6 +24,7 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/grafana/alloy/internal/component"
 	"github.com/grafana/alloy/internal/featuregate"
+	alloy_runtime "github.com/grafana/alloy/internal/runtime"
 	"github.com/grafana/alloy/internal/runtime/logging"
 	"github.com/grafana/alloy/internal/runtime/logging/level"
 	"github.com/grafana/alloy/internal/service"
@@ -52,6 +55,7 @@ type Options struct {
 
 	ReadyFunc  func() bool
 	ReloadFunc func() error
+	PlanFunc   func() (alloy_runtime.Plan, error)
 
 	HTTPListenAddr   string                // Address to listen for HTTP traffic on.
 	MemoryListenAddr string                // Address to accept in-memory traffic on.
@@ -242,6 +246,7 @@ func (s *Service) Run(ctx context.Context, host service.Host) error {
 		promhttp.HandlerFor(s.gatherer, promhttp.HandlerOpts{}),
 	)
 	if s.opts.EnablePProf {
+		r.HandleFunc("/debug/pprof/component/{profile}", componentProfileHandler).Methods("GET")
 		r.PathPrefix("/debug/pprof").Handler(http.DefaultServeMux)
 	}
 
@@ -278,6 +283,20 @@ func (s *Service) Run(ctx context.Context, host service.Host) error {
 		}).Methods(http.MethodGet, http.MethodPost)
 	}
 
+	if s.opts.PlanFunc != nil {
+		r.HandleFunc("/-/plan", func(w http.ResponseWriter, _ *http.Request) {
+			plan, err := s.opts.PlanFunc()
+			if err != nil {
+				level.Error(s.log).Log("msg", "failed to plan config reload", "err", err.Error())
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(plan)
+		}).Methods(http.MethodGet, http.MethodPost)
+	}
+
 	// Wire in support bundle generator
 	r.HandleFunc("/-/support", s.generateSupportBundleHandler(host)).Methods("GET")
 
@@ -341,6 +360,19 @@ func (s *Service) generateSupportBundleHandler(host service.Host) func(rw http.R
 			}
 			duration = time.Duration(d) * time.Second
 		}
+
+		var redactor *Redactor
+		if redact, patterns, err := redactionOptions(r); err != nil {
+			http.Error(rw, err.Error(), http.StatusBadRequest)
+			return
+		} else if redact {
+			secrets := componentSecrets(host)
+			if remoteCfgHost, err := remotecfg.GetHost(host); err == nil {
+				secrets = append(secrets, componentSecrets(remoteCfgHost)...)
+			}
+			redactor = NewRedactor(secrets, patterns)
+		}
+
 		ctx, cancel := context.WithTimeout(context.Background(), duration)
 		defer cancel()
 
@@ -361,12 +393,28 @@ func (s *Service) generateSupportBundleHandler(host service.Host) func(rw http.R
 		// secret redaction.
 		sources := redactedSources(s.sources)
 
+		liveDebuggingSampler := startLiveDebuggingSampler(host)
 		bundle, err := ExportSupportBundle(ctx, s.opts.BundleContext.RuntimeFlags, s.opts.HTTPListenAddr, sources, cachedConfig, s.Data().(Data).DialFunc)
+		liveDebugging := liveDebuggingSampler.stop()
 		if err != nil {
 			http.Error(rw, err.Error(), http.StatusInternalServerError)
 			return
 		}
-		if err := ServeSupportBundle(rw, bundle, &logsBuffer); err != nil {
+
+		bundle.liveDebugging = liveDebugging
+		bundle.services = servicesSupportBundle(host, s.log)
+		bundle.componentsDebugInfo, err = componentsDebugInfo(host)
+		if err != nil {
+			level.Warn(s.log).Log("msg", "failed to get the debug info of components", "err", err)
+		}
+		if remoteCfgHost, err := remotecfg.GetHost(host); err == nil {
+			bundle.remoteCfgDebugInfo, err = componentsDebugInfo(remoteCfgHost)
+			if err != nil {
+				level.Warn(s.log).Log("msg", "failed to get the debug info of remote configuration components", "err", err)
+			}
+		}
+
+		if err := ServeSupportBundle(rw, bundle, &logsBuffer, redactor); err != nil {
 			http.Error(rw, err.Error(), http.StatusInternalServerError)
 			return
 		}
@@ -381,6 +429,31 @@ func (s *Service) SetSources(sources map[string]*ast.File) {
 	s.sources = sources
 }
 
+// redactionOptions returns whether the support bundle requested by r must be
+// redacted, and the patterns to redact. Passing patterns enables redaction.
+func redactionOptions(r *http.Request) (bool, []*regexp.Regexp, error) {
+	query := r.URL.Query()
+
+	var patterns []*regexp.Regexp
+	for _, p := range query["redact_pattern"] {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return false, nil, fmt.Errorf("invalid redact_pattern %q: %w", p, err)
+		}
+		patterns = append(patterns, re)
+	}
+
+	redact := len(patterns) > 0
+	if query.Has("redact") {
+		v, err := strconv.ParseBool(query.Get("redact"))
+		if err != nil {
+			return false, nil, fmt.Errorf("redact value should be a boolean: %w", err)
+		}
+		redact = redact || v
+	}
+	return redact, patterns, nil
+}
+
 func getServerWriteTimeout(r *http.Request) time.Duration {
 	srv, ok := r.Context().Value(http.ServerContextKey).(*http.Server)
 	if ok && srv.WriteTimeout != 0 {
@@ -566,6 +639,17 @@ type Component interface {
 	Handler() http.Handler
 }
 
+// SupportBundleProvider is a Service which adds files to support bundles.
+type SupportBundleProvider interface {
+	service.Service
+
+	// SupportBundle returns the files to add to support bundles, by name.
+	//
+	// This method is only called for services that declare a dependency on
+	// the http service, and for the remotecfg service.
+	SupportBundle() (map[string][]byte, error)
+}
+
 // ServiceHandler is a Service which exposes custom HTTP handlers.
 type ServiceHandler interface {
 	service.Service
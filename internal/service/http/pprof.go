@@ -0,0 +1,105 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/pprof/profile"
+	"github.com/gorilla/mux"
+
+	"github.com/grafana/alloy/internal/component"
+)
+
+// defaultComponentProfileSeconds is the default duration of component CPU
+// profiles, matching /debug/pprof/profile.
+const defaultComponentProfileSeconds = 30
+
+// componentProfileHandler serves a profile of the process which only holds
+// the samples of a single component and the modules it runs.
+//
+// The component is selected with the id query parameter. Only the CPU
+// ("profile") and goroutine profiles are supported, since other profiles
+// don't record labels.
+func componentProfileHandler(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["profile"]
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		http.Error(w, "missing id query parameter", http.StatusBadRequest)
+		return
+	}
+
+	var buf bytes.Buffer
+	switch name {
+	case "profile":
+		seconds := defaultComponentProfileSeconds
+		if v := r.URL.Query().Get("seconds"); v != "" {
+			var err error
+			if seconds, err = strconv.Atoi(v); err != nil || seconds <= 0 {
+				http.Error(w, fmt.Sprintf("invalid seconds query parameter %q", v), http.StatusBadRequest)
+				return
+			}
+		}
+		if err := writeCPUProfile(r.Context(), &buf, time.Duration(seconds)*time.Second); err != nil {
+			http.Error(w, fmt.Sprintf("could not enable CPU profiling: %s", err), http.StatusInternalServerError)
+			return
+		}
+	case "goroutine":
+		if err := pprof.Lookup("goroutine").WriteTo(&buf, 0); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	default:
+		http.Error(w, fmt.Sprintf("profile %q can't be filtered by component; supported profiles are profile and goroutine", name), http.StatusNotFound)
+		return
+	}
+
+	p, err := profile.Parse(&buf)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("failed to parse profile: %s", err), http.StatusInternalServerError)
+		return
+	}
+	filterProfileByComponent(p, id)
+	// Drop the locations and functions which are no longer used.
+	p = p.Compact()
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	if err := p.Write(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func writeCPUProfile(ctx context.Context, buf *bytes.Buffer, d time.Duration) error {
+	if err := pprof.StartCPUProfile(buf); err != nil {
+		return err
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+	case <-t.C:
+	}
+	pprof.StopCPUProfile()
+	return nil
+}
+
+// filterProfileByComponent removes the samples of p which weren't recorded by
+// the component with the given global ID or by the components of its modules.
+func filterProfileByComponent(p *profile.Profile, id string) {
+	samples := p.Sample[:0]
+	for _, s := range p.Sample {
+		for _, v := range s.Label[component.ProfileLabel] {
+			if v == id || strings.HasPrefix(v, id+"/") {
+				samples = append(samples, s)
+				break
+			}
+		}
+	}
+	p.Sample = samples
+}
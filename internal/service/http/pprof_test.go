@@ -0,0 +1,54 @@
+package http
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"runtime/pprof"
+	"testing"
+
+	"github.com/google/pprof/profile"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/internal/component"
+)
+
+func TestComponentProfileHandler(t *testing.T) {
+	stop := make(chan struct{})
+	defer close(stop)
+
+	// Start goroutines labeled like the goroutines of running components.
+	for _, id := range []string{"import.file.a", "import.file.a/local.file.b", "import.file.ab"} {
+		started := make(chan struct{})
+		go pprof.Do(context.Background(), pprof.Labels(component.ProfileLabel, id), func(context.Context) {
+			close(started)
+			<-stop
+		})
+		<-started
+	}
+
+	serve := func(profileName, query string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/debug/pprof/component/"+profileName+"?"+query, nil)
+		r = mux.SetURLVars(r, map[string]string{"profile": profileName})
+		rec := httptest.NewRecorder()
+		componentProfileHandler(rec, r)
+		return rec
+	}
+
+	rec := serve("goroutine", "id=import.file.a")
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	p, err := profile.Parse(rec.Body)
+	require.NoError(t, err)
+
+	var ids []string
+	for _, s := range p.Sample {
+		ids = append(ids, s.Label[component.ProfileLabel]...)
+	}
+	require.ElementsMatch(t, []string{"import.file.a", "import.file.a/local.file.b"}, ids)
+
+	require.Equal(t, http.StatusBadRequest, serve("goroutine", "").Code)
+	require.Equal(t, http.StatusBadRequest, serve("profile", "id=a&seconds=0").Code)
+	require.Equal(t, http.StatusNotFound, serve("heap", "id=a").Code)
+}
@@ -4,22 +4,39 @@ import (
 	"archive/zip"
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"runtime"
 	"runtime/pprof"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/go-kit/log"
+	"github.com/google/pprof/profile"
+	"github.com/google/uuid"
 	"github.com/grafana/alloy/internal/build"
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+	"github.com/grafana/alloy/internal/service"
+	"github.com/grafana/alloy/internal/service/livedebugging"
+	"github.com/grafana/alloy/internal/service/remotecfg"
 	"github.com/grafana/alloy/internal/static/server"
 	"github.com/mackerelio/go-osstat/uptime"
 	"gopkg.in/yaml.v3"
 )
 
+// supportBundleLiveDebuggingSamples is the maximum number of live debugging
+// records of each component in support bundles.
+const supportBundleLiveDebuggingSamples = 100
+
 // SupportBundleContext groups the relevant context that is used in the HTTP
 // service config for the support bundle
 type SupportBundleContext struct {
@@ -38,6 +55,10 @@ type Bundle struct {
 	environmentVariables []byte
 	sources              map[string][]byte
 	remoteCfg            []byte
+	componentsDebugInfo  []byte
+	remoteCfgDebugInfo   []byte
+	services             map[string][]byte
+	liveDebugging        []byte
 	heapBuf              *bytes.Buffer
 	goroutineBuf         *bytes.Buffer
 	blockBuf             *bytes.Buffer
@@ -204,32 +225,164 @@ func retrieveEnvironmentVariables() []string {
 	return values
 }
 
+// componentsDebugInfo returns the components of p, including the ones of its
+// modules, which report debug info.
+func componentsDebugInfo(p component.Provider) ([]byte, error) {
+	infos := []*component.Info{}
+	for _, c := range component.GetAllComponents(p, component.InfoOptions{GetHealth: true, GetDebugInfo: true}) {
+		if c.DebugInfo != nil {
+			infos = append(infos, c)
+		}
+	}
+	return json.MarshalIndent(infos, "", "  ")
+}
+
+// servicesSupportBundle returns the files of services which implement
+// SupportBundleProvider, by path in the services directory of the bundle.
+func servicesSupportBundle(host service.Host, logger log.Logger) map[string][]byte {
+	var providers []SupportBundleProvider
+	for _, consumer := range host.GetServiceConsumers(ServiceName) {
+		if consumer.Type != service.ConsumerTypeService {
+			continue
+		}
+		if p, ok := consumer.Value.(SupportBundleProvider); ok {
+			providers = append(providers, p)
+		}
+	}
+	// The HTTP service depends on remotecfg, so it's not a consumer.
+	if svc, ok := host.GetService(remotecfg.ServiceName); ok {
+		if p, ok := svc.(SupportBundleProvider); ok {
+			providers = append(providers, p)
+		}
+	}
+
+	files := make(map[string][]byte)
+	for _, p := range providers {
+		name := p.Definition().Name
+		pf, err := p.SupportBundle()
+		if err != nil {
+			level.Warn(logger).Log("msg", "failed to get the support bundle data of service", "service", name, "err", err)
+			pf = map[string][]byte{"error.txt": []byte(err.Error())}
+		}
+		for fn, b := range pf {
+			files[path.Join(name, fn)] = b
+		}
+	}
+	return files
+}
+
+// liveDebuggingSampler records the live debugging data of the components of
+// the root module, up to supportBundleLiveDebuggingSamples records by
+// component.
+type liveDebuggingSampler struct {
+	callbackManager livedebugging.CallbackManager
+	callbackID      livedebugging.CallbackID
+	componentIDs    []livedebugging.ComponentID
+
+	mut    sync.Mutex
+	counts map[livedebugging.ComponentID]int
+	buf    bytes.Buffer
+}
+
+// startLiveDebuggingSampler starts recording live debugging data. It returns
+// nil if live debugging isn't enabled.
+func startLiveDebuggingSampler(host service.Host) *liveDebuggingSampler {
+	svc, ok := host.GetService(livedebugging.ServiceName)
+	if !ok {
+		return nil
+	}
+	callbackManager, ok := svc.Data().(livedebugging.CallbackManager)
+	if !ok {
+		return nil
+	}
+	components, err := host.ListComponents("", component.InfoOptions{})
+	if err != nil {
+		return nil
+	}
+
+	s := &liveDebuggingSampler{
+		callbackManager: callbackManager,
+		callbackID:      livedebugging.CallbackID(uuid.New().String()),
+		counts:          make(map[livedebugging.ComponentID]int),
+	}
+	for _, c := range components {
+		if _, ok := c.Component.(component.LiveDebugging); !ok {
+			continue
+		}
+		id := livedebugging.ComponentID(c.ID.String())
+		// AddCallback fails when live debugging is disabled.
+		if err := callbackManager.AddCallback(host, s.callbackID, id, s.record); err != nil {
+			s.stop()
+			return nil
+		}
+		s.componentIDs = append(s.componentIDs, id)
+	}
+	return s
+}
+
+func (s *liveDebuggingSampler) record(data livedebugging.Data) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if s.counts[data.ComponentID] >= supportBundleLiveDebuggingSamples {
+		return
+	}
+	s.counts[data.ComponentID]++
+	// Records can't fail to be encoded.
+	_ = json.NewEncoder(&s.buf).Encode(livedebugging.NewRecord(data, time.Now()))
+}
+
+// stop stops recording and returns the records as newline-delimited JSON.
+func (s *liveDebuggingSampler) stop() []byte {
+	if s == nil {
+		return nil
+	}
+	for _, id := range s.componentIDs {
+		s.callbackManager.DeleteCallback(s.callbackID, id)
+	}
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	return s.buf.Bytes()
+}
+
 // ServeSupportBundle the collected data and logs as a zip file over the given
-// http.ResponseWriter.
-func ServeSupportBundle(rw http.ResponseWriter, b *Bundle, logsBuf *bytes.Buffer) error {
+// http.ResponseWriter. The text files of the bundle are redacted by r, unless
+// r is nil.
+func ServeSupportBundle(rw http.ResponseWriter, b *Bundle, logsBuf *bytes.Buffer, r *Redactor) error {
 	zw := zip.NewWriter(rw)
 	rw.Header().Set("Content-Type", "application/zip")
 	rw.Header().Set("Content-Disposition", "attachment; filename=\"alloy-support-bundle.zip\"")
 
 	zipStructure := map[string][]byte{
-		"alloy-metadata.yaml":            b.meta,
-		"alloy-components.json":          b.components,
-		"alloy-peers.json":               b.peers,
-		"alloy-metrics-sample-start.txt": b.alloyMetricsStart,
-		"alloy-metrics-sample-end.txt":   b.alloyMetricsEnd,
-		"alloy-runtime-flags.txt":        b.runtimeFlags,
-		"alloy-environment.txt":          b.environmentVariables,
-		"alloy-logs.txt":                 logsBuf.Bytes(),
-		"pprof/cpu.pprof":                b.cpuBuf.Bytes(),
-		"pprof/heap.pprof":               b.heapBuf.Bytes(),
-		"pprof/goroutine.pprof":          b.goroutineBuf.Bytes(),
-		"pprof/mutex.pprof":              b.mutexBuf.Bytes(),
-		"pprof/block.pprof":              b.blockBuf.Bytes(),
+		"alloy-metadata.yaml":              b.meta,
+		"alloy-components.json":            b.components,
+		"alloy-peers.json":                 b.peers,
+		"alloy-metrics-sample-start.txt":   b.alloyMetricsStart,
+		"alloy-metrics-sample-end.txt":     b.alloyMetricsEnd,
+		"alloy-runtime-flags.txt":          b.runtimeFlags,
+		"alloy-environment.txt":            b.environmentVariables,
+		"alloy-logs.txt":                   logsBuf.Bytes(),
+		"alloy-components-debug-info.json": b.componentsDebugInfo,
+		"pprof/cpu.pprof":                  b.cpuBuf.Bytes(),
+		"pprof/heap.pprof":                 b.heapBuf.Bytes(),
+		"pprof/goroutine.pprof":            b.goroutineBuf.Bytes(),
+		"pprof/mutex.pprof":                b.mutexBuf.Bytes(),
+		"pprof/block.pprof":                b.blockBuf.Bytes(),
 	}
 
 	if len(b.remoteCfg) > 0 {
 		zipStructure["sources/remote-config/remote.alloy"] = b.remoteCfg
 	}
+	if len(b.remoteCfgDebugInfo) > 0 {
+		zipStructure["alloy-remote-config-components-debug-info.json"] = b.remoteCfgDebugInfo
+	}
+	if len(b.liveDebugging) > 0 {
+		zipStructure["alloy-live-debugging.ndjson"] = b.liveDebugging
+	}
+	for p, s := range b.services {
+		zipStructure[path.Join("services", p)] = s
+	}
 
 	for p, s := range b.sources {
 		zipStructure[filepath.Join("sources", filepath.Base(p))] = s
@@ -237,6 +390,9 @@ func ServeSupportBundle(rw http.ResponseWriter, b *Bundle, logsBuf *bytes.Buffer
 
 	for fn, b := range zipStructure {
 		if b != nil {
+			if r != nil && !strings.HasPrefix(fn, "pprof/") {
+				b = r.Redact(b)
+			}
 			path := append([]string{"alloy-support-bundle"}, strings.Split(fn, "/")...)
 			if err := writeByteSlice(zw, b, path...); err != nil {
 				return err
@@ -262,3 +418,84 @@ func writeByteSlice(zw *zip.Writer, b []byte, fn ...string) error {
 	}
 	return nil
 }
+
+// requiredSupportBundleFiles are the files which every support bundle has.
+var requiredSupportBundleFiles = []string{
+	"alloy-metadata.yaml",
+	"alloy-components.json",
+	"alloy-peers.json",
+	"alloy-metrics-sample-start.txt",
+	"alloy-metrics-sample-end.txt",
+}
+
+// ValidateSupportBundle checks that the zip file read from r is a valid
+// support bundle, and returns its metadata.
+func ValidateSupportBundle(r io.ReaderAt, size int64) (Metadata, error) {
+	var meta Metadata
+
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return meta, fmt.Errorf("failed to open the support bundle: %w", err)
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[strings.TrimPrefix(filepath.ToSlash(f.Name), "alloy-support-bundle/")] = f
+	}
+
+	var errs []error
+	for _, fn := range requiredSupportBundleFiles {
+		if _, ok := files[fn]; !ok {
+			errs = append(errs, fmt.Errorf("missing %s", fn))
+		}
+	}
+	if len(errs) > 0 {
+		return meta, errors.Join(errs...)
+	}
+
+	readFile := func(fn string) ([]byte, error) {
+		rc, err := files[fn].Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		return io.ReadAll(rc)
+	}
+
+	bb, err := readFile("alloy-metadata.yaml")
+	if err == nil {
+		err = yaml.Unmarshal(bb, &meta)
+	}
+	if err != nil {
+		errs = append(errs, fmt.Errorf("invalid alloy-metadata.yaml: %w", err))
+	}
+
+	for _, fn := range []string{"alloy-components.json", "alloy-peers.json", "alloy-components-debug-info.json"} {
+		if _, ok := files[fn]; !ok {
+			continue
+		}
+		bb, err := readFile(fn)
+		if err == nil && !json.Valid(bb) {
+			err = errors.New("not valid JSON")
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s: %w", fn, err))
+		}
+	}
+
+	for fn := range files {
+		if !strings.HasPrefix(fn, "pprof/") {
+			continue
+		}
+		bb, err := readFile(fn)
+		if err == nil && len(bb) > 0 {
+			_, err = profile.ParseData(bb)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid %s: %w", fn, err))
+		}
+	}
+
+	slices.SortFunc(errs, func(a, b error) int { return strings.Compare(a.Error(), b.Error()) })
+	return meta, errors.Join(errs...)
+}
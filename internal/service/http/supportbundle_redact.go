@@ -0,0 +1,112 @@
+package http
+
+import (
+	"cmp"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/syntax/alloytypes"
+)
+
+var (
+	secretType         = reflect.TypeOf(alloytypes.Secret(""))
+	optionalSecretType = reflect.TypeOf(alloytypes.OptionalSecret{})
+)
+
+// Redactor strips secrets and text matching patterns from the files of
+// support bundles.
+type Redactor struct {
+	secrets  *strings.Replacer
+	patterns []*regexp.Regexp
+}
+
+// NewRedactor returns a Redactor which replaces secrets with "(secret)" and
+// text matching any of patterns with "(redacted)".
+func NewRedactor(secrets []string, patterns []*regexp.Regexp) *Redactor {
+	// Replace the longest secrets first, in case secrets contain each other.
+	secrets = slices.Clone(secrets)
+	slices.SortFunc(secrets, func(a, b string) int {
+		return cmp.Or(cmp.Compare(len(b), len(a)), strings.Compare(a, b))
+	})
+	oldnew := make([]string, 0, 2*len(secrets))
+	for _, s := range slices.Compact(secrets) {
+		if s != "" {
+			oldnew = append(oldnew, s, "(secret)")
+		}
+	}
+
+	return &Redactor{
+		secrets:  strings.NewReplacer(oldnew...),
+		patterns: patterns,
+	}
+}
+
+// Redact returns b with its secrets and text matching patterns redacted.
+func (r *Redactor) Redact(b []byte) []byte {
+	s := r.secrets.Replace(string(b))
+	for _, p := range r.patterns {
+		s = p.ReplaceAllLiteralString(s, "(redacted)")
+	}
+	return []byte(s)
+}
+
+// componentSecrets returns the values of the secrets in the arguments and
+// exports of the components of p, including the ones of its modules.
+func componentSecrets(p component.Provider) []string {
+	var secrets []string
+	for _, c := range component.GetAllComponents(p, component.InfoOptions{GetArguments: true, GetExports: true}) {
+		secrets = collectSecrets(reflect.ValueOf(c.Arguments), secrets, map[uintptr]struct{}{})
+		secrets = collectSecrets(reflect.ValueOf(c.Exports), secrets, map[uintptr]struct{}{})
+	}
+	return secrets
+}
+
+// collectSecrets appends the values of the secrets found in v to secrets.
+// Interfaces, functions and channels aren't followed, as they may hold live
+// state of components rather than configuration.
+func collectSecrets(v reflect.Value, secrets []string, visited map[uintptr]struct{}) []string {
+	if !v.IsValid() {
+		return secrets
+	}
+
+	switch v.Type() {
+	case secretType:
+		return append(secrets, v.String())
+	case optionalSecretType:
+		if s := v.Interface().(alloytypes.OptionalSecret); s.IsSecret {
+			return append(secrets, s.Value)
+		}
+		return secrets
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return secrets
+		}
+		if _, ok := visited[v.Pointer()]; ok {
+			return secrets
+		}
+		visited[v.Pointer()] = struct{}{}
+		return collectSecrets(v.Elem(), secrets, visited)
+	case reflect.Struct:
+		for i := range v.NumField() {
+			if v.Type().Field(i).IsExported() {
+				secrets = collectSecrets(v.Field(i), secrets, visited)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := range v.Len() {
+			secrets = collectSecrets(v.Index(i), secrets, visited)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			secrets = collectSecrets(iter.Value(), secrets, visited)
+		}
+	}
+	return secrets
+}
@@ -0,0 +1,133 @@
+package http
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"runtime/pprof"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/syntax/alloytypes"
+)
+
+func TestCollectSecrets(t *testing.T) {
+	type auth struct {
+		Password alloytypes.Secret
+		Token    alloytypes.OptionalSecret
+		User     alloytypes.OptionalSecret
+	}
+	type arguments struct {
+		Auth    *auth
+		Headers map[string]alloytypes.Secret
+		Keys    []alloytypes.Secret
+		Any     any
+		secret  alloytypes.Secret
+	}
+
+	a := &auth{
+		Password: "password",
+		Token:    alloytypes.OptionalSecret{IsSecret: true, Value: "token"},
+		User:     alloytypes.OptionalSecret{Value: "user"},
+	}
+	args := arguments{
+		Auth:    a,
+		Headers: map[string]alloytypes.Secret{"X-Key": "header"},
+		Keys:    []alloytypes.Secret{"key"},
+		Any:     alloytypes.Secret("interface"),
+		secret:  "unexported",
+	}
+
+	secrets := collectSecrets(reflect.ValueOf(args), nil, map[uintptr]struct{}{})
+	require.ElementsMatch(t, []string{"password", "token", "header", "key"}, secrets)
+}
+
+func TestRedactor(t *testing.T) {
+	r := NewRedactor(
+		[]string{"pass", "password", "", "password"},
+		[]*regexp.Regexp{regexp.MustCompile(`\d{3}-\d{4}`)},
+	)
+	require.Equal(t,
+		"a=(secret) b=(secret) phone=(redacted)",
+		string(r.Redact([]byte("a=password b=pass phone=555-1234"))),
+	)
+}
+
+func TestRedactionOptions(t *testing.T) {
+	tt := []struct {
+		query    string
+		redact   bool
+		patterns int
+		err      bool
+	}{
+		{query: "", redact: false},
+		{query: "redact=true", redact: true},
+		{query: "redact=false", redact: false},
+		{query: "redact_pattern=a&redact_pattern=b", redact: true, patterns: 2},
+		{query: "redact=maybe", err: true},
+		{query: "redact_pattern=(", err: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.query, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/-/support?"+tc.query, nil)
+			redact, patterns, err := redactionOptions(r)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.redact, redact)
+			require.Len(t, patterns, tc.patterns)
+		})
+	}
+}
+
+func TestServeAndValidateSupportBundle(t *testing.T) {
+	var heapBuf bytes.Buffer
+	require.NoError(t, pprof.Lookup("heap").WriteTo(&heapBuf, 0))
+
+	b := &Bundle{
+		meta:                []byte("build_version: v1.0.0\nos: linux\narchitecture: amd64\nuptime: 1.5\n"),
+		alloyMetricsStart:   []byte("metric 1\n"),
+		alloyMetricsEnd:     []byte("metric 2\n"),
+		components:          []byte(`[{"arguments": "password"}]`),
+		peers:               []byte(`[]`),
+		componentsDebugInfo: []byte(`[]`),
+		services:            map[string][]byte{"cluster/cluster.json": []byte(`{}`)},
+		heapBuf:             &heapBuf,
+		goroutineBuf:        &bytes.Buffer{},
+		blockBuf:            &bytes.Buffer{},
+		mutexBuf:            &bytes.Buffer{},
+		cpuBuf:              &bytes.Buffer{},
+	}
+
+	rec := httptest.NewRecorder()
+	require.NoError(t, ServeSupportBundle(rec, b, &bytes.Buffer{}, NewRedactor([]string{"password"}, nil)))
+
+	zb := rec.Body.Bytes()
+	meta, err := ValidateSupportBundle(bytes.NewReader(zb), int64(len(zb)))
+	require.NoError(t, err)
+	require.Equal(t, Metadata{BuildVersion: "v1.0.0", OS: "linux", Architecture: "amd64", Uptime: 1.5}, meta)
+	require.NotContains(t, string(zb), "password")
+
+	// Bundles with missing or invalid files aren't valid.
+	b.peers = nil
+	b.heapBuf = bytes.NewBufferString("not a profile")
+	rec = httptest.NewRecorder()
+	require.NoError(t, ServeSupportBundle(rec, b, &bytes.Buffer{}, nil))
+
+	zb = rec.Body.Bytes()
+	_, err = ValidateSupportBundle(bytes.NewReader(zb), int64(len(zb)))
+	require.ErrorContains(t, err, "missing alloy-peers.json")
+
+	b.peers = []byte(`[]`)
+	rec = httptest.NewRecorder()
+	require.NoError(t, ServeSupportBundle(rec, b, &bytes.Buffer{}, nil))
+
+	zb = rec.Body.Bytes()
+	_, err = ValidateSupportBundle(bytes.NewReader(zb), int64(len(zb)))
+	require.ErrorContains(t, err, "invalid pprof/heap.pprof")
+}
@@ -0,0 +1,393 @@
+package livedebugging
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql/parser"
+
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+)
+
+const (
+	// captureSegments is the number of files the capture is split in. The
+	// oldest file is deleted when the capture reaches its maximum size.
+	captureSegments = 8
+	// captureBufferSize is the number of records waiting to be written to
+	// disk. Records are dropped when the buffer is full.
+	captureBufferSize = 1000
+	// captureFlushInterval is how often records are flushed to disk.
+	captureFlushInterval = time.Second
+
+	captureSegmentPrefix = "capture-"
+	captureSegmentSuffix = ".ndjson"
+)
+
+// Record is debugging data captured to disk. Records are stored and
+// downloaded as newline-delimited JSON.
+type Record struct {
+	Timestamp          time.Time         `json:"timestamp"`
+	ComponentID        ComponentID       `json:"component_id"`
+	TargetComponentIDs []string          `json:"target_component_ids,omitempty"`
+	Type               DataType          `json:"type"`
+	Count              uint64            `json:"count"`
+	Data               string            `json:"data"`
+	Payload            json.RawMessage   `json:"payload,omitempty"`
+	Attributes         map[string]string `json:"attributes,omitempty"`
+	Replay             *Replay           `json:"replay,omitempty"`
+}
+
+// NewRecord evaluates data into a record.
+func NewRecord(data Data, now time.Time) Record {
+	r := Record{
+		Timestamp:          now.UTC(),
+		ComponentID:        data.ComponentID,
+		TargetComponentIDs: data.TargetComponentIDs,
+		Type:               data.Type,
+		Count:              data.Count,
+	}
+	if data.DataFunc != nil {
+		r.Data = data.DataFunc()
+	}
+	r.Payload = data.Payload()
+	if data.AttributesFunc != nil {
+		r.Attributes = data.AttributesFunc()
+	}
+	if data.ReplayFunc != nil {
+		r.Replay = data.ReplayFunc()
+	}
+	// Samples such as staleness markers can't be encoded as JSON, and can't
+	// be replayed meaningfully anyway.
+	if r.Replay != nil && r.Replay.Sample != nil {
+		if v := r.Replay.Sample.Value; math.IsNaN(v) || math.IsInf(v, 0) {
+			r.Replay = nil
+		}
+	}
+	return r
+}
+
+// Filter selects records. The zero value selects all records.
+type Filter struct {
+	// Components, if not empty, selects records of these components only.
+	Components []ComponentID
+	// Since, if not zero, selects records captured at or after Since.
+	Since time.Time
+	// Matchers select records whose fields and attributes match all of them.
+	// The component_id, type and data names match the fields of records,
+	// other names match attributes.
+	Matchers []*labels.Matcher
+}
+
+// ParseMatchers parses an expression such as {type="loki_log", job=~"api.*"}
+// into matchers for Filter.
+func ParseMatchers(expr string) ([]*labels.Matcher, error) {
+	if strings.TrimSpace(expr) == "" {
+		return nil, nil
+	}
+	matchers, err := parser.ParseMetricSelector(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter %q: %w", expr, err)
+	}
+	return matchers, nil
+}
+
+// Matches reports whether the filter selects r.
+func (f *Filter) Matches(r *Record) bool {
+	if len(f.Components) > 0 && !slices.Contains(f.Components, r.ComponentID) {
+		return false
+	}
+	if !f.Since.IsZero() && r.Timestamp.Before(f.Since) {
+		return false
+	}
+	for _, m := range f.Matchers {
+		var value string
+		switch m.Name {
+		case "component_id":
+			value = string(r.ComponentID)
+		case "type":
+			value = string(r.Type)
+		case "data":
+			value = r.Data
+		default:
+			value = r.Attributes[m.Name]
+		}
+		if !m.Matches(value) {
+			return false
+		}
+	}
+	return true
+}
+
+// ReadRecords reads records written as newline-delimited JSON from r, and
+// calls fn with each record selected by filter. An incomplete last line is
+// ignored.
+func ReadRecords(r io.Reader, filter Filter, fn func(Record) error) error {
+	br := bufio.NewReader(r)
+	for lineNum := 1; ; lineNum++ {
+		line, err := br.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal(line, &record); err != nil {
+			return fmt.Errorf("invalid record on line %d: %w", lineNum, err)
+		}
+		if !filter.Matches(&record) {
+			continue
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+}
+
+// captureStore writes records to a bounded set of files in a directory.
+// Records are appended to the newest file, and the oldest file is deleted
+// when the files exceed the maximum size, like a ring buffer.
+type captureStore struct {
+	log         log.Logger
+	dir         string
+	segmentSize int64
+
+	records chan Record
+	done    chan struct{}
+	dropped atomic.Bool // Whether a record was dropped, to only log it once.
+
+	mut      sync.Mutex
+	segments []int64 // Sequence numbers of the files, oldest first.
+	file     *os.File
+	writer   *bufio.Writer
+	size     int64 // Size of the newest file.
+}
+
+func newCaptureStore(logger log.Logger, dir string, maxSize int64) (*captureStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create capture directory: %w", err)
+	}
+	segments, err := listSegments(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &captureStore{
+		log:         logger,
+		dir:         dir,
+		segmentSize: max(maxSize/captureSegments, 1),
+		records:     make(chan Record, captureBufferSize),
+		done:        make(chan struct{}),
+		segments:    segments,
+	}
+
+	// Always start a new file so that records of previous runs are kept as
+	// they are.
+	s.mut.Lock()
+	err = s.rotate()
+	s.mut.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	go s.run()
+	return s, nil
+}
+
+// write queues a record to be written. It never blocks: records are dropped
+// when too many are waiting to be written.
+func (s *captureStore) write(r Record) {
+	select {
+	case s.records <- r:
+	default:
+		if !s.dropped.Swap(true) {
+			level.Warn(s.log).Log("msg", "live debugging data throughput is very high, not all data can be captured")
+		}
+	}
+}
+
+func (s *captureStore) run() {
+	defer close(s.done)
+
+	t := time.NewTicker(captureFlushInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case r, ok := <-s.records:
+			if !ok {
+				s.flush()
+				return
+			}
+			if err := s.append(r); err != nil {
+				level.Warn(s.log).Log("msg", "failed to write live debugging record", "err", err)
+			}
+		case <-t.C:
+			s.flush()
+		}
+	}
+}
+
+func (s *captureStore) append(r Record) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if s.size > 0 && s.size+int64(len(line)) > s.segmentSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+	n, err := s.writer.Write(line)
+	s.size += int64(n)
+	return err
+}
+
+// rotate starts a new file, deleting the oldest files above the limit. mut
+// must be held.
+func (s *captureStore) rotate() error {
+	if s.file != nil {
+		if err := s.closeFile(); err != nil {
+			level.Warn(s.log).Log("msg", "failed to close live debugging capture file", "err", err)
+		}
+	}
+
+	var seq int64 = 1
+	if len(s.segments) > 0 {
+		seq = s.segments[len(s.segments)-1] + 1
+	}
+	f, err := os.OpenFile(segmentPath(s.dir, seq), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return fmt.Errorf("failed to create capture file: %w", err)
+	}
+	s.file, s.writer, s.size = f, bufio.NewWriter(f), 0
+	s.segments = append(s.segments, seq)
+
+	for len(s.segments) > captureSegments {
+		if err := os.Remove(segmentPath(s.dir, s.segments[0])); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			level.Warn(s.log).Log("msg", "failed to remove live debugging capture file", "err", err)
+		}
+		s.segments = s.segments[1:]
+	}
+	return nil
+}
+
+func (s *captureStore) flush() {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if s.writer == nil {
+		return
+	}
+	if err := s.writer.Flush(); err != nil {
+		level.Warn(s.log).Log("msg", "failed to flush live debugging capture file", "err", err)
+	}
+}
+
+// closeFile closes the newest file. mut must be held.
+func (s *captureStore) closeFile() error {
+	flushErr := s.writer.Flush()
+	closeErr := s.file.Close()
+	s.file, s.writer = nil, nil
+	return errors.Join(flushErr, closeErr)
+}
+
+// close writes the queued records and closes the store. write must not be
+// called after close.
+func (s *captureStore) close() {
+	close(s.records)
+	<-s.done
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+	if s.file != nil {
+		if err := s.closeFile(); err != nil {
+			level.Warn(s.log).Log("msg", "failed to close live debugging capture file", "err", err)
+		}
+	}
+}
+
+// readCapture writes the records in dir selected by filter to w, oldest
+// first.
+func readCapture(ctx context.Context, dir string, filter Filter, w io.Writer) error {
+	segments, err := listSegments(dir)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	for _, seq := range segments {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		f, err := os.Open(segmentPath(dir, seq))
+		if errors.Is(err, fs.ErrNotExist) {
+			// The file was deleted to make room for new records.
+			continue
+		} else if err != nil {
+			return err
+		}
+		err = ReadRecords(f, filter, func(r Record) error { return enc.Encode(r) })
+		f.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// listSegments returns the sequence numbers of the capture files in dir,
+// oldest first.
+func listSegments(dir string) ([]int64, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read capture directory: %w", err)
+	}
+
+	var segments []int64
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, captureSegmentPrefix) || !strings.HasSuffix(name, captureSegmentSuffix) {
+			continue
+		}
+		seq, err := strconv.ParseInt(strings.TrimSuffix(strings.TrimPrefix(name, captureSegmentPrefix), captureSegmentSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		segments = append(segments, seq)
+	}
+	slices.Sort(segments)
+	return segments, nil
+}
+
+func segmentPath(dir string, seq int64) string {
+	return filepath.Join(dir, fmt.Sprintf("%s%010d%s", captureSegmentPrefix, seq, captureSegmentSuffix))
+}
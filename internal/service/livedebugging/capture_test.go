@@ -0,0 +1,155 @@
+package livedebugging
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/grafana/loki/pkg/push"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+)
+
+func newCaptureLiveDebugging(t *testing.T) *liveDebugging {
+	ld := NewLiveDebugging()
+	ld.captureDir = t.TempDir()
+	ld.SetEnabled(true)
+	t.Cleanup(func() { require.NoError(t, ld.SetCapture(nil, 0)) })
+	return ld
+}
+
+func readAll(t *testing.T, ld *liveDebugging, filter Filter) []Record {
+	t.Helper()
+
+	var buf bytes.Buffer
+	require.NoError(t, ld.ReadCapture(t.Context(), filter, &buf))
+	var records []Record
+	require.NoError(t, ReadRecords(&buf, Filter{}, func(r Record) error {
+		records = append(records, r)
+		return nil
+	}))
+	return records
+}
+
+func TestCapture(t *testing.T) {
+	ld := newCaptureLiveDebugging(t)
+
+	matchers, err := ParseMatchers(`{job=~"api|web"}`)
+	require.NoError(t, err)
+	require.NoError(t, ld.SetCapture(&Filter{
+		Components: []ComponentID{"loki.process.default"},
+		Matchers:   matchers,
+	}, 1024*1024))
+
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	publish := func(id ComponentID, job, line string) {
+		lset := model.LabelSet{"job": model.LabelValue(job)}
+		ld.PublishIfActive(NewData(id, LokiLog, 1, func() string { return line },
+			WithAttributes(func() map[string]string { return LokiLabels(lset) }),
+			WithReplay(func() *Replay { return NewLokiReplay(lset, push.Entry{Timestamp: ts, Line: line}) }),
+		))
+	}
+	publish("loki.process.default", "api", "first")
+	publish("loki.process.default", "db", "filtered out")
+	publish("loki.process.other", "api", "not captured")
+	publish("loki.process.default", "web", "second")
+
+	require.Eventually(t, func() bool {
+		return len(readAll(t, ld, Filter{})) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+
+	records := readAll(t, ld, Filter{})
+	require.Equal(t, "first", records[0].Data)
+	require.Equal(t, map[string]string{"job": "api"}, records[0].Attributes)
+	require.Equal(t, &Replay{Loki: &ReplayLokiEntry{
+		Labels:    map[string]string{"job": "api"},
+		Line:      "first",
+		Timestamp: ts,
+	}}, records[0].Replay)
+	require.Equal(t, "second", records[1].Data)
+
+	// Records are filtered when read too.
+	matchers, err = ParseMatchers(`{data="second"}`)
+	require.NoError(t, err)
+	records = readAll(t, ld, Filter{Matchers: matchers})
+	require.Len(t, records, 1)
+	require.Equal(t, "second", records[0].Data)
+
+	require.Empty(t, readAll(t, ld, Filter{Since: time.Now().Add(time.Hour)}))
+
+	// Captured data can still be read once capturing stops.
+	require.NoError(t, ld.SetCapture(nil, 0))
+	require.Len(t, readAll(t, ld, Filter{}), 2)
+}
+
+func TestCapture_MaxSize(t *testing.T) {
+	dir := t.TempDir()
+	store, err := newCaptureStore(NewLiveDebugging().logger, dir, 8*1024)
+	require.NoError(t, err)
+
+	for i := range 1000 {
+		require.NoError(t, store.append(Record{ComponentID: "loki.process.default", Type: LokiLog, Data: fmt.Sprintf("line %d", i)}))
+	}
+	store.close()
+
+	segments, err := listSegments(dir)
+	require.NoError(t, err)
+	require.Len(t, segments, captureSegments)
+
+	var total int64
+	for _, seq := range segments {
+		fi, err := os.Stat(segmentPath(dir, seq))
+		require.NoError(t, err)
+		total += fi.Size()
+	}
+	require.LessOrEqual(t, total, int64(8*1024))
+
+	// The newest records are kept.
+	var buf bytes.Buffer
+	require.NoError(t, readCapture(t.Context(), dir, Filter{}, &buf))
+	var last Record
+	require.NoError(t, ReadRecords(&buf, Filter{}, func(r Record) error {
+		last = r
+		return nil
+	}))
+	require.Equal(t, "line 999", last.Data)
+
+	// Reopening the store keeps previous records.
+	store, err = newCaptureStore(NewLiveDebugging().logger, dir, 8*1024)
+	require.NoError(t, err)
+	store.close()
+	buf.Reset()
+	require.NoError(t, readCapture(t.Context(), dir, Filter{}, &buf))
+	require.Contains(t, buf.String(), "line 999")
+}
+
+func TestNewRecord_NonFiniteSample(t *testing.T) {
+	lbls := labels.FromStrings("__name__", "up")
+	r := NewRecord(NewData("prometheus.scrape.default", PrometheusMetric, 1, func() string { return "up" },
+		WithReplay(func() *Replay { return NewSampleReplay(lbls, 1000, math.NaN()) }),
+	), time.Now())
+	require.Nil(t, r.Replay)
+
+	r = NewRecord(NewData("prometheus.scrape.default", PrometheusMetric, 1, func() string { return "up" },
+		WithReplay(func() *Replay { return NewSampleReplay(lbls, 1000, 1) }),
+	), time.Now())
+	require.Equal(t, &ReplaySample{Labels: map[string]string{"__name__": "up"}, Value: 1, Timestamp: time.UnixMilli(1000).UTC()}, r.Replay.Sample)
+}
+
+func TestCaptureArguments_Validate(t *testing.T) {
+	var args CaptureArguments
+	args.SetToDefault()
+	require.EqualError(t, args.Validate(), "at least one component must be captured")
+
+	args.Components = []string{"loki.process.default"}
+	require.NoError(t, args.Validate())
+
+	args.Filter = `{job=`
+	require.ErrorContains(t, args.Validate(), "invalid filter")
+
+	require.EqualError(t, (&Arguments{Capture: &args}).Validate(), "the capture block requires live debugging to be enabled")
+}
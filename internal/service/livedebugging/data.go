@@ -1,5 +1,14 @@
 package livedebugging
 
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/grafana/loki/pkg/push"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+)
+
 type DataType string
 
 const (
@@ -9,6 +18,7 @@ const (
 	OtelMetric       DataType = "otel_metric"
 	OtelLog          DataType = "otel_log"
 	OtelTrace        DataType = "otel_trace"
+	Profile          DataType = "profile"
 )
 
 type DataOption func(Data) Data
@@ -20,6 +30,35 @@ func WithTargetComponentIDs(ids []string) DataOption {
 	}
 }
 
+// WithAttributes sets a function returning the attributes of the data, such
+// as the labels of a log entry or a series. Captured data can be filtered by
+// attributes.
+func WithAttributes(attributesFunc func() map[string]string) DataOption {
+	return func(d Data) Data {
+		d.AttributesFunc = attributesFunc
+		return d
+	}
+}
+
+// WithReplay sets a function returning the data in a form which can be sent
+// to a component again.
+func WithReplay(replayFunc func() *Replay) DataOption {
+	return func(d Data) Data {
+		d.ReplayFunc = replayFunc
+		return d
+	}
+}
+
+// WithPayload sets a function returning the data as a structured value, such
+// as a LabelsDiff or a ProfileSummary. The value is encoded as JSON so that
+// the UI and the API can render it as a table.
+func WithPayload(payloadFunc func() any) DataOption {
+	return func(d Data) Data {
+		d.PayloadFunc = payloadFunc
+		return d
+	}
+}
+
 type Data struct {
 	// ID of the component that created the data.
 	ComponentID ComponentID
@@ -33,6 +72,88 @@ type Data struct {
 	Count uint64
 	// The data string is passed as a function to only compute the string if needed.
 	DataFunc func() string
+	// AttributesFunc, if set, returns the attributes of the data. It's only
+	// called when the data is captured.
+	AttributesFunc func() map[string]string
+	// ReplayFunc, if set, returns the data in a form which can be replayed.
+	// It's only called when the data is captured.
+	ReplayFunc func() *Replay
+	// PayloadFunc, if set, returns the data as a structured value which can
+	// be encoded as JSON. It's only called when the data is consumed.
+	PayloadFunc func() any
+}
+
+// Payload returns the structured value of the data encoded as JSON, or nil
+// when the data doesn't have one.
+func (d Data) Payload() json.RawMessage {
+	if d.PayloadFunc == nil {
+		return nil
+	}
+	payload, err := json.Marshal(d.PayloadFunc())
+	if err != nil {
+		return nil
+	}
+	return payload
+}
+
+// LabelsDiff is the payload of components changing labels, such as relabel
+// components.
+type LabelsDiff struct {
+	Before map[string]string `json:"before"`
+	// After is empty when the labels were dropped.
+	After   map[string]string `json:"after,omitempty"`
+	Dropped bool              `json:"dropped,omitempty"`
+}
+
+// ProfileSummary is the payload of profiles.
+type ProfileSummary struct {
+	Labels map[string]string `json:"labels"`
+	// SampleTypes are the types of the samples of the profile, such as
+	// cpu:nanoseconds. They are empty when the profile format isn't known.
+	SampleTypes []string `json:"sample_types,omitempty"`
+	// Size is the size of the profile in bytes.
+	Size    int `json:"size"`
+	Samples int `json:"samples,omitempty"`
+	// TopFrames are the functions with the highest self value for the
+	// default sample type, highest first.
+	TopFrames []ProfileFrame `json:"top_frames,omitempty"`
+}
+
+// ProfileFrame is the self value of a function in a profile.
+type ProfileFrame struct {
+	Function string `json:"function"`
+	Self     int64  `json:"self"`
+}
+
+// Replay holds debugging data in a form which can be sent to a component
+// again, for example in the input of a test run with `alloy test`. Only one
+// of its fields is set.
+type Replay struct {
+	Loki   *ReplayLokiEntry `json:"loki,omitempty"`
+	Sample *ReplaySample    `json:"sample,omitempty"`
+	OTLP   *ReplayOTLP      `json:"otlp,omitempty"`
+}
+
+// ReplayLokiEntry is a Loki log entry.
+type ReplayLokiEntry struct {
+	Labels             map[string]string `json:"labels,omitempty"`
+	Line               string            `json:"line"`
+	Timestamp          time.Time         `json:"timestamp"`
+	StructuredMetadata map[string]string `json:"structured_metadata,omitempty"`
+}
+
+// ReplaySample is a Prometheus sample.
+type ReplaySample struct {
+	Labels    map[string]string `json:"labels"`
+	Value     float64           `json:"value"`
+	Timestamp time.Time         `json:"timestamp"`
+}
+
+// ReplayOTLP holds OTLP data in the OTLP JSON encoding, by signal.
+type ReplayOTLP struct {
+	Traces  json.RawMessage `json:"traces,omitempty"`
+	Metrics json.RawMessage `json:"metrics,omitempty"`
+	Logs    json.RawMessage `json:"logs,omitempty"`
 }
 
 func NewData(componentID ComponentID, dataType DataType, count uint64, dataFunc func() string, opts ...DataOption) Data {
@@ -49,3 +170,39 @@ func NewData(componentID ComponentID, dataType DataType, count uint64, dataFunc
 
 	return data
 }
+
+// LokiLabels returns the labels of a log entry as attributes.
+func LokiLabels(lset model.LabelSet) map[string]string {
+	res := make(map[string]string, len(lset))
+	for k, v := range lset {
+		res[string(k)] = string(v)
+	}
+	return res
+}
+
+// NewLokiReplay returns a log entry in a form which can be replayed.
+func NewLokiReplay(lset model.LabelSet, entry push.Entry) *Replay {
+	var metadata map[string]string
+	if len(entry.StructuredMetadata) > 0 {
+		metadata = make(map[string]string, len(entry.StructuredMetadata))
+		for _, l := range entry.StructuredMetadata {
+			metadata[l.Name] = l.Value
+		}
+	}
+	return &Replay{Loki: &ReplayLokiEntry{
+		Labels:             LokiLabels(lset),
+		Line:               entry.Line,
+		Timestamp:          entry.Timestamp.UTC(),
+		StructuredMetadata: metadata,
+	}}
+}
+
+// NewSampleReplay returns a sample, with its timestamp in milliseconds, in a
+// form which can be replayed.
+func NewSampleReplay(lbls labels.Labels, t int64, v float64) *Replay {
+	return &Replay{Sample: &ReplaySample{
+		Labels:    lbls.Map(),
+		Value:     v,
+		Timestamp: time.UnixMilli(t).UTC(),
+	}}
+}
@@ -1,8 +1,14 @@
 package livedebugging
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"slices"
 	"sync"
+	"time"
+
+	"github.com/go-kit/log"
 
 	"github.com/grafana/alloy/internal/component"
 	"github.com/grafana/alloy/internal/service"
@@ -28,6 +34,13 @@ type CallbackManager interface {
 	DeleteCallbackMulti(host service.Host, callbackID CallbackID, moduleID ModuleID)
 }
 
+// CaptureReader is used to read live debugging data captured to disk.
+type CaptureReader interface {
+	// ReadCapture writes the captured records selected by filter to w as
+	// newline-delimited JSON, oldest first.
+	ReadCapture(ctx context.Context, filter Filter, w io.Writer) error
+}
+
 // DebugDataPublisher is used by components to push information to live debugging consumers.
 type DebugDataPublisher interface {
 	// Publish sends debugging data for a given componentID if a least one consumer is listening for debugging data for the given componentID.
@@ -38,17 +51,25 @@ type liveDebugging struct {
 	loadMut   sync.RWMutex
 	callbacks map[ComponentID]map[CallbackID]func(Data)
 	enabled   bool
+
+	logger        log.Logger
+	captureDir    string // Empty if data can't be captured.
+	capture       *captureStore
+	captureFilter Filter
+	captureSize   int64
 }
 
 var (
 	_ CallbackManager    = &liveDebugging{}
 	_ DebugDataPublisher = &liveDebugging{}
+	_ CaptureReader      = &liveDebugging{}
 )
 
 // NewLiveDebugging creates a new instance of liveDebugging.
 func NewLiveDebugging() *liveDebugging {
 	return &liveDebugging{
 		callbacks: make(map[ComponentID]map[CallbackID]func(Data)),
+		logger:    log.NewNopLogger(),
 	}
 }
 
@@ -56,6 +77,13 @@ func (s *liveDebugging) PublishIfActive(data Data) {
 	s.loadMut.RLock()
 	defer s.loadMut.RUnlock()
 
+	if s.capture != nil && slices.Contains(s.captureFilter.Components, data.ComponentID) {
+		record := NewRecord(data, time.Now())
+		if s.captureFilter.Matches(&record) {
+			s.capture.write(record)
+		}
+	}
+
 	if callbacks, exist := s.callbacks[data.ComponentID]; !exist || len(callbacks) == 0 {
 		return
 	}
@@ -145,6 +173,51 @@ func (s *liveDebugging) DeleteCallbackMulti(host service.Host, callbackID Callba
 	// If this ever become a realistic scenario we should cleanup the map here.
 }
 
+// SetCapture starts capturing the data selected by filter to disk, in files
+// of at most maxSize bytes in total. Capturing stops if filter is nil.
+func (s *liveDebugging) SetCapture(filter *Filter, maxSize int64) error {
+	s.loadMut.Lock()
+	defer s.loadMut.Unlock()
+
+	if filter != nil && s.captureDir == "" {
+		return fmt.Errorf("capturing live debugging data requires a storage path")
+	}
+
+	// The store is reopened when the maximum size changes, which starts a new
+	// file of the new size.
+	if s.capture != nil && (filter == nil || s.captureSize != maxSize) {
+		s.capture.close()
+		s.capture = nil
+	}
+	if filter == nil {
+		return nil
+	}
+
+	if s.capture == nil {
+		store, err := newCaptureStore(s.logger, s.captureDir, maxSize)
+		if err != nil {
+			return err
+		}
+		s.capture, s.captureSize = store, maxSize
+	}
+	s.captureFilter = *filter
+	return nil
+}
+
+func (s *liveDebugging) ReadCapture(ctx context.Context, filter Filter, w io.Writer) error {
+	s.loadMut.RLock()
+	dir, store := s.captureDir, s.capture
+	s.loadMut.RUnlock()
+
+	if dir == "" {
+		return fmt.Errorf("capturing live debugging data requires a storage path")
+	}
+	if store != nil {
+		store.flush()
+	}
+	return readCapture(ctx, dir, filter, w)
+}
+
 func (s *liveDebugging) SetEnabled(enabled bool) {
 	s.loadMut.Lock()
 	defer s.loadMut.Unlock()
@@ -0,0 +1,32 @@
+// Package livedebuggingtest provides helpers to test the live debugging
+// support of components.
+package livedebuggingtest
+
+import (
+	"sync"
+
+	"github.com/grafana/alloy/internal/service/livedebugging"
+)
+
+// Publisher implements livedebugging.DebugDataPublisher and records all the
+// published data.
+type Publisher struct {
+	mut  sync.Mutex
+	data []livedebugging.Data
+}
+
+var _ livedebugging.DebugDataPublisher = (*Publisher)(nil)
+
+// PublishIfActive records data.
+func (p *Publisher) PublishIfActive(data livedebugging.Data) {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	p.data = append(p.data, data)
+}
+
+// Data returns the data published so far.
+func (p *Publisher) Data() []livedebugging.Data {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	return append([]livedebugging.Data{}, p.data...)
+}
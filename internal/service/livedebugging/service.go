@@ -3,6 +3,11 @@ package livedebugging
 
 import (
 	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/alecthomas/units"
+	"github.com/go-kit/log"
 
 	"github.com/grafana/alloy/internal/featuregate"
 	"github.com/grafana/alloy/internal/service"
@@ -11,20 +16,77 @@ import (
 // ServiceName defines the name used for the livedebugging service.
 const ServiceName = "livedebugging"
 
+// Options are used to configure the livedebugging service. Options are
+// constant for the lifetime of the service.
+type Options struct {
+	Logger      log.Logger
+	StoragePath string // Where to capture data on-disk. Data can't be captured if empty.
+}
+
 type Service struct {
 	liveDebugging *liveDebugging
 }
 
 var _ service.Service = (*Service)(nil)
 
-func New() *Service {
+func New(opts Options) *Service {
+	ld := NewLiveDebugging()
+	if opts.Logger != nil {
+		ld.logger = opts.Logger
+	}
+	if opts.StoragePath != "" {
+		ld.captureDir = filepath.Join(opts.StoragePath, ServiceName, "capture")
+	}
 	return &Service{
-		liveDebugging: NewLiveDebugging(),
+		liveDebugging: ld,
 	}
 }
 
 type Arguments struct {
-	Enabled bool `alloy:"enabled,attr,optional"`
+	Enabled bool              `alloy:"enabled,attr,optional"`
+	Capture *CaptureArguments `alloy:"capture,block,optional"`
+}
+
+// Validate implements syntax.Validator.
+func (args *Arguments) Validate() error {
+	if args.Capture != nil && !args.Enabled {
+		return fmt.Errorf("the capture block requires live debugging to be enabled")
+	}
+	return nil
+}
+
+// CaptureArguments configures capturing live debugging data to disk.
+type CaptureArguments struct {
+	Components []string         `alloy:"components,attr"`
+	Filter     string           `alloy:"filter,attr,optional"`
+	MaxSize    units.Base2Bytes `alloy:"max_size,attr,optional"`
+}
+
+// SetToDefault implements syntax.Defaulter.
+func (args *CaptureArguments) SetToDefault() {
+	*args = CaptureArguments{MaxSize: 64 * units.MiB}
+}
+
+// Validate implements syntax.Validator.
+func (args *CaptureArguments) Validate() error {
+	if len(args.Components) == 0 {
+		return fmt.Errorf("at least one component must be captured")
+	}
+	if args.MaxSize <= 0 {
+		return fmt.Errorf("max_size must be greater than 0")
+	}
+	_, err := ParseMatchers(args.Filter)
+	return err
+}
+
+func (args *CaptureArguments) filter() *Filter {
+	f := &Filter{}
+	for _, id := range args.Components {
+		f.Components = append(f.Components, ComponentID(id))
+	}
+	// The filter was checked in Validate.
+	f.Matchers, _ = ParseMatchers(args.Filter)
+	return f
 }
 
 // Data implements service.Service.
@@ -46,12 +108,17 @@ func (*Service) Definition() service.Definition {
 // Run implements service.Service.
 func (s *Service) Run(ctx context.Context, host service.Host) error {
 	<-ctx.Done()
-	return nil
+	// Write the captured data before exiting.
+	return s.liveDebugging.SetCapture(nil, 0)
 }
 
 // Update implements service.Service.
 func (s *Service) Update(args any) error {
 	newArgs := args.(Arguments)
 	s.liveDebugging.SetEnabled(newArgs.Enabled)
-	return nil
+
+	if newArgs.Capture == nil {
+		return s.liveDebugging.SetCapture(nil, 0)
+	}
+	return s.liveDebugging.SetCapture(newArgs.Capture.filter(), int64(newArgs.Capture.MaxSize))
 }
@@ -2,6 +2,7 @@ package remotecfg
 
 import (
 	"context"
+	"net/http"
 	"time"
 
 	"connectrpc.com/connect"
@@ -57,12 +58,23 @@ func newCollectorClient(args Arguments) (collectorv1connect.CollectorServiceClie
 	), nil
 }
 
+type responseHeaderKey struct{}
+
+// withResponseHeader returns a context which makes apiClient.GetConfig store
+// the headers of its response in h, including for "not modified" responses.
+func withResponseHeader(ctx context.Context, h *http.Header) context.Context {
+	return context.WithValue(ctx, responseHeaderKey{}, h)
+}
+
 func (c *apiClient) GetConfig(ctx context.Context, req *connect.Request[collectorv1.GetConfigRequest]) (*connect.Response[collectorv1.GetConfigResponse], error) {
 	start := time.Now()
 	resp, err := c.client.GetConfig(ctx, req)
 	if err != nil {
 		return nil, err
 	}
+	if h, ok := ctx.Value(responseHeaderKey{}).(*http.Header); ok {
+		*h = resp.Header()
+	}
 	c.metrics.getConfigTime.Observe(time.Since(start).Seconds())
 	if resp.Msg.NotModified {
 		return nil, errNotModified
@@ -17,9 +17,24 @@ type Arguments struct {
 	Name             string                   `alloy:"name,attr,optional"`
 	Attributes       map[string]string        `alloy:"attributes,attr,optional"`
 	PollFrequency    time.Duration            `alloy:"poll_frequency,attr,optional"`
+	LongPollTimeout  time.Duration            `alloy:"long_poll_timeout,attr,optional"`
+	CacheSize        int                      `alloy:"cache_size,attr,optional"`
+	Signature        *SignatureArguments      `alloy:"signature,block,optional"`
+	Rollout          *RolloutArguments        `alloy:"rollout,block,optional"`
 	HTTPClientConfig *config.HTTPClientConfig `alloy:",squash"`
 }
 
+// SignatureArguments configures the verification of the signature of remote
+// configurations.
+type SignatureArguments struct {
+	PublicKeys []string `alloy:"public_keys,attr"`
+}
+
+// RolloutArguments configures the rollout cohort of the collector.
+type RolloutArguments struct {
+	Cohort string `alloy:"cohort,attr,optional"`
+}
+
 // Make sure Arguments implements the syntax.Defaulter interface
 var _ syntax.Defaulter = (*Arguments)(nil)
 
@@ -32,6 +47,7 @@ func getDefaultArguments() Arguments {
 		ID:               alloyseed.Get().UID,
 		Attributes:       make(map[string]string),
 		PollFrequency:    1 * time.Minute,
+		CacheSize:        1,
 		HTTPClientConfig: config.CloneDefaultHTTPClientConfig(),
 	}
 }
@@ -47,6 +63,23 @@ func (a *Arguments) Validate() error {
 		return fmt.Errorf("poll_frequency must be at least \"10s\", got %q", a.PollFrequency)
 	}
 
+	if a.LongPollTimeout < 0 {
+		return fmt.Errorf("long_poll_timeout must not be negative, got %q", a.LongPollTimeout)
+	}
+
+	if a.CacheSize < 0 {
+		return fmt.Errorf("cache_size must not be negative, got %d", a.CacheSize)
+	}
+
+	if a.Signature != nil {
+		if len(a.Signature.PublicKeys) == 0 {
+			return fmt.Errorf("signature block must have at least one public key")
+		}
+		if _, err := parsePublicKeys(a.Signature.PublicKeys); err != nil {
+			return err
+		}
+	}
+
 	for k := range a.Attributes {
 		if strings.HasPrefix(k, reservedAttributeNamespace+namespaceDelimiter) {
 			return fmt.Errorf("%q is a reserved namespace for remotecfg attribute keys", reservedAttributeNamespace)
@@ -62,9 +95,13 @@ func (a *Arguments) Validate() error {
 	return nil
 }
 
-// Hash marshals the Arguments and returns a hash representation.
+// Hash marshals the Arguments and returns a hash representation. The long
+// polling, cache size, signature and rollout settings don't change which
+// configuration is served, so they are left out of the hash.
 func (a *Arguments) Hash() (string, error) {
-	b, err := syntax.Marshal(a)
+	c := *a
+	c.LongPollTimeout, c.CacheSize, c.Signature, c.Rollout = 0, 0, nil, nil
+	b, err := syntax.Marshal(c)
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal arguments: %w", err)
 	}
@@ -152,6 +152,48 @@ func TestArguments_Validate_PollFrequencyTooShort(t *testing.T) {
 	}
 }
 
+func TestArguments_Validate_SignatureAndCache(t *testing.T) {
+	tests := []struct {
+		name        string
+		args        Arguments
+		expectedErr string
+	}{
+		{
+			name:        "negative cache size",
+			args:        Arguments{CacheSize: -1},
+			expectedErr: "cache_size must not be negative",
+		},
+		{
+			name:        "signature without public keys",
+			args:        Arguments{Signature: &SignatureArguments{}},
+			expectedErr: "signature block must have at least one public key",
+		},
+		{
+			name:        "invalid public key",
+			args:        Arguments{Signature: &SignatureArguments{PublicKeys: []string{"not a key"}}},
+			expectedErr: "invalid public key at index 0",
+		},
+		{
+			name: "valid public key",
+			args: Arguments{Signature: &SignatureArguments{PublicKeys: []string{"wxWCCBtXt0hysEbqcWS6EFXWiPb57oZL/de4bbasHAk="}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.args.PollFrequency = 30 * time.Second
+			tt.args.HTTPClientConfig = config.CloneDefaultHTTPClientConfig()
+
+			err := tt.args.Validate()
+			if tt.expectedErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.expectedErr)
+		})
+	}
+}
+
 func TestArguments_Validate_ReservedAttributeNamespace(t *testing.T) {
 	tests := []struct {
 		name       string
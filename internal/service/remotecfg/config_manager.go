@@ -1,12 +1,18 @@
 package remotecfg
 
 import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
 	"errors"
 	"fmt"
 	"hash/fnv"
 	"math"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -31,6 +37,12 @@ var errNotModified = errors.New("config not modified since last fetch")
 // Alloy configuration to the remote config service.
 const effectiveConfigContentType = "text/plain"
 
+// getConfigResponse is a response of the API, along with its headers.
+type getConfigResponse struct {
+	*collectorv1.GetConfigResponse
+	header http.Header
+}
+
 // configManager is responsible for managing the configuration of the remotecfg service.
 type configManager struct {
 	// Mutex to protect internal state
@@ -89,6 +101,18 @@ type configManager struct {
 
 	// lastSentEffectiveConfig tracks the last effective config sent to the server to avoid redundant updates
 	lastSentEffectiveConfig *collectorv1.EffectiveConfig
+
+	// The ID and rollout cohort of the collector, used to decide whether
+	// configurations are rolled out to it.
+	collectorID string
+	cohort      string
+
+	// The public keys used to verify the signature of configurations. When
+	// empty, configurations aren't verified.
+	publicKeys []ed25519.PublicKey
+
+	// The number of configurations kept in the on-disk cache.
+	cacheSize int
 }
 
 func newConfigManager(metrics *metrics, logger log.Logger, remotecfgPath string, configPath string) *configManager {
@@ -125,10 +149,58 @@ func (cm *configManager) setPollFrequency(t time.Duration) {
 	}
 }
 
+// setArguments updates the settings of the configManager which come from the
+// arguments of the service.
+func (cm *configManager) setArguments(args Arguments) error {
+	var publicKeys []ed25519.PublicKey
+	if args.Signature != nil {
+		var err error
+		publicKeys, err = parsePublicKeys(args.Signature.PublicKeys)
+		if err != nil {
+			return err
+		}
+	}
+	var cohort string
+	if args.Rollout != nil {
+		cohort = args.Rollout.Cohort
+	}
+
+	cm.mut.Lock()
+	defer cm.mut.Unlock()
+	cm.collectorID = args.ID
+	cm.cohort = cohort
+	cm.publicKeys = publicKeys
+	cm.cacheSize = args.CacheSize
+	return nil
+}
+
 func (cm *configManager) getCachedConfigPath() string {
+	return cm.getCachedConfigPathAt(0)
+}
+
+// getCachedConfigPathAt returns the path of a configuration of the on-disk
+// cache. The most recent configuration is at index 0.
+func (cm *configManager) getCachedConfigPathAt(i int) string {
 	cm.mut.RLock()
 	defer cm.mut.RUnlock()
-	return filepath.Join(cm.remotecfgPath, cm.argsHash)
+
+	p := filepath.Join(cm.remotecfgPath, cm.argsHash)
+	if i > 0 {
+		p += "." + strconv.Itoa(i)
+	}
+	return p
+}
+
+// getCachedSignaturePathAt returns the path of the signature of a
+// configuration of the on-disk cache.
+func (cm *configManager) getCachedSignaturePathAt(i int) string {
+	return cm.getCachedConfigPathAt(i) + ".sig"
+}
+
+func (cm *configManager) getCacheSize() int {
+	cm.mut.RLock()
+	defer cm.mut.RUnlock()
+	return max(cm.cacheSize, 1)
 }
 
 func (cm *configManager) getCachedConfig() ([]byte, error) {
@@ -136,12 +208,51 @@ func (cm *configManager) getCachedConfig() ([]byte, error) {
 	return os.ReadFile(p)
 }
 
-func (cm *configManager) setCachedConfig(b []byte) {
+// setCachedConfig writes b and its signature as the most recent configuration
+// of the on-disk cache. The previous configurations are kept, up to the cache
+// size.
+func (cm *configManager) setCachedConfig(b []byte, signature string) {
 	p := cm.getCachedConfigPath()
+	size := cm.getCacheSize()
+
+	if prev, err := os.ReadFile(p); err == nil && !bytes.Equal(prev, b) {
+		for i := size - 1; i > 0; i-- {
+			err := os.Rename(cm.getCachedConfigPathAt(i-1), cm.getCachedConfigPathAt(i))
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				level.Warn(cm.logger).Log("msg", "failed to rotate the on-disk cache of remote configurations", "err", err)
+			}
+			err = os.Rename(cm.getCachedSignaturePathAt(i-1), cm.getCachedSignaturePathAt(i))
+			if err != nil && !errors.Is(err, os.ErrNotExist) {
+				level.Warn(cm.logger).Log("msg", "failed to rotate the on-disk cache of remote configuration signatures", "err", err)
+			}
+		}
+	}
+
+	// Remove the configurations beyond the cache size, in case it was lowered.
+	for i := size; ; i++ {
+		_ = os.Remove(cm.getCachedSignaturePathAt(i))
+		if err := os.Remove(cm.getCachedConfigPathAt(i)); err != nil {
+			break
+		}
+	}
+
 	err := os.WriteFile(p, b, 0750)
 	if err != nil {
 		level.Error(cm.logger).Log("msg", "failed to flush remote configuration contents the on-disk cache", "err", err)
 	}
+
+	sigPath := cm.getCachedSignaturePathAt(0)
+	if signature == "" {
+		err = os.Remove(sigPath)
+		if errors.Is(err, os.ErrNotExist) {
+			err = nil
+		}
+	} else {
+		err = os.WriteFile(sigPath, []byte(signature), 0750)
+	}
+	if err != nil {
+		level.Error(cm.logger).Log("msg", "failed to flush remote configuration signature to the on-disk cache", "err", err)
+	}
 }
 
 func (cm *configManager) getLastLoadedCfgHash() string {
@@ -208,9 +319,10 @@ func (cm *configManager) getAstFile() *ast.File {
 
 // fetchLoadConfig attempts to read configuration from the API and the local cache
 // and then parse/load their contents in order of preference. useCacheAsFallback
-// determines whether to fall back to the cache on remote failure.
-func (cm *configManager) fetchLoadConfig(getAPIConfig func() (*collectorv1.GetConfigResponse, error), useCacheAsFallback bool) {
-	if err := cm.fetchLoadRemoteConfig(getAPIConfig); err != nil && err != errNotModified {
+// determines whether to fall back to the cache on remote failure. Status
+// changes are then reported with notifyAPIConfig.
+func (cm *configManager) fetchLoadConfig(getAPIConfig, notifyAPIConfig func() (*getConfigResponse, error), useCacheAsFallback bool) {
+	if err := cm.fetchLoadRemoteConfig(getAPIConfig); err != nil && err != errNotModified && !errors.Is(err, context.Canceled) {
 		if useCacheAsFallback {
 			level.Error(cm.logger).Log("msg", "failed to fetch remote config, falling back to cache", "err", err)
 			cm.fetchLoadLocalConfig()
@@ -219,12 +331,12 @@ func (cm *configManager) fetchLoadConfig(getAPIConfig func() (*collectorv1.GetCo
 		}
 	}
 
-	cm.notifyStatusUpdate(getAPIConfig)
+	cm.notifyStatusUpdate(notifyAPIConfig)
 }
 
 // notifyStatusUpdate makes an immediate GetConfig call to notify the server of status changes.
 // This is used when we want to immediately report status changes without waiting for the next poll cycle.
-func (cm *configManager) notifyStatusUpdate(getAPIConfig func() (*collectorv1.GetConfigResponse, error)) {
+func (cm *configManager) notifyStatusUpdate(getAPIConfig func() (*getConfigResponse, error)) {
 	// Avoid unnecessary immediate calls if there's nothing new to report.
 	if !cm.hasPendingUpdates() {
 		level.Debug(cm.logger).Log("msg", "no pending status/effective-config updates; skipping notify")
@@ -243,7 +355,7 @@ func (cm *configManager) notifyStatusUpdate(getAPIConfig func() (*collectorv1.Ge
 	}
 }
 
-func (cm *configManager) fetchLoadRemoteConfig(getAPIConfig func() (*collectorv1.GetConfigResponse, error)) error {
+func (cm *configManager) fetchLoadRemoteConfig(getAPIConfig func() (*getConfigResponse, error)) error {
 	level.Debug(cm.logger).Log("msg", "fetching remote configuration")
 
 	gcr, err := getAPIConfig()
@@ -268,6 +380,12 @@ func (cm *configManager) fetchLoadRemoteConfig(getAPIConfig func() (*collectorv1
 		return nil
 	}
 
+	// Canceled requests, such as long polls interrupted by an update of the
+	// arguments, aren't failures of the API.
+	if errors.Is(err, context.Canceled) {
+		return err
+	}
+
 	// Handle other errors
 	if err != nil {
 		level.Error(cm.logger).Log("msg", "failed to fetch remote config", "err", err)
@@ -281,15 +399,40 @@ func (cm *configManager) fetchLoadRemoteConfig(getAPIConfig func() (*collectorv1
 	cm.metrics.lastFetchSuccessTime.SetToCurrentTime()
 	cm.metrics.lastFetchNotModified.Set(0)
 
+	b := []byte(gcr.GetContent())
+	newConfigHash := getHash(b)
+
+	// Neither the remote hash nor the received hash are recorded for
+	// configurations which aren't rolled out to this collector or aren't
+	// signed, so that they're fetched again once the rollout widens or the
+	// signature is fixed.
+	if ok, err := cm.inRollout(gcr.header, newConfigHash); err != nil {
+		level.Error(cm.logger).Log("msg", "invalid rollout of remote configuration", "config_hash", newConfigHash, "err", err)
+		cm.metrics.totalFailures.Add(1)
+		cm.setRemoteConfigStatus(collectorv1.RemoteConfigStatuses_RemoteConfigStatuses_FAILED, getErrorMessage(err))
+		return err
+	} else if !ok {
+		level.Info(cm.logger).Log("msg", "skipping over API response since this collector is not part of its rollout", "config_hash", newConfigHash)
+		cm.metrics.rolloutSkipped.Add(1)
+		return nil
+	}
+
+	signature := gcr.header.Get(signatureHeader)
+	if err := cm.verifySignature(b, signature); err != nil {
+		level.Error(cm.logger).Log("msg", "failed to verify the signature of remote configuration", "config_hash", newConfigHash, "err", err)
+		cm.metrics.totalFailures.Add(1)
+		cm.metrics.signatureFailures.Add(1)
+		cm.metrics.lastLoadSuccess.Set(0)
+		cm.setRemoteConfigStatus(collectorv1.RemoteConfigStatuses_RemoteConfigStatuses_FAILED, getErrorMessage(err))
+		return err
+	}
+
 	// Store the remote hash from the API response
 	if gcr.Hash != "" {
 		level.Debug(cm.logger).Log("msg", "setting remote hash", "hash", gcr.Hash)
 		cm.setRemoteHash(gcr.Hash)
 	}
 
-	b := []byte(gcr.GetContent())
-	newConfigHash := getHash(b)
-
 	// Check if we already received this exact config from remote
 	alreadyReceived := cm.getLastReceivedCfgHash() == newConfigHash
 	alreadyLoaded := cm.getLastLoadedCfgHash() == newConfigHash
@@ -335,6 +478,11 @@ func (cm *configManager) fetchLoadRemoteConfig(getAPIConfig func() (*collectorv1
 				level.Error(cm.logger).Log("msg", "failed to read cached configuration for fallback", "err", err)
 				return err
 			}
+			if err := cm.verifyCachedSignature(0, cachedConfig); err != nil {
+				level.Error(cm.logger).Log("msg", "failed to verify the signature of cached configuration for fallback", "err", err)
+				cm.metrics.signatureFailures.Add(1)
+				return err
+			}
 
 			err = cm.parseAndLoad(cachedConfig)
 			if err != nil {
@@ -362,29 +510,82 @@ func (cm *configManager) fetchLoadRemoteConfig(getAPIConfig func() (*collectorv1
 		"config_hash", newConfigHash, "config_size", len(b))
 
 	// If successful, flush to disk and keep a copy.
-	cm.setCachedConfig(b)
+	cm.setCachedConfig(b, signature)
 	return nil
 }
 
+// fetchLoadLocalConfig loads the most recent configuration of the on-disk
+// cache, falling back to older ones when it fails to load.
 func (cm *configManager) fetchLoadLocalConfig() {
-	b, err := cm.getCachedConfig()
-	if err != nil {
-		level.Error(cm.logger).Log("msg", "failed to read from cache", "cache_path", cm.getCachedConfigPath(), "err", err)
+	for i := range cm.getCacheSize() {
+		cachePath := cm.getCachedConfigPathAt(i)
+		b, err := os.ReadFile(cachePath)
+		if err != nil {
+			// Older configurations are only missing if this one is.
+			if i == 0 || !errors.Is(err, os.ErrNotExist) {
+				level.Error(cm.logger).Log("msg", "failed to read from cache", "cache_path", cachePath, "err", err)
+			}
+			return
+		}
+
+		// The cache is verified like configurations fetched from the API, so
+		// that tampering with it doesn't bypass the signature.
+		if err := cm.verifyCachedSignature(i, b); err != nil {
+			level.Error(cm.logger).Log("msg", "failed to verify the signature of cached configuration", "cache_path", cachePath, "err", err)
+			cm.metrics.signatureFailures.Add(1)
+			continue
+		}
+
+		err = cm.parseAndLoad(b)
+		if err != nil {
+			level.Error(cm.logger).Log("msg", "failed to load from cache", "cache_path", cachePath, "err", err)
+			continue
+		}
+
+		// Successfully loaded from cache - update the loaded hash (but not received hash, since this came from cache)
+		cacheHash := getHash(b)
+		cm.setLastLoadedCfgHash(cacheHash)
+
+		level.Info(cm.logger).Log("msg", "successfully loaded configuration from cache",
+			"config_hash", cacheHash, "config_size", len(b), "cache_path", cachePath)
 		return
 	}
+}
 
-	err = cm.parseAndLoad(b)
+// inRollout returns whether this collector is part of the rollout described
+// by the headers of a response.
+func (cm *configManager) inRollout(h http.Header, configHash string) (bool, error) {
+	r, err := parseRollout(h)
 	if err != nil {
-		level.Error(cm.logger).Log("msg", "failed to load from cache", "cache_path", cm.getCachedConfigPath(), "err", err)
-		return
+		return false, err
 	}
 
-	// Successfully loaded from cache - update the loaded hash (but not received hash, since this came from cache)
-	cacheHash := getHash(b)
-	cm.setLastLoadedCfgHash(cacheHash)
+	cm.mut.RLock()
+	defer cm.mut.RUnlock()
+	return r.includes(cm.collectorID, cm.cohort, configHash), nil
+}
+
+// verifySignature checks the signature of the configuration b when public
+// keys are configured.
+func (cm *configManager) verifySignature(b []byte, signature string) error {
+	cm.mut.RLock()
+	publicKeys := cm.publicKeys
+	cm.mut.RUnlock()
+
+	if len(publicKeys) == 0 {
+		return nil
+	}
+	return verifySignature(publicKeys, b, signature)
+}
 
-	level.Info(cm.logger).Log("msg", "successfully loaded configuration from cache",
-		"config_hash", cacheHash, "config_size", len(b), "cache_path", cm.getCachedConfigPath())
+// verifyCachedSignature checks the configuration b of the on-disk cache
+// against the signature stored next to it when public keys are configured.
+func (cm *configManager) verifyCachedSignature(i int, b []byte) error {
+	signature, err := os.ReadFile(cm.getCachedSignaturePathAt(i))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return cm.verifySignature(b, strings.TrimSpace(string(signature)))
 }
 
 // cleanup properly stops and cleans up the configManager's resources.
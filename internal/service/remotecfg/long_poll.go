@@ -0,0 +1,162 @@
+package remotecfg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/alloy/internal/runtime/logging/level"
+)
+
+const (
+	// longPollMargin is added to the long polling timeout to get the timeout of
+	// requests, to leave time for the API to respond.
+	longPollMargin = 30 * time.Second
+
+	// minLongPollBackoff is the delay before retrying a failed long poll. It
+	// doubles with each failure, up to the poll frequency.
+	minLongPollBackoff = 1 * time.Second
+)
+
+// setLongPollHeaders asks the API to hold a request until the configuration
+// with the given hash changes, for at most wait. The preference follows RFC
+// 7240, and the hash is used as the ETag of the configuration.
+func setLongPollHeaders(h http.Header, wait time.Duration, hash string) {
+	h.Set("Prefer", fmt.Sprintf("wait=%d", int(wait.Seconds())))
+	if hash != "" {
+		h.Set("If-None-Match", strconv.Quote(hash))
+	}
+}
+
+// longPollApplied returns whether the API held a request as asked by
+// setLongPollHeaders.
+func longPollApplied(h http.Header) bool {
+	for _, v := range h.Values("Preference-Applied") {
+		for _, pref := range strings.Split(v, ",") {
+			name, _, _ := strings.Cut(pref, "=")
+			if strings.EqualFold(strings.TrimSpace(name), "wait") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *Service) getLongPollTimeout() time.Duration {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+	return s.args.LongPollTimeout
+}
+
+// wakeLongPoll cancels the in-flight long poll and makes the long polling
+// loop read the arguments again.
+func (s *Service) wakeLongPoll() {
+	s.mut.Lock()
+	if s.cancelLongPoll != nil {
+		s.cancelLongPoll()
+	}
+	s.mut.Unlock()
+
+	select {
+	case s.longPollWake <- struct{}{}:
+	default:
+	}
+}
+
+// runLongPoll requests configurations with long polling while it's enabled,
+// until ctx is canceled. Configurations are pushed as soon as they change
+// when the API supports long polling; otherwise requests are sent every poll
+// frequency. Failed requests are retried with a jittered exponential backoff.
+func (s *Service) runLongPoll(ctx context.Context) {
+	var failures int
+	for ctx.Err() == nil {
+		wait := s.getLongPollTimeout()
+		if wait <= 0 || !s.isEnabled() {
+			s.sleep(ctx, -1)
+			continue
+		}
+
+		applied, err := s.longPollConfig(ctx, wait)
+		switch {
+		case ctx.Err() != nil:
+			return
+		case errors.Is(err, context.Canceled):
+			// The arguments changed while the request was in flight.
+			continue
+		case err != nil && !errors.Is(err, errNotModified):
+			failures++
+			delay := longPollBackoff(failures, s.cm.getPollFrequency())
+			level.Warn(s.opts.Logger).Log("msg", "long polling failed, retrying", "retries", failures, "delay", delay, "err", err)
+			s.sleep(ctx, delay)
+		case !applied:
+			// The API doesn't support long polling, so fall back to polling.
+			failures = 0
+			level.Debug(s.opts.Logger).Log("msg", "API did not hold the long polling request, falling back to polling")
+			s.sleep(ctx, jitterDuration(s.cm.getPollFrequency(), baseJitter))
+		default:
+			// Spread reconnections, in case many collectors are notified at
+			// once.
+			failures = 0
+			s.sleep(ctx, rand.N(baseJitter))
+		}
+	}
+}
+
+// longPollConfig requests the configuration with long polling, and loads it
+// when it changed. It returns whether the API supports long polling.
+func (s *Service) longPollConfig(ctx context.Context, wait time.Duration) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, wait+longPollMargin)
+	defer cancel()
+
+	s.mut.Lock()
+	s.cancelLongPoll = cancel
+	s.mut.Unlock()
+
+	var (
+		header http.Header
+		err    error
+	)
+	getAPIConfig := func() (*getConfigResponse, error) {
+		var gcr *getConfigResponse
+		gcr, err = s.requestConfig(withResponseHeader(ctx, &header), wait)
+		return gcr, err
+	}
+	s.cm.fetchLoadConfig(getAPIConfig, s.getConfig, false)
+
+	return longPollApplied(header), err
+}
+
+// sleep waits for d, or until the arguments change or ctx is canceled. A
+// negative d waits until one of the latter.
+func (s *Service) sleep(ctx context.Context, d time.Duration) {
+	var timer <-chan time.Time
+	if d >= 0 {
+		t := time.NewTimer(d)
+		defer t.Stop()
+		timer = t.C
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-s.longPollWake:
+	case <-timer:
+	}
+}
+
+// longPollBackoff returns the delay before retrying after the given number of
+// consecutive failures. The delay is jittered so that collectors don't
+// reconnect all at once after an outage.
+func longPollBackoff(failures int, maxDelay time.Duration) time.Duration {
+	d := min(minLongPollBackoff<<min(failures-1, 16), maxDelay)
+	return d/2 + rand.N(d/2+1)
+}
+
+// jitterDuration returns d adjusted by a random jitter in [-j, j).
+func jitterDuration(d, j time.Duration) time.Duration {
+	return d - j + rand.N(2*j)
+}
@@ -0,0 +1,211 @@
+package remotecfg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	collectorv1 "github.com/grafana/alloy-remote-config/api/gen/proto/go/collector/v1"
+	"github.com/grafana/alloy-remote-config/api/gen/proto/go/collector/v1/collectorv1connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+
+	"github.com/grafana/alloy/internal/util"
+	"github.com/grafana/alloy/syntax"
+)
+
+// testServer is a stand-in for a remote configuration API, which optionally
+// supports long polling.
+type testServer struct {
+	collectorv1connect.UnimplementedCollectorServiceHandler
+
+	longPoll bool
+
+	mut     sync.Mutex
+	content string
+	changed chan struct{} // Closed when the content changes.
+	failing bool
+
+	requests atomic.Int32
+	held     atomic.Int32
+}
+
+func newTestServer(t *testing.T, longPoll bool, content string) (*testServer, string) {
+	ts := &testServer{longPoll: longPoll, content: content, changed: make(chan struct{})}
+	mux := http.NewServeMux()
+	mux.Handle(collectorv1connect.NewCollectorServiceHandler(ts))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return ts, srv.URL
+}
+
+func (ts *testServer) setContent(content string) {
+	ts.mut.Lock()
+	defer ts.mut.Unlock()
+	ts.content = content
+	close(ts.changed)
+	ts.changed = make(chan struct{})
+}
+
+func (ts *testServer) setFailing(failing bool) {
+	ts.mut.Lock()
+	defer ts.mut.Unlock()
+	ts.failing = failing
+}
+
+func (ts *testServer) current() (content string, changed chan struct{}, failing bool) {
+	ts.mut.Lock()
+	defer ts.mut.Unlock()
+	return ts.content, ts.changed, ts.failing
+}
+
+func (ts *testServer) GetConfig(ctx context.Context, req *connect.Request[collectorv1.GetConfigRequest]) (*connect.Response[collectorv1.GetConfigResponse], error) {
+	ts.requests.Inc()
+
+	content, changed, failing := ts.current()
+	if failing {
+		return nil, connect.NewError(connect.CodeUnavailable, errors.New("server is down"))
+	}
+
+	wait, _ := strconv.Atoi(strings.TrimPrefix(req.Header().Get("Prefer"), "wait="))
+	longPoll := ts.longPoll && wait > 0
+	if longPoll && req.Msg.Hash == getHash([]byte(content)) {
+		ts.held.Inc()
+		select {
+		case <-changed:
+		case <-time.After(time.Duration(wait) * time.Second):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		content, _, _ = ts.current()
+	}
+
+	hash := getHash([]byte(content))
+	resp := connect.NewResponse(&collectorv1.GetConfigResponse{
+		Content:     content,
+		Hash:        hash,
+		NotModified: req.Msg.Hash == hash,
+	})
+	if longPoll {
+		resp.Header().Set("Preference-Applied", fmt.Sprintf("wait=%d", wait))
+	}
+	return resp, nil
+}
+
+func (ts *testServer) RegisterCollector(context.Context, *connect.Request[collectorv1.RegisterCollectorRequest]) (*connect.Response[collectorv1.RegisterCollectorResponse], error) {
+	return connect.NewResponse(&collectorv1.RegisterCollectorResponse{}), nil
+}
+
+func (ts *testServer) UnregisterCollector(context.Context, *connect.Request[collectorv1.UnregisterCollectorRequest]) (*connect.Response[collectorv1.UnregisterCollectorResponse], error) {
+	return connect.NewResponse(&collectorv1.UnregisterCollectorResponse{}), nil
+}
+
+// runLongPollService runs a service polling url every pollFrequency, with
+// long polling enabled.
+func runLongPollService(t *testing.T, url string, pollFrequency time.Duration) *Service {
+	svc, err := New(Options{
+		Logger:      util.TestLogger(t),
+		StoragePath: t.TempDir(),
+	})
+	require.NoError(t, err)
+
+	var args Arguments
+	require.NoError(t, syntax.Unmarshal(fmt.Appendf(nil, `
+		url               = %q
+		long_poll_timeout = "1m"
+	`, url), &args))
+	// Poll frequencies lower than the minimum keep tests fast.
+	args.PollFrequency = pollFrequency
+	require.NoError(t, svc.Update(args))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		require.NoError(t, svc.Run(ctx, fakeHost{}))
+	}()
+	t.Cleanup(func() { cancel(); wg.Wait() })
+	return svc
+}
+
+func TestLongPoll(t *testing.T) {
+	cfg1 := `loki.process "a" { forward_to = [] }`
+	cfg2 := `loki.process "b" { forward_to = [] }`
+
+	ts, url := newTestServer(t, true, cfg1)
+	svc := runLongPollService(t, url, time.Hour)
+
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, getHash([]byte(cfg1)), svc.cm.getLastLoadedCfgHash())
+		assert.Positive(c, ts.held.Load())
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// The new configuration is pushed right away, even though the poll
+	// frequency is an hour.
+	ts.setContent(cfg2)
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, getHash([]byte(cfg2)), svc.cm.getLastLoadedCfgHash())
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// The collector reconnects after the server recovers from an outage.
+	ts.setFailing(true)
+	ts.setContent(cfg1)
+	require.Eventually(t, func() bool {
+		return svc.cm.getRemoteConfigStatus().Status == collectorv1.RemoteConfigStatuses_RemoteConfigStatuses_FAILED
+	}, 5*time.Second, 10*time.Millisecond)
+	ts.setFailing(false)
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, getHash([]byte(cfg1)), svc.cm.getLastLoadedCfgHash())
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestLongPollFallbackToPolling(t *testing.T) {
+	cfg1 := `loki.process "a" { forward_to = [] }`
+	cfg2 := `loki.process "b" { forward_to = [] }`
+
+	// The server doesn't support long polling, so the collector polls it.
+	ts, url := newTestServer(t, false, cfg1)
+	svc := runLongPollService(t, url, 200*time.Millisecond)
+
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, getHash([]byte(cfg1)), svc.cm.getLastLoadedCfgHash())
+	}, 5*time.Second, 10*time.Millisecond)
+
+	ts.setContent(cfg2)
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, getHash([]byte(cfg2)), svc.cm.getLastLoadedCfgHash())
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.Zero(t, ts.held.Load())
+	require.Greater(t, ts.requests.Load(), int32(2))
+}
+
+func TestLongPollHeaders(t *testing.T) {
+	h := http.Header{}
+	setLongPollHeaders(h, time.Minute, "abc")
+	require.Equal(t, "wait=60", h.Get("Prefer"))
+	require.Equal(t, `"abc"`, h.Get("If-None-Match"))
+
+	require.False(t, longPollApplied(http.Header{}))
+	require.True(t, longPollApplied(http.Header{"Preference-Applied": {"respond-async, wait=60"}}))
+	require.True(t, longPollApplied(http.Header{"Preference-Applied": {"WAIT=1"}}))
+	require.False(t, longPollApplied(http.Header{"Preference-Applied": {"return=minimal"}}))
+}
+
+func TestLongPollBackoff(t *testing.T) {
+	for failures, maxDelay := range map[int]time.Duration{1: time.Second, 3: 4 * time.Second, 30: time.Minute} {
+		d := longPollBackoff(failures, time.Minute)
+		require.GreaterOrEqual(t, d, maxDelay/2)
+		require.LessOrEqual(t, d, maxDelay)
+	}
+}
@@ -15,6 +15,8 @@ type metrics struct {
 	lastFetchSuccessTime   prometheus.Gauge
 	totalAttempts          prometheus.Counter
 	getConfigTime          prometheus.Histogram
+	signatureFailures      prometheus.Counter
+	rolloutSkipped         prometheus.Counter
 }
 
 func registerMetrics(reg prometheus.Registerer) *metrics {
@@ -73,6 +75,18 @@ func registerMetrics(reg prometheus.Registerer) *metrics {
 				Help: "Duration of remote configuration requests.",
 			},
 		),
+		signatureFailures: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: "remotecfg_signature_failures_total",
+				Help: "Remote configurations rejected because of a missing or invalid signature",
+			},
+		),
+		rolloutSkipped: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: "remotecfg_rollout_skipped_total",
+				Help: "Remote configurations skipped because the collector is not part of their rollout",
+			},
+		),
 	}
 
 	// Register metrics safely - ignore AlreadyRegisteredError
@@ -83,6 +97,8 @@ func registerMetrics(reg prometheus.Registerer) *metrics {
 	safeRegister(reg, m.totalAttempts)
 	safeRegister(reg, m.lastFetchSuccessTime)
 	safeRegister(reg, m.getConfigTime)
+	safeRegister(reg, m.signatureFailures)
+	safeRegister(reg, m.rolloutSkipped)
 
 	return m
 }
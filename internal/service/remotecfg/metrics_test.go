@@ -28,6 +28,8 @@ func TestRegisterMetrics(t *testing.T) {
 	assert.NotNil(t, m.lastFetchSuccessTime)
 	assert.NotNil(t, m.totalAttempts)
 	assert.NotNil(t, m.getConfigTime)
+	assert.NotNil(t, m.signatureFailures)
+	assert.NotNil(t, m.rolloutSkipped)
 }
 
 func TestMetricsRegistration(t *testing.T) {
@@ -53,6 +55,8 @@ func TestMetricsRegistration(t *testing.T) {
 		"remotecfg_load_attempts_total",
 		"remotecfg_last_load_success_timestamp_seconds",
 		"remotecfg_request_duration_seconds",
+		"remotecfg_signature_failures_total",
+		"remotecfg_rollout_skipped_total",
 	}
 
 	// Check that all expected metrics are registered
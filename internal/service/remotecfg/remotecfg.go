@@ -2,13 +2,16 @@ package remotecfg
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"maps"
+	"net/url"
 	"os"
 	"path/filepath"
 	"reflect"
 	"runtime"
+	"strconv"
 	"sync"
 	"time"
 
@@ -43,6 +46,11 @@ type Service struct {
 
 	// runCtx is the context from Run method, used for service lifecycle operations
 	runCtx context.Context
+
+	// longPollWake wakes up the long polling loop when the arguments change,
+	// and cancelLongPoll cancels its in-flight request.
+	longPollWake   chan struct{}
+	cancelLongPoll context.CancelFunc
 }
 
 // ServiceName defines the name used for the remotecfg service.
@@ -71,10 +79,11 @@ func New(opts Options) (*Service, error) {
 	metrics := registerMetrics(opts.Metrics)
 
 	svc := &Service{
-		opts:        opts,
-		systemAttrs: getSystemAttributes(),
-		metrics:     metrics,
-		cm:          newConfigManager(metrics, opts.Logger, remotecfgPath, opts.ConfigPath),
+		opts:         opts,
+		systemAttrs:  getSystemAttributes(),
+		metrics:      metrics,
+		cm:           newConfigManager(metrics, opts.Logger, remotecfgPath, opts.ConfigPath),
+		longPollWake: make(chan struct{}, 1),
 	}
 
 	return svc, nil
@@ -155,9 +164,15 @@ func (s *Service) Run(ctx context.Context, host service.Host) error {
 		s.cm.getController().Run(ctx)
 	}()
 
+	// Long polling replaces the ticker when it's enabled.
+	go s.runLongPoll(ctx)
+
 	for {
 		select {
 		case <-s.cm.getTickerC():
+			if s.getLongPollTimeout() > 0 {
+				continue
+			}
 			s.fetchLoadConfig(false) // Don't reload cache during periodic polling
 		case <-s.cm.getUpdateTickerChan():
 			s.cm.getTicker().Reset(s.cm.getPollFrequency())
@@ -195,6 +210,9 @@ func (s *Service) Update(newConfig any) error {
 		return err
 	}
 
+	// Restart long polling with the updated Arguments.
+	s.wakeLongPoll()
+
 	// If we've already called Run, then immediately trigger an API call with
 	// the updated Arguments, and/or fall back to the updated cache location.
 	if s.cm.getController() != nil && s.cm.getController().Ready() {
@@ -206,6 +224,8 @@ func (s *Service) Update(newConfig any) error {
 
 // updateHandleEmptyUrl handles impacts of changes to the arguments when the URL is empty.
 func (s *Service) updateHandleEmptyUrl(args Arguments) {
+	defer s.wakeLongPoll()
+
 	s.mut.Lock()
 	defer s.mut.Unlock()
 
@@ -262,6 +282,11 @@ func (s *Service) updateHandleArgs(newArgs Arguments) error {
 	// Update the poll frequency
 	s.cm.setPollFrequency(newArgs.PollFrequency)
 
+	// Update the rollout, signature and cache settings
+	if err := s.cm.setArguments(newArgs); err != nil {
+		return err
+	}
+
 	// Combine the new attributes on top of the system attributes
 	s.attrs = maps.Clone(s.systemAttrs)
 	maps.Copy(s.attrs, newArgs.Attributes)
@@ -280,35 +305,62 @@ func (s *Service) fetchLoadConfig(allowCacheFallback bool) {
 		return
 	}
 
-	s.cm.fetchLoadConfig(s.getConfig, allowCacheFallback)
+	s.cm.fetchLoadConfig(s.getConfig, s.getConfig, allowCacheFallback)
 }
 
-func (s *Service) getConfig() (*collectorv1.GetConfigResponse, error) {
-	s.mut.RLock()
-	defer s.mut.RUnlock()
+func (s *Service) getConfig() (*getConfigResponse, error) {
+	return s.requestConfig(s.getContext(), 0)
+}
 
-	response, err := s.apiClient.GetConfig(s.getContext(), &connect.Request[collectorv1.GetConfigRequest]{
-		Msg: &collectorv1.GetConfigRequest{
-			Id:                 s.args.ID,
-			LocalAttributes:    s.attrs,
-			Hash:               s.cm.getRemoteHash(),
-			RemoteConfigStatus: s.cm.getRemoteConfigStatusForRequest(),
-			EffectiveConfig:    s.cm.getEffectiveConfigForRequest(),
-		},
+// requestConfig requests the configuration from the API. When wait is
+// positive, the API is asked to hold the request until the configuration
+// changes, for at most wait.
+func (s *Service) requestConfig(ctx context.Context, wait time.Duration) (*getConfigResponse, error) {
+	// The lock isn't held during the request, which may be a long poll.
+	s.mut.RLock()
+	apiClient := s.apiClient
+	cm := s.cm
+	id := s.args.ID
+	req := connect.NewRequest(&collectorv1.GetConfigRequest{
+		Id:                 id,
+		LocalAttributes:    s.attrs,
+		Hash:               cm.getRemoteHash(),
+		RemoteConfigStatus: cm.getRemoteConfigStatusForRequest(),
+		EffectiveConfig:    cm.getEffectiveConfigForRequest(),
 	})
+	s.mut.RUnlock()
+
+	if h := cm.getLastLoadedCfgHash(); h != "" {
+		req.Header().Set(appliedConfigHashHeader, h)
+	}
+	if ctrl, ok := cm.getController().(hostController); ok {
+		health, omitted := getComponentHealth(ctrl.GetHost())
+		req.Header().Set(componentHealthHeader, health)
+		if omitted > 0 {
+			req.Header().Set(componentHealthTruncatedHeader, strconv.Itoa(omitted))
+		}
+	}
+	if wait > 0 {
+		setLongPollHeaders(req.Header(), wait, req.Msg.Hash)
+	}
+
+	response, err := apiClient.GetConfig(ctx, req)
 
 	if err != nil {
 		// Don't log error or reset status for "not modified" responses
 		if !errors.Is(err, errNotModified) {
 			// Reset lastSentConfigStatus and lastSentEffectiveConfig since the API request failed
 			// and they weren't actually sent
-			s.cm.resetLastSentConfigStatus()
-			s.cm.resetLastSentEffectiveConfig()
-			s.opts.Logger.Log("level", "error", "msg", "failed to get configuration from remote server", "id", s.args.ID, "err", err)
+			cm.resetLastSentConfigStatus()
+			cm.resetLastSentEffectiveConfig()
+			// Canceled long polls aren't failures.
+			if ctx.Err() == nil {
+				s.opts.Logger.Log("level", "error", "msg", "failed to get configuration from remote server", "id", id, "err", err)
+			}
 		}
 		return nil, err
 	}
-	return response.Msg, nil
+	return &getConfigResponse{GetConfigResponse: response.Msg, header: response.Header()}, nil
 }
 
 func (s *Service) registerCollector() error {
@@ -393,3 +445,51 @@ func (s *Service) GetCachedAstFile() *ast.File {
 	defer s.mut.RUnlock()
 	return s.cm.getAstFile()
 }
+
+// SupportBundle returns the state of the remote configuration, to add to
+// support bundles.
+func (s *Service) SupportBundle() (map[string][]byte, error) {
+	s.mut.RLock()
+	cm := s.cm
+	remoteURL := s.args.URL
+	s.mut.RUnlock()
+
+	state := struct {
+		Enabled                bool   `json:"enabled"`
+		URL                    string `json:"url,omitempty"`
+		PollFrequency          string `json:"poll_frequency,omitempty"`
+		LastLoadedConfigHash   string `json:"last_loaded_config_hash"`
+		LastReceivedConfigHash string `json:"last_received_config_hash"`
+		RemoteHash             string `json:"remote_hash"`
+		Status                 string `json:"status,omitempty"`
+		ErrorMessage           string `json:"error_message,omitempty"`
+	}{
+		Enabled: s.isEnabled(),
+		URL:     redactURL(remoteURL),
+	}
+	if cm != nil {
+		state.PollFrequency = cm.getPollFrequency().String()
+		state.LastLoadedConfigHash = cm.getLastLoadedCfgHash()
+		state.LastReceivedConfigHash = cm.getLastReceivedCfgHash()
+		state.RemoteHash = cm.getRemoteHash()
+		if status := cm.getRemoteConfigStatus(); status != nil {
+			state.Status = status.Status.String()
+			state.ErrorMessage = status.ErrorMessage
+		}
+	}
+
+	bb, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]byte{"remotecfg.json": bb}, nil
+}
+
+// redactURL hides the password of rawURL, if it has one.
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Redacted()
+}
@@ -2,8 +2,13 @@ package remotecfg
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"sync"
 	"testing"
@@ -434,6 +439,8 @@ type GetConfigHandlerOptions struct {
 	Content     string
 	Hash        string
 	NotModified bool
+	// Optional response headers
+	Header http.Header
 	// Optional status capture functionality
 	StatusHistory  *[]collectorv1.RemoteConfigStatuses
 	StatusMessages *[]string
@@ -467,6 +474,9 @@ func buildGetConfigHandlerWithOptions(opts GetConfigHandlerOptions) func(context
 				Hash:        opts.Hash,
 			},
 		}
+		for k, v := range opts.Header {
+			rsp.Header()[k] = v
+		}
 		return rsp, nil
 	}
 }
@@ -580,7 +590,7 @@ func (f fakeHost) NewController(id string) (service.Controller, error) {
 		MinStability:    featuregate.StabilityGenerallyAvailable,
 		Reg:             prometheus.NewRegistry(),
 		OnExportsChange: func(map[string]any) {},
-		Services:        []service.Service{livedebugging.New()},
+		Services:        []service.Service{livedebugging.New(livedebugging.Options{})},
 	})
 	if err != nil {
 		return nil, err
@@ -601,7 +611,8 @@ func (sc serviceController) LoadSource(b []byte, args map[string]any, configPath
 	}
 	return source.SourceFiles()[""], sc.f.LoadSource(source, args, configPath)
 }
-func (sc serviceController) Ready() bool { return sc.f.Ready() }
+func (sc serviceController) Ready() bool           { return sc.f.Ready() }
+func (sc serviceController) GetHost() service.Host { return sc.f }
 
 func TestRemoteConfigStatus_InitialState(t *testing.T) {
 	env := newTestEnvironment(t, &mockCollectorClient{})
@@ -920,3 +931,277 @@ func buildUnregisterCollectorFunc(called *atomic.Bool) func(ctx context.Context,
 		}, nil
 	}
 }
+
+// runTestEnvironment applies config to a new service using client and runs
+// it until the test completes.
+func runTestEnvironment(t *testing.T, client *mockCollectorClient, config string) *testEnvironment {
+	ctx, cancel := context.WithCancel(t.Context())
+
+	var registerCalled atomic.Bool
+	client.mut.Lock()
+	client.registerCollectorFunc = buildRegisterCollectorFunc(&registerCalled)
+	client.mut.Unlock()
+
+	env := newTestEnvironment(t, client)
+	require.NoError(t, env.ApplyConfig(config))
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		require.NoError(t, env.Run(ctx))
+	}()
+	t.Cleanup(func() {
+		cancel()
+		wg.Wait()
+	})
+
+	require.Eventually(t, func() bool { return registerCalled.Load() }, 1*time.Second, 10*time.Millisecond)
+	return env
+}
+
+func TestSignedConfig(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	cfg1 := `loki.process "signed" { forward_to = [] }`
+	cfg2 := `loki.process "unsigned" { forward_to = [] }`
+	sign := func(cfg string) http.Header {
+		h := http.Header{}
+		h.Set(signatureHeader, base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte(cfg))))
+		return h
+	}
+
+	client := &mockCollectorClient{}
+	client.getConfigFunc = buildGetConfigHandlerWithOptions(GetConfigHandlerOptions{Content: cfg1, Header: sign(cfg1)})
+
+	env := runTestEnvironment(t, client, fmt.Sprintf(`
+		url            = "https://example.com/"
+		poll_frequency = "10s"
+		signature {
+			public_keys = [%q]
+		}
+	`, base64.StdEncoding.EncodeToString(pub)))
+
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, getHash([]byte(cfg1)), env.svc.cm.getLastLoadedCfgHash())
+	}, time.Second, 10*time.Millisecond)
+
+	// Unsigned configurations and configurations signed for other contents
+	// are rejected.
+	for _, h := range []http.Header{nil, sign(cfg1)} {
+		client.mut.Lock()
+		client.getConfigFunc = buildGetConfigHandlerWithOptions(GetConfigHandlerOptions{Content: cfg2, Header: h})
+		client.mut.Unlock()
+
+		assertRemoteConfigStatus(t, env, collectorv1.RemoteConfigStatuses_RemoteConfigStatuses_FAILED, true)
+		require.Equal(t, getHash([]byte(cfg1)), env.svc.cm.getLastLoadedCfgHash())
+		require.Equal(t, getHash([]byte(cfg1)), env.svc.cm.getLastReceivedCfgHash())
+		env.svc.cm.setRemoteConfigStatus(collectorv1.RemoteConfigStatuses_RemoteConfigStatuses_APPLIED, "")
+	}
+	require.Positive(t, testutil.ToFloat64(env.svc.metrics.signatureFailures))
+
+	// Once signed, the configuration is applied.
+	client.mut.Lock()
+	client.getConfigFunc = buildGetConfigHandlerWithOptions(GetConfigHandlerOptions{Content: cfg2, Header: sign(cfg2)})
+	client.mut.Unlock()
+
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, getHash([]byte(cfg2)), env.svc.cm.getLastLoadedCfgHash())
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestRolloutCohort(t *testing.T) {
+	cfg1 := `loki.process "stable" { forward_to = [] }`
+	cfg2 := `loki.process "canary" { forward_to = [] }`
+	rolloutHeader := func(cohorts, percentage string) http.Header {
+		h := http.Header{}
+		h.Set(rolloutCohortsHeader, cohorts)
+		h.Set(rolloutPercentageHeader, percentage)
+		return h
+	}
+
+	client := &mockCollectorClient{}
+	client.getConfigFunc = buildGetConfigHandler(cfg1, "", false)
+
+	env := runTestEnvironment(t, client, `
+		url            = "https://example.com/"
+		poll_frequency = "10s"
+		rollout {
+			cohort = "stable"
+		}
+	`)
+
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, getHash([]byte(cfg1)), env.svc.cm.getLastLoadedCfgHash())
+	}, time.Second, 10*time.Millisecond)
+
+	// The configuration is only rolled out to canaries.
+	client.mut.Lock()
+	client.getConfigFunc = buildGetConfigHandlerWithOptions(GetConfigHandlerOptions{Content: cfg2, Hash: "canary", Header: rolloutHeader("canary", "")})
+	client.mut.Unlock()
+
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Positive(c, testutil.ToFloat64(env.svc.metrics.rolloutSkipped))
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, getHash([]byte(cfg1)), env.svc.cm.getLastLoadedCfgHash())
+	require.NotEqual(t, "canary", env.svc.cm.getRemoteHash())
+
+	// The same configuration is then rolled out to everyone.
+	client.mut.Lock()
+	client.getConfigFunc = buildGetConfigHandlerWithOptions(GetConfigHandlerOptions{Content: cfg2, Hash: "canary", Header: rolloutHeader("canary,stable", "100")})
+	client.mut.Unlock()
+
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, getHash([]byte(cfg2)), env.svc.cm.getLastLoadedCfgHash())
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestStatusHeaders(t *testing.T) {
+	cfg := `loki.process "default" { forward_to = [] }`
+
+	var (
+		mut    sync.Mutex
+		header http.Header
+	)
+	client := &mockCollectorClient{}
+	client.getConfigFunc = func(ctx context.Context, req *connect.Request[collectorv1.GetConfigRequest]) (*connect.Response[collectorv1.GetConfigResponse], error) {
+		mut.Lock()
+		header = req.Header().Clone()
+		mut.Unlock()
+		return buildGetConfigHandler(cfg, "", false)(ctx, req)
+	}
+
+	runTestEnvironment(t, client, `
+		url            = "https://example.com/"
+		poll_frequency = "10s"
+	`)
+
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		mut.Lock()
+		defer mut.Unlock()
+
+		assert.Equal(c, getHash([]byte(cfg)), header.Get(appliedConfigHashHeader))
+
+		var health map[string]componentHealth
+		assert.NoError(c, json.Unmarshal([]byte(header.Get(componentHealthHeader)), &health))
+		assert.Equal(c, map[string]componentHealth{"loki.process.default": {State: "healthy"}}, health)
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestCacheHistory(t *testing.T) {
+	cfgs := []string{
+		`loki.process "a" { forward_to = [] }`,
+		`loki.process "b" { forward_to = [] }`,
+		`loki.process "c" { forward_to = [] }`,
+		`loki.process "d" { forward_to = [] }`,
+	}
+
+	env := newTestEnvironment(t, &mockCollectorClient{})
+	require.NoError(t, env.ApplyConfig(`
+		url            = "https://example.com/"
+		poll_frequency = "10s"
+		cache_size     = 3
+	`))
+
+	for _, cfg := range cfgs {
+		env.svc.cm.setCachedConfig([]byte(cfg), "")
+	}
+	// Writing the same configuration again doesn't rotate the cache.
+	env.svc.cm.setCachedConfig([]byte(cfgs[3]), "")
+
+	for i, cfg := range []string{cfgs[3], cfgs[2], cfgs[1]} {
+		b, err := os.ReadFile(env.svc.cm.getCachedConfigPathAt(i))
+		require.NoError(t, err)
+		require.Equal(t, cfg, string(b))
+	}
+	require.NoFileExists(t, env.svc.cm.getCachedConfigPathAt(3))
+
+	// Lowering the cache size drops the oldest configurations.
+	require.NoError(t, env.svc.cm.setArguments(Arguments{CacheSize: 2}))
+	env.svc.cm.setCachedConfig([]byte(cfgs[0]), "")
+	require.FileExists(t, env.svc.cm.getCachedConfigPathAt(1))
+	require.NoFileExists(t, env.svc.cm.getCachedConfigPathAt(2))
+}
+
+func TestCacheHistoryFallback(t *testing.T) {
+	cfgGood := `loki.process "good" { forward_to = [] }`
+	cfgBad := `unparseable bad config`
+
+	client := &mockCollectorClient{}
+	client.getConfigFunc = func(context.Context, *connect.Request[collectorv1.GetConfigRequest]) (*connect.Response[collectorv1.GetConfigResponse], error) {
+		return nil, fmt.Errorf("server is down")
+	}
+
+	env := newTestEnvironment(t, client)
+	require.NoError(t, env.ApplyConfig(`
+		url            = "https://example.com/"
+		poll_frequency = "10s"
+		cache_size     = 3
+	`))
+
+	// The most recent cached configuration is broken, so the previous one is
+	// loaded.
+	require.NoError(t, os.WriteFile(env.svc.cm.getCachedConfigPathAt(0), []byte(cfgBad), 0644))
+	require.NoError(t, os.WriteFile(env.svc.cm.getCachedConfigPathAt(1), []byte(cfgGood), 0644))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		require.NoError(t, env.Run(ctx))
+	}()
+	defer func() { cancel(); wg.Wait() }()
+
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, getHash([]byte(cfgGood)), env.svc.cm.getLastLoadedCfgHash())
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestSignedCacheFallback(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	cfgSigned := `loki.process "signed" { forward_to = [] }`
+	cfgUnsigned := `loki.process "unsigned" { forward_to = [] }`
+
+	client := &mockCollectorClient{}
+	client.getConfigFunc = func(context.Context, *connect.Request[collectorv1.GetConfigRequest]) (*connect.Response[collectorv1.GetConfigResponse], error) {
+		return nil, fmt.Errorf("server is down")
+	}
+
+	env := newTestEnvironment(t, client)
+	require.NoError(t, env.ApplyConfig(fmt.Sprintf(`
+		url            = "https://example.com/"
+		poll_frequency = "10s"
+		cache_size     = 3
+		signature {
+			public_keys = [%q]
+		}
+	`, base64.StdEncoding.EncodeToString(pub))))
+
+	// The signature is cached along with the configuration.
+	env.svc.cm.setCachedConfig([]byte(cfgSigned), base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte(cfgSigned))))
+	require.FileExists(t, env.svc.cm.getCachedSignaturePathAt(0))
+
+	// A configuration written to the cache without a signature isn't loaded,
+	// so the previous one is.
+	env.svc.cm.setCachedConfig([]byte(cfgUnsigned), "")
+	require.NoFileExists(t, env.svc.cm.getCachedSignaturePathAt(0))
+	require.FileExists(t, env.svc.cm.getCachedSignaturePathAt(1))
+
+	ctx, cancel := context.WithCancel(t.Context())
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		require.NoError(t, env.Run(ctx))
+	}()
+	defer func() { cancel(); wg.Wait() }()
+
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.Equal(c, getHash([]byte(cfgSigned)), env.svc.cm.getLastLoadedCfgHash())
+	}, time.Second, 10*time.Millisecond)
+	require.Positive(t, testutil.ToFloat64(env.svc.metrics.signatureFailures))
+}
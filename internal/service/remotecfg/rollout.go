@@ -0,0 +1,70 @@
+package remotecfg
+
+import (
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// rolloutCohortsHeader is the response header holding the comma-separated
+	// list of cohorts a configuration is rolled out to.
+	rolloutCohortsHeader = "X-Alloy-Rollout-Cohorts"
+
+	// rolloutPercentageHeader is the response header holding the percentage,
+	// from 0 to 100, of collectors a configuration is rolled out to.
+	rolloutPercentageHeader = "X-Alloy-Rollout-Percentage"
+)
+
+// rollout describes which collectors a configuration is rolled out to.
+type rollout struct {
+	cohorts    []string // Empty for all cohorts.
+	percentage int      // -1 for all collectors.
+}
+
+// parseRollout returns the rollout described by the headers of a response.
+func parseRollout(h http.Header) (rollout, error) {
+	r := rollout{percentage: -1}
+
+	for _, c := range strings.Split(h.Get(rolloutCohortsHeader), ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			r.cohorts = append(r.cohorts, c)
+		}
+	}
+
+	if v := h.Get(rolloutPercentageHeader); v != "" {
+		p, err := strconv.Atoi(strings.TrimSpace(v))
+		if err != nil || p < 0 || p > 100 {
+			return r, fmt.Errorf("invalid %s header %q: must be an integer between 0 and 100", rolloutPercentageHeader, v)
+		}
+		r.percentage = p
+	}
+	return r, nil
+}
+
+// includes returns whether the collector with the given ID and cohort is part
+// of the rollout of the configuration with the given hash.
+//
+// Collectors are assigned to a bucket from 0 to 99 for each configuration, so
+// that increasing the percentage of a rollout keeps the collectors which
+// already applied the configuration.
+func (r rollout) includes(id, cohort, configHash string) bool {
+	if len(r.cohorts) > 0 && !slices.Contains(r.cohorts, cohort) {
+		return false
+	}
+	if r.percentage < 0 {
+		return true
+	}
+	return rolloutBucket(id, configHash) < r.percentage
+}
+
+func rolloutBucket(id, configHash string) int {
+	h := fnv.New32a()
+	h.Write([]byte(id))
+	h.Write([]byte{0})
+	h.Write([]byte(configHash))
+	return int(h.Sum32() % 100)
+}
@@ -0,0 +1,60 @@
+package remotecfg
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRollout(t *testing.T) {
+	r, err := parseRollout(http.Header{})
+	require.NoError(t, err)
+	require.Equal(t, rollout{percentage: -1}, r)
+
+	h := http.Header{}
+	h.Set(rolloutCohortsHeader, "canary, early ,")
+	h.Set(rolloutPercentageHeader, "25")
+	r, err = parseRollout(h)
+	require.NoError(t, err)
+	require.Equal(t, rollout{cohorts: []string{"canary", "early"}, percentage: 25}, r)
+
+	for _, v := range []string{"-1", "101", "half"} {
+		h.Set(rolloutPercentageHeader, v)
+		_, err = parseRollout(h)
+		require.Error(t, err, v)
+	}
+}
+
+func TestRolloutIncludes(t *testing.T) {
+	all := rollout{percentage: -1}
+	require.True(t, all.includes("id", "", "hash"))
+	require.True(t, all.includes("id", "canary", "hash"))
+
+	canary := rollout{cohorts: []string{"canary"}, percentage: -1}
+	require.True(t, canary.includes("id", "canary", "hash"))
+	require.False(t, canary.includes("id", "", "hash"))
+	require.False(t, canary.includes("id", "stable", "hash"))
+
+	require.False(t, rollout{percentage: 0}.includes("id", "", "hash"))
+	require.True(t, rollout{percentage: 100}.includes("id", "", "hash"))
+
+	// Widening a rollout keeps the collectors which were already part of it,
+	// and a percentage roughly selects that share of collectors.
+	var included10, included50 int
+	for i := range 1000 {
+		id := fmt.Sprintf("collector-%d", i)
+		in10 := rollout{percentage: 10}.includes(id, "", "hash")
+		in50 := rollout{percentage: 50}.includes(id, "", "hash")
+		if in10 {
+			require.True(t, in50, id)
+			included10++
+		}
+		if in50 {
+			included50++
+		}
+	}
+	require.InDelta(t, 100, included10, 40)
+	require.InDelta(t, 500, included50, 80)
+}
@@ -0,0 +1,75 @@
+package remotecfg
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// signatureHeader is the response header holding the base64-encoded ed25519
+// signature of the configuration content.
+const signatureHeader = "X-Alloy-Config-Signature"
+
+var (
+	errMissingSignature = errors.New("remote configuration is not signed")
+	errInvalidSignature = errors.New("remote configuration signature does not match any public key")
+)
+
+// parsePublicKeys parses ed25519 public keys, either PEM-encoded in PKIX form
+// or as the base64 encoding of the raw key.
+func parsePublicKeys(keys []string) ([]ed25519.PublicKey, error) {
+	res := make([]ed25519.PublicKey, 0, len(keys))
+	for i, key := range keys {
+		pk, err := parsePublicKey(strings.TrimSpace(key))
+		if err != nil {
+			return nil, fmt.Errorf("invalid public key at index %d: %w", i, err)
+		}
+		res = append(res, pk)
+	}
+	return res, nil
+}
+
+func parsePublicKey(key string) (ed25519.PublicKey, error) {
+	if block, _ := pem.Decode([]byte(key)); block != nil {
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		pk, ok := pub.(ed25519.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("expected an ed25519 public key, got %T", pub)
+		}
+		return pk, nil
+	}
+
+	b, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("expected %d bytes, got %d", ed25519.PublicKeySize, len(b))
+	}
+	return ed25519.PublicKey(b), nil
+}
+
+// verifySignature checks that the base64-encoded signature of content was
+// made with the private key of one of keys.
+func verifySignature(keys []ed25519.PublicKey, content []byte, signature string) error {
+	if signature == "" {
+		return errMissingSignature
+	}
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("invalid remote configuration signature: %w", err)
+	}
+	for _, pk := range keys {
+		if ed25519.Verify(pk, content, sig) {
+			return nil
+		}
+	}
+	return errInvalidSignature
+}
@@ -0,0 +1,47 @@
+package remotecfg
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePublicKeys(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	require.NoError(t, err)
+	pemKey := string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+
+	keys, err := parsePublicKeys([]string{base64.StdEncoding.EncodeToString(pub), pemKey})
+	require.NoError(t, err)
+	require.Equal(t, []ed25519.PublicKey{pub, pub}, keys)
+
+	_, err = parsePublicKeys([]string{"not base64"})
+	require.ErrorContains(t, err, "invalid public key at index 0")
+
+	_, err = parsePublicKeys([]string{base64.StdEncoding.EncodeToString([]byte("short"))})
+	require.ErrorContains(t, err, "expected 32 bytes, got 5")
+}
+
+func TestVerifySignature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	otherPub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	content := []byte(`loki.process "default" { forward_to = [] }`)
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, content))
+
+	require.NoError(t, verifySignature([]ed25519.PublicKey{otherPub, pub}, content, sig))
+	require.ErrorIs(t, verifySignature([]ed25519.PublicKey{otherPub}, content, sig), errInvalidSignature)
+	require.ErrorIs(t, verifySignature([]ed25519.PublicKey{pub}, []byte("tampered"), sig), errInvalidSignature)
+	require.ErrorIs(t, verifySignature([]ed25519.PublicKey{pub}, content, ""), errMissingSignature)
+	require.Error(t, verifySignature([]ed25519.PublicKey{pub}, content, "not base64"))
+}
@@ -0,0 +1,123 @@
+package remotecfg
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/grafana/alloy/internal/component"
+	"github.com/grafana/alloy/internal/service"
+)
+
+const (
+	// appliedConfigHashHeader is the request header holding the hash of the
+	// configuration which is currently applied.
+	appliedConfigHashHeader = "X-Alloy-Applied-Config-Hash"
+
+	// componentHealthHeader is the request header holding the health of the
+	// components of the applied configuration, as a JSON object keyed by
+	// component ID.
+	componentHealthHeader = "X-Alloy-Component-Health"
+
+	// componentHealthTruncatedHeader is the request header holding the number
+	// of components left out of the componentHealthHeader header to keep it
+	// under maxComponentHealthSize.
+	componentHealthTruncatedHeader = "X-Alloy-Component-Health-Truncated"
+
+	// maxComponentHealthSize is the maximum size of the componentHealthHeader
+	// header, well below the header size limits of common proxies and servers.
+	maxComponentHealthSize = 4096
+
+	// maxComponentHealthMessageLength is the maximum length of the health
+	// message reported for a component.
+	maxComponentHealthMessageLength = 256
+)
+
+// hostController is a service.Controller which exposes its host.
+type hostController interface {
+	service.Controller
+	GetHost() service.Host
+}
+
+type componentHealth struct {
+	State   string `json:"state"`
+	Message string `json:"message,omitempty"`
+}
+
+// getComponentHealth returns the health of the components of host, including
+// the ones of its modules, encoded for the componentHealthHeader header, and
+// the number of components left out of it. Components are keyed by their ID
+// relative to the remote configuration.
+func getComponentHealth(host service.Host) (string, int) {
+	health := make(map[string]componentHealth)
+	for _, c := range component.GetAllComponents(host, component.InfoOptions{GetHealth: true}) {
+		h := componentHealth{State: c.Health.Health.String()}
+		if c.Health.Health != component.HealthTypeHealthy {
+			h.Message = truncateHealthMessage(c.Health.Message)
+		}
+		health[strings.TrimPrefix(c.ID.String(), ServiceName+"/")] = h
+	}
+	return encodeComponentHealth(health, maxComponentHealthSize)
+}
+
+// encodeComponentHealth encodes health as a JSON object of at most limit
+// bytes, and returns the number of components which didn't fit. Unhealthy
+// components come first so that they're the last ones to be left out.
+func encodeComponentHealth(health map[string]componentHealth, limit int) (string, int) {
+	healthy := component.HealthTypeHealthy.String()
+	ids := slices.SortedFunc(maps.Keys(health), func(a, b string) int {
+		aHealthy, bHealthy := health[a].State == healthy, health[b].State == healthy
+		switch {
+		case aHealthy && !bHealthy:
+			return 1
+		case !aHealthy && bHealthy:
+			return -1
+		}
+		return strings.Compare(a, b)
+	})
+
+	// JSON encoding never emits newlines, so the result is a valid header
+	// value.
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, id := range ids {
+		k, err := json.Marshal(id)
+		if err != nil {
+			return "", len(ids)
+		}
+		v, err := json.Marshal(health[id])
+		if err != nil {
+			return "", len(ids)
+		}
+
+		size := len(k) + 1 + len(v)
+		if i > 0 {
+			size++
+		}
+		// Keep room for the closing brace.
+		if buf.Len()+size+1 > limit {
+			buf.WriteByte('}')
+			return buf.String(), len(ids) - i
+		}
+
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(v)
+	}
+	buf.WriteByte('}')
+	return buf.String(), 0
+}
+
+// truncateHealthMessage shortens msg to at most
+// maxComponentHealthMessageLength bytes.
+func truncateHealthMessage(msg string) string {
+	if len(msg) <= maxComponentHealthMessageLength {
+		return msg
+	}
+	return strings.ToValidUTF8(msg[:maxComponentHealthMessageLength-3], "") + "..."
+}
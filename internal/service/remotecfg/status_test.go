@@ -0,0 +1,59 @@
+package remotecfg
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeComponentHealth(t *testing.T) {
+	health := map[string]componentHealth{
+		"loki.process.b": {State: "healthy"},
+		"loki.process.a": {State: "healthy"},
+		"loki.process.c": {State: "unhealthy", Message: "failed"},
+	}
+
+	s, omitted := encodeComponentHealth(health, maxComponentHealthSize)
+	require.Zero(t, omitted)
+	require.Equal(t, `{"loki.process.c":{"state":"unhealthy","message":"failed"},"loki.process.a":{"state":"healthy"},"loki.process.b":{"state":"healthy"}}`, s)
+
+	// Unhealthy components are kept when others don't fit.
+	s, omitted = encodeComponentHealth(health, 80)
+	require.Equal(t, 2, omitted)
+	require.Equal(t, `{"loki.process.c":{"state":"unhealthy","message":"failed"}}`, s)
+
+	s, omitted = encodeComponentHealth(health, 10)
+	require.Equal(t, 3, omitted)
+	require.Equal(t, `{}`, s)
+}
+
+func TestEncodeComponentHealth_Limit(t *testing.T) {
+	health := make(map[string]componentHealth)
+	for i := range 1000 {
+		health[fmt.Sprintf("prometheus.scrape.target_%d", i)] = componentHealth{
+			State:   "unhealthy",
+			Message: truncateHealthMessage(strings.Repeat("error ", 100)),
+		}
+	}
+
+	s, omitted := encodeComponentHealth(health, maxComponentHealthSize)
+	require.LessOrEqual(t, len(s), maxComponentHealthSize)
+	require.Positive(t, omitted)
+
+	var decoded map[string]componentHealth
+	require.NoError(t, json.Unmarshal([]byte(s), &decoded))
+	require.Len(t, decoded, len(health)-omitted)
+}
+
+func TestTruncateHealthMessage(t *testing.T) {
+	require.Equal(t, "short", truncateHealthMessage("short"))
+
+	msg := truncateHealthMessage(strings.Repeat("é", maxComponentHealthMessageLength))
+	require.LessOrEqual(t, len(msg), maxComponentHealthMessageLength)
+	require.True(t, strings.HasSuffix(msg, "..."))
+	require.True(t, utf8.ValidString(msg))
+}
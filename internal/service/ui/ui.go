@@ -27,6 +27,7 @@ const ServiceName = "ui"
 type Options struct {
 	UIPrefix        string                        // Path prefix to host the UI at.
 	CallbackManager livedebugging.CallbackManager // CallbackManager is used for live debugging in the UI.
+	CaptureReader   livedebugging.CaptureReader   // CaptureReader is used to download captured live debugging data.
 	Logger          log.Logger
 }
 
@@ -81,7 +82,7 @@ func (s *Service) Data() any {
 func (s *Service) ServiceHandler(host service.Host) (base string, handler http.Handler) {
 	r := mux.NewRouter()
 
-	fa := api.NewAlloyAPI(host, s.opts.CallbackManager, s.opts.Logger)
+	fa := api.NewAlloyAPI(host, s.opts.CallbackManager, s.opts.CaptureReader, s.opts.Logger)
 	fa.RegisterRoutes(path.Join(s.opts.UIPrefix, "/api/v0/web"), r)
 	ui.RegisterRoutes(s.opts.UIPrefix, r)
 
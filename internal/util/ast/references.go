@@ -2,6 +2,7 @@ package ast
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/grafana/alloy/internal/dag"
@@ -120,6 +121,22 @@ func (tw *traversalWalker) Visit(node ast.Node) ast.Visitor {
 			ast.Walk(tw, arg)
 		}
 		return nil
+
+	case *ast.LambdaExpr:
+		// Lambda parameters shadow any other identifier with the same name, so
+		// traversals of the body starting with a parameter aren't references.
+		tw.flush()
+
+		var bw traversalWalker
+		ast.Walk(&bw, n.Body)
+		bw.flush()
+
+		for _, t := range bw.traversals {
+			if !slices.ContainsFunc(n.Params, func(p *ast.Ident) bool { return p.Name == t[0].Name }) {
+				tw.traversals = append(tw.traversals, t)
+			}
+		}
+		return nil
 	}
 
 	return tw
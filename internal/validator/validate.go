@@ -2,6 +2,7 @@ package validator
 
 import (
 	"fmt"
+	"maps"
 	"reflect"
 	"slices"
 	"strconv"
@@ -20,6 +21,7 @@ import (
 	"github.com/grafana/alloy/internal/nodeconf/argument"
 	"github.com/grafana/alloy/internal/nodeconf/export"
 	"github.com/grafana/alloy/internal/nodeconf/foreach"
+	"github.com/grafana/alloy/internal/nodeconf/function"
 	"github.com/grafana/alloy/internal/nodeconf/importsource"
 	alloy_runtime "github.com/grafana/alloy/internal/runtime"
 	"github.com/grafana/alloy/internal/runtime/logging"
@@ -30,6 +32,9 @@ import (
 type Options struct {
 	// Sources are all source files to validate.
 	Sources map[string][]byte
+	// Format is the format of Sources. Sources are in the Alloy syntax if
+	// Format is empty.
+	Format alloy_runtime.SourceFormat
 	// ServiceDefinitions is used to validate service config.
 	ServiceDefinitions []service.Definition
 	// ComponentRegistry is used to validate component config.
@@ -47,6 +52,7 @@ func Validate(opts Options) error {
 type validator struct {
 	minStability featuregate.Stability
 	sources      map[string][]byte
+	format       alloy_runtime.SourceFormat
 	sm           map[string]service.Definition
 }
 
@@ -56,15 +62,21 @@ func newValidator(opts Options) *validator {
 		sm[def.Name] = def
 	}
 
+	format := opts.Format
+	if format == "" {
+		format = alloy_runtime.SourceFormatAlloy
+	}
+
 	return &validator{
 		minStability: opts.MinStability,
 		sources:      opts.Sources,
+		format:       format,
 		sm:           sm,
 	}
 }
 
 func (v *validator) run(cr *componentRegistry) error {
-	s, err := alloy_runtime.ParseSources(v.sources)
+	s, err := alloy_runtime.ParseSourcesFormat(v.sources, v.format)
 	if err != nil {
 		return err
 	}
@@ -104,9 +116,13 @@ type state struct {
 	cr         *componentRegistry
 	// arguments registered by module
 	arguments []*ast.BlockStmt
+	// functions available to function blocks, including the ones of parent modules
+	functions map[string]any
 }
 
 func (v *validator) validate(s *state) *state {
+	// Functions can be used anywhere in the module, including in declare blocks.
+	v.registerFunctions(s)
 	// Need to validate declares first because we will register "custom" components.
 	v.validateDeclares(s)
 	v.validateConfigs(s)
@@ -154,6 +170,7 @@ func (v *validator) validateDeclares(s *state) {
 			components: components,
 			cr:         newComponentRegistry(s.cr),
 			scope:      vm.NewScope(s.scope.Variables),
+			functions:  s.functions,
 		}
 
 		// Add module state as node to graph
@@ -211,6 +228,8 @@ func (v *validator) validateConfigs(s *state) {
 			s.graph.Add(node)
 		case foreach.BlockName:
 			v.validateForeach(node, s)
+		case function.BlockName:
+			v.validateFunction(node, s)
 		case argument.BlockName:
 			node.args = &argument.Arguments{}
 			if s.root {
@@ -271,6 +290,17 @@ func (v *validator) validateImport(node *node, register bool, s *state) {
 	case importsource.BlockNameGit:
 		node.args = &importsource.GitArguments{}
 		s.graph.Add(node)
+	case importsource.BlockNameOCI:
+		if err := featuregate.CheckAllowed(importsource.OCIStabilityLevel, v.minStability, fmt.Sprintf("config block %q", importsource.BlockNameOCI)); err != nil {
+			node.diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				StartPos: ast.StartPos(node.block).Position(),
+				EndPos:   ast.EndPos(node.block).Position(),
+				Message:  err.Error(),
+			})
+		}
+		node.args = &importsource.OCIArguments{}
+		s.graph.Add(node)
 	}
 
 	if register {
@@ -338,6 +368,7 @@ func (v *validator) validateForeach(node *node, s *state) {
 		components: components,
 		cr:         newComponentRegistry(s.cr),
 		scope:      vm.NewScope(s.scope.Variables),
+		functions:  s.functions,
 	}
 
 	value, ok := typecheck.TryUnwrapBlockAttr(node.block, "var", reflect.String)
@@ -350,6 +381,57 @@ func (v *validator) validateForeach(node *node, s *state) {
 	s.graph.Add(newForeachNode(node, v.validate(foreachState)))
 }
 
+// registerFunctions adds the functions defined by the config blocks of the
+// module to its scope. When functions are allowed, import namespaces are
+// added as well because they may be used to call imported functions.
+func (v *validator) registerFunctions(s *state) {
+	var (
+		functions = make(map[string]any, len(s.functions))
+		vars      = make(map[string]any, len(s.scope.Variables))
+		allowed   = featuregate.CheckAllowed(function.StabilityLevel, v.minStability, "") == nil
+	)
+	maps.Copy(functions, s.functions)
+	maps.Copy(vars, s.scope.Variables)
+
+	for _, c := range s.configs {
+		if c.Label == "" {
+			continue
+		}
+		switch name := c.GetBlockName(); {
+		case name == function.BlockName:
+			functions[c.Label] = struct{}{}
+		case allowed && strings.HasPrefix(name, "import."):
+			functions[c.Label] = struct{}{}
+		}
+	}
+	maps.Copy(vars, functions)
+
+	s.functions = functions
+	s.scope = vm.NewScope(vars)
+}
+
+func (v *validator) validateFunction(node *node, s *state) {
+	name := node.block.GetBlockName()
+
+	// Check required stability level.
+	if err := featuregate.CheckAllowed(function.StabilityLevel, v.minStability, fmt.Sprintf("function block %q", node.block.Label)); err != nil {
+		node.diags.Add(diag.Diagnostic{
+			Severity: diag.SeverityLevelError,
+			StartPos: node.block.NamePos.Position(),
+			EndPos:   node.block.NamePos.Add(len(name) - 1).Position(),
+			Message:  err.Error(),
+		})
+	}
+
+	// Function blocks can only use their parameters and other functions.
+	node.diags.Merge(typecheck.Function(node.block, vm.NewScope(s.functions)))
+
+	// We need to empty the body of the function block so that parameters are not
+	// reported as missing references.
+	node.block.Body = ast.Body{}
+	s.graph.Add(node)
+}
+
 // validateComponents will perform validation on component blocks.
 func (v *validator) validateComponents(s *state) {
 	mem := make(map[string]*ast.BlockStmt, len(s.components))
@@ -415,8 +497,8 @@ func (v *validator) validateServices(s *state) {
 }
 
 var configBlockNames = [...]string{
-	foreach.BlockName, argument.BlockName, export.BlockName, "logging", "tracing",
-	importsource.BlockNameFile, importsource.BlockNameString, importsource.BlockNameHTTP, importsource.BlockNameGit,
+	foreach.BlockName, function.BlockName, argument.BlockName, export.BlockName, "logging", "tracing",
+	importsource.BlockNameFile, importsource.BlockNameString, importsource.BlockNameHTTP, importsource.BlockNameGit, importsource.BlockNameOCI,
 }
 
 // extractBlocks extracts configs, declares and components blocks from body
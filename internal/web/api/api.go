@@ -29,12 +29,13 @@ import (
 type AlloyAPI struct {
 	alloy           service.Host
 	CallbackManager livedebugging.CallbackManager
+	CaptureReader   livedebugging.CaptureReader
 	logger          log.Logger
 }
 
 // NewAlloyAPI instantiates a new Alloy API.
-func NewAlloyAPI(alloy service.Host, CallbackManager livedebugging.CallbackManager, l log.Logger) *AlloyAPI {
-	return &AlloyAPI{alloy: alloy, CallbackManager: CallbackManager, logger: l}
+func NewAlloyAPI(alloy service.Host, CallbackManager livedebugging.CallbackManager, CaptureReader livedebugging.CaptureReader, l log.Logger) *AlloyAPI {
+	return &AlloyAPI{alloy: alloy, CallbackManager: CallbackManager, CaptureReader: CaptureReader, logger: l}
 }
 
 // RegisterRoutes registers all the API's routes.
@@ -54,6 +55,7 @@ func (a *AlloyAPI) RegisterRoutes(urlPrefix string, r *mux.Router) {
 
 	r.Handle(path.Join(urlPrefix, "/peers"), httputil.CompressionHandler{Handler: getClusteringPeersHandler(a.alloy)})
 	r.Handle(path.Join(urlPrefix, "/debug/{id:.+}"), liveDebugging(a.alloy, a.CallbackManager, a.logger))
+	r.Handle(path.Join(urlPrefix, "/capture"), httputil.CompressionHandler{Handler: getCaptureHandler(a.CaptureReader, a.logger)})
 
 	r.Handle(path.Join(urlPrefix, "/graph"), graph(a.alloy, a.CallbackManager, a.logger))
 	r.Handle(path.Join(urlPrefix, "/graph/{moduleID:.+}"), graph(a.alloy, a.CallbackManager, a.logger))
@@ -122,10 +124,11 @@ func getComponentHandlerInternal(host service.Host, w http.ResponseWriter, r *ht
 	requestedComponent := component.ParseID(vars["id"])
 
 	component, err := host.GetComponent(requestedComponent, component.InfoOptions{
-		GetHealth:    true,
-		GetArguments: true,
-		GetExports:   true,
-		GetDebugInfo: true,
+		GetHealth:        true,
+		GetArguments:     true,
+		GetExports:       true,
+		GetDebugInfo:     true,
+		GetResourceUsage: true,
 	})
 	if err != nil {
 		http.NotFound(w, r)
@@ -280,6 +283,7 @@ func liveDebugging(h service.Host, callbackManager livedebugging.CallbackManager
 		ctx := r.Context()
 
 		sampleProb := setSampleProb(w, r.URL.Query().Get("sampleProb"))
+		jsonFormat := r.URL.Query().Get("format") == "json"
 
 		id := livedebugging.CallbackID(uuid.New().String())
 
@@ -294,7 +298,7 @@ func liveDebugging(h service.Host, callbackManager livedebugging.CallbackManager
 				}
 				// Avoid blocking the channel when the channel is full
 				select {
-				case dataCh <- data.DataFunc():
+				case dataCh <- formatLiveDebuggingData(data, jsonFormat):
 				default:
 					if !droppedData {
 						level.Warn(logger).Log("msg", "data throughput is very high, not all debugging data can be sent the live debugging stream")
@@ -337,6 +341,69 @@ func liveDebugging(h service.Host, callbackManager livedebugging.CallbackManager
 	}
 }
 
+// formatLiveDebuggingData returns the string of the data. When jsonFormat is
+// set, it returns the data as a JSON object instead, with its structured
+// payload if it has one.
+func formatLiveDebuggingData(data livedebugging.Data, jsonFormat bool) string {
+	if !jsonFormat {
+		return data.DataFunc()
+	}
+	bb, err := json.Marshal(livedebugging.Record{
+		Timestamp:          time.Now().UTC(),
+		ComponentID:        data.ComponentID,
+		TargetComponentIDs: data.TargetComponentIDs,
+		Type:               data.Type,
+		Count:              data.Count,
+		Data:               data.DataFunc(),
+		Payload:            data.Payload(),
+	})
+	if err != nil {
+		return data.DataFunc()
+	}
+	return string(bb)
+}
+
+// getCaptureHandler downloads the live debugging data captured to disk as
+// newline-delimited JSON. The component query parameter, which can be
+// repeated, selects the records of some components. The filter query
+// parameter selects records with matchers such as {type="loki_log"}. The
+// since query parameter, in RFC 3339 format, selects recent records.
+func getCaptureHandler(reader livedebugging.CaptureReader, logger log.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if reader == nil {
+			http.Error(w, "capturing live debugging data is not supported", http.StatusNotFound)
+			return
+		}
+
+		var (
+			query  = r.URL.Query()
+			filter livedebugging.Filter
+			err    error
+		)
+		for _, id := range query["component"] {
+			filter.Components = append(filter.Components, livedebugging.ComponentID(id))
+		}
+		if filter.Matchers, err = livedebugging.ParseMatchers(query.Get("filter")); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if since := query.Get("since"); since != "" {
+			if filter.Since, err = time.Parse(time.RFC3339Nano, since); err != nil {
+				http.Error(w, "Invalid since: must be a timestamp in RFC 3339 format", http.StatusBadRequest)
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Content-Disposition", `attachment; filename="livedebugging.ndjson"`)
+		if err := reader.ReadCapture(r.Context(), filter, w); err != nil {
+			// The response may already be partially written, so the error can
+			// only be logged.
+			level.Warn(logger).Log("msg", "error reading captured live debugging data", "error", err)
+		}
+	}
+}
+
 func resolveServiceHost(host service.Host, id string) (service.Host, error) {
 	if strings.HasPrefix(id, "remotecfg/") {
 		remoteCfgHost, err := remotecfg.GetHost(host)
@@ -10,7 +10,8 @@ import ComponentList from './ComponentList';
 import styles from './ComponentView.module.css';
 import ForeachList from './ForeachList';
 import { HealthLabel } from './HealthLabel';
-import type { ComponentDetail, ComponentInfo, PartitionedBody } from './types';
+import Table from './Table';
+import type { ComponentDetail, ComponentInfo, ComponentResourceUsage, PartitionedBody } from './types';
 
 export interface ComponentViewProps {
   component: ComponentDetail;
@@ -77,6 +78,13 @@ export const ComponentView: FC<ComponentViewProps> = (props) => {
           {argsPartition && partitionTOC(argsPartition)}
           {exportsPartition && partitionTOC(exportsPartition)}
           {debugPartition && partitionTOC(debugPartition)}
+          {props.component.resourceUsage && (
+            <li>
+              <Link to="#resource-usage" target="_top">
+                Resource usage
+              </Link>
+            </li>
+          )}
           {props.component.referencesTo.length > 0 && (
             <li>
               <Link to="#dependencies" target="_top">
@@ -145,6 +153,15 @@ export const ComponentView: FC<ComponentViewProps> = (props) => {
         {exportsPartition && <ComponentBody partition={exportsPartition} />}
         {debugPartition && <ComponentBody partition={debugPartition} />}
 
+        {props.component.resourceUsage && (
+          <section id="resource-usage">
+            <h2>Resource usage</h2>
+            <div className={styles.sectionContent}>
+              <ResourceUsageTable usage={props.component.resourceUsage} />
+            </div>
+          </section>
+        )}
+
         {props.component.referencesTo.length > 0 && (
           <section id="dependencies">
             <h2>Dependencies</h2>
@@ -185,6 +202,31 @@ export const ComponentView: FC<ComponentViewProps> = (props) => {
   );
 };
 
+const ResourceUsageTable: FC<{ usage: ComponentResourceUsage }> = ({ usage }) => {
+  return (
+    <Table
+      tableHeaders={['Goroutines', 'CPU time', 'Allocated memory']}
+      renderTableData={() => [
+        <tr key="usage">
+          <td>{usage.goroutines}</td>
+          <td>{usage.cpuSeconds.toFixed(2)}s</td>
+          <td>{formatBytes(usage.allocatedBytes)}</td>
+        </tr>,
+      ]}
+    />
+  );
+};
+
+function formatBytes(bytes: number): string {
+  const units = ['B', 'KiB', 'MiB', 'GiB', 'TiB'];
+  let i = 0;
+  while (bytes >= 1024 && i < units.length - 1) {
+    bytes /= 1024;
+    i++;
+  }
+  return `${bytes.toFixed(i === 0 ? 0 : 1)} ${units[i]}`;
+}
+
 function pathJoin(paths: (string | undefined)[]): string {
   return paths.filter((p) => p && p !== '').join('/');
 }
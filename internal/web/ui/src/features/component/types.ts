@@ -111,6 +111,12 @@ export interface ComponentDetail extends ComponentInfo {
    */
   debugInfo?: AlloyBody;
 
+  /**
+   * Estimated resource usage of the component. Only set when resource
+   * accounting is enabled.
+   */
+  resourceUsage?: ComponentResourceUsage;
+
   /**
    * If a component is a module loader, the IDs of modules it created are included here.
    */
@@ -122,6 +128,18 @@ export interface ComponentDetail extends ComponentInfo {
   moduleInfo?: ComponentInfo[];
 }
 
+/**
+ * ComponentResourceUsage is the estimated resource usage of a component.
+ */
+export interface ComponentResourceUsage {
+  /** Current number of goroutines started by the component. */
+  goroutines: number;
+  /** Estimated CPU time used by the component, in seconds. */
+  cpuSeconds: number;
+  /** Estimated number of heap bytes allocated by the component. */
+  allocatedBytes: number;
+}
+
 export interface PartitionedBody {
   /** key is a list of unique identifiers for this partitioned body. */
   key: string[];
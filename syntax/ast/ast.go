@@ -176,6 +176,16 @@ type ParenExpr struct {
 	Secret bool
 }
 
+// LambdaExpr declares an anonymous function, such as (x) => x + 1.
+type LambdaExpr struct {
+	Params               []*Ident
+	LParenPos, RParenPos token.Pos
+	ArrowPos             token.Pos
+	Body                 Expr
+
+	Secret bool
+}
+
 // Type assertions
 
 var (
@@ -194,6 +204,7 @@ var (
 	_ Node = (*UnaryExpr)(nil)
 	_ Node = (*BinaryExpr)(nil)
 	_ Node = (*ParenExpr)(nil)
+	_ Node = (*LambdaExpr)(nil)
 
 	_ Stmt = (*AttributeStmt)(nil)
 	_ Stmt = (*BlockStmt)(nil)
@@ -208,6 +219,7 @@ var (
 	_ Expr = (*UnaryExpr)(nil)
 	_ Expr = (*BinaryExpr)(nil)
 	_ Expr = (*ParenExpr)(nil)
+	_ Expr = (*LambdaExpr)(nil)
 )
 
 func (n *File) astNode()           {}
@@ -227,6 +239,7 @@ func (n *CallExpr) astNode()       {}
 func (n *UnaryExpr) astNode()      {}
 func (n *BinaryExpr) astNode()     {}
 func (n *ParenExpr) astNode()      {}
+func (n *LambdaExpr) astNode()     {}
 
 func (n *AttributeStmt) astStmt() {}
 func (n *BlockStmt) astStmt()     {}
@@ -241,6 +254,7 @@ func (n *CallExpr) astExpr()       {}
 func (n *UnaryExpr) astExpr()      {}
 func (n *BinaryExpr) astExpr()     {}
 func (n *ParenExpr) astExpr()      {}
+func (n *LambdaExpr) astExpr()     {}
 
 func (n *IdentifierExpr) IsSecret() bool { return n.Secret }
 func (n *LiteralExpr) IsSecret() bool    { return n.Secret }
@@ -252,6 +266,7 @@ func (n *CallExpr) IsSecret() bool       { return n.Secret }
 func (n *UnaryExpr) IsSecret() bool      { return n.Secret }
 func (n *BinaryExpr) IsSecret() bool     { return n.Secret }
 func (n *ParenExpr) IsSecret() bool      { return n.Secret }
+func (n *LambdaExpr) IsSecret() bool     { return n.Secret }
 
 func (n *IdentifierExpr) SetSecret(s bool) { n.Secret = s }
 func (n *LiteralExpr) SetSecret(s bool)    { n.Secret = s }
@@ -263,6 +278,7 @@ func (n *CallExpr) SetSecret(s bool)       { n.Secret = s }
 func (n *UnaryExpr) SetSecret(s bool)      { n.Secret = s }
 func (n *BinaryExpr) SetSecret(s bool)     { n.Secret = s }
 func (n *ParenExpr) SetSecret(s bool)      { n.Secret = s }
+func (n *LambdaExpr) SetSecret(s bool)     { n.Secret = s }
 
 // StartPos returns the position of the first character belonging to a Node.
 func StartPos(n Node) token.Pos {
@@ -310,6 +326,8 @@ func StartPos(n Node) token.Pos {
 		return StartPos(n.Left)
 	case *ParenExpr:
 		return n.LParenPos
+	case *LambdaExpr:
+		return n.LParenPos
 	default:
 		panic(fmt.Sprintf("Unhandled Node type %T", n))
 	}
@@ -361,6 +379,8 @@ func EndPos(n Node) token.Pos {
 		return EndPos(n.Right)
 	case *ParenExpr:
 		return n.RParenPos
+	case *LambdaExpr:
+		return EndPos(n.Body)
 	default:
 		panic(fmt.Sprintf("Unhandled Node type %T", n))
 	}
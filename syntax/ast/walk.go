@@ -65,6 +65,11 @@ func Walk(v Visitor, node Node) {
 		Walk(v, n.Right)
 	case *ParenExpr:
 		Walk(v, n.Inner)
+	case *LambdaExpr:
+		for _, p := range n.Params {
+			Walk(v, p)
+		}
+		Walk(v, n.Body)
 	default:
 		panic(fmt.Sprintf("syntax/ast: unexpected node type %T", n))
 	}
@@ -0,0 +1,480 @@
+package alloyjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/diag"
+	"github.com/grafana/alloy/syntax/parser"
+	"github.com/grafana/alloy/syntax/printer"
+	"github.com/grafana/alloy/syntax/scanner"
+	"github.com/grafana/alloy/syntax/token"
+)
+
+// typeExpr is the type of values holding an Alloy expression which can't be
+// represented as a JSON value, such as a reference to a component export.
+const typeExpr = "expr"
+
+// MarshalFile marshals an Alloy file to JSON. The file is represented as a
+// list of statements, using the same representation as [MarshalBody].
+//
+// Literals, arrays and objects are marshaled as JSON values. Other
+// expressions are marshaled as a value of type "expr" holding the expression
+// formatted as Alloy syntax. Comments aren't marshaled.
+func MarshalFile(f *ast.File) ([]byte, error) {
+	body, err := encodeASTBody(f.Body)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(body)
+}
+
+func encodeASTBody(body ast.Body) (jsonBody, error) {
+	res := jsonBody{}
+	for _, stmt := range body {
+		switch stmt := stmt.(type) {
+		case *ast.AttributeStmt:
+			val, err := encodeASTExpr(stmt.Value)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, jsonAttr{Name: stmt.Name.Name, Type: "attr", Value: val})
+
+		case *ast.BlockStmt:
+			inner, err := encodeASTBody(stmt.Body)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, jsonBlock{
+				Name:  strings.Join(stmt.Name, "."),
+				Type:  "block",
+				Label: stmt.Label,
+				Body:  inner,
+			})
+
+		default:
+			return nil, fmt.Errorf("syntax/encoding/alloyjson: unsupported statement type %T", stmt)
+		}
+	}
+	return res, nil
+}
+
+func encodeASTExpr(expr ast.Expr) (jsonValue, error) {
+	switch expr := expr.(type) {
+	case *ast.LiteralExpr:
+		switch expr.Kind {
+		case token.NULL:
+			return jsonValue{Type: "null"}, nil
+		case token.BOOL:
+			return jsonValue{Type: "bool", Value: expr.Value == "true"}, nil
+		case token.STRING:
+			s, err := strconv.Unquote(expr.Value)
+			if err != nil {
+				return jsonValue{}, err
+			}
+			return jsonValue{Type: "string", Value: s}, nil
+		case token.NUMBER, token.FLOAT:
+			if json.Valid([]byte(expr.Value)) {
+				return jsonValue{Type: "number", Value: json.Number(expr.Value)}, nil
+			}
+		}
+
+	case *ast.UnaryExpr:
+		// Negative numbers are parsed as unary expressions.
+		if lit, ok := expr.Value.(*ast.LiteralExpr); ok && expr.Kind == token.SUB && (lit.Kind == token.NUMBER || lit.Kind == token.FLOAT) {
+			if json.Valid([]byte("-" + lit.Value)) {
+				return jsonValue{Type: "number", Value: json.Number("-" + lit.Value)}, nil
+			}
+		}
+
+	case *ast.ArrayExpr:
+		elements := []any{}
+		for _, elem := range expr.Elements {
+			val, err := encodeASTExpr(elem)
+			if err != nil {
+				return jsonValue{}, err
+			}
+			elements = append(elements, val)
+		}
+		return jsonValue{Type: "array", Value: elements}, nil
+
+	case *ast.ObjectExpr:
+		fields := []jsonObjectField{}
+		for _, field := range expr.Fields {
+			val, err := encodeASTExpr(field.Value)
+			if err != nil {
+				return jsonValue{}, err
+			}
+			fields = append(fields, jsonObjectField{Key: field.Name.Name, Value: val})
+		}
+		return jsonValue{Type: "object", Value: fields}, nil
+	}
+
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, expr); err != nil {
+		return jsonValue{}, err
+	}
+	return jsonValue{Type: typeExpr, Value: buf.String()}, nil
+}
+
+// UnmarshalFile unmarshals the JSON representation of an Alloy file, as
+// produced by [MarshalFile], into an AST. filename is used for reporting
+// errors.
+//
+// The positions of the returned AST refer to the JSON document, so that
+// diagnostics reported against the AST point to the JSON which produced
+// them. If an error is encountered, the returned AST is nil and err is a
+// [diag.Diagnostics].
+func UnmarshalFile(filename string, data []byte) (*ast.File, error) {
+	d := &fileDecoder{file: token.NewFile(filename), data: data}
+	for i, b := range data {
+		if b == '\n' {
+			d.file.AddLine(i + 1)
+		}
+	}
+
+	root, err := decodeNode(data)
+	if err != nil {
+		return nil, d.errorAt(err.Offset, "%s", err.Message)
+	}
+
+	body := d.body(root)
+	if len(d.diags) > 0 {
+		return nil, d.diags
+	}
+	return &ast.File{Name: filename, Body: body}, nil
+}
+
+type fileDecoder struct {
+	file  *token.File
+	data  []byte
+	diags diag.Diagnostics
+}
+
+func (d *fileDecoder) errorAt(offset int, format string, args ...any) diag.Diagnostics {
+	d.diags.Add(diag.Diagnostic{
+		Severity: diag.SeverityLevelError,
+		StartPos: d.file.Pos(min(offset, len(d.data))).Position(),
+		Message:  fmt.Sprintf(format, args...),
+	})
+	return d.diags
+}
+
+func (d *fileDecoder) body(n *jsonNode) ast.Body {
+	elems, ok := n.value.([]*jsonNode)
+	if !ok {
+		d.errorAt(n.start, "expected a list of statements, got %s", n.kind())
+		return nil
+	}
+
+	body := ast.Body{}
+	for _, elem := range elems {
+		if stmt := d.statement(elem); stmt != nil {
+			body = append(body, stmt)
+		}
+	}
+	return body
+}
+
+func (d *fileDecoder) statement(n *jsonNode) ast.Stmt {
+	name, ok := d.stringMember(n, "name", true)
+	if !ok {
+		return nil
+	}
+	typ, ok := d.stringMember(n, "type", true)
+	if !ok {
+		return nil
+	}
+
+	switch typ.value {
+	case "attr":
+		if !scanner.IsValidIdentifier(name.value) {
+			d.errorAt(name.node.start, "attribute name %q is not a valid identifier", name.value)
+			return nil
+		}
+		value, ok := n.member("value")
+		if !ok {
+			d.errorAt(n.start, "missing value of attribute %q", name.value)
+			return nil
+		}
+		expr := d.value(value)
+		if expr == nil {
+			return nil
+		}
+		return &ast.AttributeStmt{
+			Name:  &ast.Ident{Name: name.value, NamePos: d.pos(name.node.start + 1)},
+			Value: expr,
+		}
+
+	case "block":
+		fragments := strings.Split(name.value, ".")
+		for _, fragment := range fragments {
+			if !scanner.IsValidIdentifier(fragment) {
+				d.errorAt(name.node.start, "block name %q is not valid", name.value)
+				return nil
+			}
+		}
+		block := &ast.BlockStmt{
+			Name:    fragments,
+			NamePos: d.pos(name.node.start + 1),
+		}
+		if label, ok := d.stringMember(n, "label", false); ok && label.node != nil {
+			block.Label = label.value
+			block.LabelPos = d.pos(label.node.start)
+		}
+
+		body, ok := n.member("body")
+		if !ok {
+			d.errorAt(n.start, "missing body of block %q", name.value)
+			return nil
+		}
+		block.LCurlyPos = d.pos(body.start)
+		block.RCurlyPos = d.pos(body.end - 1)
+		block.Body = d.body(body)
+		return block
+
+	default:
+		d.errorAt(typ.node.start, "unknown statement type %q, expected attr or block", typ.value)
+		return nil
+	}
+}
+
+func (d *fileDecoder) value(n *jsonNode) ast.Expr {
+	typ, ok := d.stringMember(n, "type", true)
+	if !ok {
+		return nil
+	}
+	value, hasValue := n.member("value")
+	if !hasValue && typ.value != "null" {
+		d.errorAt(n.start, "missing value of %s", typ.value)
+		return nil
+	}
+
+	switch typ.value {
+	case "null":
+		return &ast.LiteralExpr{Kind: token.NULL, Value: "null", ValuePos: d.pos(n.start)}
+
+	case "bool":
+		b, ok := value.value.(bool)
+		if !ok {
+			d.errorAt(value.start, "expected a bool, got %s", value.kind())
+			return nil
+		}
+		return &ast.LiteralExpr{Kind: token.BOOL, Value: strconv.FormatBool(b), ValuePos: d.pos(value.start)}
+
+	case "string":
+		s, ok := value.value.(string)
+		if !ok {
+			d.errorAt(value.start, "expected a string, got %s", value.kind())
+			return nil
+		}
+		return &ast.LiteralExpr{Kind: token.STRING, Value: strconv.Quote(s), ValuePos: d.pos(value.start)}
+
+	case "number":
+		num, ok := value.value.(json.Number)
+		if !ok {
+			d.errorAt(value.start, "expected a number, got %s", value.kind())
+			return nil
+		}
+		text, negative := strings.CutPrefix(num.String(), "-")
+		lit := &ast.LiteralExpr{Kind: token.NUMBER, Value: text, ValuePos: d.pos(value.start)}
+		if strings.ContainsAny(text, ".eE") {
+			lit.Kind = token.FLOAT
+		}
+		if negative {
+			lit.ValuePos = d.pos(value.start + 1)
+			return &ast.UnaryExpr{Kind: token.SUB, KindPos: d.pos(value.start), Value: lit}
+		}
+		return lit
+
+	case "array":
+		elems, ok := value.value.([]*jsonNode)
+		if !ok {
+			d.errorAt(value.start, "expected an array, got %s", value.kind())
+			return nil
+		}
+		arr := &ast.ArrayExpr{LBrackPos: d.pos(value.start), RBrackPos: d.pos(value.end - 1)}
+		for _, elem := range elems {
+			expr := d.value(elem)
+			if expr == nil {
+				return nil
+			}
+			arr.Elements = append(arr.Elements, expr)
+		}
+		return arr
+
+	case "object":
+		fields, ok := value.value.([]*jsonNode)
+		if !ok {
+			d.errorAt(value.start, "expected an array of object fields, got %s", value.kind())
+			return nil
+		}
+		obj := &ast.ObjectExpr{LCurlyPos: d.pos(value.start), RCurlyPos: d.pos(value.end - 1)}
+		for _, field := range fields {
+			key, ok := d.stringMember(field, "key", true)
+			if !ok {
+				return nil
+			}
+			fieldValue, ok := field.member("value")
+			if !ok {
+				d.errorAt(field.start, "missing value of object field %q", key.value)
+				return nil
+			}
+			expr := d.value(fieldValue)
+			if expr == nil {
+				return nil
+			}
+			obj.Fields = append(obj.Fields, &ast.ObjectField{
+				Name:   &ast.Ident{Name: key.value, NamePos: d.pos(key.node.start)},
+				Quoted: !scanner.IsValidIdentifier(key.value),
+				Value:  expr,
+			})
+		}
+		return obj
+
+	case typeExpr:
+		s, ok := value.value.(string)
+		if !ok {
+			d.errorAt(value.start, "expected a string, got %s", value.kind())
+			return nil
+		}
+		return d.expr(value, s)
+
+	default:
+		d.errorAt(typ.node.start, "values of type %q can't be decoded", typ.value)
+		return nil
+	}
+}
+
+// expr parses the Alloy expression held by the JSON string n. The positions
+// of the expression are moved to the JSON document.
+func (d *fileDecoder) expr(n *jsonNode, s string) ast.Expr {
+	offsets := stringOffsets(d.data[n.start:n.end])
+	mapOffset := func(off int) int {
+		if off < len(offsets) {
+			return n.start + offsets[off]
+		}
+		return n.end - 1
+	}
+
+	expr, err := parser.ParseExpression(s)
+	if err != nil {
+		var diags diag.Diagnostics
+		if !errors.As(err, &diags) {
+			d.errorAt(n.start, "%s", err)
+			return nil
+		}
+		for _, dg := range diags {
+			d.errorAt(mapOffset(dg.StartPos.Offset), "%s", dg.Message)
+		}
+		return nil
+	}
+
+	movePositions(reflect.ValueOf(expr), func(p token.Pos) token.Pos {
+		return d.pos(mapOffset(p.Offset()))
+	})
+	return expr
+}
+
+func (d *fileDecoder) pos(offset int) token.Pos { return d.file.Pos(offset) }
+
+type stringMember struct {
+	value string
+	node  *jsonNode // nil if the member is missing.
+}
+
+// stringMember returns the string member named key of the JSON object n.
+func (d *fileDecoder) stringMember(n *jsonNode, key string, required bool) (stringMember, bool) {
+	if _, ok := n.value.([]jsonMember); !ok {
+		d.errorAt(n.start, "expected an object, got %s", n.kind())
+		return stringMember{}, false
+	}
+
+	m, ok := n.member(key)
+	if !ok {
+		if required {
+			d.errorAt(n.start, "missing %q key", key)
+		}
+		return stringMember{}, !required
+	}
+	s, ok := m.value.(string)
+	if !ok {
+		d.errorAt(m.start, "expected %q to be a string, got %s", key, m.kind())
+		return stringMember{}, false
+	}
+	return stringMember{value: s, node: m}, true
+}
+
+var goPos = reflect.TypeFor[token.Pos]()
+
+// movePositions replaces all the positions of the AST node v with the result
+// of move.
+func movePositions(v reflect.Value, move func(token.Pos) token.Pos) {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if !v.IsNil() {
+			movePositions(v.Elem(), move)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			movePositions(v.Index(i), move)
+		}
+	case reflect.Struct:
+		if v.Type() == goPos {
+			if p := v.Interface().(token.Pos); p.Valid() {
+				v.Set(reflect.ValueOf(move(p)))
+			}
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				movePositions(v.Field(i), move)
+			}
+		}
+	}
+}
+
+// stringOffsets returns the offset in the JSON string literal raw, including
+// its quotes, of each byte of the decoded string.
+func stringOffsets(raw []byte) []int {
+	var offsets []int
+	for i := 1; i < len(raw)-1; {
+		if raw[i] != '\\' {
+			offsets = append(offsets, i)
+			i++
+			continue
+		}
+
+		size, n := 2, 1
+		if raw[i+1] == 'u' {
+			size = 6
+			r := decodeHex(raw[i+2 : i+6])
+			if utf16.IsSurrogate(r) && i+12 <= len(raw) && raw[i+6] == '\\' && raw[i+7] == 'u' {
+				r = utf16.DecodeRune(r, decodeHex(raw[i+8:i+12]))
+				size = 12
+			}
+			n = utf8.RuneLen(r)
+			if n < 0 {
+				n = utf8.RuneLen(utf8.RuneError)
+			}
+		}
+		for range n {
+			offsets = append(offsets, i)
+		}
+		i += size
+	}
+	return offsets
+}
+
+func decodeHex(b []byte) rune {
+	v, _ := strconv.ParseUint(string(b), 16, 32)
+	return rune(v)
+}
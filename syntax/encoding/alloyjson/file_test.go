@@ -0,0 +1,191 @@
+package alloyjson_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/diag"
+	"github.com/grafana/alloy/syntax/encoding/alloyjson"
+	"github.com/grafana/alloy/syntax/parser"
+	"github.com/grafana/alloy/syntax/printer"
+	"github.com/grafana/alloy/syntax/token"
+)
+
+const testFile = `logging {
+	level = "debug"
+}
+
+prometheus.scrape "default" {
+	targets         = [{__address__ = "localhost:12345", job = "alloy"}]
+	forward_to      = [prometheus.remote_write.default.receiver]
+	scrape_interval = "15s"
+	sample_limit    = -1
+	body_size_limit = 1.5
+	honor_labels    = true
+	params          = null
+	extra           = array.concat(["a"], ["b"])
+}
+`
+
+func TestMarshalFile(t *testing.T) {
+	f, err := parser.ParseFile("test.alloy", []byte(testFile))
+	require.NoError(t, err)
+
+	bb, err := alloyjson.MarshalFile(f)
+	require.NoError(t, err)
+
+	expect := `[
+		{
+			"name": "logging",
+			"type": "block",
+			"body": [
+				{ "name": "level", "type": "attr", "value": { "type": "string", "value": "debug" } }
+			]
+		},
+		{
+			"name": "prometheus.scrape",
+			"type": "block",
+			"label": "default",
+			"body": [
+				{
+					"name": "targets",
+					"type": "attr",
+					"value": {
+						"type": "array",
+						"value": [{
+							"type": "object",
+							"value": [
+								{ "key": "__address__", "value": { "type": "string", "value": "localhost:12345" } },
+								{ "key": "job", "value": { "type": "string", "value": "alloy" } }
+							]
+						}]
+					}
+				},
+				{
+					"name": "forward_to",
+					"type": "attr",
+					"value": {
+						"type": "array",
+						"value": [{ "type": "expr", "value": "prometheus.remote_write.default.receiver" }]
+					}
+				},
+				{ "name": "scrape_interval", "type": "attr", "value": { "type": "string", "value": "15s" } },
+				{ "name": "sample_limit", "type": "attr", "value": { "type": "number", "value": -1 } },
+				{ "name": "body_size_limit", "type": "attr", "value": { "type": "number", "value": 1.5 } },
+				{ "name": "honor_labels", "type": "attr", "value": { "type": "bool", "value": true } },
+				{ "name": "params", "type": "attr", "value": { "type": "null", "value": null } },
+				{ "name": "extra", "type": "attr", "value": { "type": "expr", "value": "array.concat([\"a\"], [\"b\"])" } }
+			]
+		}
+	]`
+	require.JSONEq(t, expect, string(bb))
+}
+
+func TestUnmarshalFile_RoundTrip(t *testing.T) {
+	f, err := parser.ParseFile("test.alloy", []byte(testFile))
+	require.NoError(t, err)
+
+	bb, err := alloyjson.MarshalFile(f)
+	require.NoError(t, err)
+
+	decoded, err := alloyjson.UnmarshalFile("test.json", bb)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, printer.Fprint(&buf, decoded))
+	require.Equal(t, testFile, buf.String()+"\n")
+}
+
+func TestUnmarshalFile_Positions(t *testing.T) {
+	input := `[
+  {
+    "name": "local.file",
+    "type": "block",
+    "label": "token",
+    "body": [
+      { "name": "filename", "type": "attr", "value": { "type": "expr", "value": "sys.env(\"TOKEN\")" } }
+    ]
+  }
+]`
+
+	f, err := alloyjson.UnmarshalFile("test.json", []byte(input))
+	require.NoError(t, err)
+	require.Len(t, f.Body, 1)
+
+	block := f.Body[0].(*ast.BlockStmt)
+	require.Equal(t, token.Position{Filename: "test.json", Offset: 19, Line: 3, Column: 14}, block.NamePos.Position())
+	require.Equal(t, strings.Index(input, `"token"`), block.LabelPos.Offset())
+
+	attr := block.Body[0].(*ast.AttributeStmt)
+	require.Equal(t, strings.Index(input, `filename`), attr.Name.NamePos.Offset())
+
+	// Positions within expressions account for escape sequences.
+	call := attr.Value.(*ast.CallExpr)
+	require.Equal(t, strings.Index(input, `sys.env`), ast.StartPos(call).Offset())
+	require.Equal(t, strings.Index(input, `)"`), call.RParenPos.Offset())
+	require.Equal(t, "test.json", call.RParenPos.Position().Filename)
+}
+
+func TestUnmarshalFile_Errors(t *testing.T) {
+	tt := []struct {
+		name   string
+		input  string
+		expect string
+	}{
+		{
+			name:   "invalid JSON",
+			input:  "[\n  {\"name\": }\n]",
+			expect: `test.json:2:13: missing value after object key`,
+		},
+		{
+			name:   "not a list",
+			input:  `{"name": "logging"}`,
+			expect: `test.json:1:1: expected a list of statements, got object`,
+		},
+		{
+			name:   "unknown statement type",
+			input:  `[{"name": "logging", "type": "blocks", "body": []}]`,
+			expect: `test.json:1:30: unknown statement type "blocks", expected attr or block`,
+		},
+		{
+			name:   "invalid block name",
+			input:  `[{"name": "local..file", "type": "block", "body": []}]`,
+			expect: `test.json:1:11: block name "local..file" is not valid`,
+		},
+		{
+			name:   "missing value",
+			input:  `[{"name": "a", "type": "attr"}]`,
+			expect: `test.json:1:2: missing value of attribute "a"`,
+		},
+		{
+			name:   "invalid expression",
+			input:  "[\n  {\"name\": \"a\", \"type\": \"attr\", \"value\": {\"type\": \"expr\", \"value\": \"1 + \\\"\\u00e9\\\" +\"}}\n]",
+			expect: `test.json:2:85: expected expression, got EOF`,
+		},
+		{
+			name:   "expression after escape sequences",
+			input:  `[{"name": "a", "type": "attr", "value": {"type": "expr", "value": "\"\u00e9\\n\" + )"}}]`,
+			expect: `test.json:1:84: expected expression, got )`,
+		},
+		{
+			name:   "capsule",
+			input:  `[{"name": "a", "type": "attr", "value": {"type": "capsule", "value": "foo"}}]`,
+			expect: `test.json:1:50: values of type "capsule" can't be decoded`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := alloyjson.UnmarshalFile("test.json", []byte(tc.input))
+			require.Error(t, err)
+
+			var diags diag.Diagnostics
+			require.ErrorAs(t, err, &diags)
+			require.Equal(t, tc.expect, diags[0].Error())
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package alloyjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// jsonNode is a JSON value along with its location in the document it was
+// decoded from.
+type jsonNode struct {
+	start, end int // Byte offsets; end is exclusive.
+
+	// value is one of nil, bool, json.Number, string, []*jsonNode for arrays
+	// or []jsonMember for objects.
+	value any
+}
+
+// jsonMember is a member of a JSON object.
+type jsonMember struct {
+	key   string
+	value *jsonNode
+}
+
+// member returns the value of the member named key, if n is an object which
+// has one.
+func (n *jsonNode) member(key string) (*jsonNode, bool) {
+	members, _ := n.value.([]jsonMember)
+	for _, m := range members {
+		if m.key == key {
+			return m.value, true
+		}
+	}
+	return nil, false
+}
+
+// kind returns the JSON type of n for error messages.
+func (n *jsonNode) kind() string {
+	switch n.value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case json.Number:
+		return "number"
+	case string:
+		return "string"
+	case []*jsonNode:
+		return "array"
+	case []jsonMember:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", n.value)
+	}
+}
+
+// decodeError is an error encountered while decoding JSON.
+type decodeError struct {
+	Offset  int
+	Message string
+}
+
+func (e *decodeError) Error() string { return e.Message }
+
+// decodeNode decodes the single JSON value held by data.
+func decodeNode(data []byte) (*jsonNode, *decodeError) {
+	d := &nodeDecoder{data: data, dec: json.NewDecoder(bytes.NewReader(data))}
+	d.dec.UseNumber()
+
+	n, err := d.next()
+	if err == nil {
+		if _, err := d.dec.Token(); !errors.Is(err, io.EOF) {
+			return nil, &decodeError{Offset: d.skip(), Message: "unexpected data after top-level value"}
+		}
+		return n, nil
+	}
+
+	var (
+		decodeErr *decodeError
+		syntaxErr *json.SyntaxError
+	)
+	switch {
+	case errors.As(err, &decodeErr):
+		return nil, decodeErr
+	case errors.As(err, &syntaxErr):
+		return nil, &decodeError{Offset: int(syntaxErr.Offset), Message: syntaxErr.Error()}
+	default:
+		return nil, &decodeError{Offset: len(data), Message: err.Error()}
+	}
+}
+
+type nodeDecoder struct {
+	data []byte
+	dec  *json.Decoder
+}
+
+// skip returns the offset of the next token, skipping whitespace and
+// separators.
+func (d *nodeDecoder) skip() int {
+	off := int(d.dec.InputOffset())
+	for off < len(d.data) {
+		switch d.data[off] {
+		case ' ', '\t', '\r', '\n', ',', ':':
+			off++
+		default:
+			return off
+		}
+	}
+	return off
+}
+
+func (d *nodeDecoder) next() (*jsonNode, error) {
+	start := d.skip()
+	tok, err := d.dec.Token()
+	if errors.Is(err, io.EOF) {
+		return nil, &decodeError{Offset: start, Message: "unexpected end of JSON input"}
+	} else if err != nil {
+		return nil, err
+	}
+
+	n := &jsonNode{start: start}
+	switch tok := tok.(type) {
+	case json.Delim:
+		switch tok {
+		case '[':
+			elems := []*jsonNode{}
+			for d.dec.More() {
+				elem, err := d.next()
+				if err != nil {
+					return nil, err
+				}
+				elems = append(elems, elem)
+			}
+			n.value = elems
+
+		case '{':
+			members := []jsonMember{}
+			for d.dec.More() {
+				key, err := d.dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := d.next()
+				if err != nil {
+					return nil, err
+				}
+				members = append(members, jsonMember{key: key.(string), value: value})
+			}
+			n.value = members
+		}
+
+		// Consume the closing delimiter.
+		if _, err := d.dec.Token(); err != nil {
+			return nil, err
+		}
+
+	default:
+		n.value = tok
+	}
+
+	n.end = int(d.dec.InputOffset())
+	return n, nil
+}
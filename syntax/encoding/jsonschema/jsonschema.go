@@ -0,0 +1,370 @@
+// Package jsonschema generates JSON Schema documents describing the Alloy
+// configuration accepted by Go types.
+//
+// Schemas describe bodies as JSON objects: attributes and blocks are
+// properties of the object, repeatable blocks are arrays of objects, and
+// labeled blocks are objects keyed by their label. Enums of blocks, where the
+// order of blocks matters, are arrays where each element sets exactly one
+// block.
+package jsonschema
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/grafana/alloy/syntax/alloytypes"
+	"github.com/grafana/alloy/syntax/internal/reflectutil"
+	"github.com/grafana/alloy/syntax/internal/syntaxtags"
+	"github.com/grafana/alloy/syntax/internal/value"
+)
+
+// Draft is the JSON Schema dialect of generated schemas.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Kinds of statements, set as the Kind of the schema of properties.
+const (
+	KindAttr  = "attr"
+	KindBlock = "block"
+	KindEnum  = "enum"
+)
+
+// Schema is a JSON Schema. Fields starting with x-alloy are extensions
+// carrying information which JSON Schema can't express.
+type Schema struct {
+	Schema      string `json:"$schema,omitempty"`
+	ID          string `json:"$id,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+
+	Type                 string             `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	Default              any                `json:"default,omitempty"`
+
+	// Closed disallows properties not listed in Properties. It's encoded as
+	// "additionalProperties": false.
+	Closed bool `json:"-"`
+
+	Kind      string `json:"x-alloy-kind,omitempty"`
+	AlloyType string `json:"x-alloy-type,omitempty"`
+	Stability string `json:"x-alloy-stability,omitempty"`
+	Community bool   `json:"x-alloy-community,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	type plain Schema
+	out := struct {
+		*plain
+		AdditionalProperties any `json:"additionalProperties,omitempty"`
+	}{plain: (*plain)(s)}
+
+	if s.AdditionalProperties != nil {
+		out.AdditionalProperties = s.AdditionalProperties
+	} else if s.Closed {
+		out.AdditionalProperties = false
+	}
+	return json.Marshal(out)
+}
+
+var (
+	goAlloyDefaulter = reflect.TypeFor[value.Defaulter]()
+	goCapsule        = reflect.TypeFor[value.Capsule]()
+	goTextMarshaler  = reflect.TypeFor[encoding.TextMarshaler]()
+	goDuration       = reflect.TypeFor[time.Duration]()
+	goSecret         = reflect.TypeFor[alloytypes.Secret]()
+	goOptionalSecret = reflect.TypeFor[alloytypes.OptionalSecret]()
+)
+
+// ForBody returns the schema of a body decoded into v, which must be a struct
+// with alloy tags or a pointer to one. Default values are taken from a new
+// value of the type of v after calling its SetToDefault method, as done when
+// decoding.
+func ForBody(v any) *Schema {
+	t := derefType(reflect.TypeOf(v))
+	if t == nil || t.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("syntax/encoding/jsonschema: ForBody requires a struct, got %T", v))
+	}
+	return (&generator{visiting: map[reflect.Type]bool{}}).body(newDefault(t))
+}
+
+// ForType returns the schema of a value decoded into a Go value of type t.
+func ForType(t reflect.Type) *Schema {
+	return (&generator{visiting: map[reflect.Type]bool{}}).value(t)
+}
+
+type generator struct {
+	// visiting holds the struct types being generated, to stop at recursive
+	// types.
+	visiting map[reflect.Type]bool
+}
+
+// body returns the schema of the body of the struct rv, whose fields hold
+// their default values.
+func (g *generator) body(rv reflect.Value) *Schema {
+	t := rv.Type()
+	if g.visiting[t] {
+		return &Schema{Type: "object"}
+	}
+	g.visiting[t] = true
+	defer delete(g.visiting, t)
+
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}, Closed: true}
+	for _, field := range syntaxtags.Get(t) {
+		name := strings.Join(field.Name, ".")
+		fieldValue := reflectutil.Get(rv, field)
+
+		var prop *Schema
+		switch {
+		case field.IsAttr():
+			prop = g.value(fieldValue.Type())
+			prop.Kind = KindAttr
+			if field.IsOptional() {
+				prop.Default = defaultValue(fieldValue)
+			}
+		case field.IsBlock():
+			prop = g.block(fieldValue)
+			prop.Kind = KindBlock
+		case field.IsEnum():
+			prop = g.enum(fieldValue.Type())
+			prop.Kind = KindEnum
+		default:
+			continue
+		}
+
+		s.Properties[name] = prop
+		if !field.IsOptional() && !field.IsEnum() {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return s
+}
+
+// block returns the schema of a block field holding its default value.
+func (g *generator) block(rv reflect.Value) *Schema {
+	t := derefType(rv.Type())
+
+	switch t.Kind() {
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.value(t.Elem())}
+
+	case reflect.Slice, reflect.Array:
+		elem := derefType(t.Elem())
+		body := g.body(newDefault(elem))
+		if labelField(elem) {
+			return &Schema{Type: "object", AdditionalProperties: body}
+		}
+		return &Schema{Type: "array", Items: body}
+	}
+
+	// Blocks which don't implement Defaulter keep the value set by the
+	// defaults of their parent.
+	def := rv
+	for def.Kind() == reflect.Pointer && !def.IsNil() {
+		def = def.Elem()
+	}
+	if def.Kind() == reflect.Pointer || reflect.PointerTo(t).Implements(goAlloyDefaulter) {
+		def = newDefault(t)
+	}
+	body := g.body(def)
+	if labelField(t) {
+		return &Schema{Type: "object", AdditionalProperties: body}
+	}
+	return body
+}
+
+// enum returns the schema of an enum field, which is a slice of structs where
+// each field is a block.
+func (g *generator) enum(t reflect.Type) *Schema {
+	elem := derefType(derefType(t).Elem())
+	blocks := g.body(newDefault(elem))
+
+	items := &Schema{}
+	for _, name := range slices.Sorted(maps.Keys(blocks.Properties)) {
+		items.OneOf = append(items.OneOf, &Schema{
+			Type:       "object",
+			Properties: map[string]*Schema{name: blocks.Properties[name]},
+			Required:   []string{name},
+			Closed:     true,
+		})
+	}
+	return &Schema{Type: "array", Items: items}
+}
+
+// value returns the schema of a value decoded into a Go value of type t.
+func (g *generator) value(t reflect.Type) *Schema {
+	switch derefType(t) {
+	case goDuration:
+		return &Schema{Type: "string", AlloyType: "duration"}
+	case goSecret, goOptionalSecret:
+		return &Schema{Type: "string", AlloyType: "secret"}
+	}
+
+	for pt := t; ; pt = pt.Elem() {
+		switch {
+		case pt.Implements(goCapsule):
+			return &Schema{AlloyType: fmt.Sprintf("capsule(%s)", t)}
+		case pt.Implements(goTextMarshaler), reflect.PointerTo(pt).Implements(goTextMarshaler):
+			return &Schema{Type: "string"}
+		}
+		if pt.Kind() != reflect.Pointer {
+			break
+		}
+	}
+
+	t = derefType(t)
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: g.value(t.Elem())}
+
+	case reflect.Map:
+		if t.Key().Kind() == reflect.String {
+			return &Schema{Type: "object", AdditionalProperties: g.value(t.Elem())}
+		}
+
+	case reflect.Struct:
+		if len(syntaxtags.Get(t)) > 0 {
+			return g.object(t)
+		}
+
+	case reflect.Func:
+		return &Schema{AlloyType: "function"}
+
+	case reflect.Interface:
+		if t.NumMethod() == 0 {
+			return &Schema{}
+		}
+	}
+	return &Schema{AlloyType: fmt.Sprintf("capsule(%s)", t)}
+}
+
+// object returns the schema of an object decoded into the struct type t.
+func (g *generator) object(t reflect.Type) *Schema {
+	if g.visiting[t] {
+		return &Schema{Type: "object"}
+	}
+	g.visiting[t] = true
+	defer delete(g.visiting, t)
+
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}, Closed: true}
+	for _, field := range syntaxtags.Get(t) {
+		if !field.IsAttr() {
+			continue
+		}
+		name := strings.Join(field.Name, ".")
+		s.Properties[name] = g.value(reflectutil.Get(reflect.New(t).Elem(), field).Type())
+		if !field.IsOptional() {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return s
+}
+
+// defaultValue returns the JSON representation of the default value of an
+// attribute, or nil if it has no default value or the value can't be
+// represented as JSON.
+func defaultValue(rv reflect.Value) any {
+	if !rv.IsValid() || rv.IsZero() {
+		return nil
+	}
+	v, ok := jsonValue(value.FromRaw(rv))
+	if !ok {
+		return nil
+	}
+	return v
+}
+
+func jsonValue(v value.Value) (any, bool) {
+	switch v.Type() {
+	case value.TypeNumber:
+		switch n := v.Number(); n.Kind() {
+		case value.NumberKindInt:
+			return n.Int(), true
+		case value.NumberKindUint:
+			return n.Uint(), true
+		default:
+			return n.Float(), true
+		}
+
+	case value.TypeString:
+		return v.Text(), true
+
+	case value.TypeBool:
+		return v.Bool(), true
+
+	case value.TypeArray:
+		res := make([]any, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			elem, ok := jsonValue(v.Index(i))
+			if !ok {
+				return nil, false
+			}
+			res = append(res, elem)
+		}
+		return res, true
+
+	case value.TypeObject:
+		res := make(map[string]any)
+		for _, key := range v.Keys() {
+			field, _ := v.Key(key)
+			elem, ok := jsonValue(field)
+			if !ok {
+				return nil, false
+			}
+			res[key] = elem
+		}
+		return res, true
+	}
+
+	// Null values, functions and capsules don't have a JSON representation.
+	return nil, false
+}
+
+// newDefault returns a new value of the struct type t after calling its
+// SetToDefault method, if any.
+func newDefault(t reflect.Type) reflect.Value {
+	rv := reflect.New(t)
+	if t.Kind() == reflect.Struct && rv.Type().Implements(goAlloyDefaulter) {
+		rv.Interface().(value.Defaulter).SetToDefault()
+	}
+	return rv.Elem()
+}
+
+// labelField reports whether the struct type t has a label field.
+func labelField(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for _, field := range syntaxtags.Get(t) {
+		if field.IsLabel() {
+			return true
+		}
+	}
+	return false
+}
+
+func derefType(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
@@ -0,0 +1,185 @@
+package jsonschema_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/alloy/syntax/alloytypes"
+	"github.com/grafana/alloy/syntax/encoding/jsonschema"
+)
+
+type testArgs struct {
+	Targets  []map[string]string `alloy:"targets,attr"`
+	Interval time.Duration       `alloy:"interval,attr,optional"`
+	Retries  int                 `alloy:"retries,attr,optional"`
+	Password alloytypes.Secret   `alloy:"password,attr,optional"`
+
+	Client   clientBlock    `alloy:",squash"`
+	Endpoint []endpoint     `alloy:"endpoint,block,optional"`
+	Rule     []labeledBlock `alloy:"rule,block,optional"`
+	Stages   []stage        `alloy:"stage,enum,optional"`
+}
+
+func (a *testArgs) SetToDefault() {
+	*a = testArgs{Interval: time.Minute, Client: clientBlock{Name: "alloy"}}
+}
+
+type clientBlock struct {
+	Name string `alloy:"client_name,attr,optional"`
+}
+
+type endpoint struct {
+	URL     string `alloy:"url,attr"`
+	Enabled bool   `alloy:"enabled,attr,optional"`
+}
+
+func (e *endpoint) SetToDefault() { *e = endpoint{Enabled: true} }
+
+type labeledBlock struct {
+	Label string   `alloy:",label"`
+	Keys  []string `alloy:"keys,attr"`
+}
+
+type stage struct {
+	Match *matchStage `alloy:"match,block,optional"`
+	Drop  *dropStage  `alloy:"drop,block,optional"`
+}
+
+type matchStage struct {
+	Selector string `alloy:"selector,attr"`
+}
+
+type dropStage struct {
+	Expression string `alloy:"expression,attr,optional"`
+}
+
+func TestForBody(t *testing.T) {
+	expect := `{
+		"type": "object",
+		"properties": {
+			"targets": {
+				"type": "array",
+				"items": { "type": "object", "additionalProperties": { "type": "string" } },
+				"x-alloy-kind": "attr"
+			},
+			"interval": { "type": "string", "default": "1m0s", "x-alloy-kind": "attr", "x-alloy-type": "duration" },
+			"retries": { "type": "integer", "x-alloy-kind": "attr" },
+			"password": { "type": "string", "x-alloy-kind": "attr", "x-alloy-type": "secret" },
+			"client_name": { "type": "string", "default": "alloy", "x-alloy-kind": "attr" },
+			"endpoint": {
+				"type": "array",
+				"items": {
+					"type": "object",
+					"properties": {
+						"url": { "type": "string", "x-alloy-kind": "attr" },
+						"enabled": { "type": "boolean", "default": true, "x-alloy-kind": "attr" }
+					},
+					"required": ["url"],
+					"additionalProperties": false
+				},
+				"x-alloy-kind": "block"
+			},
+			"rule": {
+				"type": "object",
+				"additionalProperties": {
+					"type": "object",
+					"properties": {
+						"keys": { "type": "array", "items": { "type": "string" }, "x-alloy-kind": "attr" }
+					},
+					"required": ["keys"],
+					"additionalProperties": false
+				},
+				"x-alloy-kind": "block"
+			},
+			"stage": {
+				"type": "array",
+				"items": {
+					"oneOf": [
+						{
+							"type": "object",
+							"properties": {
+								"drop": {
+									"type": "object",
+									"properties": {
+										"expression": { "type": "string", "x-alloy-kind": "attr" }
+									},
+									"additionalProperties": false,
+									"x-alloy-kind": "block"
+								}
+							},
+							"required": ["drop"],
+							"additionalProperties": false
+						},
+						{
+							"type": "object",
+							"properties": {
+								"match": {
+									"type": "object",
+									"properties": {
+										"selector": { "type": "string", "x-alloy-kind": "attr" }
+									},
+									"required": ["selector"],
+									"additionalProperties": false,
+									"x-alloy-kind": "block"
+								}
+							},
+							"required": ["match"],
+							"additionalProperties": false
+						}
+					]
+				},
+				"x-alloy-kind": "enum"
+			}
+		},
+		"required": ["targets"],
+		"additionalProperties": false
+	}`
+
+	actual, err := json.Marshal(jsonschema.ForBody(testArgs{}))
+	require.NoError(t, err)
+	require.JSONEq(t, expect, string(actual))
+}
+
+type recursive struct {
+	Name     string       `alloy:"name,attr"`
+	Children []*recursive `alloy:"children,attr,optional"`
+}
+
+func TestForType(t *testing.T) {
+	tt := []struct {
+		name   string
+		input  reflect.Type
+		expect string
+	}{
+		{"number", reflect.TypeFor[float64](), `{ "type": "number" }`},
+		{"pointer", reflect.TypeFor[*time.Duration](), `{ "type": "string", "x-alloy-type": "duration" }`},
+		{"text marshaler", reflect.TypeFor[time.Time](), `{ "type": "string" }`},
+		{"any", reflect.TypeFor[any](), `{}`},
+		{"capsule", reflect.TypeFor[chan int](), `{ "x-alloy-type": "capsule(chan int)" }`},
+		{
+			name:  "recursive object",
+			input: reflect.TypeFor[recursive](),
+			expect: `{
+				"type": "object",
+				"properties": {
+					"name": { "type": "string" },
+					"children": { "type": "array", "items": { "type": "object" } }
+				},
+				"required": ["name"],
+				"additionalProperties": false
+			}`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := json.Marshal(jsonschema.ForType(tc.input))
+			require.NoError(t, err)
+			require.JSONEq(t, tc.expect, string(actual))
+		})
+	}
+}
@@ -0,0 +1,331 @@
+package stdlib
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/grafana/alloy/syntax/internal/transform"
+	"github.com/grafana/alloy/syntax/internal/value"
+	"github.com/grafana/alloy/syntax/token"
+)
+
+var object = map[string]any{
+	"keys":       mapKeys,
+	"values":     mapValues,
+	"merge_deep": mergeDeep,
+}
+
+// distinct returns the elements of an array without duplicates. The first
+// occurrence of each element is kept.
+var distinct = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if len(args) != 1 {
+		return value.Null, fmt.Errorf("distinct: expected 1 argument, got %d", len(args))
+	}
+	if err := checkArgType(funcValue, args, 0, value.TypeArray); err != nil {
+		return value.Null, err
+	}
+
+	res := make([]value.Value, 0, args[0].Len())
+	for i := 0; i < args[0].Len(); i++ {
+		elem := args[0].Index(i)
+		if !containsValue(res, elem) {
+			res = append(res, elem)
+		}
+	}
+	return value.Array(res...), nil
+})
+
+// flatten replaces the nested arrays of an array with their elements,
+// recursively.
+var flatten = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if len(args) != 1 {
+		return value.Null, fmt.Errorf("flatten: expected 1 argument, got %d", len(args))
+	}
+	if err := checkArgType(funcValue, args, 0, value.TypeArray); err != nil {
+		return value.Null, err
+	}
+
+	var (
+		res  = make([]value.Value, 0, args[0].Len())
+		walk func(arr value.Value)
+	)
+	walk = func(arr value.Value) {
+		for i := 0; i < arr.Len(); i++ {
+			if elem := arr.Index(i); elem.Type() == value.TypeArray {
+				walk(elem)
+			} else {
+				res = append(res, elem)
+			}
+		}
+	}
+	walk(args[0])
+
+	return value.Array(res...), nil
+})
+
+// contains reports whether an array contains a value.
+var contains = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if len(args) != 2 {
+		return value.Null, fmt.Errorf("contains: expected 2 arguments, got %d", len(args))
+	}
+	if err := checkArgType(funcValue, args, 0, value.TypeArray); err != nil {
+		return value.Null, err
+	}
+
+	for i := 0; i < args[0].Len(); i++ {
+		if valuesEqual(args[0].Index(i), args[1]) {
+			return value.Bool(true), nil
+		}
+	}
+	return value.Bool(false), nil
+})
+
+// sortArray returns the elements of an array in ascending order. The elements
+// must either be all numbers or all strings.
+var sortArray = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if len(args) != 1 {
+		return value.Null, fmt.Errorf("sort: expected 1 argument, got %d", len(args))
+	}
+	if err := checkArgType(funcValue, args, 0, value.TypeArray); err != nil {
+		return value.Null, err
+	}
+
+	res := make([]value.Value, args[0].Len())
+	for i := range res {
+		elem := args[0].Index(i)
+		if elem.Type() != value.TypeNumber && elem.Type() != value.TypeString {
+			return value.Null, value.ArgError{
+				Function: funcValue,
+				Argument: elem,
+				Index:    i,
+				Inner:    fmt.Errorf("sort: elements must be numbers or strings, got %s", elem.Type()),
+			}
+		}
+		if i > 0 && elem.Type() != res[0].Type() {
+			return value.Null, value.ArgError{
+				Function: funcValue,
+				Argument: elem,
+				Index:    i,
+				Inner: value.TypeError{
+					Value:    elem,
+					Expected: res[0].Type(),
+				},
+			}
+		}
+		res[i] = elem
+	}
+
+	slices.SortStableFunc(res, func(a, b value.Value) int {
+		switch {
+		case lessThan(a, b):
+			return -1
+		case lessThan(b, a):
+			return 1
+		default:
+			return 0
+		}
+	})
+	return value.Array(res...), nil
+})
+
+// mapArray returns the results of calling a function on each element of an
+// array.
+var mapArray = value.ContextFunction(func(ctx value.CallContext, funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if len(args) != 2 {
+		return value.Null, fmt.Errorf("map: expected 2 arguments, got %d", len(args))
+	}
+	if err := checkArgType(funcValue, args, 0, value.TypeArray); err != nil {
+		return value.Null, err
+	}
+	if err := checkArgType(funcValue, args, 1, value.TypeFunction); err != nil {
+		return value.Null, err
+	}
+
+	res := make([]value.Value, args[0].Len())
+	for i := range res {
+		elem, err := args[1].CallWithContext(ctx, args[0].Index(i))
+		if err != nil {
+			return value.Null, err
+		}
+		res[i] = elem
+	}
+	return value.Array(res...), nil
+})
+
+// filterArray returns the elements of an array for which a function returns
+// true. The function must return a bool.
+var filterArray = value.ContextFunction(func(ctx value.CallContext, funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if len(args) != 2 {
+		return value.Null, fmt.Errorf("filter: expected 2 arguments, got %d", len(args))
+	}
+	if err := checkArgType(funcValue, args, 0, value.TypeArray); err != nil {
+		return value.Null, err
+	}
+	if err := checkArgType(funcValue, args, 1, value.TypeFunction); err != nil {
+		return value.Null, err
+	}
+
+	res := make([]value.Value, 0, args[0].Len())
+	for i := 0; i < args[0].Len(); i++ {
+		elem := args[0].Index(i)
+		keep, err := args[1].CallWithContext(ctx, elem)
+		if err != nil {
+			return value.Null, err
+		}
+		if keep.Type() != value.TypeBool {
+			return value.Null, value.Error{
+				Value: funcValue,
+				Inner: fmt.Errorf("filter: function must return a bool, got %s", keep.Type()),
+			}
+		}
+		if keep.Bool() {
+			res = append(res, elem)
+		}
+	}
+	return value.Array(res...), nil
+})
+
+// mapKeys returns the keys of an object in ascending order.
+var mapKeys = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if len(args) != 1 {
+		return value.Null, fmt.Errorf("keys: expected 1 argument, got %d", len(args))
+	}
+	obj, err := objectArg(funcValue, args[0], 0)
+	if err != nil {
+		return value.Null, err
+	}
+
+	keys := sortedKeys(obj)
+	res := make([]value.Value, len(keys))
+	for i, k := range keys {
+		res[i] = value.String(k)
+	}
+	return value.Array(res...), nil
+})
+
+// mapValues returns the values of an object, sorted by their keys.
+var mapValues = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if len(args) != 1 {
+		return value.Null, fmt.Errorf("values: expected 1 argument, got %d", len(args))
+	}
+	obj, err := objectArg(funcValue, args[0], 0)
+	if err != nil {
+		return value.Null, err
+	}
+
+	keys := sortedKeys(obj)
+	res := make([]value.Value, len(keys))
+	for i, k := range keys {
+		res[i], _ = obj.Key(k)
+	}
+	return value.Array(res...), nil
+})
+
+// mergeDeep merges objects from left to right. When a key exists in several
+// objects, the values are merged recursively if they're all objects,
+// otherwise the rightmost value is used.
+var mergeDeep = value.RawFunction(func(funcValue value.Value, args ...value.Value) (value.Value, error) {
+	res := make(map[string]value.Value)
+	for i, arg := range args {
+		obj, err := objectArg(funcValue, arg, i)
+		if err != nil {
+			return value.Null, err
+		}
+		mergeInto(res, obj)
+	}
+	return value.Object(res), nil
+})
+
+func mergeInto(dst map[string]value.Value, src value.Value) {
+	for _, key := range src.Keys() {
+		srcVal, _ := src.Key(key)
+		dstVal, found := dst[key]
+		if !found {
+			dst[key] = srcVal
+			continue
+		}
+
+		srcObj, srcIsObj := asObject(srcVal)
+		dstObj, dstIsObj := asObject(dstVal)
+		if !srcIsObj || !dstIsObj {
+			dst[key] = srcVal
+			continue
+		}
+
+		merged := make(map[string]value.Value, dstObj.Len())
+		mergeInto(merged, dstObj)
+		mergeInto(merged, srcObj)
+		dst[key] = value.Object(merged)
+	}
+}
+
+// asObject returns v as an object if it's an object or a capsule which can
+// be converted to an object.
+func asObject(v value.Value) (value.Value, bool) {
+	if v.Type() == value.TypeObject {
+		return v, true
+	}
+	if obj, ok := v.TryConvertToObject(); ok {
+		return value.Object(obj), true
+	}
+	return value.Null, false
+}
+
+func objectArg(funcValue value.Value, arg value.Value, index int) (value.Value, error) {
+	obj, ok := asObject(arg)
+	if !ok {
+		return value.Null, value.ArgError{
+			Function: funcValue,
+			Argument: arg,
+			Index:    index,
+			Inner: value.TypeError{
+				Value:    arg,
+				Expected: value.TypeObject,
+			},
+		}
+	}
+	return obj, nil
+}
+
+func checkArgType(funcValue value.Value, args []value.Value, index int, expected value.Type) error {
+	if args[index].Type() != expected {
+		return value.ArgError{
+			Function: funcValue,
+			Argument: args[index],
+			Index:    index,
+			Inner: value.TypeError{
+				Value:    args[index],
+				Expected: expected,
+			},
+		}
+	}
+	return nil
+}
+
+func sortedKeys(obj value.Value) []string {
+	keys := obj.Keys()
+	slices.Sort(keys)
+	return keys
+}
+
+func containsValue(values []value.Value, v value.Value) bool {
+	for _, elem := range values {
+		if valuesEqual(elem, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// valuesEqual compares values the same way as the == operator.
+func valuesEqual(lhs, rhs value.Value) bool {
+	res, err := transform.BinaryOp(lhs, token.EQ, rhs)
+	return err == nil && res.Bool()
+}
+
+// lessThan compares values the same way as the < operator. lhs and rhs must
+// both be numbers or both be strings.
+func lessThan(lhs, rhs value.Value) bool {
+	res, err := transform.BinaryOp(lhs, token.LT, rhs)
+	return err == nil && res.Bool()
+}
@@ -0,0 +1,25 @@
+package stdlib
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash/fnv"
+)
+
+var hashing = map[string]any{
+	"sha256": sha256Hex,
+	"fnv":    fnv64a,
+}
+
+// sha256Hex returns the hex-encoded SHA-256 checksum of in.
+func sha256Hex(in string) string {
+	sum := sha256.Sum256([]byte(in))
+	return hex.EncodeToString(sum[:])
+}
+
+// fnv64a returns the 64-bit FNV-1a hash of in.
+func fnv64a(in string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(in))
+	return h.Sum64()
+}
@@ -0,0 +1,39 @@
+package stdlib
+
+import "regexp"
+
+// regexMatch reports whether the string s contains any match of the regular
+// expression pattern.
+func regexMatch(s string, pattern string) (bool, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return false, err
+	}
+	return re.MatchString(s), nil
+}
+
+// regexReplace replaces the matches of the regular expression pattern in s
+// with replacement. Inside replacement, $ signs are interpreted as in
+// regexp.Regexp.Expand, so $1 refers to the first capture group.
+func regexReplace(s string, pattern string, replacement string) (string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", err
+	}
+	return re.ReplaceAllString(s, replacement), nil
+}
+
+// regexExtract returns the leftmost match of the regular expression pattern
+// in s followed by the text of its capture groups. It returns an empty array
+// if there's no match.
+func regexExtract(s string, pattern string) ([]string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	match := re.FindStringSubmatch(s)
+	if match == nil {
+		return []string{}, nil
+	}
+	return match, nil
+}
@@ -22,8 +22,25 @@ import (
 // ExperimentalIdentifiers contains the full name (namespace + identifier's name) of stdlib
 // identifiers that are considered "experimental".
 var ExperimentalIdentifiers = map[string]bool{
-	"array.combine_maps": true,
-	"array.group_by":     true,
+	"array.combine_maps":   true,
+	"array.group_by":       true,
+	"array.distinct":       true,
+	"array.flatten":        true,
+	"array.contains":       true,
+	"array.sort":           true,
+	"array.map":            true,
+	"array.filter":         true,
+	"string.regex_match":   true,
+	"string.regex_replace": true,
+	"string.regex_extract": true,
+	"hash.sha256":          true,
+	"hash.fnv":             true,
+	"time.now":             true,
+	"time.format":          true,
+	"time.parse_duration":  true,
+	"map.keys":             true,
+	"map.values":           true,
+	"map.merge_deep":       true,
 }
 
 // DeprecatedIdentifiers are deprecated in favour of the namespaced ones.
@@ -64,6 +81,9 @@ var Identifiers = map[string]any{
 	"encoding": encoding,
 	"string":   str,
 	"file":     file,
+	"hash":     hashing,
+	"time":     datetime,
+	"map":      object,
 }
 
 func init() {
@@ -98,6 +118,10 @@ var str = map[string]any{
 	"trim_prefix": strings.TrimPrefix,
 	"trim_suffix": strings.TrimSuffix,
 	"trim_space":  strings.TrimSpace,
+
+	"regex_match":   regexMatch,
+	"regex_replace": regexReplace,
+	"regex_extract": regexExtract,
 }
 
 // groupBy takes an array of objects, a key to group by, and a boolean to determine
@@ -209,6 +233,12 @@ var array = map[string]any{
 	"concat":       concat,
 	"combine_maps": combineMaps,
 	"group_by":     groupBy,
+	"distinct":     distinct,
+	"flatten":      flatten,
+	"contains":     contains,
+	"sort":         sortArray,
+	"map":          mapArray,
+	"filter":       filterArray,
 }
 
 var convert = map[string]any{
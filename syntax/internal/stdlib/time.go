@@ -0,0 +1,34 @@
+package stdlib
+
+import "time"
+
+var datetime = map[string]any{
+	"now":            timeNow,
+	"format":         timeFormat,
+	"parse_duration": parseDuration,
+}
+
+// timeNow returns the current time in UTC as an RFC 3339 timestamp. The
+// timestamp is computed each time the expression calling it is evaluated.
+func timeNow() string {
+	return time.Now().UTC().Format(time.RFC3339Nano)
+}
+
+// timeFormat formats an RFC 3339 timestamp with a Go reference layout.
+func timeFormat(timestamp string, layout string) (string, error) {
+	t, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		return "", err
+	}
+	return t.Format(layout), nil
+}
+
+// parseDuration parses a Go duration string and returns it as a number of
+// seconds.
+func parseDuration(in string) (float64, error) {
+	d, err := time.ParseDuration(in)
+	if err != nil {
+		return 0, err
+	}
+	return d.Seconds(), nil
+}
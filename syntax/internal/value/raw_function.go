@@ -7,3 +7,15 @@ package value
 // The func value itself is provided as an argument so error types can be
 // filled.
 type RawFunction func(funcValue Value, args ...Value) (Value, error)
+
+// CallContext holds the state of the evaluation which calls a function.
+type CallContext struct {
+	// Depth is the number of user-defined function calls the caller is nested
+	// in.
+	Depth int
+}
+
+// ContextFunction is a RawFunction which also receives the CallContext of its
+// caller. Functions which call other functions use it to pass the context
+// along, so that the depth of nested calls can be limited.
+type ContextFunction func(ctx CallContext, funcValue Value, args ...Value) (Value, error)
@@ -29,6 +29,7 @@ var (
 	goAlloyDecoder    = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
 	goAlloyValidator  = reflect.TypeOf((*Validator)(nil)).Elem()
 	goRawAlloyFunc    = reflect.TypeOf((RawFunction)(nil))
+	goContextFunc     = reflect.TypeOf((ContextFunction)(nil))
 	goAlloyValue      = reflect.TypeOf(Null)
 )
 
@@ -421,12 +422,21 @@ func (v Value) Key(key string) (index Value, ok bool) {
 // will be returned if the function call returns an error or if the number of
 // arguments doesn't match
 func (v Value) Call(args ...Value) (Value, error) {
+	return v.CallWithContext(CallContext{}, args...)
+}
+
+// CallWithContext is like Call, but passes ctx to the function if it's a
+// ContextFunction.
+func (v Value) CallWithContext(ctx CallContext, args ...Value) (Value, error) {
 	if v.ty != TypeFunction {
 		panic("syntax/value: Call called on non-function type")
 	}
 
-	if v.rv.Type() == goRawAlloyFunc {
+	switch v.rv.Type() {
+	case goRawAlloyFunc:
 		return v.rv.Interface().(RawFunction)(v, args...)
+	case goContextFunc:
+		return v.rv.Interface().(ContextFunction)(ctx, v, args...)
 	}
 
 	var (
@@ -142,7 +142,13 @@ func (p *parser) expect(t token.Token) (pos token.Pos, tok token.Token, lit stri
 }
 
 func (p *parser) addErrorf(format string, args ...any) {
-	pos := p.file.PositionFor(p.pos)
+	p.addErrorAtf(p.pos, format, args...)
+}
+
+// addErrorAtf is like addErrorf but reports the error at the provided
+// position instead of the position of the current token.
+func (p *parser) addErrorAtf(at token.Pos, format string, args ...any) {
+	pos := p.file.PositionFor(at)
 
 	// Ignore errors which occur on the same line.
 	if p.lastError.Line == pos.Line {
@@ -569,13 +575,18 @@ func isUnaryOp(tok token.Token) bool {
 
 // parsePrimaryExpr parses a primary expression.
 //
-//	PrimaryExpr = LiteralValue | ArrayExpr | ObjectExpr
+//	PrimaryExpr = LiteralValue | ArrayExpr | ObjectExpr | LambdaExpr
 //
 //	LiteralValue = identifier | string | number | float | bool | null |
 //	               "(" Expression ")"
 //
 //	ArrayExpr  = "[" [ ExpressionList ] "]"
 //	ObjectExpr = "{" [ FieldList ] "}"
+//	LambdaExpr = "(" [ ParamList ] ")" "=>" Expression
+//
+// Parenthesized expressions and lambdas can't be told apart until the token
+// following the closing parenthesis is known, so both are handled by
+// parseParenOrLambdaExpr.
 func (p *parser) parsePrimaryExpr() ast.Expr {
 	switch p.tok {
 	case token.IDENT:
@@ -598,15 +609,7 @@ func (p *parser) parsePrimaryExpr() ast.Expr {
 		return res
 
 	case token.LPAREN:
-		lParen, _, _ := p.expect(token.LPAREN)
-		expr := p.ParseExpression()
-		rParen, _, _ := p.expect(token.RPAREN)
-
-		return &ast.ParenExpr{
-			LParenPos: lParen,
-			Inner:     expr,
-			RParenPos: rParen,
-		}
+		return p.parseParenOrLambdaExpr()
 
 	case token.LBRACK:
 		var res ast.ArrayExpr
@@ -635,6 +638,87 @@ func (p *parser) parsePrimaryExpr() ast.Expr {
 	return res
 }
 
+// parseParenOrLambdaExpr parses either an expression wrapped in parentheses
+// or a lambda expression.
+//
+//	ParenExpr  = "(" Expression ")"
+//	LambdaExpr = "(" [ ParamList ] ")" "=>" Expression
+//	ParamList  = identifier { "," identifier } [ "," ]
+func (p *parser) parseParenOrLambdaExpr() ast.Expr {
+	lParen, _, _ := p.expect(token.LPAREN)
+
+	// Empty parentheses are only valid for lambdas; the error is reported
+	// below if there's no arrow.
+	var exprs []ast.Expr
+	if p.tok != token.RPAREN {
+		exprs = append(exprs, p.ParseExpression())
+	}
+
+	if p.tok == token.COMMA {
+		// Only lambdas may have more than one expression between parentheses.
+		p.next()
+		if p.tok != token.RPAREN {
+			exprs = append(exprs, p.parseExpressionList(token.RPAREN)...)
+		}
+		rParen, _, _ := p.expect(token.RPAREN)
+		if p.tok != token.ARROW {
+			p.addErrorf("expected %s, got %s", token.ARROW, p.tok)
+			return &ast.LiteralExpr{Kind: token.NULL, Value: "null", ValuePos: lParen}
+		}
+		arrow := p.pos
+		p.next() // Consume =>
+		return p.parseLambdaBody(lParen, exprs, rParen, arrow)
+	}
+
+	rParen, tok, _ := p.expect(token.RPAREN)
+	if p.tok == token.ARROW {
+		arrow := p.pos
+		p.next() // Consume =>
+		return p.parseLambdaBody(lParen, exprs, rParen, arrow)
+	}
+
+	if len(exprs) == 0 {
+		if tok == token.RPAREN {
+			p.addErrorAtf(rParen, "expected expression, got %s", token.RPAREN)
+		}
+		return &ast.LiteralExpr{Kind: token.NULL, Value: "null", ValuePos: rParen}
+	}
+	return &ast.ParenExpr{
+		LParenPos: lParen,
+		Inner:     exprs[0],
+		RParenPos: rParen,
+	}
+}
+
+// parseLambdaBody parses the body of a lambda expression after its arrow. The
+// expressions found between the parentheses of the lambda are converted into
+// parameters.
+func (p *parser) parseLambdaBody(lParen token.Pos, params []ast.Expr, rParen, arrow token.Pos) ast.Expr {
+	res := &ast.LambdaExpr{
+		LParenPos: lParen,
+		RParenPos: rParen,
+		ArrowPos:  arrow,
+	}
+
+	seen := make(map[string]struct{}, len(params))
+	for _, param := range params {
+		ident, ok := param.(*ast.IdentifierExpr)
+		if !ok {
+			p.addErrorAtf(ast.StartPos(param), "expected parameter name, got expression")
+			continue
+		}
+		if _, dup := seen[ident.Ident.Name]; dup {
+			p.addErrorAtf(ident.Ident.NamePos, "duplicate parameter %q", ident.Ident.Name)
+			continue
+		}
+		seen[ident.Ident.Name] = struct{}{}
+		res.Params = append(res.Params, ident.Ident)
+	}
+
+	res.Body = p.ParseExpression()
+	return res
+}
+
 var statementEnd = map[token.Token]struct{}{
 	token.TERMINATOR: {},
 	token.RPAREN:     {},
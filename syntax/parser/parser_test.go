@@ -108,6 +108,14 @@ func TestParseExpressions(t *testing.T) {
 
 		"parens": `(1 + 5) * 100`,
 
+		"lambda no params":       `() => 5`,
+		"lambda one param":       `(x) => x + 1`,
+		"lambda many params":     `(x, y) => x * y`,
+		"lambda trailing comma":  `(x, y,) => x * y`,
+		"lambda as argument":     `array.map(list, (t) => t["__address__"])`,
+		"lambda nested":          `(x) => (y) => x + y`,
+		"lambda called directly": `((x) => x * 2)(5)`,
+
 		"mixed expression": `(a.b.c)(1, 3 * some_list[magic_index * 2]).resulting_field`,
 	}
 
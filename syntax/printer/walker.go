@@ -179,6 +179,17 @@ func (w *walker) walkExpr(e ast.Expr) {
 		w.p.Write(token.LPAREN)
 		w.walkExpr(e.Inner)
 		w.p.Write(token.RPAREN)
+
+	case *ast.LambdaExpr:
+		w.p.Write(e.LParenPos, token.LPAREN)
+		for i, param := range e.Params {
+			if i > 0 {
+				w.p.Write(token.COMMA, wsBlank)
+			}
+			w.p.Write(param.NamePos, param)
+		}
+		w.p.Write(e.RParenPos, token.RPAREN, wsBlank, e.ArrowPos, token.ARROW, wsBlank)
+		w.walkExpr(e.Body)
 	}
 }
 
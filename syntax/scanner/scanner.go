@@ -51,6 +51,7 @@ import (
 //   RBRACK  = "]"
 //   COMMA   = ","
 //   DOT     = "."
+//   ARROW   = "=>"
 //
 // The EBNF for escape_sequence is currently undocumented; see scanEscape for
 // details. The escape sequences supported by Alloy are the same as the escape
@@ -275,8 +276,13 @@ scanAgain:
 
 		case '!': // !, !=
 			tok = s.switch2(token.NOT, token.NEQ, '=')
-		case '=': // =, ==
-			tok = s.switch2(token.ASSIGN, token.EQ, '=')
+		case '=': // =, ==, =>
+			if s.ch == '>' {
+				s.next() // consume '>'
+				tok = token.ARROW
+			} else {
+				tok = s.switch2(token.ASSIGN, token.EQ, '=')
+			}
 		case '<': // <, <=
 			tok = s.switch2(token.LT, token.LTE, '=')
 		case '>': // >, >=
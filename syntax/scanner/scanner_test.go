@@ -69,6 +69,7 @@ var tokens = []tokenExample{
 	{token.LCURLY, "{"},
 	{token.COMMA, ","},
 	{token.DOT, "."},
+	{token.ARROW, "=>"},
 
 	{token.RPAREN, ")"},
 	{token.RBRACK, "]"},
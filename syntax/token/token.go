@@ -57,6 +57,7 @@ const (
 	RBRACK // ]
 	COMMA  // ,
 	DOT    // .
+	ARROW  // =>
 	operatorEnd
 
 	TERMINATOR // \n
@@ -102,6 +103,7 @@ var tokenNames = [...]string{
 	RBRACK: "]",
 	COMMA:  ",",
 	DOT:    ".",
+	ARROW:  "=>",
 
 	TERMINATOR: "TERMINATOR",
 }
@@ -0,0 +1,111 @@
+package typecheck
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/diag"
+	"github.com/grafana/alloy/syntax/internal/transform"
+	"github.com/grafana/alloy/syntax/vm"
+)
+
+// Function checks that b is a valid function block. Besides the structure of
+// the block, Function checks that every identifier used in the result
+// expression is either a parameter of the function or can be found in scope.
+// scope may be nil, in which case only the stdlib is available.
+//
+// Operations between literals are checked as well, so that errors such as
+// adding a string to a number are reported without calling the function.
+func Function(b *ast.BlockStmt, scope *vm.Scope) diag.Diagnostics {
+	fn, err := vm.NewFunction(b, nil)
+	if err != nil {
+		var diags diag.Diagnostics
+		if errors.As(err, &diags) {
+			return diags
+		}
+		return diag.Diagnostics{{
+			Severity: diag.SeverityLevelError,
+			StartPos: ast.StartPos(b).Position(),
+			EndPos:   ast.EndPos(b).Position(),
+			Message:  err.Error(),
+		}}
+	}
+
+	params := make(map[string]struct{}, len(fn.Params()))
+	for _, p := range fn.Params() {
+		params[p] = struct{}{}
+	}
+
+	fw := &functionWalker{name: fn.Name(), params: params, scope: scope}
+	ast.Walk(fw, fn.Result())
+	return fw.diags
+}
+
+type functionWalker struct {
+	name   string
+	params map[string]struct{}
+	scope  *vm.Scope
+	diags  diag.Diagnostics
+}
+
+func (fw *functionWalker) Visit(node ast.Node) ast.Visitor {
+	switch n := node.(type) {
+	case *ast.IdentifierExpr:
+		if _, ok := fw.params[n.Ident.Name]; ok {
+			return nil
+		}
+		if _, ok := fw.scope.Lookup(n.Ident.Name); ok {
+			return nil
+		}
+		fw.diags.Add(diag.Diagnostic{
+			Severity: diag.SeverityLevelError,
+			StartPos: ast.StartPos(n).Position(),
+			EndPos:   ast.EndPos(n).Position(),
+			Message:  fmt.Sprintf("identifier %q is neither a parameter of function %q nor a function in scope", n.Ident.Name, fw.name),
+		})
+		return nil
+
+	case *ast.LambdaExpr:
+		// Lambda parameters are only in scope of the lambda body.
+		params := maps.Clone(fw.params)
+		for _, p := range n.Params {
+			params[p.Name] = struct{}{}
+		}
+		lw := &functionWalker{name: fw.name, params: params, scope: fw.scope}
+		ast.Walk(lw, n.Body)
+		fw.diags.Merge(lw.diags)
+		return nil
+
+	case *ast.BinaryExpr:
+		lhs, lok := n.Left.(*ast.LiteralExpr)
+		rhs, rok := n.Right.(*ast.LiteralExpr)
+		if lok && rok {
+			if _, err := transform.BinaryOp(valueFromLiteralExpr(lhs), n.Kind, valueFromLiteralExpr(rhs)); err != nil {
+				fw.diags.Add(diag.Diagnostic{
+					Severity: diag.SeverityLevelError,
+					StartPos: ast.StartPos(lhs).Position(),
+					EndPos:   ast.EndPos(rhs).Position(),
+					Message:  err.Error(),
+				})
+			}
+			return nil
+		}
+
+	case *ast.UnaryExpr:
+		if v, ok := n.Value.(*ast.LiteralExpr); ok {
+			if _, err := transform.UnaryOp(n.Kind, valueFromLiteralExpr(v)); err != nil {
+				fw.diags.Add(diag.Diagnostic{
+					Severity: diag.SeverityLevelError,
+					StartPos: ast.StartPos(v).Position(),
+					EndPos:   ast.EndPos(v).Position(),
+					Message:  err.Error(),
+				})
+			}
+			return nil
+		}
+	}
+
+	return fw
+}
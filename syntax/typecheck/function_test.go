@@ -0,0 +1,113 @@
+package typecheck
+
+import (
+	"testing"
+
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/parser"
+	"github.com/grafana/alloy/syntax/vm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFunction(t *testing.T) {
+	scope := vm.NewScope(map[string]any{
+		"double": func(v int) int { return v * 2 },
+		"utils":  map[string]any{"triple": func(v int) int { return v * 3 }},
+	})
+
+	type testCase struct {
+		desc   string
+		src    string
+		expect string
+	}
+
+	tests := []testCase{
+		{
+			desc: "valid function",
+			src: `
+				function "f" {
+					args   = ["a", "b"]
+					result = string.format("%d-%d", double(a), utils.triple(b))
+				}
+			`,
+		},
+		{
+			desc: "function without parameters",
+			src: `
+				function "f" {
+					result = 42
+				}
+			`,
+		},
+		{
+			desc: "parameters shadow the scope",
+			src: `
+				function "f" {
+					args   = ["double"]
+					result = double + 1
+				}
+			`,
+		},
+		{
+			desc: "unknown identifier",
+			src: `
+				function "f" {
+					args   = ["a"]
+					result = a + b
+				}
+			`,
+			expect: `identifier "b" is neither a parameter of function "f" nor a function in scope`,
+		},
+		{
+			desc: "lambda parameters",
+			src: `
+				function "f" {
+					args   = ["list", "offset"]
+					result = array.map(list, (x) => double(x) + offset)
+				}
+			`,
+		},
+		{
+			desc: "lambda parameter used outside of the lambda",
+			src: `
+				function "f" {
+					args   = ["list"]
+					result = array.concat(array.map(list, (x) => x), [x])
+				}
+			`,
+			expect: `identifier "x" is neither a parameter of function "f" nor a function in scope`,
+		},
+		{
+			desc: "invalid literal operation",
+			src: `
+				function "f" {
+					result = 1 + "a"
+				}
+			`,
+			expect: `expected number, got string`,
+		},
+		{
+			desc: "invalid block",
+			src: `
+				function "f" {
+					args = ["a"]
+				}
+			`,
+			expect: `missing required attribute "result"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.desc, func(t *testing.T) {
+			file, err := parser.ParseFile("", []byte(tt.src))
+			require.NoError(t, err)
+
+			diags := Function(file.Body[0].(*ast.BlockStmt), scope)
+			if tt.expect == "" {
+				require.Len(t, diags, 0, "unexpected diagnostics: %s", diags)
+			} else {
+				require.ErrorContains(t, diags, tt.expect)
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/diag"
+	"github.com/grafana/alloy/syntax/internal/transform"
+	"github.com/grafana/alloy/syntax/internal/value"
+	"github.com/grafana/alloy/syntax/scanner"
+	"github.com/grafana/alloy/syntax/token"
+)
+
+// maxCallDepth is the maximum number of nested user-defined function calls.
+// Deeper calls fail, so that unbounded recursion is reported as an error
+// instead of overflowing the stack.
+const maxCallDepth = 1000
+
+// errMaxCallDepth is returned by calls exceeding maxCallDepth.
+var errMaxCallDepth = fmt.Errorf("maximum call depth of %d exceeded", maxCallDepth)
+
+// Attribute names of a function block.
+const (
+	FunctionArgsAttr   = "args"
+	FunctionResultAttr = "result"
+)
+
+// Function is a user-defined function, declared with a block of the form:
+//
+//	function "add" {
+//	  args   = ["a", "b"]
+//	  result = a + b
+//	}
+//
+// The block label is the name of the function, args lists the names of its
+// parameters, and result is the expression returned by the function.
+//
+// Functions are also created for lambda expressions such as (a, b) => a + b,
+// in which case the Function has no name.
+//
+// Calling a Function evaluates result with the scope the Function was created
+// with, extended with the call arguments bound to the parameter names.
+type Function struct {
+	name   string
+	params []string
+	result ast.Expr
+	scope  *Scope
+}
+
+// NewFunction creates a Function from a function block. scope provides the
+// variables available to the result expression in addition to the
+// parameters and may be nil. scope must not be modified after the first call
+// to the Function.
+//
+// The returned error is a diag.Diagnostics if b is not a valid function block.
+func NewFunction(b *ast.BlockStmt, scope *Scope) (*Function, error) {
+	var (
+		diags diag.Diagnostics
+		fn    = &Function{name: b.Label, scope: scope}
+
+		argsAttr *ast.AttributeStmt
+	)
+
+	if b.Label == "" {
+		diags.Add(diag.Diagnostic{
+			Severity: diag.SeverityLevelError,
+			StartPos: b.NamePos.Position(),
+			EndPos:   b.LCurlyPos.Position(),
+			Message:  fmt.Sprintf("block %q requires a label", b.GetBlockName()),
+		})
+	} else if !scanner.IsValidIdentifier(b.Label) {
+		diags.Add(diag.Diagnostic{
+			Severity: diag.SeverityLevelError,
+			StartPos: b.LabelPos.Position(),
+			EndPos:   b.LabelPos.Add(len(b.Label) + 1).Position(),
+			Message:  fmt.Sprintf("function name %q must be a valid identifier", b.Label),
+		})
+	}
+
+	for _, stmt := range b.Body {
+		attr, ok := stmt.(*ast.AttributeStmt)
+		if !ok {
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				StartPos: ast.StartPos(stmt).Position(),
+				EndPos:   ast.EndPos(stmt).Position(),
+				Message:  "function blocks may only contain attributes",
+			})
+			continue
+		}
+
+		switch attr.Name.Name {
+		case FunctionArgsAttr:
+			if argsAttr != nil {
+				diags.Add(duplicateAttr(attr))
+				continue
+			}
+			argsAttr = attr
+		case FunctionResultAttr:
+			if fn.result != nil {
+				diags.Add(duplicateAttr(attr))
+				continue
+			}
+			fn.result = attr.Value
+		default:
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				StartPos: ast.StartPos(attr).Position(),
+				EndPos:   ast.EndPos(attr).Position(),
+				Message:  fmt.Sprintf("unrecognized attribute name %q", attr.Name.Name),
+			})
+		}
+	}
+
+	if fn.result == nil {
+		diags.Add(diag.Diagnostic{
+			Severity: diag.SeverityLevelError,
+			StartPos: ast.StartPos(b).Position(),
+			EndPos:   ast.EndPos(b).Position(),
+			Message:  fmt.Sprintf("missing required attribute %q", FunctionResultAttr),
+		})
+	}
+
+	if argsAttr != nil {
+		params, paramDiags := functionParams(argsAttr.Value)
+		diags.Merge(paramDiags)
+		fn.params = params
+	}
+
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return fn, nil
+}
+
+// newLambda creates an anonymous Function from a lambda expression. The
+// lambda captures scope, the scope it's declared in.
+func newLambda(expr *ast.LambdaExpr, scope *Scope) *Function {
+	params := make([]string, len(expr.Params))
+	for i, param := range expr.Params {
+		params[i] = param.Name
+	}
+	return &Function{params: params, result: expr.Body, scope: scope}
+}
+
+// functionParams returns the parameter names listed in the args attribute of
+// a function block. Parameter names must be string literals so that they can
+// be known without evaluating anything.
+func functionParams(expr ast.Expr) ([]string, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	arr, ok := expr.(*ast.ArrayExpr)
+	if !ok {
+		diags.Add(diag.Diagnostic{
+			Severity: diag.SeverityLevelError,
+			StartPos: ast.StartPos(expr).Position(),
+			EndPos:   ast.EndPos(expr).Position(),
+			Message:  fmt.Sprintf("%q must be an array of strings", FunctionArgsAttr),
+		})
+		return nil, diags
+	}
+
+	var (
+		params = make([]string, 0, len(arr.Elements))
+		seen   = make(map[string]struct{}, len(arr.Elements))
+	)
+	for _, elem := range arr.Elements {
+		lit, ok := elem.(*ast.LiteralExpr)
+		if !ok || lit.Kind != token.STRING {
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				StartPos: ast.StartPos(elem).Position(),
+				EndPos:   ast.EndPos(elem).Position(),
+				Message:  "function parameter names must be string literals",
+			})
+			continue
+		}
+
+		val, err := transform.ValueFromLiteral(lit.Value, lit.Kind)
+		if err != nil {
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				StartPos: ast.StartPos(elem).Position(),
+				EndPos:   ast.EndPos(elem).Position(),
+				Message:  err.Error(),
+			})
+			continue
+		}
+
+		name := val.Text()
+		switch _, dup := seen[name]; {
+		case !scanner.IsValidIdentifier(name):
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				StartPos: ast.StartPos(elem).Position(),
+				EndPos:   ast.EndPos(elem).Position(),
+				Message:  fmt.Sprintf("function parameter name %q must be a valid identifier", name),
+			})
+		case dup:
+			diags.Add(diag.Diagnostic{
+				Severity: diag.SeverityLevelError,
+				StartPos: ast.StartPos(elem).Position(),
+				EndPos:   ast.EndPos(elem).Position(),
+				Message:  fmt.Sprintf("function parameter %q may only be provided once", name),
+			})
+		default:
+			seen[name] = struct{}{}
+			params = append(params, name)
+		}
+	}
+
+	return params, diags
+}
+
+func duplicateAttr(attr *ast.AttributeStmt) diag.Diagnostic {
+	return diag.Diagnostic{
+		Severity: diag.SeverityLevelError,
+		StartPos: ast.StartPos(attr).Position(),
+		EndPos:   ast.EndPos(attr).Position(),
+		Message:  fmt.Sprintf("attribute %q may only be provided once", attr.Name.Name),
+	}
+}
+
+// Name returns the name of the function.
+func (fn *Function) Name() string { return fn.name }
+
+// Params returns the names of the function parameters.
+func (fn *Function) Params() []string { return fn.params }
+
+// Result returns the expression evaluated when calling the function.
+func (fn *Function) Result() ast.Expr { return fn.result }
+
+// Value returns a callable value for fn which can be exposed to expressions
+// through the Variables of a Scope.
+func (fn *Function) Value() any {
+	return value.ContextFunction(fn.call)
+}
+
+func (fn *Function) call(ctx value.CallContext, funcValue value.Value, args ...value.Value) (value.Value, error) {
+	if ctx.Depth >= maxCallDepth {
+		return value.Null, errMaxCallDepth
+	}
+	if len(args) != len(fn.params) {
+		return value.Null, value.Error{
+			Value: funcValue,
+			Inner: fmt.Errorf("expected %d args, got %d", len(fn.params), len(args)),
+		}
+	}
+
+	// Parameters are held by a child of the scope of fn, so that calls don't
+	// copy the variables of the scope.
+	variables := make(map[string]any, len(args))
+	for i, param := range fn.params {
+		variables[param] = args[i]
+	}
+
+	var (
+		vm    = New(fn.result)
+		scope = &Scope{Variables: variables, parent: fn.scope, depth: ctx.Depth + 1}
+		assoc = make(map[value.Value]ast.Node)
+	)
+	res, err := vm.evaluateExpr(scope, assoc, fn.result)
+	if err != nil {
+		// Only the outermost call reports exceeding the maximum depth, rather
+		// than every nested call wrapping the error.
+		if errors.Is(err, errMaxCallDepth) && ctx.Depth > 0 {
+			return value.Null, err
+		}
+		desc := "lambda"
+		if fn.name != "" {
+			desc = fmt.Sprintf("function %q", fn.name)
+		}
+		return value.Null, value.Error{
+			Value: funcValue,
+			Inner: fmt.Errorf("calling %s: %w", desc, makeDiagnostic(err, assoc)),
+		}
+	}
+	return res, nil
+}
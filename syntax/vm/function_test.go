@@ -0,0 +1,287 @@
+package vm_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/grafana/alloy/syntax"
+	"github.com/grafana/alloy/syntax/alloytypes"
+	"github.com/grafana/alloy/syntax/ast"
+	"github.com/grafana/alloy/syntax/parser"
+	"github.com/grafana/alloy/syntax/vm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFunction(t *testing.T) {
+	double := parseFunction(t, `
+		function "double" {
+			args   = ["x"]
+			result = x * 2
+		}
+	`)
+	doubleFn, err := vm.NewFunction(double, nil)
+	require.NoError(t, err)
+	require.Equal(t, "double", doubleFn.Name())
+	require.Equal(t, []string{"x"}, doubleFn.Params())
+
+	labels := parseFunction(t, `
+		function "labels" {
+			args   = ["name", "value"]
+			result = {"name" = string.to_upper(name), "value" = double(value)}
+		}
+	`)
+	labelsFn, err := vm.NewFunction(labels, vm.NewScope(map[string]any{
+		"double": doubleFn.Value(),
+	}))
+	require.NoError(t, err)
+
+	scope := vm.NewScope(map[string]any{
+		"double": doubleFn.Value(),
+		"labels": labelsFn.Value(),
+		"utils":  map[string]any{"double": doubleFn.Value()},
+	})
+
+	tt := []struct {
+		name   string
+		input  string
+		expect any
+	}{
+		{"call", `double(21)`, 42},
+		{"namespaced call", `utils.double(1.5)`, 3.0},
+		{"nested calls", `double(double(2))`, 8},
+		{"function calling function", `labels("job", 3)`, map[string]any{"name": "JOB", "value": 6}},
+		{"function as argument", `array.concat([double(1)], [double(2)])`, []any{2, 4}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := parser.ParseExpression(tc.input)
+			require.NoError(t, err)
+
+			var actual any
+			require.NoError(t, vm.New(expr).Evaluate(scope, &actual))
+			require.EqualValues(t, tc.expect, actual)
+		})
+	}
+}
+
+func TestFunction_CallErrors(t *testing.T) {
+	fn, err := vm.NewFunction(parseFunction(t, `
+		function "add" {
+			args   = ["a", "b"]
+			result = a + b
+		}
+	`), nil)
+	require.NoError(t, err)
+	scope := vm.NewScope(map[string]any{"add": fn.Value()})
+
+	tt := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{"too few arguments", `add(1)`, `expected 2 args, got 1`},
+		{"too many arguments", `add(1, 2, 3)`, `expected 2 args, got 3`},
+		{"invalid argument types", `add(1, "a")`, `calling function "add"`},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := parser.ParseExpression(tc.input)
+			require.NoError(t, err)
+
+			var actual any
+			err = vm.New(expr).Evaluate(scope, &actual)
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestFunction_Pure(t *testing.T) {
+	// Variables which are not part of the function scope can't be used, even
+	// if they're available where the function is called.
+	fn, err := vm.NewFunction(parseFunction(t, `
+		function "get" {
+			result = outside
+		}
+	`), nil)
+	require.NoError(t, err)
+
+	expr, err := parser.ParseExpression(`get()`)
+	require.NoError(t, err)
+
+	var actual any
+	err = vm.New(expr).Evaluate(vm.NewScope(map[string]any{
+		"get":     fn.Value(),
+		"outside": "value",
+	}), &actual)
+	require.ErrorContains(t, err, `identifier "outside" does not exist`)
+}
+
+func TestFunction_Recursion(t *testing.T) {
+	// Functions a and b call each other, so they share a scope which is
+	// populated before they're called.
+	vars := map[string]any{}
+	scope := vm.NewScope(vars)
+	a, err := vm.NewFunction(parseFunction(t, `
+		function "a" {
+			args   = ["n"]
+			result = b(n + 1)
+		}
+	`), scope)
+	require.NoError(t, err)
+	b, err := vm.NewFunction(parseFunction(t, `
+		function "b" {
+			args   = ["n"]
+			result = {"value" = a(n)}
+		}
+	`), scope)
+	require.NoError(t, err)
+	vars["a"] = a.Value()
+	vars["b"] = b.Value()
+
+	tt := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{"self application", `((f) => f(f))((f) => f(f))`, `1:1: ((f) => f(f)) calling lambda: maximum call depth of 1000 exceeded`},
+		{"mutual recursion", `a(0)`, `1:1: a calling function "a": maximum call depth of 1000 exceeded`},
+		{"recursion through map", `((f) => f(f))((f) => array.map([f], f))`, `1:1: ((f) => f(f)) calling lambda: maximum call depth of 1000 exceeded`},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := parser.ParseExpression(tc.input)
+			require.NoError(t, err)
+
+			var actual any
+			err = vm.New(expr).Evaluate(scope, &actual)
+			require.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestNewFunction_Invalid(t *testing.T) {
+	tt := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{"missing label", "function {\n result = 1\n}", `block "function" requires a label`},
+		{"missing result", "function \"f\" {\n args = []\n}", `missing required attribute "result"`},
+		{"unknown attribute", "function \"f\" {\n result = 1\n value = 2\n}", `unrecognized attribute name "value"`},
+		{"nested block", "function \"f\" {\n result = 1\n inner {}\n}", `function blocks may only contain attributes`},
+		{"duplicate attribute", "function \"f\" {\n result = 1\n result = 2\n}", `attribute "result" may only be provided once`},
+		{"args not an array", "function \"f\" {\n args = \"a\"\n result = 1\n}", `"args" must be an array of strings`},
+		{"args not literals", "function \"f\" {\n args = [a]\n result = 1\n}", `function parameter names must be string literals`},
+		{"invalid parameter name", "function \"f\" {\n args = [\"a-b\"]\n result = 1\n}", `function parameter name "a-b" must be a valid identifier`},
+		{"duplicate parameter", "function \"f\" {\n args = [\"a\", \"a\"]\n result = a\n}", `function parameter "a" may only be provided once`},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := vm.NewFunction(parseFunction(t, tc.input), nil)
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestLambda(t *testing.T) {
+	scope := vm.NewScope(map[string]any{
+		"offset": 10,
+		"secret": alloytypes.Secret("foo"),
+		"targets": []capsuleTarget{
+			{name: "a", namespace: "prod"},
+			{name: "b", namespace: "dev"},
+		},
+	})
+
+	tt := []struct {
+		name   string
+		input  string
+		expect any
+	}{
+		{"direct call", `((x) => x * 2)(21)`, 42},
+		{"no parameters", `(() => "hello")()`, "hello"},
+		{"several parameters", `((a, b) => a + b)(1, 2)`, 3},
+		{"captures scope", `((x) => x + offset)(1)`, 11},
+		{"parameters shadow scope", `((offset) => offset)(1)`, 1},
+		{"closure", `((x) => (y) => x + y)(1)(2)`, 3},
+		{"nested closures", `((x) => (y) => (x) => x + y + offset)(1)(2)(3)`, 15},
+		{"map", `array.map([1, 2, 3], (x) => x * offset)`, []int{10, 20, 30}},
+		{"filter", `array.filter([1, 2, 3, 4], (x) => x % 2 == 0)`, []int{2, 4}},
+		{"map capsules", `array.map(targets, (t) => t.name)`, []string{"a", "b"}},
+		{"filter capsules", `array.map(array.filter(targets, (t) => t["namespace"] == "prod"), (t) => t.name)`, []string{"a"}},
+		{"map secrets", `array.map([secret], (s) => s)`, []alloytypes.Secret{"foo"}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := parser.ParseExpression(tc.input)
+			require.NoError(t, err)
+
+			rv := reflect.New(reflect.TypeOf(tc.expect))
+			require.NoError(t, vm.New(expr).Evaluate(scope, rv.Interface()))
+			require.Equal(t, tc.expect, rv.Elem().Interface())
+		})
+	}
+}
+
+func TestLambda_Errors(t *testing.T) {
+	scope := vm.NewScope(map[string]any{
+		"secret": alloytypes.Secret("foo"),
+	})
+
+	tt := []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{"wrong number of arguments", `((x) => x)(1, 2)`, `expected 1 args, got 2`},
+		{"error in body", `((x) => x + "a")(1)`, `calling lambda`},
+		{"unknown identifier", `((x) => y)(1)`, `identifier "y" does not exist`},
+		{"parameter out of scope", `array.concat(array.map([1], (x) => x), [x])`, `identifier "x" does not exist`},
+		{"secret stays secret", `array.map([secret], (s) => s + "bar")`, `secrets may not be converted into strings`},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := parser.ParseExpression(tc.input)
+			require.NoError(t, err)
+
+			var actual []string
+			err = vm.New(expr).Evaluate(scope, &actual)
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+// capsuleTarget is a capsule which can be converted into an object, such as
+// the targets exported by discovery components.
+type capsuleTarget struct {
+	name, namespace string
+}
+
+func (c capsuleTarget) AlloyCapsule() {}
+
+func (c capsuleTarget) ConvertInto(dst any) error {
+	if dst, ok := dst.(*map[string]syntax.Value); ok {
+		*dst = map[string]syntax.Value{
+			"name":      syntax.ValueFromString(c.name),
+			"namespace": syntax.ValueFromString(c.namespace),
+		}
+		return nil
+	}
+	return fmt.Errorf("capsuleTarget: conversion to '%T' is not supported", dst)
+}
+
+func parseFunction(t *testing.T, input string) *ast.BlockStmt {
+	t.Helper()
+
+	f, err := parser.ParseFile(t.Name(), []byte(input))
+	require.NoError(t, err)
+	require.Len(t, f.Body, 1)
+	return f.Body[0].(*ast.BlockStmt)
+}
@@ -462,6 +462,9 @@ func (vm *Evaluator) evaluateExpr(scope *Scope, assoc map[value.Value]ast.Node,
 	case *ast.ParenExpr:
 		return vm.evaluateExpr(scope, assoc, expr.Inner)
 
+	case *ast.LambdaExpr:
+		return value.Encode(newLambda(expr, scope).Value()), nil
+
 	case *ast.UnaryExpr:
 		val, err := vm.evaluateExpr(scope, assoc, expr.Value)
 		if err != nil {
@@ -484,7 +487,7 @@ func (vm *Evaluator) evaluateExpr(scope *Scope, assoc map[value.Value]ast.Node,
 				return value.Null, err
 			}
 		}
-		return funcVal.Call(args...)
+		return funcVal.CallWithContext(value.CallContext{Depth: scope.callDepth()}, args...)
 
 	default:
 		panic(fmt.Sprintf("syntax/vm: unexpected ast.Expr type %T", expr))
@@ -500,6 +503,14 @@ type Scope struct {
 	// Evaluate; maps and slices will be copied by reference for performance
 	// optimizations.
 	Variables map[string]any
+
+	// parent is the scope looked up for names which aren't in Variables, if
+	// any.
+	parent *Scope
+
+	// depth is the number of user-defined function calls the scope is nested
+	// in.
+	depth int
 }
 
 func NewScope(variables map[string]any) *Scope {
@@ -508,10 +519,19 @@ func NewScope(variables map[string]any) *Scope {
 	}
 }
 
-// Lookup looks up a named identifier from the scope and the stdlib.
+// callDepth returns the number of user-defined function calls s is nested in.
+func (s *Scope) callDepth() int {
+	if s == nil {
+		return 0
+	}
+	return s.depth
+}
+
+// Lookup looks up a named identifier from the scope, its parents and the
+// stdlib.
 func (s *Scope) Lookup(name string) (any, bool) {
 	// Check the scope first.
-	if s != nil {
+	for ; s != nil; s = s.parent {
 		if val, ok := s.Variables[name]; ok {
 			return val, true
 		}
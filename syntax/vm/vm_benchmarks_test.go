@@ -102,3 +102,22 @@ func BenchmarkExprs(b *testing.B) {
 		})
 	}
 }
+
+func BenchmarkFunctionCall(b *testing.B) {
+	// Calls shouldn't get slower as the scope of the function grows.
+	vars := make(map[string]any, 10000)
+	for i := 0; i < 10000; i++ {
+		vars[fmt.Sprintf("var_%d", i)] = i
+	}
+	scope := vm.NewScope(vars)
+
+	expr, err := parser.ParseExpression(`array.map([0, 1, 2, 3, 4, 5, 6, 7, 8, 9], (x) => x + var_1)`)
+	require.NoError(b, err)
+	eval := vm.New(expr)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var actual []int
+		_ = eval.Evaluate(scope, &actual)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/grafana/alloy/syntax/alloytypes"
 	"github.com/grafana/alloy/syntax/internal/value"
@@ -199,6 +200,61 @@ func TestVM_Stdlib_Errors(t *testing.T) {
 			`encoding.to_json(12)`,
 			`encoding.to_json jsonEncode only supports map`,
 		},
+		{
+			"string.regex_match",
+			`string.regex_match("a", "(")`,
+			"error parsing regexp: missing closing ): `(`",
+		},
+		{
+			"time.format",
+			`time.format("yesterday", "2006")`,
+			`cannot parse "yesterday"`,
+		},
+		{
+			"time.parse_duration",
+			`time.parse_duration("1 minute")`,
+			`time: unknown unit " minute"`,
+		},
+		{
+			"array.distinct",
+			`array.distinct("a")`,
+			`"a" should be array, got string`,
+		},
+		{
+			"array.contains",
+			`array.contains(["a"])`,
+			`contains: expected 2 arguments, got 1`,
+		},
+		{
+			"array.sort",
+			`array.sort([1, "a"])`,
+			`"a" should be number, got string`,
+		},
+		{
+			"array.sort",
+			`array.sort([true])`,
+			`sort: elements must be numbers or strings, got bool`,
+		},
+		{
+			"array.map",
+			`array.map([1, 2], "a")`,
+			`"a" should be function, got string`,
+		},
+		{
+			"array.filter",
+			`array.filter([1, 2], (x) => x)`,
+			`filter: function must return a bool, got number`,
+		},
+		{
+			"map.keys",
+			`map.keys(["a"])`,
+			`["a"] should be object, got array`,
+		},
+		{
+			"map.merge_deep",
+			`map.merge_deep({"a" = 1}, "b")`,
+			`"b" should be object, got string`,
+		},
 	}
 
 	for _, tc := range tt {
@@ -364,6 +420,12 @@ func TestStdlib_StringFunc(t *testing.T) {
 		{"string.trim2", `string.trim("   hello! world.!  ", "! ")`, "hello! world."},
 		{"string.trim_prefix", `string.trim_prefix("helloworld", "hello")`, "world"},
 		{"string.trim_suffix", `string.trim_suffix("helloworld", "world")`, "hello"},
+		{"string.regex_match", `string.regex_match("api-server-1", "^api-.*-[0-9]+$")`, true},
+		{"string.regex_match no match", `string.regex_match("web-1", "^api-")`, false},
+		{"string.regex_replace", `string.regex_replace("host:9090", ":[0-9]+$", "")`, "host"},
+		{"string.regex_replace with groups", `string.regex_replace("eu-west-1a", "^([a-z]+)-([a-z]+)-.*$", "${2}_${1}")`, "west_eu"},
+		{"string.regex_extract", `string.regex_extract("version=1.2.3", "([0-9]+)\\.([0-9]+)")`, []string{"1.2", "1", "2"}},
+		{"string.regex_extract no match", `string.regex_extract("version", "[0-9]+")`, []string{}},
 	}
 
 	for _, tc := range tt {
@@ -594,3 +656,120 @@ func TestStdlibGroupBy_Errors(t *testing.T) {
 		})
 	}
 }
+
+func TestStdlibHash(t *testing.T) {
+	tt := []struct {
+		name   string
+		input  string
+		expect any
+	}{
+		{"hash.sha256", `hash.sha256("alloy")`, "65d9d6c5c7c2d5c29e38b777edf8da1dbd764f67deeeaa230724967cafe4e684"},
+		{"hash.sha256 empty", `hash.sha256("")`, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"},
+		{"hash.fnv", `hash.fnv("alloy")`, uint64(7624464132355086616)},
+		{"hash.fnv empty", `hash.fnv("")`, uint64(14695981039346656037)},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := parser.ParseExpression(tc.input)
+			require.NoError(t, err)
+
+			eval := vm.New(expr)
+
+			rv := reflect.New(reflect.TypeOf(tc.expect))
+			require.NoError(t, eval.Evaluate(nil, rv.Interface()))
+			require.Equal(t, tc.expect, rv.Elem().Interface())
+		})
+	}
+}
+
+func TestStdlibTime(t *testing.T) {
+	tt := []struct {
+		name   string
+		input  string
+		expect any
+	}{
+		{"time.format", `time.format("2024-03-01T10:20:30Z", "2006-01-02")`, "2024-03-01"},
+		{"time.format with offset", `time.format("2024-03-01T10:20:30+02:00", "15:04 MST")`, "10:20 +0200"},
+		{"time.parse_duration", `time.parse_duration("1m30s")`, float64(90)},
+		{"time.parse_duration subsecond", `time.parse_duration("250ms")`, 0.25},
+		{"time.format+time.now", `time.format(time.now(), "2006") != ""`, true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := parser.ParseExpression(tc.input)
+			require.NoError(t, err)
+
+			eval := vm.New(expr)
+
+			rv := reflect.New(reflect.TypeOf(tc.expect))
+			require.NoError(t, eval.Evaluate(nil, rv.Interface()))
+			require.Equal(t, tc.expect, rv.Elem().Interface())
+		})
+	}
+
+	t.Run("time.now", func(t *testing.T) {
+		expr, err := parser.ParseExpression(`time.now()`)
+		require.NoError(t, err)
+
+		var now string
+		require.NoError(t, vm.New(expr).Evaluate(nil, &now))
+		parsed, err := time.Parse(time.RFC3339Nano, now)
+		require.NoError(t, err)
+		require.WithinDuration(t, time.Now(), parsed, time.Minute)
+	})
+}
+
+func TestStdlibCollections(t *testing.T) {
+	tt := []struct {
+		name   string
+		input  string
+		expect any
+	}{
+		{"array.distinct", `array.distinct(["a", "b", "a", "c", "b"])`, []string{"a", "b", "c"}},
+		{"array.distinct numbers", `array.distinct([1, 1.0, 2, 3, 2])`, []any{1, 2, 3}},
+		{"array.distinct objects", `array.distinct([{"a" = 1}, {"a" = 2}, {"a" = 1}])`, []map[string]any{{"a": 1}, {"a": 2}}},
+		{"array.distinct empty", `array.distinct([])`, []any{}},
+		{"array.flatten", `array.flatten([["a", "b"], [], ["c", ["d", ["e"]]], "f"])`, []string{"a", "b", "c", "d", "e", "f"}},
+		{"array.contains", `array.contains(["a", "b"], "b")`, true},
+		{"array.contains missing", `array.contains(["a", "b"], "c")`, false},
+		{"array.contains number", `array.contains([1, 2], 2.0)`, true},
+		{"array.contains object", `array.contains([{"a" = 1}], {"a" = 1})`, true},
+		{"array.sort strings", `array.sort(["c", "a", "b"])`, []string{"a", "b", "c"}},
+		{"array.sort numbers", `array.sort([3, 1.5, -2, 10])`, []any{-2, 1.5, 3, 10}},
+		{"array.sort+array.distinct", `array.sort(array.distinct(["b", "a", "b"]))`, []string{"a", "b"}},
+		{"array.map", `array.map(["a", "b"], (x) => string.to_upper(x))`, []string{"A", "B"}},
+		{"array.map objects", `array.map([{"name" = "a"}, {"name" = "b"}], (x) => x.name)`, []string{"a", "b"}},
+		{"array.map empty", `array.map([], (x) => x)`, []any{}},
+		{"array.filter", `array.filter([{"ns" = "prod"}, {"ns" = "dev"}], (t) => t["ns"] == "prod")`, []map[string]any{{"ns": "prod"}}},
+		{"array.filter none", `array.filter([1, 2], (x) => false)`, []any{}},
+		{"map.keys", `map.keys({"b" = 1, "a" = 2, "c" = 3})`, []string{"a", "b", "c"}},
+		{"map.keys empty", `map.keys({})`, []string{}},
+		{"map.values", `map.values({"b" = 1, "a" = 2, "c" = 3})`, []int{2, 1, 3}},
+		{
+			"map.merge_deep",
+			`map.merge_deep({"a" = {"b" = 1, "c" = {"d" = 1}}, "e" = [1]}, {"a" = {"c" = {"f" = 2}}, "e" = [2]}, {"g" = 3})`,
+			map[string]any{
+				"a": map[string]any{"b": 1, "c": map[string]any{"d": 1, "f": 2}},
+				"e": []any{2},
+				"g": 3,
+			},
+		},
+		{"map.merge_deep replaces non-objects", `map.merge_deep({"a" = {"b" = 1}}, {"a" = "b"})`, map[string]any{"a": "b"}},
+		{"map.merge_deep no arguments", `map.merge_deep()`, map[string]any{}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := parser.ParseExpression(tc.input)
+			require.NoError(t, err)
+
+			eval := vm.New(expr)
+
+			rv := reflect.New(reflect.TypeOf(tc.expect))
+			require.NoError(t, eval.Evaluate(nil, rv.Interface()))
+			require.Equal(t, tc.expect, rv.Elem().Interface())
+		})
+	}
+}